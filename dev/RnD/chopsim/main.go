@@ -31,5 +31,9 @@ func main() {
 		log.Fatal("Unable to unmarshal manifest data -> ", err)
 	}
 	d := dataYAML{}
-	fmt.Println(parser.GenerateArtifacts(chi, d))
+	manifest, err := parser.GenerateArtifacts(chi, d)
+	if err != nil {
+		log.Fatal("Unable to generate manifest -> ", err)
+	}
+	fmt.Println(manifest)
 }
@@ -261,7 +261,8 @@ type metaData struct {
 }
 
 type genOptions struct {
-	ssNames       map[string]struct{}
+	// ssNames maps StatefulSet name to the key of deployment it is made from
+	ssNames       map[string]string
 	ssDeployments map[string]*chiDeployment
 	dRefsMax      chiDeploymentRefs
 }
@@ -273,9 +274,12 @@ type statefulSetList []*statefullSet
 type serviceList []*service
 
 // GenerateArtifacts returns resulting (composite) manifest
-func GenerateArtifacts(chi *ClickHouseInstallation, om ObjectMarshaller) string {
+func GenerateArtifacts(chi *ClickHouseInstallation, om ObjectMarshaller) (string, error) {
 	b := &bytes.Buffer{}
-	objects := chi.createObjects()
+	objects, err := chi.createObjects()
+	if err != nil {
+		return "", err
+	}
 	n := len(objects) - 1
 	i := 0
 	for _, o := range objects {
@@ -310,10 +314,10 @@ func GenerateArtifacts(chi *ClickHouseInstallation, om ObjectMarshaller) string
 		}
 		i++
 	}
-	return b.String()
+	return b.String(), nil
 }
 
-func (chi *ClickHouseInstallation) createObjects() objectsMap {
+func (chi *ClickHouseInstallation) createObjects() (objectsMap, error) {
 	var (
 		clusters []*chiCluster
 		options  genOptions
@@ -321,17 +325,26 @@ func (chi *ClickHouseInstallation) createObjects() objectsMap {
 	chi.setDefaults()
 	chi.Spec.Deployment.setDefaults(nil)
 	clusters, options.dRefsMax = chi.getNormalizedClusters()
-	options.ssNames = make(map[string]struct{})
+	options.ssNames = make(map[string]string)
 	options.ssDeployments = make(map[string]*chiDeployment)
+	remoteServers, err := chi.genRemoteServersConfig(&options, clusters)
+	if err != nil {
+		return nil, err
+	}
 	cmData := make(map[string]string)
-	cmData[remoteServersXML] = chi.genRemoteServersConfig(&options, clusters)
+	cmData[remoteServersXML] = remoteServers
 	cmData[zookeeperXML] = chi.genZookeeperConfig()
 
+	statefulSets, err := chi.createStatefulSetObjects(&options)
+	if err != nil {
+		return nil, err
+	}
+
 	return objectsMap{
 		objectsConfigMaps:   chi.createConfigMapObjects(cmData),
 		objectsServices:     chi.createServiceObjects(&options),
-		objectsStatefulSets: chi.createStatefulSetObjects(&options),
-	}
+		objectsStatefulSets: statefulSets,
+	}, nil
 }
 
 func (chi *ClickHouseInstallation) createConfigMapObjects(data map[string]string) configMapList {
@@ -402,11 +415,16 @@ func (chi *ClickHouseInstallation) createServiceObjects(o *genOptions) serviceLi
 	return svcList
 }
 
-func (chi *ClickHouseInstallation) createStatefulSetObjects(o *genOptions) statefulSetList {
+// Returns list of StatefulSets, one per registered StatefulSet name.
+// Each StatefulSet has to be made from deployment, registered during config generation
+func (chi *ClickHouseInstallation) createStatefulSetObjects(o *genOptions) (statefulSetList, error) {
 	ssList := make(statefulSetList, 0, len(o.ssNames))
 	cmName := fmt.Sprintf(configMapNamePattern, chi.Metadata.Name)
 	vmName := fmt.Sprintf(vmClickHouseDataPattern, chi.Metadata.Name)
-	for ssName := range o.ssNames {
+	for ssName, key := range o.ssNames {
+		if _, ok := o.ssDeployments[key]; !ok {
+			return nil, fmt.Errorf("deployment %s of StatefulSet %s has no registered template", key, ssName)
+		}
 		svcName := fmt.Sprintf(svcNamePattern, ssName)
 		ssList = append(ssList, &statefullSet{
 			APIVersion: "apps/v1",
@@ -490,7 +508,7 @@ func (chi *ClickHouseInstallation) createStatefulSetObjects(o *genOptions) state
 			},
 		})
 	}
-	return ssList
+	return ssList, nil
 }
 
 func (chi *ClickHouseInstallation) genZookeeperConfig() string {
@@ -498,7 +516,7 @@ func (chi *ClickHouseInstallation) genZookeeperConfig() string {
 	return b.String()
 }
 
-func (chi *ClickHouseInstallation) genRemoteServersConfig(o *genOptions, c []*chiCluster) string {
+func (chi *ClickHouseInstallation) genRemoteServersConfig(o *genOptions, c []*chiCluster) (string, error) {
 	b := &bytes.Buffer{}
 	dRefIndex := make(map[string]int)
 	dID := make(map[string]string)
@@ -516,6 +534,10 @@ func (chi *ClickHouseInstallation) genRemoteServersConfig(o *genOptions, c []*ch
 			}
 			for _, r := range c[i].Layout.Shards[j].Replicas {
 				k := r.Deployment.key
+				id, ok := dID[k]
+				if !ok {
+					return "", fmt.Errorf("deployment %s has no registered ID", k)
+				}
 				idx, ok := dRefIndex[k]
 				if !ok {
 					idx = 1
@@ -526,8 +548,8 @@ func (chi *ClickHouseInstallation) genRemoteServersConfig(o *genOptions, c []*ch
 					}
 				}
 				dRefIndex[k] = idx
-				prefix := fmt.Sprintf(ssNamePattern, dID[k], idx)
-				o.ssNames[prefix] = struct{}{}
+				prefix := fmt.Sprintf(ssNamePattern, id, idx)
+				o.ssNames[prefix] = k
 				o.ssDeployments[k] = &r.Deployment
 				fmt.Fprintf(b, "%16s<replica>\n%20[1]s<host>%s</host>\n", " ", chi.instanceHostname(prefix))
 				fmt.Fprintf(b, "%20s<port>9000</port>\n%16[1]s</replica>\n", " ")
@@ -537,7 +559,7 @@ func (chi *ClickHouseInstallation) genRemoteServersConfig(o *genOptions, c []*ch
 		fmt.Fprintf(b, "%8s</%s>\n", " ", c[i].Name)
 	}
 	fmt.Fprintf(b, "%4s</remote_servers>\n</yandex>\n", " ")
-	return b.String()
+	return b.String(), nil
 }
 
 func (chi *ClickHouseInstallation) instanceHostname(prefix string) string {
@@ -0,0 +1,28 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateStatefulSetObjectsMissingDeployment(t *testing.T) {
+	chi := &ClickHouseInstallation{}
+	chi.Metadata.Name = "test"
+	options := &genOptions{
+		ssNames: map[string]string{
+			"chi-abc-i1": "Default::::",
+		},
+		ssDeployments: map[string]*chiDeployment{},
+	}
+
+	ssList, err := chi.createStatefulSetObjects(options)
+	require.Error(t, err, "missing deployment should be reported")
+	require.Contains(t, err.Error(), "Default::::", "error should name the deployment")
+	require.Nil(t, ssList)
+
+	options.ssDeployments["Default::::"] = &chiDeployment{Scenario: deploymentScenarioDefault}
+	ssList, err = chi.createStatefulSetObjects(options)
+	require.Nil(t, err)
+	require.Len(t, ssList, 1)
+}
@@ -56,6 +56,15 @@ const (
 	OnStatefulSetUpdateFailureActionIgnore = "ignore"
 )
 
+// used in type OperatorConfig struct
+const (
+	// What to do in case CHI requests more shards or replicas than allowed - reject CHI, no objects are generated
+	OnCHILimitsExceededActionReject = "reject"
+
+	// What to do in case CHI requests more shards or replicas than allowed - generate allowed number of shards and replicas only
+	OnCHILimitsExceededActionTruncate = "truncate"
+)
+
 const (
 	PodDistributionUnspecified = "Unspecified"
 	// AntiAffinity section
@@ -101,6 +110,20 @@ const (
 	PortDistributionClusterScopeIndex = "ClusterScopeIndex"
 )
 
+const (
+	// ShardAntiAffinityRequired does not schedule replicas of the same shard onto the same node
+	ShardAntiAffinityRequired = "required"
+	// ShardAntiAffinityPreferred schedules replicas of the same shard onto different nodes whenever possible
+	ShardAntiAffinityPreferred = "preferred"
+)
+
+const (
+	// TmpVolumeTypeEmptyDir places ClickHouse tmp_path on emptyDir volume
+	TmpVolumeTypeEmptyDir = "emptyDir"
+	// TmpVolumeMediumMemory places emptyDir in memory (tmpfs)
+	TmpVolumeMediumMemory = "Memory"
+)
+
 const (
 	UsernameReplacer = "***"
 	PasswordReplacer = "***"
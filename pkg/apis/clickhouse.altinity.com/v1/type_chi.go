@@ -17,6 +17,7 @@ package v1
 import (
 	"math"
 
+	"github.com/altinity/clickhouse-operator/pkg/util"
 	"github.com/altinity/clickhouse-operator/pkg/version"
 )
 
@@ -452,6 +453,14 @@ func (spec *ChiSpec) MergeFrom(from *ChiSpec, _type MergeType) {
 		if spec.NamespaceDomainPattern == "" {
 			spec.NamespaceDomainPattern = from.NamespaceDomainPattern
 		}
+		if spec.ServiceNamespace == "" {
+			spec.ServiceNamespace = from.ServiceNamespace
+		}
+		if len(spec.PropagateAnnotations) == 0 {
+			spec.PropagateAnnotations = from.PropagateAnnotations
+		}
+		spec.Labels = fillEmptyStringMap(spec.Labels, from.Labels)
+		spec.Annotations = fillEmptyStringMap(spec.Annotations, from.Annotations)
 	case MergeTypeOverrideByNonEmptyValues:
 		if from.Stop != "" {
 			// Override by non-empty values only
@@ -460,6 +469,18 @@ func (spec *ChiSpec) MergeFrom(from *ChiSpec, _type MergeType) {
 		if from.NamespaceDomainPattern != "" {
 			spec.NamespaceDomainPattern = from.NamespaceDomainPattern
 		}
+		if from.ServiceNamespace != "" {
+			spec.ServiceNamespace = from.ServiceNamespace
+		}
+		if len(from.PropagateAnnotations) > 0 {
+			spec.PropagateAnnotations = from.PropagateAnnotations
+		}
+		if len(from.Labels) > 0 {
+			spec.Labels = util.MergeStringMaps(spec.Labels, from.Labels)
+		}
+		if len(from.Annotations) > 0 {
+			spec.Annotations = util.MergeStringMaps(spec.Annotations, from.Annotations)
+		}
 	}
 
 	(&spec.Defaults).MergeFrom(&from.Defaults, _type)
@@ -480,6 +501,16 @@ func (chi *ClickHouseInstallation) FindCluster(name string) *ChiCluster {
 	return cluster
 }
 
+// IsClusterDiscovery checks whether any cluster of the CHI uses cluster discovery
+func (chi *ClickHouseInstallation) IsClusterDiscovery() bool {
+	discovery := false
+	chi.WalkClusters(func(cluster *ChiCluster) error {
+		discovery = discovery || cluster.IsDiscovery()
+		return nil
+	})
+	return discovery
+}
+
 func (chi *ClickHouseInstallation) ClustersCount() int {
 	count := 0
 	chi.WalkClusters(func(cluster *ChiCluster) error {
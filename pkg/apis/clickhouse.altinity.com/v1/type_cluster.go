@@ -14,20 +14,37 @@
 
 package v1
 
+import (
+	"github.com/altinity/clickhouse-operator/pkg/util"
+)
+
 // ChiCluster defines item of a clusters section of .configuration
 type ChiCluster struct {
-	Name      string             `json:"name"`
-	Zookeeper ChiZookeeperConfig `json:"zookeeper,omitempty"`
-	Settings  Settings           `json:"settings,omitempty"`
-	Files     Settings           `json:"files,omitempty"`
-	Templates ChiTemplateNames   `json:"templates,omitempty"`
-	Layout    ChiClusterLayout   `json:"layout"`
+	Name      string              `json:"name"`
+	Zookeeper ChiZookeeperConfig  `json:"zookeeper,omitempty"`
+	Settings  Settings            `json:"settings,omitempty"`
+	Files     Settings            `json:"files,omitempty"`
+	Templates ChiTemplateNames    `json:"templates,omitempty"`
+	Layout    ChiClusterLayout    `json:"layout"`
+	Standby   string              `json:"standby,omitempty"`
+	Discovery ChiClusterDiscovery `json:"discovery,omitempty"`
+	// Default internal_replication of cluster's shards, shard may override it. StringBool
+	InternalReplication string `json:"internalReplication,omitempty"`
 
 	// Internal data
 	Address ChiClusterAddress       `json:"address,omitempty"`
 	CHI     *ClickHouseInstallation `json:"-" testdiff:"ignore"`
 }
 
+// ChiClusterDiscovery defines discovery section of .spec.configuration.clusters
+// Hosts of the cluster register themselves in ZooKeeper instead of being listed in remote_servers
+type ChiClusterDiscovery struct {
+	// Whether cluster discovery is used. StringBool
+	Enabled string `json:"enabled,omitempty"`
+	// ZooKeeper path hosts register in
+	Path string `json:"path,omitempty"`
+}
+
 // ChiClusterAddress defines address of a cluster within ClickHouseInstallation
 type ChiClusterAddress struct {
 	Namespace    string `json:"namespace,omitempty"`
@@ -76,6 +93,16 @@ func (cluster *ChiCluster) IsShardSpecified() bool {
 	return cluster.isShardSpecified()
 }
 
+// IsStandby checks whether cluster is a standby one, which should not perform merges and mutations until promoted
+func (cluster *ChiCluster) IsStandby() bool {
+	return util.IsStringBoolTrue(cluster.Standby)
+}
+
+// IsDiscovery checks whether hosts of the cluster are discovered via ZooKeeper instead of being listed in remote_servers
+func (cluster *ChiCluster) IsDiscovery() bool {
+	return util.IsStringBoolTrue(cluster.Discovery.Enabled)
+}
+
 func (cluster *ChiCluster) InheritZookeeperFrom(chi *ClickHouseInstallation) {
 	if cluster.Zookeeper.IsEmpty() {
 		(&cluster.Zookeeper).MergeFrom(&chi.Spec.Configuration.Zookeeper, MergeTypeFillEmptyValues)
@@ -14,6 +14,14 @@
 
 package v1
 
+import (
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/altinity/clickhouse-operator/pkg/util"
+)
+
 const (
 	// CommonConfigDir specifies folder's name, where generated common XML files for ClickHouse would be placed
 	CommonConfigDir = "config.d"
@@ -36,6 +44,50 @@ type Configuration struct {
 	Quotas    Settings           `json:"quotas,omitempty"    yaml:"quotas"`
 	Settings  Settings           `json:"settings,omitempty"  yaml:"settings"`
 	Files     Settings           `json:"files,omitempty"     yaml:"files"`
+	// Quotas with multiple intervals, which can not be specified by quotas settings
+	QuotaIntervals []ChiQuotaIntervals `json:"quotaIntervals,omitempty" yaml:"quotaIntervals"`
+	// Monitoring user setup
+	Monitoring ChiMonitoring `json:"monitoring,omitempty" yaml:"monitoring"`
+	// SQL RBAC roles to be bootstrapped
+	Roles []ChiRole `json:"roles,omitempty" yaml:"roles"`
+	// Executable user defined functions setup
+	UserDefinedFunctions ChiUserDefinedFunctions `json:"userDefinedFunctions,omitempty" yaml:"userDefinedFunctions"`
+	// Schema files of Protobuf/Cap'n Proto formats
+	FormatSchemas ChiFormatSchemas `json:"formatSchemas,omitempty" yaml:"formatSchemas"`
+	// Kafka table engine defaults
+	Kafka ChiKafka `json:"kafka,omitempty" yaml:"kafka"`
+	// Credentials of replication fetches between replicas
+	InterserverCredentials ChiInterserverCredentials `json:"interserverCredentials,omitempty" yaml:"interserverCredentials"`
+	// Secure native and HTTPS ports
+	TLS ChiTLS `json:"tls,omitempty" yaml:"tls"`
+	// System logs with bounded retention
+	SystemLogs ChiSystemLogs `json:"systemLogs,omitempty" yaml:"systemLogs"`
+	// ClickHouse Keeper tuning
+	Keeper ChiKeeper `json:"keeper,omitempty" yaml:"keeper"`
+	// Filesystem cache over remote disk
+	FilesystemCache ChiFilesystemCache `json:"filesystemCache,omitempty" yaml:"filesystemCache"`
+	// Disks and storage policies
+	Storage ChiStorage `json:"storage,omitempty" yaml:"storage"`
+	// Compression methods of data parts
+	Compression []ChiCompressionCase `json:"compression,omitempty" yaml:"compression"`
+	// BACKUP/RESTORE threads and throttling
+	Backups ChiBackups `json:"backups,omitempty" yaml:"backups"`
+	// Core dump of crashed server
+	CoreDump ChiCoreDump `json:"coreDump,omitempty" yaml:"coreDump"`
+	// Experimental features toggles per profile
+	ExperimentalFeatures []ChiExperimentalFeatures `json:"experimentalFeatures,omitempty" yaml:"experimentalFeatures"`
+	// Profiles with resource limits scaled from base ones
+	ProfileTiers ChiProfileTiers `json:"profileTiers,omitempty" yaml:"profileTiers"`
+	// Prefixes of custom settings, such as 'custom_', accepted by the server
+	CustomSettingsPrefixes []string `json:"customSettingsPrefixes,omitempty" yaml:"customSettingsPrefixes"`
+	// ConfigMap with users.d files, which override operator-generated users, profiles and quotas
+	UsersOverrideConfigMap string `json:"usersOverrideConfigMap,omitempty" yaml:"usersOverrideConfigMap"`
+	// Regexp-based redaction of sensitive data in queries written to logs
+	QueryMaskingRules []ChiQueryMaskingRule `json:"queryMaskingRules,omitempty" yaml:"queryMaskingRules"`
+	// Custom macros added to macros of each host
+	Macros map[string]string `json:"macros,omitempty" yaml:"macros"`
+	// Passwords of users taken from Secrets instead of users config
+	UserPasswordSecrets map[string]corev1.SecretKeySelector `json:"userPasswordSecrets,omitempty" yaml:"userPasswordSecrets"`
 
 	// TODO refactor into map[string]ChiCluster
 	Clusters []ChiCluster `json:"clusters,omitempty"`
@@ -53,8 +105,125 @@ func (configuration *Configuration) MergeFrom(from *Configuration, _type MergeTy
 	(&configuration.Quotas).MergeFrom(from.Quotas)
 	(&configuration.Settings).MergeFrom(from.Settings)
 	(&configuration.Files).MergeFrom(from.Files)
+	(&configuration.Monitoring).MergeFrom(&from.Monitoring, _type)
+	(&configuration.UserDefinedFunctions).MergeFrom(&from.UserDefinedFunctions, _type)
+	(&configuration.FormatSchemas).MergeFrom(&from.FormatSchemas, _type)
+	(&configuration.Kafka).MergeFrom(&from.Kafka, _type)
+	(&configuration.InterserverCredentials).MergeFrom(&from.InterserverCredentials, _type)
+	(&configuration.TLS).MergeFrom(&from.TLS, _type)
+	(&configuration.SystemLogs).MergeFrom(&from.SystemLogs, _type)
+	(&configuration.Keeper).MergeFrom(&from.Keeper, _type)
+	(&configuration.FilesystemCache).MergeFrom(&from.FilesystemCache, _type)
+	(&configuration.Storage).MergeFrom(&from.Storage, _type)
+	(&configuration.Backups).MergeFrom(&from.Backups, _type)
+	(&configuration.CoreDump).MergeFrom(&from.CoreDump, _type)
+	(&configuration.ProfileTiers).MergeFrom(&from.ProfileTiers, _type)
+
+	switch _type {
+	case MergeTypeFillEmptyValues:
+		if len(configuration.Roles) == 0 {
+			configuration.Roles = from.Roles
+		}
+		if len(configuration.QuotaIntervals) == 0 {
+			configuration.QuotaIntervals = from.QuotaIntervals
+		}
+		if len(configuration.Compression) == 0 {
+			configuration.Compression = from.Compression
+		}
+		if len(configuration.ExperimentalFeatures) == 0 {
+			configuration.ExperimentalFeatures = from.ExperimentalFeatures
+		}
+		if len(configuration.CustomSettingsPrefixes) == 0 {
+			configuration.CustomSettingsPrefixes = from.CustomSettingsPrefixes
+		}
+		if len(configuration.QueryMaskingRules) == 0 {
+			configuration.QueryMaskingRules = from.QueryMaskingRules
+		}
+		if configuration.UsersOverrideConfigMap == "" {
+			configuration.UsersOverrideConfigMap = from.UsersOverrideConfigMap
+		}
+		configuration.Macros = fillEmptyStringMap(configuration.Macros, from.Macros)
+		for username, secret := range from.UserPasswordSecrets {
+			if configuration.UserPasswordSecrets == nil {
+				configuration.UserPasswordSecrets = make(map[string]corev1.SecretKeySelector)
+			}
+			if _, ok := configuration.UserPasswordSecrets[username]; !ok {
+				configuration.UserPasswordSecrets[username] = *secret.DeepCopy()
+			}
+		}
+	case MergeTypeOverrideByNonEmptyValues:
+		if len(from.Roles) > 0 {
+			// Override by non-empty values only
+			configuration.Roles = from.Roles
+		}
+		if len(from.QuotaIntervals) > 0 {
+			// Override by non-empty values only
+			configuration.QuotaIntervals = from.QuotaIntervals
+		}
+		if len(from.Compression) > 0 {
+			// Override by non-empty values only
+			configuration.Compression = from.Compression
+		}
+		if len(from.ExperimentalFeatures) > 0 {
+			// Override by non-empty values only
+			configuration.ExperimentalFeatures = from.ExperimentalFeatures
+		}
+		if len(from.CustomSettingsPrefixes) > 0 {
+			// Override by non-empty values only
+			configuration.CustomSettingsPrefixes = from.CustomSettingsPrefixes
+		}
+		if len(from.QueryMaskingRules) > 0 {
+			// Override by non-empty values only
+			configuration.QueryMaskingRules = from.QueryMaskingRules
+		}
+		if from.UsersOverrideConfigMap != "" {
+			// Override by non-empty values only
+			configuration.UsersOverrideConfigMap = from.UsersOverrideConfigMap
+		}
+		if len(from.Macros) > 0 {
+			// Override by non-empty values only
+			configuration.Macros = util.MergeStringMaps(configuration.Macros, from.Macros)
+		}
+		for username, secret := range from.UserPasswordSecrets {
+			// Override by non-empty values only
+			if configuration.UserPasswordSecrets == nil {
+				configuration.UserPasswordSecrets = make(map[string]corev1.SecretKeySelector)
+			}
+			configuration.UserPasswordSecrets[username] = *secret.DeepCopy()
+		}
+	}
 
 	// TODO merge clusters
 	// Copy Clusters for now
 	configuration.Clusters = from.Clusters
 }
+
+// GetUserPasswordSecretsUsernames returns sorted names of users, which have password provided via Secret
+func (configuration *Configuration) GetUserPasswordSecretsUsernames() []string {
+	usernames := make([]string, 0, len(configuration.UserPasswordSecrets))
+	for username := range configuration.UserPasswordSecrets {
+		usernames = append(usernames, username)
+	}
+	sort.Strings(usernames)
+	return usernames
+}
+
+// HasStandbyClusters checks whether any of clusters is a standby one
+func (configuration *Configuration) HasStandbyClusters() bool {
+	for i := range configuration.Clusters {
+		if configuration.Clusters[i].IsStandby() {
+			return true
+		}
+	}
+	return false
+}
+
+// HasQuotaIntervals checks whether quota with specified name is specified in quotaIntervals
+func (configuration *Configuration) HasQuotaIntervals(name string) bool {
+	for i := range configuration.QuotaIntervals {
+		if configuration.QuotaIntervals[i].Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -54,6 +54,9 @@ const (
 
 	// Default number of controller threads running concurrently (used in case no other specified in config)
 	defaultReconcileThreadsNumber = 1
+
+	// Default number of previous config versions kept in StatefulSet annotation
+	defaultConfigHistoryLength = 5
 )
 
 // !!! IMPORTANT !!!
@@ -128,6 +131,16 @@ type OperatorConfig struct {
 
 	// Max number of concurrent reconciles in progress
 	ReconcileThreadsNumber int `json:"reconcileThreadsNumber" yaml:"reconcileThreadsNumber"`
+	// Max number of hosts, which objects are generated concurrently within one reconcile. 0 means GOMAXPROCS
+	ReconcileGenerateThreadsNumber int `json:"reconcileGenerateThreadsNumber" yaml:"reconcileGenerateThreadsNumber"`
+	// Max number of previous config versions kept in StatefulSet annotation
+	ConfigHistoryLength int `json:"configHistoryLength" yaml:"configHistoryLength"`
+
+	// Max number of shards of a cluster and replicas of a shard CHI is allowed to request. 0 means unlimited
+	CHIMaxShardsCount   int `json:"chiMaxShardsCount"   yaml:"chiMaxShardsCount"`
+	CHIMaxReplicasCount int `json:"chiMaxReplicasCount" yaml:"chiMaxReplicasCount"`
+	// What to do in case CHI requests more shards or replicas than allowed - reject CHI or truncate its layout
+	OnCHILimitsExceededAction string `json:"onCHILimitsExceededAction" yaml:"onCHILimitsExceededAction"`
 
 	//
 	// The end of OperatorConfig
@@ -386,6 +399,14 @@ func (config *OperatorConfig) normalize() {
 	if config.ReconcileThreadsNumber == 0 {
 		config.ReconcileThreadsNumber = defaultReconcileThreadsNumber
 	}
+	if config.ConfigHistoryLength == 0 {
+		config.ConfigHistoryLength = defaultConfigHistoryLength
+	}
+
+	// Default action on exceeded shards and replicas limits - reject CHI
+	if config.OnCHILimitsExceededAction == "" {
+		config.OnCHILimitsExceededAction = OnCHILimitsExceededActionReject
+	}
 }
 
 // applyEnvVarParams applies ENV VARS over config
@@ -522,6 +543,11 @@ func (config *OperatorConfig) String(hideCredentials bool) string {
 	util.Fprintf(b, "Log_backtrace_at string: %s\n", config.Log_backtrace_at)
 
 	util.Fprintf(b, "ReconcileThreadsNumber: %d\n", config.ReconcileThreadsNumber)
+	util.Fprintf(b, "ReconcileGenerateThreadsNumber: %d\n", config.ReconcileGenerateThreadsNumber)
+	util.Fprintf(b, "ConfigHistoryLength: %d\n", config.ConfigHistoryLength)
+	util.Fprintf(b, "CHIMaxShardsCount: %d\n", config.CHIMaxShardsCount)
+	util.Fprintf(b, "CHIMaxReplicasCount: %d\n", config.CHIMaxReplicasCount)
+	util.Fprintf(b, "OnCHILimitsExceededAction: %s\n", config.OnCHILimitsExceededAction)
 
 	return b.String()
 }
@@ -0,0 +1,52 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// MergeFrom merges from specified source
+func (d *ChiContainerDefaults) MergeFrom(from *ChiContainerDefaults, _type MergeType) {
+	if from == nil {
+		return
+	}
+
+	mergeStringFields(d, from, _type)
+
+	switch _type {
+	case MergeTypeFillEmptyValues:
+		if len(d.ImagePullSecrets) == 0 {
+			d.ImagePullSecrets = append(d.ImagePullSecrets, from.ImagePullSecrets...)
+		}
+		if len(d.Env) == 0 {
+			for i := range from.Env {
+				d.Env = append(d.Env, *from.Env[i].DeepCopy())
+			}
+		}
+	case MergeTypeOverrideByNonEmptyValues:
+		if len(from.ImagePullSecrets) > 0 {
+			// Override by non-empty values only
+			d.ImagePullSecrets = append([]corev1.LocalObjectReference{}, from.ImagePullSecrets...)
+		}
+		if len(from.Env) > 0 {
+			// Override by non-empty values only
+			d.Env = nil
+			for i := range from.Env {
+				d.Env = append(d.Env, *from.Env[i].DeepCopy())
+			}
+		}
+	}
+}
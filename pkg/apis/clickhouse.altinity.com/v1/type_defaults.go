@@ -14,24 +14,100 @@
 
 package v1
 
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/altinity/clickhouse-operator/pkg/util"
+)
+
+// HasCertRotationTokenSecret checks whether cert rotation token is provided via Secret
+func (defaults *ChiDefaults) HasCertRotationTokenSecret() bool {
+	return (defaults.CertRotationTokenSecret != nil) && (defaults.CertRotationTokenSecret.Name != "") && (defaults.CertRotationTokenSecret.Key != "")
+}
+
 func (defaults *ChiDefaults) MergeFrom(from *ChiDefaults, _type MergeType) {
 	if from == nil {
 		return
 	}
 
+	mergeStringFields(defaults, from, _type)
+
 	switch _type {
 	case MergeTypeFillEmptyValues:
-		if from.ReplicasUseFQDN == "" {
-			defaults.ReplicasUseFQDN = from.ReplicasUseFQDN
+		if defaults.CertRotationTokenSecret == nil {
+			defaults.CertRotationTokenSecret = from.CertRotationTokenSecret.DeepCopy()
+		}
+		if defaults.ShardBaseIndex == 0 {
+			defaults.ShardBaseIndex = from.ShardBaseIndex
+		}
+		if defaults.ReplicaBaseIndex == 0 {
+			defaults.ReplicaBaseIndex = from.ReplicaBaseIndex
+		}
+		if defaults.PodAffinity == nil {
+			defaults.PodAffinity = from.PodAffinity.DeepCopy()
+		}
+		defaults.NodeSelector = fillEmptyStringMap(defaults.NodeSelector, from.NodeSelector)
+		if defaults.Tolerations == nil {
+			defaults.Tolerations = copyTolerations(from.Tolerations)
 		}
 	case MergeTypeOverrideByNonEmptyValues:
-		if from.ReplicasUseFQDN != "" {
+		if from.CertRotationTokenSecret != nil {
+			// Override by non-empty values only
+			defaults.CertRotationTokenSecret = from.CertRotationTokenSecret.DeepCopy()
+		}
+		if from.ShardBaseIndex != 0 {
 			// Override by non-empty values only
-			defaults.ReplicasUseFQDN = from.ReplicasUseFQDN
+			defaults.ShardBaseIndex = from.ShardBaseIndex
+		}
+		if from.ReplicaBaseIndex != 0 {
+			// Override by non-empty values only
+			defaults.ReplicaBaseIndex = from.ReplicaBaseIndex
+		}
+		if from.PodAffinity != nil {
+			// Override by non-empty values only
+			defaults.PodAffinity = from.PodAffinity.DeepCopy()
+		}
+		if len(from.NodeSelector) > 0 {
+			// Override by non-empty values only
+			defaults.NodeSelector = util.MergeStringMaps(defaults.NodeSelector, from.NodeSelector)
+		}
+		if len(from.Tolerations) > 0 {
+			// Override by non-empty values only
+			defaults.Tolerations = copyTolerations(from.Tolerations)
 		}
 	}
 
 	(&defaults.DistributedDDL).MergeFrom(&from.DistributedDDL, _type)
+	(&defaults.DistributedQueries).MergeFrom(&from.DistributedQueries, _type)
+	(&defaults.FilesystemRead).MergeFrom(&from.FilesystemRead, _type)
+	(&defaults.ReadinessProbe).MergeFrom(&from.ReadinessProbe, _type)
+	(&defaults.LivenessProbe).MergeFrom(&from.LivenessProbe, _type)
+	(&defaults.ServiceMesh).MergeFrom(&from.ServiceMesh, _type)
+	(&defaults.DropSafeguards).MergeFrom(&from.DropSafeguards, _type)
+	(&defaults.Caches).MergeFrom(&from.Caches, _type)
+	(&defaults.MergeLimits).MergeFrom(&from.MergeLimits, _type)
+	(&defaults.DNSCache).MergeFrom(&from.DNSCache, _type)
+	(&defaults.Ports).MergeFrom(&from.Ports, _type)
+	(&defaults.MemoryTracker).MergeFrom(&from.MemoryTracker, _type)
+	(&defaults.ScaleDownSafeguards).MergeFrom(&from.ScaleDownSafeguards, _type)
+	(&defaults.Container).MergeFrom(&from.Container, _type)
+	(&defaults.TmpVolume).MergeFrom(&from.TmpVolume, _type)
+	(&defaults.ShmVolume).MergeFrom(&from.ShmVolume, _type)
+	(&defaults.DataVolumeChown).MergeFrom(&from.DataVolumeChown, _type)
+	(&defaults.SecurityContext).MergeFrom(&from.SecurityContext, _type)
+	(&defaults.ServiceAccount).MergeFrom(&from.ServiceAccount, _type)
+	(&defaults.UpdateStrategy).MergeFrom(&from.UpdateStrategy, _type)
 	(&defaults.Templates).MergeFrom(&from.Templates, _type)
+}
 
+// copyTolerations makes deep copy of tolerations list
+func copyTolerations(src []corev1.Toleration) []corev1.Toleration {
+	if src == nil {
+		return nil
+	}
+	dst := make([]corev1.Toleration, len(src))
+	for i := range src {
+		src[i].DeepCopyInto(&dst[i])
+	}
+	return dst
 }
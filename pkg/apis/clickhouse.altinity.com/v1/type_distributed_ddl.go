@@ -14,6 +14,8 @@
 
 package v1
 
+import "github.com/altinity/clickhouse-operator/pkg/util"
+
 func (d *ChiDistributedDDL) MergeFrom(from *ChiDistributedDDL, _type MergeType) {
 	if from == nil {
 		return
@@ -24,10 +26,43 @@ func (d *ChiDistributedDDL) MergeFrom(from *ChiDistributedDDL, _type MergeType)
 		if d.Profile == "" {
 			d.Profile = from.Profile
 		}
+		if d.Cleanup == "" {
+			d.Cleanup = from.Cleanup
+		}
+		if d.MaxTasksInQueue == "" {
+			d.MaxTasksInQueue = from.MaxTasksInQueue
+		}
+		if d.TaskMaxLifetime == "" {
+			d.TaskMaxLifetime = from.TaskMaxLifetime
+		}
+		if d.CleanupDelayPeriod == "" {
+			d.CleanupDelayPeriod = from.CleanupDelayPeriod
+		}
 	case MergeTypeOverrideByNonEmptyValues:
 		if from.Profile != "" {
 			// Override by non-empty values only
 			d.Profile = from.Profile
 		}
+		if from.Cleanup != "" {
+			// Override by non-empty values only
+			d.Cleanup = from.Cleanup
+		}
+		if from.MaxTasksInQueue != "" {
+			// Override by non-empty values only
+			d.MaxTasksInQueue = from.MaxTasksInQueue
+		}
+		if from.TaskMaxLifetime != "" {
+			// Override by non-empty values only
+			d.TaskMaxLifetime = from.TaskMaxLifetime
+		}
+		if from.CleanupDelayPeriod != "" {
+			// Override by non-empty values only
+			d.CleanupDelayPeriod = from.CleanupDelayPeriod
+		}
 	}
 }
+
+// IsCleanup checks whether distributed DDL queue cleanup settings are to be generated
+func (d *ChiDistributedDDL) IsCleanup() bool {
+	return util.IsStringBoolTrue(d.Cleanup)
+}
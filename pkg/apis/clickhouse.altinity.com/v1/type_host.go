@@ -55,6 +55,44 @@ func (host *ChiHost) InheritTemplatesFrom(shard *ChiShard, replica *ChiReplica,
 	(&host.Templates).HandleDeprecatedFields()
 }
 
+// InheritDataVolumeSizeFrom inherits data volume size from shard, unless host has its own one specified
+func (host *ChiHost) InheritDataVolumeSizeFrom(shard *ChiShard) {
+	if (shard != nil) && (host.DataVolumeSize == "") {
+		host.DataVolumeSize = shard.DataVolumeSize
+	}
+}
+
+// InheritBackgroundPoolsFrom inherits background pools sizes from shard, unless host has its own ones specified
+func (host *ChiHost) InheritBackgroundPoolsFrom(shard *ChiShard) {
+	if shard != nil {
+		(&host.BackgroundPools).MergeFrom(&shard.BackgroundPools, MergeTypeFillEmptyValues)
+	}
+}
+
+// InheritStatefulSetAnnotationsFrom inherits StatefulSet annotations from shard and replica.
+// Annotations specified on host level take precedence over shard's ones, which take precedence over replica's ones
+func (host *ChiHost) InheritStatefulSetAnnotationsFrom(shard *ChiShard, replica *ChiReplica) {
+	if shard != nil {
+		host.StatefulSetAnnotations = fillEmptyStringMap(host.StatefulSetAnnotations, shard.StatefulSetAnnotations)
+	}
+	if replica != nil {
+		host.StatefulSetAnnotations = fillEmptyStringMap(host.StatefulSetAnnotations, replica.StatefulSetAnnotations)
+	}
+}
+
+// fillEmptyStringMap copies into dst entries from src, which are not specified in dst
+func fillEmptyStringMap(dst, src map[string]string) map[string]string {
+	for key, value := range src {
+		if dst == nil {
+			dst = make(map[string]string)
+		}
+		if _, ok := dst[key]; !ok {
+			dst[key] = value
+		}
+	}
+	return dst
+}
+
 func (host *ChiHost) MergeFrom(from *ChiHost) {
 	if (host == nil) || (from == nil) {
 		return
@@ -72,6 +110,10 @@ func (host *ChiHost) MergeFrom(from *ChiHost) {
 	if host.InterserverHTTPPort == 0 {
 		host.InterserverHTTPPort = from.InterserverHTTPPort
 	}
+	if host.DataVolumeSize == "" {
+		host.DataVolumeSize = from.DataVolumeSize
+	}
+	host.StatefulSetAnnotations = fillEmptyStringMap(host.StatefulSetAnnotations, from.StatefulSetAnnotations)
 	(&host.Templates).MergeFrom(&from.Templates, MergeTypeFillEmptyValues)
 	(&host.Templates).HandleDeprecatedFields()
 }
@@ -94,6 +136,8 @@ func (host *ChiHost) GetServiceTemplate() (*ChiServiceTemplate, bool) {
 	return template, ok
 }
 
+// GetReplicasNum returns replicas number of the host's StatefulSet.
+// Each host has its own StatefulSet, so it is 1 for running hosts and 0 for stopped CHI
 func (host *ChiHost) GetReplicasNum() int32 {
 	if util.IsStringBoolTrue(host.CHI.Spec.Stop) {
 		return 0
@@ -0,0 +1,65 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import "github.com/altinity/clickhouse-operator/pkg/util"
+
+// IsConfigured checks whether interserver credentials are provided via Secrets
+func (c *ChiInterserverCredentials) IsConfigured() bool {
+	return c.HasUserSecret() && c.HasPasswordSecret()
+}
+
+// HasUserSecret checks whether user is provided via Secret
+func (c *ChiInterserverCredentials) HasUserSecret() bool {
+	return (c.UserSecret != nil) && (c.UserSecret.Name != "") && (c.UserSecret.Key != "")
+}
+
+// HasPasswordSecret checks whether password is provided via Secret
+func (c *ChiInterserverCredentials) HasPasswordSecret() bool {
+	return (c.PasswordSecret != nil) && (c.PasswordSecret.Name != "") && (c.PasswordSecret.Key != "")
+}
+
+// IsAllowEmpty checks whether fetches without credentials are accepted
+func (c *ChiInterserverCredentials) IsAllowEmpty() bool {
+	return util.IsStringBoolTrue(c.AllowEmpty)
+}
+
+// MergeFrom merges from specified source
+func (c *ChiInterserverCredentials) MergeFrom(from *ChiInterserverCredentials, _type MergeType) {
+	if from == nil {
+		return
+	}
+
+	mergeStringFields(c, from, _type)
+
+	switch _type {
+	case MergeTypeFillEmptyValues:
+		if c.UserSecret == nil {
+			c.UserSecret = from.UserSecret.DeepCopy()
+		}
+		if c.PasswordSecret == nil {
+			c.PasswordSecret = from.PasswordSecret.DeepCopy()
+		}
+	case MergeTypeOverrideByNonEmptyValues:
+		if from.UserSecret != nil {
+			// Override by non-empty values only
+			c.UserSecret = from.UserSecret.DeepCopy()
+		}
+		if from.PasswordSecret != nil {
+			// Override by non-empty values only
+			c.PasswordSecret = from.PasswordSecret.DeepCopy()
+		}
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+// IsConfigured checks whether Kafka defaults are specified
+func (kafka *ChiKafka) IsConfigured() bool {
+	return (kafka.BrokerList != "") ||
+		(kafka.Group != "") ||
+		(kafka.SecurityProtocol != "") ||
+		(kafka.SASLMechanism != "") ||
+		kafka.HasSASLUsernameSecret() ||
+		kafka.HasSASLPasswordSecret()
+}
+
+// HasSASLUsernameSecret checks whether SASL username is provided via Secret
+func (kafka *ChiKafka) HasSASLUsernameSecret() bool {
+	return (kafka.SASLUsernameSecret != nil) && (kafka.SASLUsernameSecret.Name != "") && (kafka.SASLUsernameSecret.Key != "")
+}
+
+// HasSASLPasswordSecret checks whether SASL password is provided via Secret
+func (kafka *ChiKafka) HasSASLPasswordSecret() bool {
+	return (kafka.SASLPasswordSecret != nil) && (kafka.SASLPasswordSecret.Name != "") && (kafka.SASLPasswordSecret.Key != "")
+}
+
+// MergeFrom merges from specified source
+func (kafka *ChiKafka) MergeFrom(from *ChiKafka, _type MergeType) {
+	if from == nil {
+		return
+	}
+
+	mergeStringFields(kafka, from, _type)
+
+	switch _type {
+	case MergeTypeFillEmptyValues:
+		if kafka.SASLUsernameSecret == nil {
+			kafka.SASLUsernameSecret = from.SASLUsernameSecret.DeepCopy()
+		}
+		if kafka.SASLPasswordSecret == nil {
+			kafka.SASLPasswordSecret = from.SASLPasswordSecret.DeepCopy()
+		}
+	case MergeTypeOverrideByNonEmptyValues:
+		if from.SASLUsernameSecret != nil {
+			// Override by non-empty values only
+			kafka.SASLUsernameSecret = from.SASLUsernameSecret.DeepCopy()
+		}
+		if from.SASLPasswordSecret != nil {
+			// Override by non-empty values only
+			kafka.SASLPasswordSecret = from.SASLPasswordSecret.DeepCopy()
+		}
+	}
+}
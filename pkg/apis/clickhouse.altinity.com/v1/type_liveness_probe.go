@@ -0,0 +1,31 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import "github.com/altinity/clickhouse-operator/pkg/util"
+
+// MergeFrom merges from specified source
+func (p *ChiLivenessProbe) MergeFrom(from *ChiLivenessProbe, _type MergeType) {
+	if from == nil {
+		return
+	}
+
+	mergeStringFields(p, from, _type)
+}
+
+// IsEnabled checks whether liveness probe is opted in
+func (p *ChiLivenessProbe) IsEnabled() bool {
+	return util.IsStringBoolTrue(p.Enabled)
+}
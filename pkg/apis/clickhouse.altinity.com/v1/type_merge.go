@@ -0,0 +1,43 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import "reflect"
+
+// mergeStringFields merges string fields of the struct pointed by dst from the struct pointed by src.
+// Both have to be pointers to the same struct type. Fields of other kinds are left untouched
+// and have to be merged by the caller
+func mergeStringFields(dst, src interface{}, _type MergeType) {
+	d := reflect.ValueOf(dst).Elem()
+	s := reflect.ValueOf(src).Elem()
+	for i := 0; i < d.NumField(); i++ {
+		field := d.Field(i)
+		if (field.Kind() != reflect.String) || !field.CanSet() {
+			continue
+		}
+		value := s.Field(i).String()
+		switch _type {
+		case MergeTypeFillEmptyValues:
+			if field.String() == "" {
+				field.SetString(value)
+			}
+		case MergeTypeOverrideByNonEmptyValues:
+			if value != "" {
+				// Override by non-empty values only
+				field.SetString(value)
+			}
+		}
+	}
+}
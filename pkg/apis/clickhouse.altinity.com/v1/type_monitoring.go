@@ -0,0 +1,79 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import "github.com/altinity/clickhouse-operator/pkg/util"
+
+// IsEnabled checks whether monitoring user has to be generated
+func (monitoring *ChiMonitoring) IsEnabled() bool {
+	return util.IsStringBoolTrue(monitoring.Enabled)
+}
+
+// HasPasswordSecret checks whether monitoring user's password is provided via Secret
+func (monitoring *ChiMonitoring) HasPasswordSecret() bool {
+	return (monitoring.PasswordSecret != nil) && (monitoring.PasswordSecret.Name != "") && (monitoring.PasswordSecret.Key != "")
+}
+
+// IsEnabled checks whether exporter sidecar has to be generated
+func (sidecar *ChiExporterSidecar) IsEnabled() bool {
+	return util.IsStringBoolTrue(sidecar.Enabled)
+}
+
+// IsEnabled checks whether built-in Prometheus endpoint has to be exposed
+func (prometheus *ChiPrometheus) IsEnabled() bool {
+	return util.IsStringBoolTrue(prometheus.Enabled)
+}
+
+// MergeFrom merges from specified source
+func (monitoring *ChiMonitoring) MergeFrom(from *ChiMonitoring, _type MergeType) {
+	if from == nil {
+		return
+	}
+
+	mergeStringFields(monitoring, from, _type)
+
+	switch _type {
+	case MergeTypeFillEmptyValues:
+		if monitoring.PasswordSecret == nil {
+			monitoring.PasswordSecret = from.PasswordSecret.DeepCopy()
+		}
+	case MergeTypeOverrideByNonEmptyValues:
+		if from.PasswordSecret != nil {
+			// Override by non-empty values only
+			monitoring.PasswordSecret = from.PasswordSecret.DeepCopy()
+		}
+	}
+
+	(&monitoring.ExporterSidecar).MergeFrom(&from.ExporterSidecar, _type)
+	(&monitoring.Prometheus).MergeFrom(&from.Prometheus, _type)
+}
+
+// MergeFrom merges from specified source
+func (sidecar *ChiExporterSidecar) MergeFrom(from *ChiExporterSidecar, _type MergeType) {
+	if from == nil {
+		return
+	}
+
+	mergeStringFields(sidecar, from, _type)
+}
+
+// MergeFrom merges from specified source
+func (prometheus *ChiPrometheus) MergeFrom(from *ChiPrometheus, _type MergeType) {
+	if from == nil {
+		return
+	}
+
+	mergeStringFields(prometheus, from, _type)
+}
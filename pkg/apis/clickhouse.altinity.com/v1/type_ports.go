@@ -0,0 +1,48 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+// MergeFrom merges from specified source
+func (p *ChiPorts) MergeFrom(from *ChiPorts, _type MergeType) {
+	if from == nil {
+		return
+	}
+
+	switch _type {
+	case MergeTypeFillEmptyValues:
+		if p.TCPPort == 0 {
+			p.TCPPort = from.TCPPort
+		}
+		if p.HTTPPort == 0 {
+			p.HTTPPort = from.HTTPPort
+		}
+		if p.InterserverHTTPPort == 0 {
+			p.InterserverHTTPPort = from.InterserverHTTPPort
+		}
+	case MergeTypeOverrideByNonEmptyValues:
+		if from.TCPPort != 0 {
+			// Override by non-empty values only
+			p.TCPPort = from.TCPPort
+		}
+		if from.HTTPPort != 0 {
+			// Override by non-empty values only
+			p.HTTPPort = from.HTTPPort
+		}
+		if from.InterserverHTTPPort != 0 {
+			// Override by non-empty values only
+			p.InterserverHTTPPort = from.InterserverHTTPPort
+		}
+	}
+}
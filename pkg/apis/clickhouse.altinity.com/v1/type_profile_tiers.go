@@ -0,0 +1,45 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+// MergeFrom merges from specified source
+func (t *ChiProfileTiers) MergeFrom(from *ChiProfileTiers, _type MergeType) {
+	if from == nil {
+		return
+	}
+
+	(&t.Base).MergeFrom(&from.Base, _type)
+
+	switch _type {
+	case MergeTypeFillEmptyValues:
+		if len(t.Tiers) == 0 {
+			t.Tiers = from.Tiers
+		}
+	case MergeTypeOverrideByNonEmptyValues:
+		if len(from.Tiers) > 0 {
+			// Override by non-empty values only
+			t.Tiers = from.Tiers
+		}
+	}
+}
+
+// MergeFrom merges from specified source
+func (l *ChiProfileTierLimits) MergeFrom(from *ChiProfileTierLimits, _type MergeType) {
+	if from == nil {
+		return
+	}
+
+	mergeStringFields(l, from, _type)
+}
@@ -0,0 +1,45 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"github.com/altinity/clickhouse-operator/pkg/util"
+)
+
+// IsDataLossAllowed checks whether shards are allowed to be scaled down below MinReplicasCount
+func (s *ChiScaleDownSafeguards) IsDataLossAllowed() bool {
+	return util.IsStringBoolTrue(s.AllowDataLoss)
+}
+
+// MergeFrom merges from specified source
+func (s *ChiScaleDownSafeguards) MergeFrom(from *ChiScaleDownSafeguards, _type MergeType) {
+	if from == nil {
+		return
+	}
+
+	mergeStringFields(s, from, _type)
+
+	switch _type {
+	case MergeTypeFillEmptyValues:
+		if s.MinReplicasCount == 0 {
+			s.MinReplicasCount = from.MinReplicasCount
+		}
+	case MergeTypeOverrideByNonEmptyValues:
+		if from.MinReplicasCount != 0 {
+			// Override by non-empty values only
+			s.MinReplicasCount = from.MinReplicasCount
+		}
+	}
+}
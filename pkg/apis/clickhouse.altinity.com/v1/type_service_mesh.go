@@ -0,0 +1,36 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import "github.com/altinity/clickhouse-operator/pkg/util"
+
+// IsEnabled checks whether installation runs inside a service mesh
+func (mesh *ChiServiceMesh) IsEnabled() bool {
+	return mesh.Type != ""
+}
+
+// IsInterserverPortExcluded checks whether inter-server port should be excluded from mesh traffic interception
+func (mesh *ChiServiceMesh) IsInterserverPortExcluded() bool {
+	return mesh.IsEnabled() && util.IsStringBoolTrue(mesh.ExcludeInterserverPort)
+}
+
+// MergeFrom merges from specified source
+func (mesh *ChiServiceMesh) MergeFrom(from *ChiServiceMesh, _type MergeType) {
+	if from == nil {
+		return
+	}
+
+	mergeStringFields(mesh, from, _type)
+}
@@ -33,6 +33,12 @@ func (shard *ChiShard) GetServiceTemplate() (*ChiServiceTemplate, bool) {
 	return template, ok
 }
 
+func (shard *ChiShard) GetLeaderServiceTemplate() (*ChiServiceTemplate, bool) {
+	name := shard.Templates.ShardLeaderServiceTemplate
+	template, ok := shard.CHI.GetServiceTemplate(name)
+	return template, ok
+}
+
 func (shard *ChiShard) WalkHosts(
 	f func(host *ChiHost) error,
 ) []error {
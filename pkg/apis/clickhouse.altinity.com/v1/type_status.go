@@ -34,6 +34,8 @@ type ChiStatus struct {
 	FQDNs             []string `json:"fqdns"`
 	Endpoint          string   `json:"endpoint"`
 	NormalizedCHI     ChiSpec  `json:"normalized"`
+	// NormalizedCHICompleted is normalized spec of the last successfully completed reconcile
+	NormalizedCHICompleted ChiSpec `json:"normalizedCompleted"`
 }
 
 const (
@@ -64,9 +66,15 @@ func (s *ChiStatus) ReconcileStart(DeleteHostsCount int) {
 	s.DeleteHostsCount = DeleteHostsCount
 }
 
-func (s *ChiStatus) ReconcileComplete() {
+func (s *ChiStatus) ReconcileComplete(spec *ChiSpec) {
 	s.Status = StatusCompleted
 	s.Action = ""
+	s.NormalizedCHICompleted = *spec
+}
+
+// HasNormalizedCHICompleted checks whether any reconcile has been completed, so the completed spec is known
+func (s *ChiStatus) HasNormalizedCHICompleted() bool {
+	return len(s.NormalizedCHICompleted.Configuration.Clusters) > 0
 }
 
 func (s *ChiStatus) DeleteStart() {
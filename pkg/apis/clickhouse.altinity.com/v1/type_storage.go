@@ -0,0 +1,46 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+// IsEmpty checks whether neither disks nor policies are specified
+func (s *ChiStorage) IsEmpty() bool {
+	return (len(s.Disks) == 0) && (len(s.Policies) == 0)
+}
+
+// MergeFrom merges from specified source
+func (s *ChiStorage) MergeFrom(from *ChiStorage, _type MergeType) {
+	if from == nil {
+		return
+	}
+
+	switch _type {
+	case MergeTypeFillEmptyValues:
+		if len(s.Disks) == 0 {
+			s.Disks = from.Disks
+		}
+		if len(s.Policies) == 0 {
+			s.Policies = from.Policies
+		}
+	case MergeTypeOverrideByNonEmptyValues:
+		if len(from.Disks) > 0 {
+			// Override by non-empty values only
+			s.Disks = from.Disks
+		}
+		if len(from.Policies) > 0 {
+			// Override by non-empty values only
+			s.Policies = from.Policies
+		}
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import "github.com/altinity/clickhouse-operator/pkg/util"
+
+// MergeFrom merges from specified source
+func (logs *ChiSystemLogs) MergeFrom(from *ChiSystemLogs, _type MergeType) {
+	if from == nil {
+		return
+	}
+
+	(&logs.PartLog).MergeFrom(&from.PartLog, _type)
+	(&logs.TextLog).MergeFrom(&from.TextLog, _type)
+	(&logs.TraceLog).MergeFrom(&from.TraceLog, _type)
+	(&logs.CrashLog).MergeFrom(&from.CrashLog, _type)
+
+	mergeStringFields(logs, from, _type)
+}
+
+// IsFlushOnShutdown checks whether system logs have to be flushed before pod termination
+func (logs *ChiSystemLogs) IsFlushOnShutdown() bool {
+	return util.IsStringBoolTrue(logs.FlushOnShutdown)
+}
+
+// IsEnabled checks whether system log is opted in
+func (log *ChiSystemLog) IsEnabled() bool {
+	return util.IsStringBoolTrue(log.Enabled)
+}
+
+// MergeFrom merges from specified source
+func (log *ChiSystemLog) MergeFrom(from *ChiSystemLog, _type MergeType) {
+	if from == nil {
+		return
+	}
+
+	mergeStringFields(log, from, _type)
+}
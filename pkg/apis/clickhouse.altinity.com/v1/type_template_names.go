@@ -47,9 +47,15 @@ func (templateNames *ChiTemplateNames) MergeFrom(from *ChiTemplateNames, _type M
 		if templateNames.ShardServiceTemplate == "" {
 			templateNames.ShardServiceTemplate = from.ShardServiceTemplate
 		}
+		if templateNames.ShardLeaderServiceTemplate == "" {
+			templateNames.ShardLeaderServiceTemplate = from.ShardLeaderServiceTemplate
+		}
 		if templateNames.ReplicaServiceTemplate == "" {
 			templateNames.ReplicaServiceTemplate = from.ReplicaServiceTemplate
 		}
+		if len(templateNames.ExtraVolumeClaimTemplates) == 0 {
+			templateNames.ExtraVolumeClaimTemplates = append(templateNames.ExtraVolumeClaimTemplates, from.ExtraVolumeClaimTemplates...)
+		}
 	case MergeTypeOverrideByNonEmptyValues:
 		// Override by non-empty values only
 		if from.HostTemplate != "" {
@@ -76,8 +82,14 @@ func (templateNames *ChiTemplateNames) MergeFrom(from *ChiTemplateNames, _type M
 		if from.ShardServiceTemplate != "" {
 			templateNames.ShardServiceTemplate = from.ShardServiceTemplate
 		}
+		if from.ShardLeaderServiceTemplate != "" {
+			templateNames.ShardLeaderServiceTemplate = from.ShardLeaderServiceTemplate
+		}
 		if from.ReplicaServiceTemplate != "" {
 			templateNames.ReplicaServiceTemplate = from.ReplicaServiceTemplate
 		}
+		if len(from.ExtraVolumeClaimTemplates) > 0 {
+			templateNames.ExtraVolumeClaimTemplates = append([]ChiVolumeClaimTemplateMount{}, from.ExtraVolumeClaimTemplates...)
+		}
 	}
 }
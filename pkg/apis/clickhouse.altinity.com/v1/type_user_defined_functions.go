@@ -0,0 +1,42 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+// IsDeclared checks whether user defined functions are provided
+func (udf *ChiUserDefinedFunctions) IsDeclared() bool {
+	return (udf.ConfigMap != "") || (udf.Secret != "")
+}
+
+// MergeFrom merges from specified source
+func (udf *ChiUserDefinedFunctions) MergeFrom(from *ChiUserDefinedFunctions, _type MergeType) {
+	if from == nil {
+		return
+	}
+
+	switch _type {
+	case MergeTypeFillEmptyValues:
+		if !udf.IsDeclared() {
+			udf.ConfigMap = from.ConfigMap
+			udf.Secret = from.Secret
+		}
+	case MergeTypeOverrideByNonEmptyValues:
+		if from.IsDeclared() {
+			// Override by non-empty values only.
+			// Functions are provided by one source only, thus the whole section is overridden
+			udf.ConfigMap = from.ConfigMap
+			udf.Secret = from.Secret
+		}
+	}
+}
@@ -18,6 +18,21 @@ func (zkc *ChiZookeeperConfig) IsEmpty() bool {
 	return len(zkc.Nodes) == 0
 }
 
+// IsSecure checks whether any of ZooKeeper nodes requires TLS connection
+func (zkc *ChiZookeeperConfig) IsSecure() bool {
+	for i := range zkc.Nodes {
+		if zkc.Nodes[i].IsSecure() {
+			return true
+		}
+	}
+	return false
+}
+
+// HasTLSSecret checks whether ZooKeeper client certificates have to be mounted from Secret
+func (zkc *ChiZookeeperConfig) HasTLSSecret() bool {
+	return zkc.IsSecure() && (zkc.TLS.Secret != "")
+}
+
 func (zkc *ChiZookeeperConfig) MergeFrom(from *ChiZookeeperConfig, _type MergeType) {
 	if from == nil {
 		return
@@ -61,4 +76,7 @@ func (zkc *ChiZookeeperConfig) MergeFrom(from *ChiZookeeperConfig, _type MergeTy
 	if from.Identity != "" {
 		zkc.Identity = from.Identity
 	}
+	if from.TLS.Secret != "" {
+		zkc.TLS.Secret = from.TLS.Secret
+	}
 }
@@ -14,6 +14,8 @@
 
 package v1
 
+import "github.com/altinity/clickhouse-operator/pkg/util"
+
 func (zkNode *ChiZookeeperNode) Equal(to *ChiZookeeperNode) bool {
 	if to == nil {
 		return false
@@ -21,3 +23,8 @@ func (zkNode *ChiZookeeperNode) Equal(to *ChiZookeeperNode) bool {
 
 	return (zkNode.Host == to.Host) && (zkNode.Port == to.Port)
 }
+
+// IsSecure checks whether connection to the node has to be secured with TLS
+func (zkNode *ChiZookeeperNode) IsSecure() bool {
+	return util.IsStringBoolTrue(zkNode.Secure)
+}
@@ -58,12 +58,16 @@ type ClickHouseOperatorConfiguration struct {
 
 // ChiSpec defines spec section of ClickHouseInstallation resource
 type ChiSpec struct {
-	Stop                   string           `json:"stop,omitempty"                   yaml:"stop"`
-	NamespaceDomainPattern string           `json:"namespaceDomainPattern,omitempty" yaml:"namespaceDomainPattern"`
-	Defaults               ChiDefaults      `json:"defaults,omitempty"               yaml:"defaults"`
-	Configuration          Configuration    `json:"configuration"                    yaml:"configuration"`
-	Templates              ChiTemplates     `json:"templates,omitempty"              yaml:"templates"`
-	UseTemplates           []ChiUseTemplate `json:"useTemplates,omitempty"           yaml:"useTemplates"`
+	Stop                   string            `json:"stop,omitempty"                   yaml:"stop"`
+	NamespaceDomainPattern string            `json:"namespaceDomainPattern,omitempty" yaml:"namespaceDomainPattern"`
+	ServiceNamespace       string            `json:"serviceNamespace,omitempty"       yaml:"serviceNamespace"`
+	PropagateAnnotations   []string          `json:"propagateAnnotations,omitempty"   yaml:"propagateAnnotations"`
+	Labels                 map[string]string `json:"labels,omitempty"                 yaml:"labels"`
+	Annotations            map[string]string `json:"annotations,omitempty"            yaml:"annotations"`
+	Defaults               ChiDefaults       `json:"defaults,omitempty"               yaml:"defaults"`
+	Configuration          Configuration     `json:"configuration"                    yaml:"configuration"`
+	Templates              ChiTemplates      `json:"templates,omitempty"              yaml:"templates"`
+	UseTemplates           []ChiUseTemplate  `json:"useTemplates,omitempty"           yaml:"useTemplates"`
 }
 
 // ChiUseTemplates defines UseTemplates section of ClickHouseInstallation resource
@@ -75,9 +79,57 @@ type ChiUseTemplate struct {
 
 // ChiDefaults defines defaults section of .spec
 type ChiDefaults struct {
-	ReplicasUseFQDN string            `json:"replicasUseFQDN,omitempty" yaml:"replicasUseFQDN"`
-	DistributedDDL  ChiDistributedDDL `json:"distributedDDL,omitempty"  yaml:"distributedDDL"`
-	Templates       ChiTemplateNames  `json:"templates,omitempty"       yaml:"templates"`
+	ReplicasUseFQDN                string                    `json:"replicasUseFQDN,omitempty"                yaml:"replicasUseFQDN"`
+	ReplicaAntiAffinityTopologyKey string                    `json:"replicaAntiAffinityTopologyKey,omitempty" yaml:"replicaAntiAffinityTopologyKey"`
+	ShardAntiAffinity              string                    `json:"shardAntiAffinity,omitempty"              yaml:"shardAntiAffinity"`
+	DistributedDDL                 ChiDistributedDDL         `json:"distributedDDL,omitempty"                 yaml:"distributedDDL"`
+	DistributedQueries             ChiDistributedQueries     `json:"distributedQueries,omitempty"             yaml:"distributedQueries"`
+	FilesystemRead                 ChiFilesystemRead         `json:"filesystemRead,omitempty"                 yaml:"filesystemRead"`
+	SecureByDefault                string                    `json:"secureByDefault,omitempty"                yaml:"secureByDefault"`
+	PodDisruptionBudget            string                    `json:"podDisruptionBudget,omitempty"            yaml:"podDisruptionBudget"`
+	RoleServices                   string                    `json:"roleServices,omitempty"                   yaml:"roleServices"`
+	CertRotationToken              string                    `json:"certRotationToken,omitempty"              yaml:"certRotationToken"`
+	CertRotationTokenSecret        *corev1.SecretKeySelector `json:"certRotationTokenSecret,omitempty"        yaml:"certRotationTokenSecret"`
+	LogToConsole                   string                    `json:"logToConsole,omitempty"                   yaml:"logToConsole"`
+	LogFormat                      string                    `json:"logFormat,omitempty"                      yaml:"logFormat"`
+	InterserverListenHost          string                    `json:"interserverListenHost,omitempty"          yaml:"interserverListenHost"`
+	MaxOpenFiles                   string                    `json:"maxOpenFiles,omitempty"                   yaml:"maxOpenFiles"`
+	ReadinessProbe                 ChiReadinessProbe         `json:"readinessProbe,omitempty"                 yaml:"readinessProbe"`
+	LivenessProbe                  ChiLivenessProbe          `json:"livenessProbe,omitempty"                  yaml:"livenessProbe"`
+	DefaultProfile                 string                    `json:"defaultProfile,omitempty"                 yaml:"defaultProfile"`
+	DefaultQuota                   string                    `json:"defaultQuota,omitempty"                   yaml:"defaultQuota"`
+	DefaultDatabase                string                    `json:"defaultDatabase,omitempty"                yaml:"defaultDatabase"`
+	UseDefaultDatabase             string                    `json:"useDefaultDatabase,omitempty"             yaml:"useDefaultDatabase"`
+	CompressionCodec               string                    `json:"compressionCodec,omitempty"               yaml:"compressionCodec"`
+	ReplicaPath                    string                    `json:"replicaPath,omitempty"                    yaml:"replicaPath"`
+	ReplicaName                    string                    `json:"replicaName,omitempty"                    yaml:"replicaName"`
+	ServiceMesh                    ChiServiceMesh            `json:"serviceMesh,omitempty"                    yaml:"serviceMesh"`
+	DropSafeguards                 ChiDropSafeguards         `json:"dropSafeguards,omitempty"                 yaml:"dropSafeguards"`
+	Caches                         ChiCaches                 `json:"caches,omitempty"                         yaml:"caches"`
+	MergeLimits                    ChiMergeLimits            `json:"mergeLimits,omitempty"                    yaml:"mergeLimits"`
+	DNSCache                       ChiDNSCache               `json:"dnsCache,omitempty"                       yaml:"dnsCache"`
+	MemoryTracker                  ChiMemoryTracker          `json:"memoryTracker,omitempty"                  yaml:"memoryTracker"`
+	ScaleDownSafeguards            ChiScaleDownSafeguards    `json:"scaleDownSafeguards,omitempty"            yaml:"scaleDownSafeguards"`
+	Container                      ChiContainerDefaults      `json:"container,omitempty"                      yaml:"container"`
+	TmpVolume                      ChiTmpVolume              `json:"tmpVolume,omitempty"                      yaml:"tmpVolume"`
+	ShmVolume                      ChiShmVolume              `json:"shmVolume,omitempty"                      yaml:"shmVolume"`
+	DataVolumeChown                ChiDataVolumeChown        `json:"dataVolumeChown,omitempty"                yaml:"dataVolumeChown"`
+	SecurityContext                ChiSecurityContext        `json:"securityContext,omitempty"                yaml:"securityContext"`
+	ServiceAccount                 ChiServiceAccount         `json:"serviceAccount,omitempty"                 yaml:"serviceAccount"`
+	UpdateStrategy                 ChiUpdateStrategy         `json:"updateStrategy,omitempty"                 yaml:"updateStrategy"`
+	PodAffinity                    *corev1.PodAffinity       `json:"podAffinity,omitempty"                    yaml:"podAffinity"`
+	NodeSelector                   map[string]string         `json:"nodeSelector,omitempty"                   yaml:"nodeSelector"`
+	Tolerations                    []corev1.Toleration       `json:"tolerations,omitempty"                    yaml:"tolerations"`
+	ShardBaseIndex                 int                       `json:"shardBaseIndex,omitempty"                 yaml:"shardBaseIndex"`
+	ReplicaBaseIndex               int                       `json:"replicaBaseIndex,omitempty"               yaml:"replicaBaseIndex"`
+	StorageSize                    string                    `json:"storageSize,omitempty"                    yaml:"storageSize"`
+	ConfigDirPath                  string                    `json:"configDirPath,omitempty"                  yaml:"configDirPath"`
+	TerminationGracePeriodSeconds  string                    `json:"terminationGracePeriodSeconds,omitempty"  yaml:"terminationGracePeriodSeconds"`
+	HostNetwork                    string                    `json:"hostNetwork,omitempty"                    yaml:"hostNetwork"`
+	DNSPolicy                      corev1.DNSPolicy          `json:"dnsPolicy,omitempty"                      yaml:"dnsPolicy"`
+	ServiceClusterIP               string                    `json:"serviceClusterIP,omitempty"               yaml:"serviceClusterIP"`
+	Ports                          ChiPorts                  `json:"ports,omitempty"                          yaml:"ports"`
+	Templates                      ChiTemplateNames          `json:"templates,omitempty"                      yaml:"templates"`
 }
 
 // ChiTemplateNames defines references to .spec.templates to be used on current level of cluster
@@ -91,7 +143,17 @@ type ChiTemplateNames struct {
 	ServiceTemplate        string `json:"serviceTemplate,omitempty"         yaml:"serviceTemplate"`
 	ClusterServiceTemplate string `json:"clusterServiceTemplate,omitempty"  yaml:"clusterServiceTemplate"`
 	ShardServiceTemplate   string `json:"shardServiceTemplate,omitempty"    yaml:"shardServiceTemplate"`
-	ReplicaServiceTemplate string `json:"replicaServiceTemplate,omitempty"  yaml:"replicaServiceTemplate"`
+	// ShardLeaderServiceTemplate specifies Service, which targets first replica of the shard only
+	ShardLeaderServiceTemplate string `json:"shardLeaderServiceTemplate,omitempty" yaml:"shardLeaderServiceTemplate"`
+	ReplicaServiceTemplate     string `json:"replicaServiceTemplate,omitempty"  yaml:"replicaServiceTemplate"`
+	// ExtraVolumeClaimTemplates specifies additional VolumeClaimTemplates mounted into ClickHouse container, such as disks of storage policies
+	ExtraVolumeClaimTemplates []ChiVolumeClaimTemplateMount `json:"extraVolumeClaimTemplates,omitempty" yaml:"extraVolumeClaimTemplates"`
+}
+
+// ChiVolumeClaimTemplateMount defines reference to VolumeClaimTemplate along with path it is mounted at
+type ChiVolumeClaimTemplateMount struct {
+	Name      string `json:"name"      yaml:"name"`
+	MountPath string `json:"mountPath" yaml:"mountPath"`
 }
 
 // ChiShard defines item of a shard section of .spec.configuration.clusters[n].shards
@@ -100,13 +162,16 @@ type ChiShard struct {
 	// DEPRECATED - to be removed soon
 	DefinitionType string `json:"definitionType"`
 
-	Name                string           `json:"name,omitempty"`
-	Weight              int              `json:"weight,omitempty"`
-	InternalReplication string           `json:"internalReplication,omitempty"`
-	Settings            Settings         `json:"settings,omitempty"`
-	Files               Settings         `json:"files,omitempty"`
-	Templates           ChiTemplateNames `json:"templates,omitempty"`
-	ReplicasCount       int              `json:"replicasCount,omitempty"`
+	Name                   string             `json:"name,omitempty"`
+	Weight                 int                `json:"weight,omitempty"`
+	InternalReplication    string             `json:"internalReplication,omitempty"`
+	Settings               Settings           `json:"settings,omitempty"`
+	Files                  Settings           `json:"files,omitempty"`
+	Templates              ChiTemplateNames   `json:"templates,omitempty"`
+	DataVolumeSize         string             `json:"dataVolumeSize,omitempty"`
+	BackgroundPools        ChiBackgroundPools `json:"backgroundPools,omitempty"`
+	StatefulSetAnnotations map[string]string  `json:"statefulSetAnnotations,omitempty"`
+	ReplicasCount          int                `json:"replicasCount,omitempty"`
 	// TODO refactor into map[string]ChiHost
 	Hosts []*ChiHost `json:"replicas,omitempty"`
 
@@ -118,11 +183,12 @@ type ChiShard struct {
 // ChiReplica defines item of a replica section of .spec.configuration.clusters[n].replicas
 // TODO unify with ChiShard based on HostsSet
 type ChiReplica struct {
-	Name        string           `json:"name,omitempty"`
-	Settings    Settings         `json:"settings,omitempty"`
-	Files       Settings         `json:"files,omitempty"`
-	Templates   ChiTemplateNames `json:"templates,omitempty"`
-	ShardsCount int              `json:"shardsCount,omitempty"`
+	Name                   string            `json:"name,omitempty"`
+	Settings               Settings          `json:"settings,omitempty"`
+	Files                  Settings          `json:"files,omitempty"`
+	Templates              ChiTemplateNames  `json:"templates,omitempty"`
+	StatefulSetAnnotations map[string]string `json:"statefulSetAnnotations,omitempty"`
+	ShardsCount            int               `json:"shardsCount,omitempty"`
 	// TODO refactor into map[string]ChiHost
 	Hosts []*ChiHost `json:"shards,omitempty"`
 
@@ -155,13 +221,16 @@ type ChiReplicaAddress struct {
 type ChiHost struct {
 	Name string `json:"name,omitempty"`
 	// DEPRECATED - to be removed soon
-	Port                int32            `json:"port,omitempty"`
-	TCPPort             int32            `json:"tcpPort,omitempty"`
-	HTTPPort            int32            `json:"httpPort,omitempty"`
-	InterserverHTTPPort int32            `json:"interserverHTTPPort,omitempty"`
-	Settings            Settings         `json:"settings,omitempty"`
-	Files               Settings         `json:"files,omitempty"`
-	Templates           ChiTemplateNames `json:"templates,omitempty"`
+	Port                   int32              `json:"port,omitempty"`
+	TCPPort                int32              `json:"tcpPort,omitempty"`
+	HTTPPort               int32              `json:"httpPort,omitempty"`
+	InterserverHTTPPort    int32              `json:"interserverHTTPPort,omitempty"`
+	Settings               Settings           `json:"settings,omitempty"`
+	Files                  Settings           `json:"files,omitempty"`
+	Templates              ChiTemplateNames   `json:"templates,omitempty"`
+	DataVolumeSize         string             `json:"dataVolumeSize,omitempty"`
+	BackgroundPools        ChiBackgroundPools `json:"backgroundPools,omitempty"`
+	StatefulSetAnnotations map[string]string  `json:"statefulSetAnnotations,omitempty"`
 
 	// Internal data
 	Address     ChiHostAddress          `json:"-"`
@@ -209,6 +278,15 @@ type ChiHostConfig struct {
 	ZookeeperFingerprint string `json:"zookeeperfingerprint"`
 	SettingsFingerprint  string `json:"settingsfingerprint"`
 	FilesFingerprint     string `json:"filesfingerprint"`
+	// Fingerprints of settings, which require ClickHouse restart to be applied, and of hot-reloadable settings
+	RestartSettingsFingerprint string `json:"restartsettingsfingerprint"`
+	HotSettingsFingerprint     string `json:"hotsettingsfingerprint"`
+	// Fingerprint of certificates Secret, specified by .spec.defaults.certRotationTokenSecret, resolved on reconcile
+	CertRotationTokenFingerprint string `json:"certrotationtokenfingerprint"`
+	// Name of existing StatefulSet of the host, created with previous naming scheme, resolved on normalization
+	StatefulSetNameAlias string `json:"statefulsetnamealias"`
+	// serviceName of existing StatefulSet of the host, which is immutable, resolved on normalization
+	StatefulSetServiceName string `json:"statefulsetservicename"`
 }
 
 // CHITemplates defines templates section of .spec
@@ -250,9 +328,17 @@ type ChiPodDistribution struct {
 
 // ChiVolumeClaimTemplate defines PersistentVolumeClaim Template, directly used by StatefulSet
 type ChiVolumeClaimTemplate struct {
-	Name             string                           `json:"name"          yaml:"name"`
-	PVCReclaimPolicy PVCReclaimPolicy                 `json:"reclaimPolicy" yaml:"reclaimPolicy"`
-	Spec             corev1.PersistentVolumeClaimSpec `json:"spec"          yaml:"spec"`
+	Name             string                           `json:"name"                       yaml:"name"`
+	PVCReclaimPolicy PVCReclaimPolicy                 `json:"reclaimPolicy"              yaml:"reclaimPolicy"`
+	ReadOnly         bool                             `json:"readOnly,omitempty"         yaml:"readOnly"`
+	MountPropagation *corev1.MountPropagationMode     `json:"mountPropagation,omitempty" yaml:"mountPropagation"`
+	Spec             corev1.PersistentVolumeClaimSpec `json:"spec"                       yaml:"spec"`
+	EmptyDir         *corev1.EmptyDirVolumeSource     `json:"emptyDir,omitempty"         yaml:"emptyDir"`
+}
+
+// IsEphemeral checks whether volume is provided as pod's emptyDir instead of PersistentVolumeClaim
+func (t *ChiVolumeClaimTemplate) IsEphemeral() bool {
+	return t.EmptyDir != nil
 }
 
 type PVCReclaimPolicy string
@@ -282,7 +368,336 @@ type ChiServiceTemplate struct {
 
 // ChiDistributedDDL defines distributedDDL section of .spec.defaults
 type ChiDistributedDDL struct {
-	Profile string `json:"profile,omitempty" yaml:"profile"`
+	Profile string `json:"profile,omitempty"            yaml:"profile"`
+	// Whether queue cleanup settings should be generated. StringBool
+	Cleanup string `json:"cleanup,omitempty"            yaml:"cleanup"`
+	// max_tasks_in_queue
+	MaxTasksInQueue string `json:"maxTasksInQueue,omitempty"    yaml:"maxTasksInQueue"`
+	// task_max_lifetime, seconds
+	TaskMaxLifetime string `json:"taskMaxLifetime,omitempty"    yaml:"taskMaxLifetime"`
+	// cleanup_delay_period, seconds
+	CleanupDelayPeriod string `json:"cleanupDelayPeriod,omitempty" yaml:"cleanupDelayPeriod"`
+}
+
+// ChiDistributedQueries defines distributedQueries section of .spec.defaults
+// Specified values are applied to default profile
+type ChiDistributedQueries struct {
+	// distributed_product_mode
+	ProductMode string `json:"productMode,omitempty"            yaml:"productMode"`
+	// prefer_localhost_replica, StringBool
+	PreferLocalhostReplica string `json:"preferLocalhostReplica,omitempty" yaml:"preferLocalhostReplica"`
+	// load_balancing
+	LoadBalancing string `json:"loadBalancing,omitempty"          yaml:"loadBalancing"`
+	// distributed_replica_error_half_life, in seconds
+	ReplicaErrorHalfLife string `json:"replicaErrorHalfLife,omitempty"   yaml:"replicaErrorHalfLife"`
+	// distributed_replica_error_cap
+	ReplicaErrorCap string `json:"replicaErrorCap,omitempty"        yaml:"replicaErrorCap"`
+}
+
+// ChiFilesystemRead defines filesystemRead section of .spec.defaults
+type ChiFilesystemRead struct {
+	// local_filesystem_read_method
+	Method string `json:"method,omitempty"            yaml:"method"`
+	// max_read_buffer_size, size such as '1Mi'
+	MaxReadBufferSize string `json:"maxReadBufferSize,omitempty" yaml:"maxReadBufferSize"`
+}
+
+// ChiReadinessProbe defines readinessProbe section of .spec.defaults
+type ChiReadinessProbe struct {
+	// Either "ping" or "replicas_status"
+	Mode string `json:"mode,omitempty"            yaml:"mode"`
+	// Replication lag acceptable by "replicas_status" mode, in seconds
+	MaxReplicaDelay string `json:"maxReplicaDelay,omitempty" yaml:"maxReplicaDelay"`
+	// Probe timings, in seconds
+	InitialDelaySeconds string `json:"initialDelaySeconds,omitempty" yaml:"initialDelaySeconds"`
+	PeriodSeconds       string `json:"periodSeconds,omitempty"       yaml:"periodSeconds"`
+}
+
+// ChiLivenessProbe defines livenessProbe section of .spec.defaults
+type ChiLivenessProbe struct {
+	// Whether liveness probe is added to ClickHouse container. StringBool
+	Enabled string `json:"enabled,omitempty"             yaml:"enabled"`
+	// Probe timings, in seconds. Slow-starting hosts require larger initial delay in order not to be killed
+	InitialDelaySeconds string `json:"initialDelaySeconds,omitempty" yaml:"initialDelaySeconds"`
+	PeriodSeconds       string `json:"periodSeconds,omitempty"       yaml:"periodSeconds"`
+}
+
+// ChiServiceMesh defines serviceMesh section of .spec.defaults
+type ChiServiceMesh struct {
+	// Either "istio" or "linkerd"
+	Type string `json:"type,omitempty"                   yaml:"type"`
+	// Whether inter-server port should be excluded from mesh traffic interception. StringBool
+	ExcludeInterserverPort string `json:"excludeInterserverPort,omitempty" yaml:"excludeInterserverPort"`
+}
+
+// ChiContainerDefaults defines container section of .spec.defaults
+// Specified values are applied to ClickHouse container in case container does not specify them explicitly
+type ChiContainerDefaults struct {
+	// Name of ClickHouse container within pod templates, other containers are considered to be sidecars
+	Name       string `json:"name,omitempty"        yaml:"name"`
+	WorkingDir string `json:"workingDir,omitempty"  yaml:"workingDir"`
+	// HOME env var
+	Home string `json:"home,omitempty"        yaml:"home"`
+	// Whether SYS_RESOURCE capability should be added, so ClickHouse can raise open files limit. StringBool
+	SysResource string `json:"sysResource,omitempty" yaml:"sysResource"`
+	// ClickHouse image, used in case container does not specify one
+	Image string `json:"image,omitempty" yaml:"image"`
+	// Pull policy of ClickHouse image
+	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy,omitempty" yaml:"imagePullPolicy"`
+	// Secrets attached to pod spec in order to pull images from private registry
+	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty" yaml:"imagePullSecrets"`
+	// Env vars of ClickHouse container. Env vars specified in Pod Template and provided by operator take precedence
+	Env []corev1.EnvVar `json:"env,omitempty" yaml:"env"`
+}
+
+// ChiSecurityContext defines securityContext section of .spec.defaults
+// Fields are applied to pod-level security context of pods, which do not specify them
+type ChiSecurityContext struct {
+	RunAsUser  string `json:"runAsUser,omitempty"  yaml:"runAsUser"`
+	RunAsGroup string `json:"runAsGroup,omitempty" yaml:"runAsGroup"`
+	FSGroup    string `json:"fsGroup,omitempty"    yaml:"fsGroup"`
+	// StringBool
+	RunAsNonRoot string `json:"runAsNonRoot,omitempty" yaml:"runAsNonRoot"`
+}
+
+// ChiServiceAccount defines serviceAccount section of .spec.defaults
+// Pods run under either generated or existing ServiceAccount
+type ChiServiceAccount struct {
+	// Whether ServiceAccount named after CHI should be generated. StringBool
+	Create string `json:"create,omitempty" yaml:"create"`
+	// Name of existing ServiceAccount to be referenced, nothing is generated in this case
+	Name string `json:"name,omitempty"   yaml:"name"`
+}
+
+// ChiDataVolumeChown defines dataVolumeChown section of .spec.defaults
+// Init container changes owner of data volume, in case fsGroup is not enough to fix permissions
+type ChiDataVolumeChown struct {
+	// Whether init container should be generated. StringBool
+	Enabled string `json:"enabled,omitempty" yaml:"enabled"`
+	// Owner user ID, ClickHouse image user by default
+	UID string `json:"uid,omitempty"     yaml:"uid"`
+	// Owner group ID, ClickHouse image group by default
+	GID string `json:"gid,omitempty"     yaml:"gid"`
+	// Image of init container, busybox by default
+	Image string `json:"image,omitempty"   yaml:"image"`
+	// Command of init container, chown of data volume by default
+	Command []string `json:"command,omitempty" yaml:"command"`
+}
+
+// ChiUpdateStrategy defines updateStrategy section of .spec.defaults
+// Specifies how hosts' StatefulSets roll pods out
+type ChiUpdateStrategy struct {
+	// Type is either RollingUpdate (default) or OnDelete
+	Type string `json:"type,omitempty"      yaml:"type"`
+	// CHI-scope index of the first host, which pods are rolled out by RollingUpdate. Hosts with lower indexes keep previous revision
+	Partition string `json:"partition,omitempty" yaml:"partition"`
+}
+
+// ChiTmpVolume defines tmpVolume section of .spec.defaults
+// Specifies volume to be used for ClickHouse tmp_path instead of data volume
+type ChiTmpVolume struct {
+	// Type is either empty (data volume is used) or emptyDir
+	Type string `json:"type,omitempty"      yaml:"type"`
+	// Medium of emptyDir, either empty (node disk) or Memory
+	Medium    string `json:"medium,omitempty"    yaml:"medium"`
+	SizeLimit string `json:"sizeLimit,omitempty" yaml:"sizeLimit"`
+}
+
+// ChiShmVolume defines shmVolume section of .spec.defaults
+// Specifies in-memory emptyDir volume to be mounted as /dev/shm instead of container runtime default one
+type ChiShmVolume struct {
+	// Whether /dev/shm volume has to be mounted. StringBool
+	Enabled   string `json:"enabled,omitempty"   yaml:"enabled"`
+	SizeLimit string `json:"sizeLimit,omitempty" yaml:"sizeLimit"`
+}
+
+// ChiSystemLogs defines systemLogs section of .spec.configuration
+type ChiSystemLogs struct {
+	PartLog  ChiSystemLog `json:"partLog,omitempty"  yaml:"partLog"`
+	TextLog  ChiSystemLog `json:"textLog,omitempty"  yaml:"textLog"`
+	TraceLog ChiSystemLog `json:"traceLog,omitempty" yaml:"traceLog"`
+	CrashLog ChiSystemLog `json:"crashLog,omitempty" yaml:"crashLog"`
+	// Whether system logs are flushed by preStop hook before pod termination. StringBool
+	FlushOnShutdown string `json:"flushOnShutdown,omitempty" yaml:"flushOnShutdown"`
+}
+
+// ChiSystemLog defines ClickHouse system log table, such as system.part_log
+type ChiSystemLog struct {
+	Enabled string `json:"enabled,omitempty"                   yaml:"enabled"`
+	// Retention of log records, in days
+	TTLDays string `json:"ttlDays,omitempty"                   yaml:"ttlDays"`
+	// How often log records are flushed into the table
+	FlushIntervalMilliseconds string `json:"flushIntervalMilliseconds,omitempty" yaml:"flushIntervalMilliseconds"`
+	// MergeTree family engine of the table, such as 'MergeTree ORDER BY event_time'. Replaces default engine along with TTL
+	Engine string `json:"engine,omitempty"                    yaml:"engine"`
+}
+
+// ChiCoreDump defines coreDump section of .spec.configuration
+type ChiCoreDump struct {
+	// Max size of core dump file, bytes or resource.Quantity
+	SizeLimit string `json:"sizeLimit,omitempty" yaml:"sizeLimit"`
+}
+
+// ChiKeeper defines keeper section of .spec.configuration
+type ChiKeeper struct {
+	CoordinationSettings ChiKeeperCoordinationSettings `json:"coordinationSettings,omitempty" yaml:"coordinationSettings"`
+}
+
+// ChiKeeperCoordinationSettings defines <keeper_server><coordination_settings> of ClickHouse Keeper
+type ChiKeeperCoordinationSettings struct {
+	OperationTimeoutMs string `json:"operationTimeoutMs,omitempty" yaml:"operationTimeoutMs"`
+	SessionTimeoutMs   string `json:"sessionTimeoutMs,omitempty"   yaml:"sessionTimeoutMs"`
+	RaftLogsLevel      string `json:"raftLogsLevel,omitempty"      yaml:"raftLogsLevel"`
+	SnapshotDistance   string `json:"snapshotDistance,omitempty"   yaml:"snapshotDistance"`
+}
+
+// ChiFilesystemCache defines filesystemCache section of .spec.configuration
+// Cache disk is layered over remote disk, such as S3 disk, and keeps cached data on local volume
+type ChiFilesystemCache struct {
+	// Name of remote disk to be cached, has to be specified in storage_configuration
+	Disk string `json:"disk,omitempty"                yaml:"disk"`
+	// Name of cache disk, '<disk>_cache' by default
+	Name string `json:"name,omitempty"                yaml:"name"`
+	// Max size of the cache, as resource.Quantity
+	MaxSize string `json:"maxSize,omitempty"             yaml:"maxSize"`
+	// VolumeClaimTemplate to keep the cache on. emptyDir is used in case not specified
+	VolumeClaimTemplate string `json:"volumeClaimTemplate,omitempty" yaml:"volumeClaimTemplate"`
+	// Name of storage policy with the cache disk to be generated, if any
+	Policy string `json:"policy,omitempty"              yaml:"policy"`
+}
+
+// ChiStorage defines storage section of .spec.configuration
+// Disks and storage policies are rendered as <storage_configuration>, so data can be tiered between disks
+type ChiStorage struct {
+	Disks    []ChiStorageDisk   `json:"disks,omitempty"    yaml:"disks"`
+	Policies []ChiStoragePolicy `json:"policies,omitempty" yaml:"policies"`
+}
+
+// ChiStorageDisk defines local disk of storage configuration
+type ChiStorageDisk struct {
+	Name string `json:"name,omitempty"               yaml:"name"`
+	// Absolute path of the disk, has to be mounted into ClickHouse container
+	Path string `json:"path,omitempty"               yaml:"path"`
+	// keep_free_space_bytes
+	KeepFreeSpaceBytes string `json:"keepFreeSpaceBytes,omitempty" yaml:"keepFreeSpaceBytes"`
+}
+
+// ChiStoragePolicy defines storage policy as ordered list of volumes, data parts are moved from one volume to the next one
+type ChiStoragePolicy struct {
+	Name    string             `json:"name,omitempty"       yaml:"name"`
+	Volumes []ChiStorageVolume `json:"volumes,omitempty"    yaml:"volumes"`
+	// move_factor, share of free space of a volume, below which parts are moved to the next volume
+	MoveFactor string `json:"moveFactor,omitempty" yaml:"moveFactor"`
+}
+
+// ChiStorageVolume defines volume of storage policy
+type ChiStorageVolume struct {
+	Name  string   `json:"name,omitempty"  yaml:"name"`
+	Disks []string `json:"disks,omitempty" yaml:"disks"`
+}
+
+// ChiBackups defines backups section of .spec.configuration
+// BACKUP/RESTORE I/O threads and bandwidth are bounded, so backups do not impact live queries
+type ChiBackups struct {
+	// Name of disk BACKUP/RESTORE is allowed to use, has to be specified in storage_configuration
+	Disk string `json:"disk,omitempty"         yaml:"disk"`
+	// max_backups_io_thread_pool_size
+	IOThreads string `json:"ioThreads,omitempty"    yaml:"ioThreads"`
+	// max_backup_bandwidth_for_server, bytes per second
+	MaxBandwidth string `json:"maxBandwidth,omitempty" yaml:"maxBandwidth"`
+}
+
+// ChiDropSafeguards defines dropSafeguards section of .spec.defaults
+// Specified values, in bytes, are applied to settings
+type ChiDropSafeguards struct {
+	// max_table_size_to_drop
+	MaxTableSizeToDrop string `json:"maxTableSizeToDrop,omitempty"     yaml:"maxTableSizeToDrop"`
+	// max_partition_size_to_drop
+	MaxPartitionSizeToDrop string `json:"maxPartitionSizeToDrop,omitempty" yaml:"maxPartitionSizeToDrop"`
+}
+
+// ChiCaches defines caches section of .spec.defaults
+// Specified values are applied to settings
+type ChiCaches struct {
+	// mark_cache_size, bytes or resource.Quantity
+	MarkCacheSize string `json:"markCacheSize,omitempty"         yaml:"markCacheSize"`
+	// uncompressed_cache_size, bytes or resource.Quantity
+	UncompressedCacheSize string `json:"uncompressedCacheSize,omitempty" yaml:"uncompressedCacheSize"`
+	// mmap_cache_size, number of mapped files
+	MmapCacheSize string `json:"mmapCacheSize,omitempty"         yaml:"mmapCacheSize"`
+}
+
+// ChiMergeLimits defines <merge_tree> settings capping concurrent merges and mutations
+type ChiMergeLimits struct {
+	// max_bytes_to_merge_at_max_space_in_pool, bytes or resource.Quantity
+	MaxBytesToMergeAtMaxSpaceInPool string `json:"maxBytesToMergeAtMaxSpaceInPool,omitempty"                yaml:"maxBytesToMergeAtMaxSpaceInPool"`
+	// max_bytes_to_merge_at_min_space_in_pool, bytes or resource.Quantity
+	MaxBytesToMergeAtMinSpaceInPool string `json:"maxBytesToMergeAtMinSpaceInPool,omitempty"                yaml:"maxBytesToMergeAtMinSpaceInPool"`
+	// max_replicated_merges_in_queue
+	MaxReplicatedMergesInQueue string `json:"maxReplicatedMergesInQueue,omitempty"                     yaml:"maxReplicatedMergesInQueue"`
+	// max_replicated_mutations_in_queue
+	MaxReplicatedMutationsInQueue string `json:"maxReplicatedMutationsInQueue,omitempty"                  yaml:"maxReplicatedMutationsInQueue"`
+	// number_of_free_entries_in_pool_to_lower_max_size_of_merge
+	NumberOfFreeEntriesInPoolToLowerMaxSizeOfMerge string `json:"numberOfFreeEntriesInPoolToLowerMaxSizeOfMerge,omitempty" yaml:"numberOfFreeEntriesInPoolToLowerMaxSizeOfMerge"`
+	// number_of_free_entries_in_pool_to_execute_mutation
+	NumberOfFreeEntriesInPoolToExecuteMutation string `json:"numberOfFreeEntriesInPoolToExecuteMutation,omitempty"     yaml:"numberOfFreeEntriesInPoolToExecuteMutation"`
+	// max_number_of_merges_with_ttl_in_pool
+	MaxNumberOfMergesWithTTLInPool string `json:"maxNumberOfMergesWithTTLInPool,omitempty"                 yaml:"maxNumberOfMergesWithTTLInPool"`
+}
+
+// ChiBackgroundPools defines backgroundPools section of a shard or a host
+// Specified values are applied to host's personal settings, so heterogeneous shards can be tuned individually
+type ChiBackgroundPools struct {
+	// background_pool_size
+	PoolSize string `json:"poolSize,omitempty"                        yaml:"poolSize"`
+	// background_merges_mutations_concurrency_ratio
+	MergesMutationsConcurrencyRatio string `json:"mergesMutationsConcurrencyRatio,omitempty" yaml:"mergesMutationsConcurrencyRatio"`
+	// background_fetches_pool_size
+	FetchesPoolSize string `json:"fetchesPoolSize,omitempty"                 yaml:"fetchesPoolSize"`
+	// background_move_pool_size
+	MovePoolSize string `json:"movePoolSize,omitempty"                    yaml:"movePoolSize"`
+	// background_schedule_pool_size
+	SchedulePoolSize string `json:"schedulePoolSize,omitempty"                yaml:"schedulePoolSize"`
+	// background_common_pool_size
+	CommonPoolSize string `json:"commonPoolSize,omitempty"                  yaml:"commonPoolSize"`
+}
+
+// ChiPorts defines ports section of .spec.defaults
+// Specified ports are used by hosts, which do not specify ports explicitly, and by installation's Service
+type ChiPorts struct {
+	TCPPort             int32 `json:"tcpPort,omitempty"             yaml:"tcpPort"`
+	HTTPPort            int32 `json:"httpPort,omitempty"            yaml:"httpPort"`
+	InterserverHTTPPort int32 `json:"interserverHTTPPort,omitempty" yaml:"interserverHTTPPort"`
+}
+
+// ChiDNSCache defines dnsCache section of .spec.defaults
+// Specified values are applied to settings, so replicas do not use stale IPs of restarted pods
+type ChiDNSCache struct {
+	// dns_cache_update_period, seconds
+	UpdatePeriod string `json:"updatePeriod,omitempty"           yaml:"updatePeriod"`
+	// dns_max_consecutive_failures
+	MaxConsecutiveFailures string `json:"maxConsecutiveFailures,omitempty" yaml:"maxConsecutiveFailures"`
+	// disable_internal_dns_cache, StringBool
+	DisableInternal string `json:"disableInternal,omitempty"        yaml:"disableInternal"`
+}
+
+// ChiMemoryTracker defines memoryTracker section of .spec.defaults
+// Specified values are applied to settings, so ClickHouse respects memory limit of the container
+type ChiMemoryTracker struct {
+	// max_server_memory_usage_to_ram_ratio, positive float
+	MaxServerMemoryUsageToRAMRatio string `json:"maxServerMemoryUsageToRAMRatio,omitempty"     yaml:"maxServerMemoryUsageToRAMRatio"`
+	// cgroups_memory_usage_observer_wait_time, seconds
+	CgroupsMemoryUsageObserverWaitTime string `json:"cgroupsMemoryUsageObserverWaitTime,omitempty" yaml:"cgroupsMemoryUsageObserverWaitTime"`
+	// StringBool, derive max_server_memory_usage of each host from memory limit of ClickHouse container
+	FromContainerLimit string `json:"fromContainerLimit,omitempty"                 yaml:"fromContainerLimit"`
+}
+
+// ChiScaleDownSafeguards defines scaleDownSafeguards section of .spec.defaults
+type ChiScaleDownSafeguards struct {
+	// Shard can not be scaled down below this number of replicas, 0 means no limit
+	MinReplicasCount int `json:"minReplicasCount,omitempty" yaml:"minReplicasCount"`
+	// Allows scale down below MinReplicasCount
+	AllowDataLoss string `json:"allowDataLoss,omitempty"    yaml:"allowDataLoss"`
 }
 
 // ChiZookeeperConfig defines zookeeper section of .spec.configuration
@@ -294,12 +709,185 @@ type ChiZookeeperConfig struct {
 	OperationTimeoutMs int                `json:"operation_timeout_ms,omitempty" yaml:"operation_timeout_ms"`
 	Root               string             `json:"root,omitempty"                 yaml:"root"`
 	Identity           string             `json:"identity,omitempty"             yaml:"identity"`
+	TLS                ChiZookeeperTLS    `json:"tls,omitempty"                  yaml:"tls"`
+}
+
+// ChiZookeeperTLS defines tls section of .spec.configuration.zookeeper
+// Secret is expected to provide client certificate, key and CA as tls.crt, tls.key and ca.crt
+type ChiZookeeperTLS struct {
+	Secret string `json:"secret,omitempty" yaml:"secret"`
 }
 
 // ChiZookeeperNode defines item of nodes section of .spec.configuration.zookeeper
 type ChiZookeeperNode struct {
 	Host string `json:"host,omitempty" yaml:"host"`
 	Port int32  `json:"port,omitempty" yaml:"port"`
+	// Whether connection to the node has to be secured with TLS. StringBool
+	Secure string `json:"secure,omitempty" yaml:"secure"`
+}
+
+// ChiMonitoring defines monitoring section of .spec.configuration
+// Describes restricted read-only user to be used by metrics exporters and monitoring tools
+type ChiMonitoring struct {
+	// Whether monitoring user should be generated. StringBool
+	Enabled string `json:"enabled,omitempty"        yaml:"enabled"`
+	User    string `json:"user,omitempty"           yaml:"user"`
+	Profile string `json:"profile,omitempty"        yaml:"profile"`
+	// Secret to get monitoring user's password from
+	PasswordSecret *corev1.SecretKeySelector `json:"passwordSecret,omitempty" yaml:"passwordSecret"`
+	// Role from .spec.configuration.roles to be granted to monitoring user instead of system database access
+	Role string `json:"role,omitempty"           yaml:"role"`
+	// asynchronous_metrics_update_period_s, seconds
+	AsyncMetricsUpdatePeriod string `json:"asyncMetricsUpdatePeriod,omitempty" yaml:"asyncMetricsUpdatePeriod"`
+	// External exporter sidecar, which scrapes ClickHouse as monitoring user
+	ExporterSidecar ChiExporterSidecar `json:"exporterSidecar,omitempty" yaml:"exporterSidecar"`
+	// Built-in Prometheus endpoint of ClickHouse
+	Prometheus ChiPrometheus `json:"prometheus,omitempty" yaml:"prometheus"`
+}
+
+// ChiPrometheus defines prometheus section of .spec.configuration.monitoring
+type ChiPrometheus struct {
+	// Whether built-in Prometheus endpoint should be exposed and advertised by scrape annotations. StringBool
+	Enabled  string `json:"enabled,omitempty"  yaml:"enabled"`
+	Port     string `json:"port,omitempty"     yaml:"port"`
+	Endpoint string `json:"endpoint,omitempty" yaml:"endpoint"`
+}
+
+// ChiExporterSidecar defines exporterSidecar section of .spec.configuration.monitoring
+type ChiExporterSidecar struct {
+	// Whether exporter sidecar container should be generated. StringBool
+	Enabled string `json:"enabled,omitempty" yaml:"enabled"`
+	Image   string `json:"image,omitempty"   yaml:"image"`
+	// Port exporter serves metrics on
+	Port string `json:"port,omitempty"    yaml:"port"`
+}
+
+// ChiCompressionCase defines item of compression section of .spec.configuration
+// Cases are evaluated by ClickHouse in order, the last matching case wins
+type ChiCompressionCase struct {
+	// Min size of data part, bytes
+	MinPartSize string `json:"minPartSize,omitempty"      yaml:"minPartSize"`
+	// Min ratio of data part size to size of the whole table
+	MinPartSizeRatio string `json:"minPartSizeRatio,omitempty" yaml:"minPartSizeRatio"`
+	// Compression method, such as lz4 or zstd
+	Method string `json:"method,omitempty"           yaml:"method"`
+}
+
+// ChiRole defines item of roles section of .spec.configuration
+type ChiRole struct {
+	Name   string     `json:"name"             yaml:"name"`
+	Grants []ChiGrant `json:"grants,omitempty" yaml:"grants"`
+}
+
+// ChiQuotaIntervals defines item of quotaIntervals section of .spec.configuration
+type ChiQuotaIntervals struct {
+	// Quota intervals are specified for
+	Name      string             `json:"name"                yaml:"name"`
+	Intervals []ChiQuotaInterval `json:"intervals,omitempty" yaml:"intervals"`
+}
+
+// ChiQuotaInterval defines interval of a quota with limits within the interval.
+// Limit of 0 means unlimited, omitted limits are not specified
+type ChiQuotaInterval struct {
+	// Seconds
+	Duration      string `json:"duration"                yaml:"duration"`
+	Queries       string `json:"queries,omitempty"       yaml:"queries"`
+	Errors        string `json:"errors,omitempty"        yaml:"errors"`
+	ResultRows    string `json:"resultRows,omitempty"    yaml:"resultRows"`
+	ReadRows      string `json:"readRows,omitempty"      yaml:"readRows"`
+	ExecutionTime string `json:"executionTime,omitempty" yaml:"executionTime"`
+}
+
+// ChiExperimentalFeatures defines item of experimentalFeatures section of .spec.configuration
+type ChiExperimentalFeatures struct {
+	// Profile features are toggled in
+	Profile string `json:"profile"            yaml:"profile"`
+	// Maps feature name, such as 'analyzer', to StringBool toggle
+	Features map[string]string `json:"features,omitempty" yaml:"features"`
+}
+
+// ChiProfileTiers defines profileTiers section of .spec.configuration
+// Each tier is generated as a profile with base limits scaled by tier multiplier
+type ChiProfileTiers struct {
+	Base  ChiProfileTierLimits `json:"base,omitempty"  yaml:"base"`
+	Tiers []ChiProfileTier     `json:"tiers,omitempty" yaml:"tiers"`
+}
+
+// ChiProfileTierLimits defines base section of .spec.configuration.profileTiers
+type ChiProfileTierLimits struct {
+	// Size, such as '10Gi'
+	MaxMemoryUsage string `json:"maxMemoryUsage,omitempty"   yaml:"maxMemoryUsage"`
+	// Seconds
+	MaxExecutionTime string `json:"maxExecutionTime,omitempty" yaml:"maxExecutionTime"`
+	MaxRowsToRead    string `json:"maxRowsToRead,omitempty"    yaml:"maxRowsToRead"`
+}
+
+// ChiProfileTier defines item of tiers section of .spec.configuration.profileTiers
+type ChiProfileTier struct {
+	// Name of generated profile
+	Name string `json:"name"                 yaml:"name"`
+	// Positive number base limits are multiplied by
+	Multiplier string `json:"multiplier,omitempty" yaml:"multiplier"`
+}
+
+// ChiQueryMaskingRule defines item of queryMaskingRules section of .spec.configuration
+type ChiQueryMaskingRule struct {
+	// Name of the rule, shown in system.events as QueryMaskingRulesMatch
+	Name string `json:"name,omitempty"    yaml:"name"`
+	// RE2 regexp of sensitive data
+	Regexp string `json:"regexp"            yaml:"regexp"`
+	// Substitution of matched data, '******' by default
+	Replace string `json:"replace,omitempty" yaml:"replace"`
+}
+
+// ChiGrant defines privileges granted to a role on databases or tables
+type ChiGrant struct {
+	// Privileges, such as SELECT
+	Privileges string `json:"privileges" yaml:"privileges"`
+	// Grant target, such as system.*
+	On string `json:"on"         yaml:"on"`
+}
+
+// ChiUserDefinedFunctions defines userDefinedFunctions section of .spec.configuration
+// Executable functions definitions (*_function.xml) along with their scripts are provided by either ConfigMap or Secret
+type ChiUserDefinedFunctions struct {
+	ConfigMap string `json:"configMap,omitempty" yaml:"configMap"`
+	Secret    string `json:"secret,omitempty"    yaml:"secret"`
+}
+
+// ChiFormatSchemas defines formatSchemas section of .spec.configuration
+// Schema files of Protobuf/Cap'n Proto formats are provided by either ConfigMap or Secret
+type ChiFormatSchemas struct {
+	ConfigMap string `json:"configMap,omitempty" yaml:"configMap"`
+	Secret    string `json:"secret,omitempty"    yaml:"secret"`
+}
+
+// ChiKafka defines kafka section of .spec.configuration
+// Cluster-wide defaults of Kafka table engine. SASL credentials are provided by Secrets only
+type ChiKafka struct {
+	BrokerList       string `json:"brokerList,omitempty"       yaml:"brokerList"`
+	Group            string `json:"group,omitempty"            yaml:"group"`
+	SecurityProtocol string `json:"securityProtocol,omitempty" yaml:"securityProtocol"`
+	SASLMechanism    string `json:"saslMechanism,omitempty"    yaml:"saslMechanism"`
+	// Secrets to get SASL username and password from
+	SASLUsernameSecret *corev1.SecretKeySelector `json:"saslUsernameSecret,omitempty" yaml:"saslUsernameSecret"`
+	SASLPasswordSecret *corev1.SecretKeySelector `json:"saslPasswordSecret,omitempty" yaml:"saslPasswordSecret"`
+}
+
+// ChiTLS defines tls section of .spec.configuration
+// Secret is expected to provide server certificate, key and CA as tls.crt, tls.key and ca.crt
+type ChiTLS struct {
+	Secret string `json:"secret,omitempty" yaml:"secret"`
+}
+
+// ChiInterserverCredentials defines interserverCredentials section of .spec.configuration
+// Credentials replicas use to authenticate each other on replication fetches
+type ChiInterserverCredentials struct {
+	// Secrets to get user and password from
+	UserSecret     *corev1.SecretKeySelector `json:"userSecret,omitempty"     yaml:"userSecret"`
+	PasswordSecret *corev1.SecretKeySelector `json:"passwordSecret,omitempty" yaml:"passwordSecret"`
+	// Whether fetches without credentials are accepted, used while credentials are rolled out. StringBool
+	AllowEmpty string `json:"allowEmpty,omitempty" yaml:"allowEmpty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -22,134 +22,62 @@ package v1
 
 import (
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ChiCluster) DeepCopyInto(out *ChiCluster) {
+func (in *ChiBackgroundPools) DeepCopyInto(out *ChiBackgroundPools) {
 	*out = *in
-	in.Zookeeper.DeepCopyInto(&out.Zookeeper)
-	in.Layout.DeepCopyInto(&out.Layout)
-	out.Templates = in.Templates
-	out.Address = in.Address
-	if in.CHI != nil {
-		in, out := &in.CHI, &out.CHI
-		*out = new(ClickHouseInstallation)
-		(*in).DeepCopyInto(*out)
-	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiCluster.
-func (in *ChiCluster) DeepCopy() *ChiCluster {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiBackgroundPools.
+func (in *ChiBackgroundPools) DeepCopy() *ChiBackgroundPools {
 	if in == nil {
 		return nil
 	}
-	out := new(ChiCluster)
+	out := new(ChiBackgroundPools)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ChiClusterAddress) DeepCopyInto(out *ChiClusterAddress) {
+func (in *ChiBackups) DeepCopyInto(out *ChiBackups) {
 	*out = *in
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiClusterAddress.
-func (in *ChiClusterAddress) DeepCopy() *ChiClusterAddress {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiBackups.
+func (in *ChiBackups) DeepCopy() *ChiBackups {
 	if in == nil {
 		return nil
 	}
-	out := new(ChiClusterAddress)
+	out := new(ChiBackups)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ChiClusterLayout) DeepCopyInto(out *ChiClusterLayout) {
+func (in *ChiCaches) DeepCopyInto(out *ChiCaches) {
 	*out = *in
-	if in.Shards != nil {
-		in, out := &in.Shards, &out.Shards
-		*out = make([]ChiShard, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
-	if in.Replicas != nil {
-		in, out := &in.Replicas, &out.Replicas
-		*out = make([]ChiReplica, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
-	if in.HostsField != nil {
-		in, out := &in.HostsField, &out.HostsField
-		*out = new(HostsField)
-		(*in).DeepCopyInto(*out)
-	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiClusterLayout.
-func (in *ChiClusterLayout) DeepCopy() *ChiClusterLayout {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiCaches.
+func (in *ChiCaches) DeepCopy() *ChiCaches {
 	if in == nil {
 		return nil
 	}
-	out := new(ChiClusterLayout)
+	out := new(ChiCaches)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Configuration) DeepCopyInto(out *Configuration) {
+func (in *ChiCluster) DeepCopyInto(out *ChiCluster) {
 	*out = *in
 	in.Zookeeper.DeepCopyInto(&out.Zookeeper)
-	if in.Users != nil {
-		in, out := &in.Users, &out.Users
-		*out = make(Settings, len(*in))
-		for key, val := range *in {
-			var outVal *Setting
-			if val == nil {
-				(*out)[key] = nil
-			} else {
-				in, out := &val, &outVal
-				*out = new(Setting)
-				(*in).DeepCopyInto(*out)
-			}
-			(*out)[key] = outVal
-		}
-	}
-	if in.Profiles != nil {
-		in, out := &in.Profiles, &out.Profiles
-		*out = make(Settings, len(*in))
-		for key, val := range *in {
-			var outVal *Setting
-			if val == nil {
-				(*out)[key] = nil
-			} else {
-				in, out := &val, &outVal
-				*out = new(Setting)
-				(*in).DeepCopyInto(*out)
-			}
-			(*out)[key] = outVal
-		}
-	}
-	if in.Quotas != nil {
-		in, out := &in.Quotas, &out.Quotas
-		*out = make(Settings, len(*in))
-		for key, val := range *in {
-			var outVal *Setting
-			if val == nil {
-				(*out)[key] = nil
-			} else {
-				in, out := &val, &outVal
-				*out = new(Setting)
-				(*in).DeepCopyInto(*out)
-			}
-			(*out)[key] = outVal
-		}
-	}
 	if in.Settings != nil {
 		in, out := &in.Settings, &out.Settings
 		*out = make(Settings, len(*in))
@@ -180,394 +108,1423 @@ func (in *Configuration) DeepCopyInto(out *Configuration) {
 			(*out)[key] = outVal
 		}
 	}
-	if in.Clusters != nil {
-		in, out := &in.Clusters, &out.Clusters
-		*out = make([]ChiCluster, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	in.Templates.DeepCopyInto(&out.Templates)
+	in.Layout.DeepCopyInto(&out.Layout)
+	out.Discovery = in.Discovery
+	out.Address = in.Address
+	if in.CHI != nil {
+		in, out := &in.CHI, &out.CHI
+		*out = new(ClickHouseInstallation)
+		(*in).DeepCopyInto(*out)
 	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Configuration.
-func (in *Configuration) DeepCopy() *Configuration {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiCluster.
+func (in *ChiCluster) DeepCopy() *ChiCluster {
 	if in == nil {
 		return nil
 	}
-	out := new(Configuration)
+	out := new(ChiCluster)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ChiDefaults) DeepCopyInto(out *ChiDefaults) {
+func (in *ChiClusterAddress) DeepCopyInto(out *ChiClusterAddress) {
 	*out = *in
-	out.DistributedDDL = in.DistributedDDL
-	out.Templates = in.Templates
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiDefaults.
-func (in *ChiDefaults) DeepCopy() *ChiDefaults {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiClusterAddress.
+func (in *ChiClusterAddress) DeepCopy() *ChiClusterAddress {
 	if in == nil {
 		return nil
 	}
-	out := new(ChiDefaults)
+	out := new(ChiClusterAddress)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ChiDistributedDDL) DeepCopyInto(out *ChiDistributedDDL) {
+func (in *ChiClusterDiscovery) DeepCopyInto(out *ChiClusterDiscovery) {
 	*out = *in
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiDistributedDDL.
-func (in *ChiDistributedDDL) DeepCopy() *ChiDistributedDDL {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiClusterDiscovery.
+func (in *ChiClusterDiscovery) DeepCopy() *ChiClusterDiscovery {
 	if in == nil {
 		return nil
 	}
-	out := new(ChiDistributedDDL)
+	out := new(ChiClusterDiscovery)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ChiHost) DeepCopyInto(out *ChiHost) {
+func (in *ChiClusterLayout) DeepCopyInto(out *ChiClusterLayout) {
 	*out = *in
-	out.Templates = in.Templates
-	out.Address = in.Address
-	out.Config = in.Config
-	if in.StatefulSet != nil {
-		in, out := &in.StatefulSet, &out.StatefulSet
-		*out = new(appsv1.StatefulSet)
-		(*in).DeepCopyInto(*out)
+	if in.Shards != nil {
+		in, out := &in.Shards, &out.Shards
+		*out = make([]ChiShard, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
-	if in.CHI != nil {
-		in, out := &in.CHI, &out.CHI
-		*out = new(ClickHouseInstallation)
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = make([]ChiReplica, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.HostsField != nil {
+		in, out := &in.HostsField, &out.HostsField
+		*out = new(HostsField)
 		(*in).DeepCopyInto(*out)
 	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiHost.
-func (in *ChiHost) DeepCopy() *ChiHost {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiClusterLayout.
+func (in *ChiClusterLayout) DeepCopy() *ChiClusterLayout {
 	if in == nil {
 		return nil
 	}
-	out := new(ChiHost)
+	out := new(ChiClusterLayout)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ChiHostAddress) DeepCopyInto(out *ChiHostAddress) {
+func (in *ChiCompressionCase) DeepCopyInto(out *ChiCompressionCase) {
 	*out = *in
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiHostAddress.
-func (in *ChiHostAddress) DeepCopy() *ChiHostAddress {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiCompressionCase.
+func (in *ChiCompressionCase) DeepCopy() *ChiCompressionCase {
 	if in == nil {
 		return nil
 	}
-	out := new(ChiHostAddress)
+	out := new(ChiCompressionCase)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ChiHostConfig) DeepCopyInto(out *ChiHostConfig) {
+func (in *ChiContainerDefaults) DeepCopyInto(out *ChiContainerDefaults) {
 	*out = *in
+	if in.ImagePullSecrets != nil {
+		in, out := &in.ImagePullSecrets, &out.ImagePullSecrets
+		*out = make([]corev1.LocalObjectReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]corev1.EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiHostConfig.
-func (in *ChiHostConfig) DeepCopy() *ChiHostConfig {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiContainerDefaults.
+func (in *ChiContainerDefaults) DeepCopy() *ChiContainerDefaults {
 	if in == nil {
 		return nil
 	}
-	out := new(ChiHostConfig)
+	out := new(ChiContainerDefaults)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ChiHostTemplate) DeepCopyInto(out *ChiHostTemplate) {
+func (in *ChiCoreDump) DeepCopyInto(out *ChiCoreDump) {
 	*out = *in
-	if in.PortDistribution != nil {
-		in, out := &in.PortDistribution, &out.PortDistribution
-		*out = make([]ChiPortDistribution, len(*in))
-		copy(*out, *in)
-	}
-	in.Spec.DeepCopyInto(&out.Spec)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiHostTemplate.
-func (in *ChiHostTemplate) DeepCopy() *ChiHostTemplate {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiCoreDump.
+func (in *ChiCoreDump) DeepCopy() *ChiCoreDump {
 	if in == nil {
 		return nil
 	}
-	out := new(ChiHostTemplate)
+	out := new(ChiCoreDump)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ChiPodDistribution) DeepCopyInto(out *ChiPodDistribution) {
+func (in *ChiDNSCache) DeepCopyInto(out *ChiDNSCache) {
 	*out = *in
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiPodDistribution.
-func (in *ChiPodDistribution) DeepCopy() *ChiPodDistribution {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiDNSCache.
+func (in *ChiDNSCache) DeepCopy() *ChiDNSCache {
 	if in == nil {
 		return nil
 	}
-	out := new(ChiPodDistribution)
+	out := new(ChiDNSCache)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ChiPodTemplate) DeepCopyInto(out *ChiPodTemplate) {
+func (in *ChiDataVolumeChown) DeepCopyInto(out *ChiDataVolumeChown) {
 	*out = *in
-	in.Zone.DeepCopyInto(&out.Zone)
-	if in.PodDistribution != nil {
-		in, out := &in.PodDistribution, &out.PodDistribution
-		*out = make([]ChiPodDistribution, len(*in))
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
-	in.Spec.DeepCopyInto(&out.Spec)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiPodTemplate.
-func (in *ChiPodTemplate) DeepCopy() *ChiPodTemplate {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiDataVolumeChown.
+func (in *ChiDataVolumeChown) DeepCopy() *ChiDataVolumeChown {
 	if in == nil {
 		return nil
 	}
-	out := new(ChiPodTemplate)
+	out := new(ChiDataVolumeChown)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ChiPodTemplateZone) DeepCopyInto(out *ChiPodTemplateZone) {
+func (in *ChiDefaults) DeepCopyInto(out *ChiDefaults) {
 	*out = *in
-	if in.Values != nil {
-		in, out := &in.Values, &out.Values
-		*out = make([]string, len(*in))
-		copy(*out, *in)
+	out.DistributedDDL = in.DistributedDDL
+	out.DistributedQueries = in.DistributedQueries
+	out.FilesystemRead = in.FilesystemRead
+	if in.CertRotationTokenSecret != nil {
+		in, out := &in.CertRotationTokenSecret, &out.CertRotationTokenSecret
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	out.ReadinessProbe = in.ReadinessProbe
+	out.LivenessProbe = in.LivenessProbe
+	out.ServiceMesh = in.ServiceMesh
+	out.DropSafeguards = in.DropSafeguards
+	out.Caches = in.Caches
+	out.MergeLimits = in.MergeLimits
+	out.DNSCache = in.DNSCache
+	out.MemoryTracker = in.MemoryTracker
+	out.ScaleDownSafeguards = in.ScaleDownSafeguards
+	in.Container.DeepCopyInto(&out.Container)
+	out.TmpVolume = in.TmpVolume
+	out.ShmVolume = in.ShmVolume
+	in.DataVolumeChown.DeepCopyInto(&out.DataVolumeChown)
+	out.SecurityContext = in.SecurityContext
+	out.ServiceAccount = in.ServiceAccount
+	out.UpdateStrategy = in.UpdateStrategy
+	if in.PodAffinity != nil {
+		in, out := &in.PodAffinity, &out.PodAffinity
+		*out = new(corev1.PodAffinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]corev1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
+	out.Ports = in.Ports
+	in.Templates.DeepCopyInto(&out.Templates)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiPodTemplateZone.
-func (in *ChiPodTemplateZone) DeepCopy() *ChiPodTemplateZone {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiDefaults.
+func (in *ChiDefaults) DeepCopy() *ChiDefaults {
 	if in == nil {
 		return nil
 	}
-	out := new(ChiPodTemplateZone)
+	out := new(ChiDefaults)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ChiPortDistribution) DeepCopyInto(out *ChiPortDistribution) {
+func (in *ChiDistributedDDL) DeepCopyInto(out *ChiDistributedDDL) {
 	*out = *in
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiPortDistribution.
-func (in *ChiPortDistribution) DeepCopy() *ChiPortDistribution {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiDistributedDDL.
+func (in *ChiDistributedDDL) DeepCopy() *ChiDistributedDDL {
 	if in == nil {
 		return nil
 	}
-	out := new(ChiPortDistribution)
+	out := new(ChiDistributedDDL)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ChiReplica) DeepCopyInto(out *ChiReplica) {
+func (in *ChiDistributedQueries) DeepCopyInto(out *ChiDistributedQueries) {
 	*out = *in
-	out.Templates = in.Templates
-	if in.Hosts != nil {
-		in, out := &in.Hosts, &out.Hosts
-		*out = make([]*ChiHost, len(*in))
-		for i := range *in {
-			if (*in)[i] != nil {
-				in, out := &(*in)[i], &(*out)[i]
-				*out = new(ChiHost)
-				(*in).DeepCopyInto(*out)
-			}
-		}
-	}
-	out.Address = in.Address
-	if in.CHI != nil {
-		in, out := &in.CHI, &out.CHI
-		*out = new(ClickHouseInstallation)
-		(*in).DeepCopyInto(*out)
-	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiReplica.
-func (in *ChiReplica) DeepCopy() *ChiReplica {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiDistributedQueries.
+func (in *ChiDistributedQueries) DeepCopy() *ChiDistributedQueries {
 	if in == nil {
 		return nil
 	}
-	out := new(ChiReplica)
+	out := new(ChiDistributedQueries)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ChiReplicaAddress) DeepCopyInto(out *ChiReplicaAddress) {
+func (in *ChiDropSafeguards) DeepCopyInto(out *ChiDropSafeguards) {
 	*out = *in
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiReplicaAddress.
-func (in *ChiReplicaAddress) DeepCopy() *ChiReplicaAddress {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiDropSafeguards.
+func (in *ChiDropSafeguards) DeepCopy() *ChiDropSafeguards {
 	if in == nil {
 		return nil
 	}
-	out := new(ChiReplicaAddress)
+	out := new(ChiDropSafeguards)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ChiServiceTemplate) DeepCopyInto(out *ChiServiceTemplate) {
+func (in *ChiExperimentalFeatures) DeepCopyInto(out *ChiExperimentalFeatures) {
 	*out = *in
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
+	if in.Features != nil {
+		in, out := &in.Features, &out.Features
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiExperimentalFeatures.
+func (in *ChiExperimentalFeatures) DeepCopy() *ChiExperimentalFeatures {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiExperimentalFeatures)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiExporterSidecar) DeepCopyInto(out *ChiExporterSidecar) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiExporterSidecar.
+func (in *ChiExporterSidecar) DeepCopy() *ChiExporterSidecar {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiExporterSidecar)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiFilesystemCache) DeepCopyInto(out *ChiFilesystemCache) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiFilesystemCache.
+func (in *ChiFilesystemCache) DeepCopy() *ChiFilesystemCache {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiFilesystemCache)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiFilesystemRead) DeepCopyInto(out *ChiFilesystemRead) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiFilesystemRead.
+func (in *ChiFilesystemRead) DeepCopy() *ChiFilesystemRead {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiFilesystemRead)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiFormatSchemas) DeepCopyInto(out *ChiFormatSchemas) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiFormatSchemas.
+func (in *ChiFormatSchemas) DeepCopy() *ChiFormatSchemas {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiFormatSchemas)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiGrant) DeepCopyInto(out *ChiGrant) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiGrant.
+func (in *ChiGrant) DeepCopy() *ChiGrant {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiGrant)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiHost) DeepCopyInto(out *ChiHost) {
+	*out = *in
+	if in.Settings != nil {
+		in, out := &in.Settings, &out.Settings
+		*out = make(Settings, len(*in))
+		for key, val := range *in {
+			var outVal *Setting
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = new(Setting)
+				(*in).DeepCopyInto(*out)
+			}
+			(*out)[key] = outVal
+		}
+	}
+	if in.Files != nil {
+		in, out := &in.Files, &out.Files
+		*out = make(Settings, len(*in))
+		for key, val := range *in {
+			var outVal *Setting
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = new(Setting)
+				(*in).DeepCopyInto(*out)
+			}
+			(*out)[key] = outVal
+		}
+	}
+	in.Templates.DeepCopyInto(&out.Templates)
+	out.BackgroundPools = in.BackgroundPools
+	if in.StatefulSetAnnotations != nil {
+		in, out := &in.StatefulSetAnnotations, &out.StatefulSetAnnotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	out.Address = in.Address
+	out.Config = in.Config
+	if in.StatefulSet != nil {
+		in, out := &in.StatefulSet, &out.StatefulSet
+		*out = new(appsv1.StatefulSet)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CHI != nil {
+		in, out := &in.CHI, &out.CHI
+		*out = new(ClickHouseInstallation)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiHost.
+func (in *ChiHost) DeepCopy() *ChiHost {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiHost)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiHostAddress) DeepCopyInto(out *ChiHostAddress) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiHostAddress.
+func (in *ChiHostAddress) DeepCopy() *ChiHostAddress {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiHostAddress)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiHostConfig) DeepCopyInto(out *ChiHostConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiHostConfig.
+func (in *ChiHostConfig) DeepCopy() *ChiHostConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiHostConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiHostTemplate) DeepCopyInto(out *ChiHostTemplate) {
+	*out = *in
+	if in.PortDistribution != nil {
+		in, out := &in.PortDistribution, &out.PortDistribution
+		*out = make([]ChiPortDistribution, len(*in))
+		copy(*out, *in)
+	}
+	in.Spec.DeepCopyInto(&out.Spec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiHostTemplate.
+func (in *ChiHostTemplate) DeepCopy() *ChiHostTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiHostTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiInterserverCredentials) DeepCopyInto(out *ChiInterserverCredentials) {
+	*out = *in
+	if in.UserSecret != nil {
+		in, out := &in.UserSecret, &out.UserSecret
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PasswordSecret != nil {
+		in, out := &in.PasswordSecret, &out.PasswordSecret
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiInterserverCredentials.
+func (in *ChiInterserverCredentials) DeepCopy() *ChiInterserverCredentials {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiInterserverCredentials)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiKafka) DeepCopyInto(out *ChiKafka) {
+	*out = *in
+	if in.SASLUsernameSecret != nil {
+		in, out := &in.SASLUsernameSecret, &out.SASLUsernameSecret
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SASLPasswordSecret != nil {
+		in, out := &in.SASLPasswordSecret, &out.SASLPasswordSecret
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiKafka.
+func (in *ChiKafka) DeepCopy() *ChiKafka {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiKafka)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiKeeper) DeepCopyInto(out *ChiKeeper) {
+	*out = *in
+	out.CoordinationSettings = in.CoordinationSettings
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiKeeper.
+func (in *ChiKeeper) DeepCopy() *ChiKeeper {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiKeeper)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiKeeperCoordinationSettings) DeepCopyInto(out *ChiKeeperCoordinationSettings) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiKeeperCoordinationSettings.
+func (in *ChiKeeperCoordinationSettings) DeepCopy() *ChiKeeperCoordinationSettings {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiKeeperCoordinationSettings)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiLivenessProbe) DeepCopyInto(out *ChiLivenessProbe) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiLivenessProbe.
+func (in *ChiLivenessProbe) DeepCopy() *ChiLivenessProbe {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiLivenessProbe)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiMemoryTracker) DeepCopyInto(out *ChiMemoryTracker) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiMemoryTracker.
+func (in *ChiMemoryTracker) DeepCopy() *ChiMemoryTracker {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiMemoryTracker)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiMergeLimits) DeepCopyInto(out *ChiMergeLimits) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiMergeLimits.
+func (in *ChiMergeLimits) DeepCopy() *ChiMergeLimits {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiMergeLimits)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiMonitoring) DeepCopyInto(out *ChiMonitoring) {
+	*out = *in
+	if in.PasswordSecret != nil {
+		in, out := &in.PasswordSecret, &out.PasswordSecret
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	out.ExporterSidecar = in.ExporterSidecar
+	out.Prometheus = in.Prometheus
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiMonitoring.
+func (in *ChiMonitoring) DeepCopy() *ChiMonitoring {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiMonitoring)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiPodDistribution) DeepCopyInto(out *ChiPodDistribution) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiPodDistribution.
+func (in *ChiPodDistribution) DeepCopy() *ChiPodDistribution {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiPodDistribution)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiPodTemplate) DeepCopyInto(out *ChiPodTemplate) {
+	*out = *in
+	in.Zone.DeepCopyInto(&out.Zone)
+	if in.PodDistribution != nil {
+		in, out := &in.PodDistribution, &out.PodDistribution
+		*out = make([]ChiPodDistribution, len(*in))
+		copy(*out, *in)
+	}
+	in.Spec.DeepCopyInto(&out.Spec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiPodTemplate.
+func (in *ChiPodTemplate) DeepCopy() *ChiPodTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiPodTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiPodTemplateZone) DeepCopyInto(out *ChiPodTemplateZone) {
+	*out = *in
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiPodTemplateZone.
+func (in *ChiPodTemplateZone) DeepCopy() *ChiPodTemplateZone {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiPodTemplateZone)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiPortDistribution) DeepCopyInto(out *ChiPortDistribution) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiPortDistribution.
+func (in *ChiPortDistribution) DeepCopy() *ChiPortDistribution {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiPortDistribution)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiPorts) DeepCopyInto(out *ChiPorts) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiPorts.
+func (in *ChiPorts) DeepCopy() *ChiPorts {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiPorts)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiProfileTier) DeepCopyInto(out *ChiProfileTier) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiProfileTier.
+func (in *ChiProfileTier) DeepCopy() *ChiProfileTier {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiProfileTier)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiProfileTierLimits) DeepCopyInto(out *ChiProfileTierLimits) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiProfileTierLimits.
+func (in *ChiProfileTierLimits) DeepCopy() *ChiProfileTierLimits {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiProfileTierLimits)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiProfileTiers) DeepCopyInto(out *ChiProfileTiers) {
+	*out = *in
+	out.Base = in.Base
+	if in.Tiers != nil {
+		in, out := &in.Tiers, &out.Tiers
+		*out = make([]ChiProfileTier, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiProfileTiers.
+func (in *ChiProfileTiers) DeepCopy() *ChiProfileTiers {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiProfileTiers)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiPrometheus) DeepCopyInto(out *ChiPrometheus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiPrometheus.
+func (in *ChiPrometheus) DeepCopy() *ChiPrometheus {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiPrometheus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiQueryMaskingRule) DeepCopyInto(out *ChiQueryMaskingRule) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiQueryMaskingRule.
+func (in *ChiQueryMaskingRule) DeepCopy() *ChiQueryMaskingRule {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiQueryMaskingRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiQuotaInterval) DeepCopyInto(out *ChiQuotaInterval) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiQuotaInterval.
+func (in *ChiQuotaInterval) DeepCopy() *ChiQuotaInterval {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiQuotaInterval)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiQuotaIntervals) DeepCopyInto(out *ChiQuotaIntervals) {
+	*out = *in
+	if in.Intervals != nil {
+		in, out := &in.Intervals, &out.Intervals
+		*out = make([]ChiQuotaInterval, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiQuotaIntervals.
+func (in *ChiQuotaIntervals) DeepCopy() *ChiQuotaIntervals {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiQuotaIntervals)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiReadinessProbe) DeepCopyInto(out *ChiReadinessProbe) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiReadinessProbe.
+func (in *ChiReadinessProbe) DeepCopy() *ChiReadinessProbe {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiReadinessProbe)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiReplica) DeepCopyInto(out *ChiReplica) {
+	*out = *in
+	if in.Settings != nil {
+		in, out := &in.Settings, &out.Settings
+		*out = make(Settings, len(*in))
+		for key, val := range *in {
+			var outVal *Setting
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = new(Setting)
+				(*in).DeepCopyInto(*out)
+			}
+			(*out)[key] = outVal
+		}
+	}
+	if in.Files != nil {
+		in, out := &in.Files, &out.Files
+		*out = make(Settings, len(*in))
+		for key, val := range *in {
+			var outVal *Setting
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = new(Setting)
+				(*in).DeepCopyInto(*out)
+			}
+			(*out)[key] = outVal
+		}
+	}
+	in.Templates.DeepCopyInto(&out.Templates)
+	if in.StatefulSetAnnotations != nil {
+		in, out := &in.StatefulSetAnnotations, &out.StatefulSetAnnotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Hosts != nil {
+		in, out := &in.Hosts, &out.Hosts
+		*out = make([]*ChiHost, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(ChiHost)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	out.Address = in.Address
+	if in.CHI != nil {
+		in, out := &in.CHI, &out.CHI
+		*out = new(ClickHouseInstallation)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiReplica.
+func (in *ChiReplica) DeepCopy() *ChiReplica {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiReplica)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiReplicaAddress) DeepCopyInto(out *ChiReplicaAddress) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiReplicaAddress.
+func (in *ChiReplicaAddress) DeepCopy() *ChiReplicaAddress {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiReplicaAddress)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiRole) DeepCopyInto(out *ChiRole) {
+	*out = *in
+	if in.Grants != nil {
+		in, out := &in.Grants, &out.Grants
+		*out = make([]ChiGrant, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiRole.
+func (in *ChiRole) DeepCopy() *ChiRole {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiRole)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiScaleDownSafeguards) DeepCopyInto(out *ChiScaleDownSafeguards) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiScaleDownSafeguards.
+func (in *ChiScaleDownSafeguards) DeepCopy() *ChiScaleDownSafeguards {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiScaleDownSafeguards)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiSecurityContext) DeepCopyInto(out *ChiSecurityContext) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiSecurityContext.
+func (in *ChiSecurityContext) DeepCopy() *ChiSecurityContext {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiSecurityContext)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiServiceAccount) DeepCopyInto(out *ChiServiceAccount) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiServiceAccount.
+func (in *ChiServiceAccount) DeepCopy() *ChiServiceAccount {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiServiceAccount)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiServiceMesh) DeepCopyInto(out *ChiServiceMesh) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiServiceMesh.
+func (in *ChiServiceMesh) DeepCopy() *ChiServiceMesh {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiServiceMesh)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiServiceTemplate) DeepCopyInto(out *ChiServiceTemplate) {
+	*out = *in
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiServiceTemplate.
+func (in *ChiServiceTemplate) DeepCopy() *ChiServiceTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiServiceTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiShard) DeepCopyInto(out *ChiShard) {
+	*out = *in
+	if in.Settings != nil {
+		in, out := &in.Settings, &out.Settings
+		*out = make(Settings, len(*in))
+		for key, val := range *in {
+			var outVal *Setting
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = new(Setting)
+				(*in).DeepCopyInto(*out)
+			}
+			(*out)[key] = outVal
+		}
+	}
+	if in.Files != nil {
+		in, out := &in.Files, &out.Files
+		*out = make(Settings, len(*in))
+		for key, val := range *in {
+			var outVal *Setting
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = new(Setting)
+				(*in).DeepCopyInto(*out)
+			}
+			(*out)[key] = outVal
+		}
+	}
+	in.Templates.DeepCopyInto(&out.Templates)
+	out.BackgroundPools = in.BackgroundPools
+	if in.StatefulSetAnnotations != nil {
+		in, out := &in.StatefulSetAnnotations, &out.StatefulSetAnnotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Hosts != nil {
+		in, out := &in.Hosts, &out.Hosts
+		*out = make([]*ChiHost, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(ChiHost)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	out.Address = in.Address
+	if in.CHI != nil {
+		in, out := &in.CHI, &out.CHI
+		*out = new(ClickHouseInstallation)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiShard.
+func (in *ChiShard) DeepCopy() *ChiShard {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiShard)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiShardAddress) DeepCopyInto(out *ChiShardAddress) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiShardAddress.
+func (in *ChiShardAddress) DeepCopy() *ChiShardAddress {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiShardAddress)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiShmVolume) DeepCopyInto(out *ChiShmVolume) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiShmVolume.
+func (in *ChiShmVolume) DeepCopy() *ChiShmVolume {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiShmVolume)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiSpec) DeepCopyInto(out *ChiSpec) {
+	*out = *in
+	if in.PropagateAnnotations != nil {
+		in, out := &in.PropagateAnnotations, &out.PropagateAnnotations
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	in.Defaults.DeepCopyInto(&out.Defaults)
+	in.Configuration.DeepCopyInto(&out.Configuration)
+	in.Templates.DeepCopyInto(&out.Templates)
+	if in.UseTemplates != nil {
+		in, out := &in.UseTemplates, &out.UseTemplates
+		*out = make([]ChiUseTemplate, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiSpec.
+func (in *ChiSpec) DeepCopy() *ChiSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiStatus) DeepCopyInto(out *ChiStatus) {
+	*out = *in
+	if in.Actions != nil {
+		in, out := &in.Actions, &out.Actions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Errors != nil {
+		in, out := &in.Errors, &out.Errors
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Pods != nil {
+		in, out := &in.Pods, &out.Pods
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.FQDNs != nil {
+		in, out := &in.FQDNs, &out.FQDNs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.NormalizedCHI.DeepCopyInto(&out.NormalizedCHI)
+	in.NormalizedCHICompleted.DeepCopyInto(&out.NormalizedCHICompleted)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiStatus.
+func (in *ChiStatus) DeepCopy() *ChiStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiStorage) DeepCopyInto(out *ChiStorage) {
+	*out = *in
+	if in.Disks != nil {
+		in, out := &in.Disks, &out.Disks
+		*out = make([]ChiStorageDisk, len(*in))
+		copy(*out, *in)
+	}
+	if in.Policies != nil {
+		in, out := &in.Policies, &out.Policies
+		*out = make([]ChiStoragePolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiStorage.
+func (in *ChiStorage) DeepCopy() *ChiStorage {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiStorage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiStorageDisk) DeepCopyInto(out *ChiStorageDisk) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiStorageDisk.
+func (in *ChiStorageDisk) DeepCopy() *ChiStorageDisk {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiStorageDisk)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiStoragePolicy) DeepCopyInto(out *ChiStoragePolicy) {
+	*out = *in
+	if in.Volumes != nil {
+		in, out := &in.Volumes, &out.Volumes
+		*out = make([]ChiStorageVolume, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiServiceTemplate.
-func (in *ChiServiceTemplate) DeepCopy() *ChiServiceTemplate {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiStoragePolicy.
+func (in *ChiStoragePolicy) DeepCopy() *ChiStoragePolicy {
 	if in == nil {
 		return nil
 	}
-	out := new(ChiServiceTemplate)
+	out := new(ChiStoragePolicy)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ChiShard) DeepCopyInto(out *ChiShard) {
+func (in *ChiStorageVolume) DeepCopyInto(out *ChiStorageVolume) {
 	*out = *in
-	out.Templates = in.Templates
-	if in.Hosts != nil {
-		in, out := &in.Hosts, &out.Hosts
-		*out = make([]*ChiHost, len(*in))
-		for i := range *in {
-			if (*in)[i] != nil {
-				in, out := &(*in)[i], &(*out)[i]
-				*out = new(ChiHost)
-				(*in).DeepCopyInto(*out)
-			}
-		}
-	}
-	out.Address = in.Address
-	if in.CHI != nil {
-		in, out := &in.CHI, &out.CHI
-		*out = new(ClickHouseInstallation)
-		(*in).DeepCopyInto(*out)
+	if in.Disks != nil {
+		in, out := &in.Disks, &out.Disks
+		*out = make([]string, len(*in))
+		copy(*out, *in)
 	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiShard.
-func (in *ChiShard) DeepCopy() *ChiShard {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiStorageVolume.
+func (in *ChiStorageVolume) DeepCopy() *ChiStorageVolume {
 	if in == nil {
 		return nil
 	}
-	out := new(ChiShard)
+	out := new(ChiStorageVolume)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ChiShardAddress) DeepCopyInto(out *ChiShardAddress) {
+func (in *ChiSystemLog) DeepCopyInto(out *ChiSystemLog) {
 	*out = *in
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiShardAddress.
-func (in *ChiShardAddress) DeepCopy() *ChiShardAddress {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiSystemLog.
+func (in *ChiSystemLog) DeepCopy() *ChiSystemLog {
 	if in == nil {
 		return nil
 	}
-	out := new(ChiShardAddress)
+	out := new(ChiSystemLog)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ChiSpec) DeepCopyInto(out *ChiSpec) {
+func (in *ChiSystemLogs) DeepCopyInto(out *ChiSystemLogs) {
 	*out = *in
-	out.Defaults = in.Defaults
-	in.Configuration.DeepCopyInto(&out.Configuration)
-	in.Templates.DeepCopyInto(&out.Templates)
-	if in.UseTemplates != nil {
-		in, out := &in.UseTemplates, &out.UseTemplates
-		*out = make([]ChiUseTemplate, len(*in))
-		copy(*out, *in)
-	}
+	out.PartLog = in.PartLog
+	out.TextLog = in.TextLog
+	out.TraceLog = in.TraceLog
+	out.CrashLog = in.CrashLog
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiSpec.
-func (in *ChiSpec) DeepCopy() *ChiSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiSystemLogs.
+func (in *ChiSystemLogs) DeepCopy() *ChiSystemLogs {
 	if in == nil {
 		return nil
 	}
-	out := new(ChiSpec)
+	out := new(ChiSystemLogs)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ChiStatus) DeepCopyInto(out *ChiStatus) {
+func (in *ChiTLS) DeepCopyInto(out *ChiTLS) {
 	*out = *in
-	if in.Actions != nil {
-		in, out := &in.Actions, &out.Actions
-		*out = make([]string, len(*in))
-		copy(*out, *in)
-	}
-	if in.Errors != nil {
-		in, out := &in.Errors, &out.Errors
-		*out = make([]string, len(*in))
-		copy(*out, *in)
-	}
-	if in.Pods != nil {
-		in, out := &in.Pods, &out.Pods
-		*out = make([]string, len(*in))
-		copy(*out, *in)
-	}
-	if in.FQDNs != nil {
-		in, out := &in.FQDNs, &out.FQDNs
-		*out = make([]string, len(*in))
-		copy(*out, *in)
-	}
-	in.NormalizedCHI.DeepCopyInto(&out.NormalizedCHI)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiStatus.
-func (in *ChiStatus) DeepCopy() *ChiStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiTLS.
+func (in *ChiTLS) DeepCopy() *ChiTLS {
 	if in == nil {
 		return nil
 	}
-	out := new(ChiStatus)
+	out := new(ChiTLS)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -575,6 +1532,11 @@ func (in *ChiStatus) DeepCopy() *ChiStatus {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChiTemplateNames) DeepCopyInto(out *ChiTemplateNames) {
 	*out = *in
+	if in.ExtraVolumeClaimTemplates != nil {
+		in, out := &in.ExtraVolumeClaimTemplates, &out.ExtraVolumeClaimTemplates
+		*out = make([]ChiVolumeClaimTemplateMount, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -692,6 +1654,38 @@ func (in *ChiTemplates) DeepCopy() *ChiTemplates {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiTmpVolume) DeepCopyInto(out *ChiTmpVolume) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiTmpVolume.
+func (in *ChiTmpVolume) DeepCopy() *ChiTmpVolume {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiTmpVolume)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiUpdateStrategy) DeepCopyInto(out *ChiUpdateStrategy) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiUpdateStrategy.
+func (in *ChiUpdateStrategy) DeepCopy() *ChiUpdateStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiUpdateStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChiUseTemplate) DeepCopyInto(out *ChiUseTemplate) {
 	*out = *in
@@ -708,10 +1702,36 @@ func (in *ChiUseTemplate) DeepCopy() *ChiUseTemplate {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiUserDefinedFunctions) DeepCopyInto(out *ChiUserDefinedFunctions) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiUserDefinedFunctions.
+func (in *ChiUserDefinedFunctions) DeepCopy() *ChiUserDefinedFunctions {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiUserDefinedFunctions)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChiVolumeClaimTemplate) DeepCopyInto(out *ChiVolumeClaimTemplate) {
 	*out = *in
+	if in.MountPropagation != nil {
+		in, out := &in.MountPropagation, &out.MountPropagation
+		*out = new(corev1.MountPropagationMode)
+		**out = **in
+	}
 	in.Spec.DeepCopyInto(&out.Spec)
+	if in.EmptyDir != nil {
+		in, out := &in.EmptyDir, &out.EmptyDir
+		*out = new(corev1.EmptyDirVolumeSource)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -725,6 +1745,22 @@ func (in *ChiVolumeClaimTemplate) DeepCopy() *ChiVolumeClaimTemplate {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiVolumeClaimTemplateMount) DeepCopyInto(out *ChiVolumeClaimTemplateMount) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiVolumeClaimTemplateMount.
+func (in *ChiVolumeClaimTemplateMount) DeepCopy() *ChiVolumeClaimTemplateMount {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiVolumeClaimTemplateMount)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChiZookeeperConfig) DeepCopyInto(out *ChiZookeeperConfig) {
 	*out = *in
@@ -733,6 +1769,7 @@ func (in *ChiZookeeperConfig) DeepCopyInto(out *ChiZookeeperConfig) {
 		*out = make([]ChiZookeeperNode, len(*in))
 		copy(*out, *in)
 	}
+	out.TLS = in.TLS
 	return
 }
 
@@ -762,6 +1799,22 @@ func (in *ChiZookeeperNode) DeepCopy() *ChiZookeeperNode {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChiZookeeperTLS) DeepCopyInto(out *ChiZookeeperTLS) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChiZookeeperTLS.
+func (in *ChiZookeeperTLS) DeepCopy() *ChiZookeeperTLS {
+	if in == nil {
+		return nil
+	}
+	out := new(ChiZookeeperTLS)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClickHouseInstallation) DeepCopyInto(out *ClickHouseInstallation) {
 	*out = *in
@@ -944,6 +1997,168 @@ func (in *ClickHouseOperatorConfigurationList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Configuration) DeepCopyInto(out *Configuration) {
+	*out = *in
+	in.Zookeeper.DeepCopyInto(&out.Zookeeper)
+	if in.Users != nil {
+		in, out := &in.Users, &out.Users
+		*out = make(Settings, len(*in))
+		for key, val := range *in {
+			var outVal *Setting
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = new(Setting)
+				(*in).DeepCopyInto(*out)
+			}
+			(*out)[key] = outVal
+		}
+	}
+	if in.Profiles != nil {
+		in, out := &in.Profiles, &out.Profiles
+		*out = make(Settings, len(*in))
+		for key, val := range *in {
+			var outVal *Setting
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = new(Setting)
+				(*in).DeepCopyInto(*out)
+			}
+			(*out)[key] = outVal
+		}
+	}
+	if in.Quotas != nil {
+		in, out := &in.Quotas, &out.Quotas
+		*out = make(Settings, len(*in))
+		for key, val := range *in {
+			var outVal *Setting
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = new(Setting)
+				(*in).DeepCopyInto(*out)
+			}
+			(*out)[key] = outVal
+		}
+	}
+	if in.Settings != nil {
+		in, out := &in.Settings, &out.Settings
+		*out = make(Settings, len(*in))
+		for key, val := range *in {
+			var outVal *Setting
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = new(Setting)
+				(*in).DeepCopyInto(*out)
+			}
+			(*out)[key] = outVal
+		}
+	}
+	if in.Files != nil {
+		in, out := &in.Files, &out.Files
+		*out = make(Settings, len(*in))
+		for key, val := range *in {
+			var outVal *Setting
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = new(Setting)
+				(*in).DeepCopyInto(*out)
+			}
+			(*out)[key] = outVal
+		}
+	}
+	if in.QuotaIntervals != nil {
+		in, out := &in.QuotaIntervals, &out.QuotaIntervals
+		*out = make([]ChiQuotaIntervals, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.Monitoring.DeepCopyInto(&out.Monitoring)
+	if in.Roles != nil {
+		in, out := &in.Roles, &out.Roles
+		*out = make([]ChiRole, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	out.UserDefinedFunctions = in.UserDefinedFunctions
+	out.FormatSchemas = in.FormatSchemas
+	in.Kafka.DeepCopyInto(&out.Kafka)
+	in.InterserverCredentials.DeepCopyInto(&out.InterserverCredentials)
+	out.TLS = in.TLS
+	out.SystemLogs = in.SystemLogs
+	out.Keeper = in.Keeper
+	out.FilesystemCache = in.FilesystemCache
+	in.Storage.DeepCopyInto(&out.Storage)
+	if in.Compression != nil {
+		in, out := &in.Compression, &out.Compression
+		*out = make([]ChiCompressionCase, len(*in))
+		copy(*out, *in)
+	}
+	out.Backups = in.Backups
+	out.CoreDump = in.CoreDump
+	if in.ExperimentalFeatures != nil {
+		in, out := &in.ExperimentalFeatures, &out.ExperimentalFeatures
+		*out = make([]ChiExperimentalFeatures, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.ProfileTiers.DeepCopyInto(&out.ProfileTiers)
+	if in.CustomSettingsPrefixes != nil {
+		in, out := &in.CustomSettingsPrefixes, &out.CustomSettingsPrefixes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.QueryMaskingRules != nil {
+		in, out := &in.QueryMaskingRules, &out.QueryMaskingRules
+		*out = make([]ChiQueryMaskingRule, len(*in))
+		copy(*out, *in)
+	}
+	if in.Macros != nil {
+		in, out := &in.Macros, &out.Macros
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.UserPasswordSecrets != nil {
+		in, out := &in.UserPasswordSecrets, &out.UserPasswordSecrets
+		*out = make(map[string]corev1.SecretKeySelector, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.Clusters != nil {
+		in, out := &in.Clusters, &out.Clusters
+		*out = make([]ChiCluster, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Configuration.
+func (in *Configuration) DeepCopy() *Configuration {
+	if in == nil {
+		return nil
+	}
+	out := new(Configuration)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HostsField) DeepCopyInto(out *HostsField) {
 	*out = *in
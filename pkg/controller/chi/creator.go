@@ -276,6 +276,13 @@ func hasStatefulSetReachedGeneration(statefulSet *apps.StatefulSet, generation i
 		return false
 	}
 
+	if isStatefulSetUpdatePaused(statefulSet) {
+		// Pods are not rolled out by StatefulSet controller, so they stay at previous revision
+		return (statefulSet.Generation == generation) &&
+			(statefulSet.Status.ObservedGeneration == statefulSet.Generation) &&
+			(statefulSet.Status.ReadyReplicas == *statefulSet.Spec.Replicas)
+	}
+
 	// StatefulSet has .spec generation we are waiting for
 	return (statefulSet.Generation == generation) &&
 		// and this .spec generation is being applied to replicas - it is observed right now
@@ -290,6 +297,18 @@ func hasStatefulSetReachedGeneration(statefulSet *apps.StatefulSet, generation i
 		(statefulSet.Status.CurrentRevision == statefulSet.Status.UpdateRevision)
 }
 
+// isStatefulSetUpdatePaused returns whether StatefulSet controller does not roll pods out on update,
+// either due to OnDelete update strategy or due to partition covering all replicas
+func isStatefulSetUpdatePaused(statefulSet *apps.StatefulSet) bool {
+	strategy := &statefulSet.Spec.UpdateStrategy
+	if strategy.Type == apps.OnDeleteStatefulSetStrategyType {
+		return true
+	}
+	return (strategy.RollingUpdate != nil) &&
+		(strategy.RollingUpdate.Partition != nil) &&
+		(*strategy.RollingUpdate.Partition >= *statefulSet.Spec.Replicas)
+}
+
 // strStatefulSetStatus returns human-friendly string representation of StatefulSet status
 func strStatefulSetStatus(status *apps.StatefulSetStatus) string {
 	return fmt.Sprintf(
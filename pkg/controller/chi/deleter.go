@@ -43,6 +43,7 @@ func (c *Controller) deleteHost(host *chop.ChiHost) error {
 	_ = c.deletePVC(host)
 	_ = c.deleteConfigMap(host)
 	_ = c.deleteServiceHost(host)
+	_ = c.deleteServiceHostHeadless(host)
 
 	log.V(1).Infof("Controller delete host completed %s/%s", host.Address.ClusterName, host.Name)
 
@@ -61,6 +62,8 @@ func (c *Controller) deleteConfigMapsCHI(chi *chop.ClickHouseInstallation) error
 
 	configMapCommon := chopmodel.CreateConfigMapCommonName(chi)
 	configMapCommonUsersName := chopmodel.CreateConfigMapCommonUsersName(chi)
+	configMapTopologyName := chopmodel.CreateConfigMapTopologyName(chi)
+	configMapExporterName := chopmodel.CreateConfigMapExporterName(chi)
 
 	// Delete ConfigMap
 	err = c.kubeClient.CoreV1().ConfigMaps(chi.Namespace).Delete(configMapCommon, newDeleteOptions())
@@ -83,6 +86,26 @@ func (c *Controller) deleteConfigMapsCHI(chi *chop.ClickHouseInstallation) error
 		log.V(1).Infof("FAIL delete ConfigMap %s/%s err:%v", chi.Namespace, configMapCommonUsersName, err)
 	}
 
+	err = c.kubeClient.CoreV1().ConfigMaps(chi.Namespace).Delete(configMapTopologyName, newDeleteOptions())
+	if err == nil {
+		log.V(1).Infof("OK delete ConfigMap %s/%s", chi.Namespace, configMapTopologyName)
+	} else if apierrors.IsNotFound(err) {
+		log.V(1).Infof("NEUTRAL not found ConfigMap %s/%s", chi.Namespace, configMapTopologyName)
+		err = nil
+	} else {
+		log.V(1).Infof("FAIL delete ConfigMap %s/%s err:%v", chi.Namespace, configMapTopologyName, err)
+	}
+
+	err = c.kubeClient.CoreV1().ConfigMaps(chi.Namespace).Delete(configMapExporterName, newDeleteOptions())
+	if err == nil {
+		log.V(1).Infof("OK delete ConfigMap %s/%s", chi.Namespace, configMapExporterName)
+	} else if apierrors.IsNotFound(err) {
+		log.V(1).Infof("NEUTRAL not found ConfigMap %s/%s", chi.Namespace, configMapExporterName)
+		err = nil
+	} else {
+		log.V(1).Infof("FAIL delete ConfigMap %s/%s err:%v", chi.Namespace, configMapExporterName, err)
+	}
+
 	return err
 }
 
@@ -219,6 +242,14 @@ func (c *Controller) deleteServiceHost(host *chop.ChiHost) error {
 	return c.deleteServiceIfExists(namespace, serviceName)
 }
 
+// deleteServiceHostHeadless deletes headless Service governing host's StatefulSet
+func (c *Controller) deleteServiceHostHeadless(host *chop.ChiHost) error {
+	serviceName := chopmodel.CreateStatefulSetHeadlessServiceName(host)
+	namespace := host.Address.Namespace
+	log.V(1).Infof("deleteServiceHostHeadless(%s/%s)", namespace, serviceName)
+	return c.deleteServiceIfExists(namespace, serviceName)
+}
+
 // deleteServiceShard
 func (c *Controller) deleteServiceShard(shard *chop.ChiShard) error {
 	serviceName := chopmodel.CreateShardServiceName(shard)
@@ -227,6 +258,51 @@ func (c *Controller) deleteServiceShard(shard *chop.ChiShard) error {
 	return c.deleteServiceIfExists(namespace, serviceName)
 }
 
+// deleteServiceShardLeader
+func (c *Controller) deleteServiceShardLeader(shard *chop.ChiShard) error {
+	serviceName := chopmodel.CreateShardLeaderServiceName(shard)
+	namespace := shard.Address.Namespace
+	log.V(1).Infof("deleteServiceShardLeader(%s/%s)", namespace, serviceName)
+	return c.deleteServiceIfExists(namespace, serviceName)
+}
+
+// deleteServiceShardRead
+func (c *Controller) deleteServiceShardRead(shard *chop.ChiShard) error {
+	serviceName := chopmodel.CreateShardReadServiceName(shard)
+	namespace := shard.Address.Namespace
+	log.V(1).Infof("deleteServiceShardRead(%s/%s)", namespace, serviceName)
+	return c.deleteServiceIfExists(namespace, serviceName)
+}
+
+// deleteServiceShardWrite
+func (c *Controller) deleteServiceShardWrite(shard *chop.ChiShard) error {
+	serviceName := chopmodel.CreateShardWriteServiceName(shard)
+	namespace := shard.Address.Namespace
+	log.V(1).Infof("deleteServiceShardWrite(%s/%s)", namespace, serviceName)
+	return c.deleteServiceIfExists(namespace, serviceName)
+}
+
+// deletePodDisruptionBudgetShard
+func (c *Controller) deletePodDisruptionBudgetShard(shard *chop.ChiShard) error {
+	name := chopmodel.CreateShardPodDisruptionBudgetName(shard)
+	namespace := shard.Address.Namespace
+
+	// Check specified PodDisruptionBudget exists
+	if _, err := c.kubeClient.PolicyV1beta1().PodDisruptionBudgets(namespace).Get(name, newGetOptions()); err != nil {
+		// No such a PodDisruptionBudget, nothing to delete
+		return nil
+	}
+
+	err := c.kubeClient.PolicyV1beta1().PodDisruptionBudgets(namespace).Delete(name, newDeleteOptions())
+	if err == nil {
+		log.V(1).Infof("OK delete PodDisruptionBudget %s/%s", namespace, name)
+	} else {
+		log.V(1).Infof("FAIL delete PodDisruptionBudget %s/%s err:%v", namespace, name, err)
+	}
+
+	return err
+}
+
 // deleteServiceCluster
 func (c *Controller) deleteServiceCluster(cluster *chop.ChiCluster) error {
 	serviceName := chopmodel.CreateClusterServiceName(cluster)
@@ -240,9 +316,58 @@ func (c *Controller) deleteServiceCHI(chi *chop.ClickHouseInstallation) error {
 	serviceName := chopmodel.CreateCHIServiceName(chi)
 	namespace := chi.Namespace
 	log.V(1).Infof("deleteServiceCHI(%s/%s)", namespace, serviceName)
+	if chi.Spec.ServiceNamespace != "" {
+		// CHI Service exposed in another namespace
+		_ = c.deleteServiceIfExists(chi.Spec.ServiceNamespace, serviceName)
+	}
 	return c.deleteServiceIfExists(namespace, serviceName)
 }
 
+// deleteConfigMapExporterCHI deletes ConfigMap with exporter sidecar config, in case it exists and is generated by the operator
+func (c *Controller) deleteConfigMapExporterCHI(chi *chop.ClickHouseInstallation) error {
+	name := chopmodel.CreateConfigMapExporterName(chi)
+	namespace := chi.Namespace
+
+	// Check specified ConfigMap exists and is generated by the operator
+	configMap, err := c.kubeClient.CoreV1().ConfigMaps(namespace).Get(name, newGetOptions())
+	if (err != nil) || !chopmodel.IsCHOPGeneratedObject(&configMap.ObjectMeta) {
+		// No such a ConfigMap, nothing to delete
+		return nil
+	}
+
+	err = c.kubeClient.CoreV1().ConfigMaps(namespace).Delete(name, newDeleteOptions())
+	if err == nil {
+		log.V(1).Infof("OK delete ConfigMap %s/%s", namespace, name)
+	} else {
+		log.V(1).Infof("FAIL delete ConfigMap %s/%s err:%v", namespace, name, err)
+	}
+
+	return err
+}
+
+// deleteServiceAccountCHI deletes ServiceAccount generated for CHI.
+// ServiceAccount not generated by the operator, such as referenced existing one, is left untouched
+func (c *Controller) deleteServiceAccountCHI(chi *chop.ClickHouseInstallation) error {
+	name := chopmodel.CreateServiceAccountName(chi)
+	namespace := chi.Namespace
+
+	// Check specified ServiceAccount exists and is generated by the operator
+	serviceAccount, err := c.kubeClient.CoreV1().ServiceAccounts(namespace).Get(name, newGetOptions())
+	if (err != nil) || !chopmodel.IsCHOPGeneratedObject(&serviceAccount.ObjectMeta) {
+		// No such a ServiceAccount, nothing to delete
+		return nil
+	}
+
+	err = c.kubeClient.CoreV1().ServiceAccounts(namespace).Delete(name, newDeleteOptions())
+	if err == nil {
+		log.V(1).Infof("OK delete ServiceAccount %s/%s", namespace, name)
+	} else {
+		log.V(1).Infof("FAIL delete ServiceAccount %s/%s err:%v", namespace, name, err)
+	}
+
+	return err
+}
+
 // deleteServiceIfExists
 func (c *Controller) deleteServiceIfExists(namespace, name string) error {
 	// Delete Service in case it does not exist
@@ -16,13 +16,18 @@ package chi
 
 import (
 	"fmt"
+	"time"
+
 	"github.com/juliangruber/go-intersect"
 	"gopkg.in/d4l3k/messagediff.v1"
 	apps "k8s.io/api/apps/v1"
 	core "k8s.io/api/core/v1"
+	policy "k8s.io/api/policy/v1beta1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kublabels "k8s.io/apimachinery/pkg/labels"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/util/workqueue"
 
@@ -40,6 +45,8 @@ type worker struct {
 	normalizer *chopmodel.Normalizer
 	schemer    *chopmodel.Schemer
 	creator    *chopmodel.Creator
+	// hostsObjects contains objects of hosts generated in advance, before reconcile
+	hostsObjects map[*chop.ChiHost]*chopmodel.HostObjects
 }
 
 // newWorker
@@ -160,7 +167,7 @@ func (w *worker) processItem(item interface{}) error {
 }
 
 // normalize
-func (w *worker) normalize(chi *chop.ClickHouseInstallation) *chop.ClickHouseInstallation {
+func (w *worker) normalize(chi *chop.ClickHouseInstallation) (*chop.ClickHouseInstallation, error) {
 	w.a.V(3).Info("normalize() - start")
 	defer w.a.V(3).Info("normalize() - end")
 
@@ -173,14 +180,51 @@ func (w *worker) normalize(chi *chop.ClickHouseInstallation) *chop.ClickHouseIns
 		withDefaultCluster = true
 	}
 
-	chi, err := w.normalizer.CreateTemplatedCHI(chi, withDefaultCluster)
-	if err != nil {
-		w.a.WithEvent(chi, eventActionReconcile, eventReasonReconcileFailed).
-			WithStatusError(chi).
-			Error("FAILED to normalize CHI : %v", err)
+	// Normalization error is reported by the caller, since not every CHI normalized is going to be reconciled
+	normalized, err := w.normalizer.CreateTemplatedCHI(chi, withDefaultCluster)
+	if err == nil {
+		w.resolveStatefulSetNameAliases(normalized)
+		w.resolveStatefulSetServiceNames(normalized)
 	}
+	return normalized, err
+}
+
+// resolveStatefulSetNameAliases keeps names of StatefulSets created with previous naming scheme.
+// In case StatefulSet of the host is not found by its name, but is found by host labels and was named with outdated scheme,
+// its name is used as an alias of the host's StatefulSet name, so StatefulSet is not recreated along with its data
+func (w *worker) resolveStatefulSetNameAliases(chi *chop.ClickHouseInstallation) {
+	labeler := chopmodel.NewLabeler(w.c.chop, chi)
+	chi.WalkHosts(func(host *chop.ChiHost) error {
+		lister := w.c.statefulSetLister.StatefulSets(host.Address.Namespace)
+		name := chopmodel.CreateStatefulSetName(host)
+		if _, err := lister.Get(name); !apierrors.IsNotFound(err) {
+			// Either found by name or unable to tell
+			return nil
+		}
 
-	return chi
+		statefulSets, err := lister.List(kublabels.SelectorFromSet(labeler.GetSelectorHostScope(host)))
+		if (err != nil) || (len(statefulSets) != 1) || !chopmodel.IsStatefulSetNameSchemeOutdated(statefulSets[0]) {
+			// No StatefulSet of previous naming scheme
+			return nil
+		}
+
+		w.a.V(1).Info("Host %s keeps StatefulSet %s named with previous naming scheme instead of %s", host.Name, statefulSets[0].Name, name)
+		host.Config.StatefulSetNameAlias = statefulSets[0].Name
+		return nil
+	})
+}
+
+// resolveStatefulSetServiceNames keeps serviceName of existing StatefulSets, since serviceName is immutable
+// and StatefulSet would have to be recreated, restarting the host, in order to change it.
+// Governing Service and pod FQDNs in remote_servers are derived from the resolved name, so they stay consistent
+func (w *worker) resolveStatefulSetServiceNames(chi *chop.ClickHouseInstallation) {
+	chi.WalkHosts(func(host *chop.ChiHost) error {
+		lister := w.c.statefulSetLister.StatefulSets(host.Address.Namespace)
+		if statefulSet, err := lister.Get(chopmodel.CreateStatefulSetName(host)); err == nil {
+			host.Config.StatefulSetServiceName = statefulSet.Spec.ServiceName
+		}
+		return nil
+	})
 }
 
 // updateCHI sync CHI which was already created earlier
@@ -237,8 +281,44 @@ func (w *worker) updateCHI(old, new *chop.ClickHouseInstallation) error {
 		return nil
 	}
 
-	old = w.normalize(old)
-	new = w.normalize(new)
+	// Compare against the last successfully reconciled state instead of the previous version of the spec,
+	// which may have never been reconciled, say, in case its scale down was refused
+	old, err := w.normalize(chopmodel.GetLastReconciledCHI(old, new))
+	if err != nil {
+		// Last reconciled CHI is used as a baseline only, it may fail validation introduced later
+		w.a.V(1).Info("updateCHI(%s/%s) last reconciled CHI normalized with error: %v", new.Namespace, new.Name, err)
+	}
+	// CHI reconciled earlier may fail validation introduced later, it is not stopped from being reconciled
+	accepted := new.Status.HasNormalizedCHICompleted()
+	new, err = w.normalize(new)
+	if err != nil {
+		// Do not reconcile CHI which failed validation
+		w.a.WithEvent(new, eventActionReconcile, eventReasonReconcileFailed).
+			WithStatusError(new).
+			Error("updateCHI(%s/%s) FAILED to normalize CHI, reconcile skipped: %v", new.Namespace, new.Name, err)
+		return nil
+	}
+
+	if err := chopmodel.ValidateCHI(new); err != nil {
+		if !accepted {
+			// Do not reconcile new CHI, objects of which can not be generated properly
+			w.a.WithEvent(new, eventActionReconcile, eventReasonReconcileFailed).
+				WithStatusError(new).
+				Error("updateCHI(%s/%s) CHI is invalid, reconcile skipped: %v", new.Namespace, new.Name, err)
+			return nil
+		}
+		w.a.WithEvent(new, eventActionReconcile, eventReasonReconcileInProgress).
+			WithStatusError(new).
+			Warning("updateCHI(%s/%s) CHI is invalid, reconciled anyway as it was reconciled earlier: %v", new.Namespace, new.Name, err)
+	}
+
+	if err := chopmodel.ValidateScaleDown(old, new); err != nil {
+		// Do not reconcile CHI which would scale shards down unsafely
+		w.a.WithEvent(new, eventActionReconcile, eventReasonReconcileFailed).
+			WithStatusError(new).
+			Error("updateCHI(%s/%s) unsafe scale down: %v", new.Namespace, new.Name, err)
+		return nil
+	}
 
 	actionPlan := NewActionPlan(old, new)
 
@@ -294,6 +374,28 @@ func (w *worker) updateCHI(old, new *chop.ClickHouseInstallation) error {
 		},
 	)
 
+	// Create default database
+	if new.Spec.Defaults.DefaultDatabase != "" {
+		w.a.V(1).
+			WithEvent(new, eventActionReconcile, eventReasonReconcileInProgress).
+			WithStatusAction(new).
+			Info("updateCHI(%s/%s) create default database %s", new.Namespace, new.Name, new.Spec.Defaults.DefaultDatabase)
+		if err := w.schemer.CHICreateDefaultDatabase(new); err != nil {
+			w.a.Error("ERROR create default database in CHI %s/%s. err: %v", new.Namespace, new.Name, err)
+		}
+	}
+
+	// Create roles and grant privileges to them, drop roles and revoke privileges removed since the last reconcile
+	if (len(new.Spec.Configuration.Roles) > 0) || ((old != nil) && (len(old.Spec.Configuration.Roles) > 0)) {
+		w.a.V(1).
+			WithEvent(new, eventActionReconcile, eventReasonReconcileInProgress).
+			WithStatusAction(new).
+			Info("updateCHI(%s/%s) reconcile roles", new.Namespace, new.Name)
+		if err := w.schemer.CHIReconcileRoles(old, new); err != nil {
+			w.a.Error("ERROR reconcile roles in CHI %s/%s. err: %v", new.Namespace, new.Name, err)
+		}
+	}
+
 	// Remove deleted items
 	w.a.V(1).
 		WithEvent(new, eventActionReconcile, eventReasonReconcileInProgress).
@@ -318,7 +420,7 @@ func (w *worker) updateCHI(old, new *chop.ClickHouseInstallation) error {
 	w.c.updateWatch(new.Namespace, new.Name, chopmodel.CreatePodFQDNsOfCHI(new))
 
 	// Update CHI object
-	(&new.Status).ReconcileComplete()
+	(&new.Status).ReconcileComplete(&new.Spec)
 	_ = w.c.updateCHIObjectStatus(new, false)
 
 	w.a.V(1).
@@ -334,7 +436,12 @@ func (w *worker) reconcile(chi *chop.ClickHouseInstallation) error {
 	w.a.V(2).Info("reconcile() - start")
 	defer w.a.V(2).Info("reconcile() - end")
 
+	w.resolveCertRotationToken(chi)
 	w.creator = chopmodel.NewCreator(w.c.chop, chi)
+	if err := w.createHostsObjects(); err != nil {
+		// Do not reconcile CHI partially
+		return err
+	}
 	return chi.WalkTillError(
 		w.reconcileCHI,
 		w.reconcileCluster,
@@ -343,6 +450,56 @@ func (w *worker) reconcile(chi *chop.ClickHouseInstallation) error {
 	)
 }
 
+// resolveCertRotationToken fingerprints key of certificates Secret specified by .spec.defaults.certRotationTokenSecret,
+// so rotated certificates change pod template of each host and roll pods. Unavailable Secret does not block reconcile
+func (w *worker) resolveCertRotationToken(chi *chop.ClickHouseInstallation) {
+	if !chi.Spec.Defaults.HasCertRotationTokenSecret() {
+		return
+	}
+
+	selector := chi.Spec.Defaults.CertRotationTokenSecret
+	fingerprint := ""
+	secret, err := w.c.kubeClient.CoreV1().Secrets(chi.Namespace).Get(selector.Name, newGetOptions())
+	switch {
+	case err != nil:
+		if !apierrors.IsNotFound(err) || (selector.Optional == nil) || !*selector.Optional {
+			w.a.WithEvent(chi, eventActionReconcile, eventReasonReconcileInProgress).
+				Warning("Unable to get cert rotation token Secret %s/%s. err: %v", chi.Namespace, selector.Name, err)
+		}
+	case secret.Data[selector.Key] == nil:
+		w.a.V(1).Warning("Cert rotation token Secret %s/%s has no key %s", chi.Namespace, selector.Name, selector.Key)
+	default:
+		fingerprint = util.Fingerprint(secret.Data[selector.Key])
+	}
+
+	chi.WalkHosts(func(host *chop.ChiHost) error {
+		host.Config.CertRotationTokenFingerprint = fingerprint
+		return nil
+	})
+}
+
+// createHostsObjects generates objects of all hosts concurrently, since for huge CHI sequential generation delays reconcile
+func (w *worker) createHostsObjects() error {
+	w.hostsObjects = make(map[*chop.ChiHost]*chopmodel.HostObjects)
+	hostsObjects, err := w.creator.CreateHostsObjects(w.c.chop.Config().ReconcileGenerateThreadsNumber)
+	if err != nil {
+		return err
+	}
+	for _, objects := range hostsObjects {
+		w.hostsObjects[objects.Host] = objects
+	}
+	return nil
+}
+
+// getHostObjects returns objects of the host, generated in advance or generated right now in case not found
+func (w *worker) getHostObjects(host *chop.ChiHost) *chopmodel.HostObjects {
+	if objects, ok := w.hostsObjects[host]; ok {
+		return objects
+	}
+
+	return w.creator.CreateHostObjects(host)
+}
+
 // reconcileCHI reconciles CHI global objects
 func (w *worker) reconcileCHI(chi *chop.ClickHouseInstallation) error {
 	w.a.V(2).Info("reconcileCHI() - start")
@@ -358,12 +515,30 @@ func (w *worker) reconcileCHI(chi *chop.ClickHouseInstallation) error {
 		return err
 	}
 
+	// CHI Service exposed in another namespace
+	if service := w.creator.CreateServiceCHIExternal(); service != nil {
+		if err := w.reconcileService(chi, service); err != nil {
+			w.a.WithEvent(chi, eventActionReconcile, eventReasonReconcileFailed).
+				WithStatusAction(chi).
+				WithStatusError(chi).
+				Error("Reconcile CHI %s failed to reconcile Service %s/%s", chi.Name, service.Namespace, service.Name)
+			return err
+		}
+	}
+
 	// 2. CHI ConfigMaps
 
 	// ConfigMap common for all resources in CHI
 	// contains several sections, mapped as separated chopConfig files,
 	// such as remote servers, zookeeper setup, etc
-	configMapCommon := w.creator.CreateConfigMapCHICommon()
+	configMapCommon, err := w.creator.CreateConfigMapCHICommon()
+	if err != nil {
+		w.a.WithEvent(chi, eventActionReconcile, eventReasonReconcileFailed).
+			WithStatusAction(chi).
+			WithStatusError(chi).
+			Error("Reconcile CHI %s failed to generate common ConfigMap. err: %v", chi.Name, err)
+		return err
+	}
 	if err := w.reconcileConfigMap(chi, configMapCommon); err != nil {
 		w.a.WithEvent(chi, eventActionReconcile, eventReasonReconcileFailed).
 			WithStatusAction(chi).
@@ -373,7 +548,14 @@ func (w *worker) reconcileCHI(chi *chop.ClickHouseInstallation) error {
 	}
 
 	// ConfigMap common for all users resources in CHI
-	configMapUsers := w.creator.CreateConfigMapCHICommonUsers()
+	configMapUsers, err := w.creator.CreateConfigMapCHICommonUsers()
+	if err != nil {
+		w.a.WithEvent(chi, eventActionReconcile, eventReasonReconcileFailed).
+			WithStatusAction(chi).
+			WithStatusError(chi).
+			Error("Reconcile CHI %s failed to generate users ConfigMap. err: %v", chi.Name, err)
+		return err
+	}
 	if err := w.reconcileConfigMap(chi, configMapUsers); err != nil {
 		w.a.WithEvent(chi, eventActionReconcile, eventReasonReconcileFailed).
 			WithStatusAction(chi).
@@ -382,6 +564,51 @@ func (w *worker) reconcileCHI(chi *chop.ClickHouseInstallation) error {
 		return err
 	}
 
+	// ConfigMap with topology summary of the CHI, consumed by external tools
+	configMapTopology, err := w.creator.CreateConfigMapCHITopology()
+	if err != nil {
+		w.a.WithEvent(chi, eventActionReconcile, eventReasonReconcileFailed).
+			WithStatusAction(chi).
+			WithStatusError(chi).
+			Error("Reconcile CHI %s failed to generate topology ConfigMap. err: %v", chi.Name, err)
+		return err
+	}
+	if err := w.reconcileConfigMap(chi, configMapTopology); err != nil {
+		w.a.WithEvent(chi, eventActionReconcile, eventReasonReconcileFailed).
+			WithStatusAction(chi).
+			WithStatusError(chi).
+			Error("Reconcile CHI %s failed to reconcile ConfigMap %s", chi.Name, configMapTopology.Name)
+		return err
+	}
+
+	// ConfigMap with exporter sidecar config, in case exporter sidecar is enabled
+	if configMapExporter := w.creator.CreateConfigMapCHIExporter(); configMapExporter != nil {
+		if err := w.reconcileConfigMap(chi, configMapExporter); err != nil {
+			w.a.WithEvent(chi, eventActionReconcile, eventReasonReconcileFailed).
+				WithStatusAction(chi).
+				WithStatusError(chi).
+				Error("Reconcile CHI %s failed to reconcile ConfigMap %s", chi.Name, configMapExporter.Name)
+			return err
+		}
+	} else {
+		// Exporter sidecar was disabled, so its config is not required anymore
+		_ = w.c.deleteConfigMapExporterCHI(chi)
+	}
+
+	// 3. CHI ServiceAccount, has to be in place before StatefulSets referring to it are created
+	if serviceAccount := w.creator.CreateServiceAccount(); serviceAccount != nil {
+		if err := w.reconcileServiceAccount(chi, serviceAccount); err != nil {
+			w.a.WithEvent(chi, eventActionReconcile, eventReasonReconcileFailed).
+				WithStatusAction(chi).
+				WithStatusError(chi).
+				Error("Reconcile CHI %s failed to reconcile ServiceAccount %s", chi.Name, serviceAccount.Name)
+			return err
+		}
+	} else if chi.Spec.Defaults.ServiceAccount.Name != chopmodel.CreateServiceAccountName(chi) {
+		// ServiceAccount generated previously is not required anymore, unless it is referenced explicitly
+		_ = w.c.deleteServiceAccountCHI(chi)
+	}
+
 	// Add here other CHI components to be reconciled
 
 	return nil
@@ -407,6 +634,38 @@ func (w *worker) reconcileShard(shard *chop.ChiShard) error {
 	w.a.V(2).Info("reconcileShard() - start")
 	defer w.a.V(2).Info("reconcileShard() - end")
 
+	// Add Shard's leader Service, which targets first replica of the shard only
+	if service := w.creator.CreateServiceShardLeader(shard); service != nil {
+		if err := w.reconcileService(shard.CHI, service); err != nil {
+			return err
+		}
+	}
+
+	// Add Shard's read and write Services, or delete them in case they are not required anymore
+	if service := w.creator.CreateServiceShardRead(shard); service != nil {
+		if err := w.reconcileService(shard.CHI, service); err != nil {
+			return err
+		}
+	} else {
+		_ = w.c.deleteServiceShardRead(shard)
+	}
+	if service := w.creator.CreateServiceShardWrite(shard); service != nil {
+		if err := w.reconcileService(shard.CHI, service); err != nil {
+			return err
+		}
+	} else {
+		_ = w.c.deleteServiceShardWrite(shard)
+	}
+
+	// Add Shard's PodDisruptionBudget, or delete it in case it is not required anymore
+	if pdb := w.creator.CreatePodDisruptionBudgetShard(shard); pdb != nil {
+		if err := w.reconcilePodDisruptionBudget(shard.CHI, pdb); err != nil {
+			return err
+		}
+	} else {
+		_ = w.c.deletePodDisruptionBudgetShard(shard)
+	}
+
 	// Add Shard's Service
 	service := w.creator.CreateServiceShard(shard)
 	if service == nil {
@@ -427,8 +686,21 @@ func (w *worker) reconcileHost(host *chop.ChiHost) error {
 		WithStatusAction(host.CHI).
 		Info("Reconcile Host %s started", host.Name)
 
+	objects := w.getHostObjects(host)
+	curStatefulSet, _ := w.c.getStatefulSet(&objects.StatefulSet.ObjectMeta, false)
+	if curStatefulSet != nil {
+		w.creator.PreserveStatefulSetVolumeClaimTemplates(objects, curStatefulSet)
+	}
+
 	// Reconcile host's ConfigMap
-	configMap := w.creator.CreateConfigMapHost(host)
+	configMap, err := objects.ConfigMap, objects.Err
+	if err != nil {
+		w.a.WithEvent(host.CHI, eventActionReconcile, eventReasonReconcileFailed).
+			WithStatusAction(host.CHI).
+			WithStatusError(host.CHI).
+			Error("Reconcile Host %s failed to generate ConfigMap. err: %v", host.Name, err)
+		return err
+	}
 	if err := w.reconcileConfigMap(host.CHI, configMap); err != nil {
 		w.a.WithEvent(host.CHI, eventActionReconcile, eventReasonReconcileFailed).
 			WithStatusAction(host.CHI).
@@ -437,8 +709,21 @@ func (w *worker) reconcileHost(host *chop.ChiHost) error {
 		return err
 	}
 
+	// Reconcile host's headless Service governing StatefulSet, or delete it in case host's Service governs StatefulSet itself
+	if service := objects.HeadlessService; service != nil {
+		if err := w.reconcileService(host.CHI, service); err != nil {
+			w.a.WithEvent(host.CHI, eventActionReconcile, eventReasonReconcileFailed).
+				WithStatusAction(host.CHI).
+				WithStatusError(host.CHI).
+				Error("Reconcile Host %s failed to reconcile Service %s", host.Name, service.Name)
+			return err
+		}
+	} else {
+		_ = w.c.deleteServiceHostHeadless(host)
+	}
+
 	// Reconcile host's StatefulSet
-	statefulSet := w.creator.CreateStatefulSet(host)
+	statefulSet := objects.StatefulSet
 	if err := w.reconcileStatefulSet(statefulSet, host); err != nil {
 		w.a.WithEvent(host.CHI, eventActionReconcile, eventReasonReconcileFailed).
 			WithStatusAction(host.CHI).
@@ -446,12 +731,21 @@ func (w *worker) reconcileHost(host *chop.ChiHost) error {
 			Error("Reconcile Host %s failed to reconcile StatefulSet %s", host.Name, statefulSet.Name)
 		return err
 	}
+	// ConfigMap is updated successfully at this point, so hot-reloadable settings can be applied
+	if curStatefulSet != nil {
+		w.reloadHotSettings(curStatefulSet, statefulSet, host)
+	}
+
+	// Reconcile host's PVCs. VolumeClaimTemplates of existing StatefulSet are kept as is, so PVCs are resized instead
+	if curStatefulSet != nil {
+		_ = w.reconcilePVCs(host)
+	}
 
 	// Reconcile host's Persistent Volumes
 	w.reconcilePersistentVolumes(host)
 
 	// Reconcile host's Service
-	service := w.creator.CreateServiceHost(host)
+	service := objects.Service
 	if err := w.reconcileService(host.CHI, service); err != nil {
 		w.a.WithEvent(host.CHI, eventActionReconcile, eventReasonReconcileFailed).
 			WithStatusAction(host.CHI).
@@ -480,13 +774,18 @@ func (w *worker) deleteCHI(chi *chop.ClickHouseInstallation) error {
 		WithStatusAction(chi).
 		Info("Delete CHI %s/%s started", chi.Namespace, chi.Name)
 
-	chi, err = w.normalizer.CreateTemplatedCHI(chi, true)
-	if err != nil {
+	normalized, err := w.normalizer.CreateTemplatedCHI(chi, true)
+	if normalized == nil {
 		w.a.WithEvent(chi, eventActionDelete, eventReasonDeleteFailed).
 			WithStatusError(chi).
 			Error("Delete CHI %s/%s failed - unable to normalize: %q", chi.Namespace, chi.Name, err)
 		return err
 	}
+	if err != nil {
+		// Validation errors should not prevent CHI from being deleted
+		w.a.V(1).Warning("Delete CHI %s/%s - normalized with error: %q", chi.Namespace, chi.Name, err)
+	}
+	chi = normalized
 
 	// Delete all clusters
 	chi.WalkClusters(func(cluster *chop.ChiCluster) error {
@@ -499,6 +798,9 @@ func (w *worker) deleteCHI(chi *chop.ClickHouseInstallation) error {
 	// Delete Service
 	err = w.c.deleteServiceCHI(chi)
 
+	// Delete ServiceAccount
+	err = w.c.deleteServiceAccountCHI(chi)
+
 	w.a.V(1).
 		WithEvent(chi, eventActionDelete, eventReasonDeleteCompleted).
 		WithStatusAction(chi).
@@ -592,8 +894,14 @@ func (w *worker) deleteShard(shard *chop.ChiShard) error {
 	// Delete all replicas
 	shard.WalkHosts(w.deleteHost)
 
-	// Delete Shard Service
+	// Delete Shard Services
 	_ = w.c.deleteServiceShard(shard)
+	_ = w.c.deleteServiceShardLeader(shard)
+	_ = w.c.deleteServiceShardRead(shard)
+	_ = w.c.deleteServiceShardWrite(shard)
+
+	// Delete Shard PodDisruptionBudget
+	_ = w.c.deletePodDisruptionBudgetShard(shard)
 
 	w.a.V(1).
 		WithEvent(shard.CHI, eventActionDelete, eventReasonDeleteCompleted).
@@ -727,20 +1035,170 @@ func (w *worker) reconcileConfigMap(chi *chop.ClickHouseInstallation, configMap
 	return err
 }
 
+// updatePodDisruptionBudget
+func (w *worker) updatePodDisruptionBudget(chi *chop.ClickHouseInstallation, curPDB, newPDB *policy.PodDisruptionBudget) error {
+	// spec of policy/v1beta1 PodDisruptionBudget is immutable, changed spec can be applied by re-creation only
+	if !apiequality.Semantic.DeepEqual(curPDB.Spec, newPDB.Spec) {
+		return w.recreatePodDisruptionBudget(chi, curPDB, newPDB)
+	}
+
+	// spec.resourceVersion is required in order to update object
+	newPDB.ResourceVersion = curPDB.ResourceVersion
+
+	_, err := w.c.kubeClient.PolicyV1beta1().PodDisruptionBudgets(newPDB.Namespace).Update(newPDB)
+
+	if err == nil {
+		w.a.V(1).
+			WithEvent(chi, eventActionUpdate, eventReasonUpdateCompleted).
+			WithStatusAction(chi).
+			Info("Update PodDisruptionBudget %s/%s", newPDB.Namespace, newPDB.Name)
+	} else {
+		w.a.WithEvent(chi, eventActionUpdate, eventReasonUpdateFailed).
+			WithStatusAction(chi).
+			WithStatusError(chi).
+			Error("Update PodDisruptionBudget %s/%s failed with error %v", newPDB.Namespace, newPDB.Name, err)
+	}
+
+	return err
+}
+
+// recreatePodDisruptionBudget deletes current PodDisruptionBudget and creates new one in place of it.
+// Pods are not protected from voluntary disruptions for a moment
+func (w *worker) recreatePodDisruptionBudget(chi *chop.ClickHouseInstallation, curPDB, newPDB *policy.PodDisruptionBudget) error {
+	w.a.V(1).
+		WithEvent(chi, eventActionUpdate, eventReasonUpdateInProgress).
+		WithStatusAction(chi).
+		Info("Update PodDisruptionBudget %s/%s - spec changed, recreate", newPDB.Namespace, newPDB.Name)
+
+	if err := w.c.kubeClient.PolicyV1beta1().PodDisruptionBudgets(curPDB.Namespace).Delete(curPDB.Name, newDeleteOptions()); err != nil && !apierrors.IsNotFound(err) {
+		w.a.WithEvent(chi, eventActionUpdate, eventReasonUpdateFailed).
+			WithStatusAction(chi).
+			WithStatusError(chi).
+			Error("Update PodDisruptionBudget %s/%s failed to delete PodDisruptionBudget with error %v", curPDB.Namespace, curPDB.Name, err)
+		return err
+	}
+
+	return w.createPodDisruptionBudget(chi, newPDB)
+}
+
+// createPodDisruptionBudget
+func (w *worker) createPodDisruptionBudget(chi *chop.ClickHouseInstallation, pdb *policy.PodDisruptionBudget) error {
+	_, err := w.c.kubeClient.PolicyV1beta1().PodDisruptionBudgets(pdb.Namespace).Create(pdb)
+
+	if err == nil {
+		w.a.V(1).
+			WithEvent(chi, eventActionCreate, eventReasonCreateCompleted).
+			WithStatusAction(chi).
+			Info("Create PodDisruptionBudget %s/%s", pdb.Namespace, pdb.Name)
+	} else {
+		w.a.WithEvent(chi, eventActionCreate, eventReasonCreateFailed).
+			WithStatusAction(chi).
+			WithStatusError(chi).
+			Error("Create PodDisruptionBudget %s/%s failed with error %v", pdb.Namespace, pdb.Name, err)
+	}
+
+	return err
+}
+
+// updateServiceAccount
+func (w *worker) updateServiceAccount(chi *chop.ClickHouseInstallation, curServiceAccount, newServiceAccount *core.ServiceAccount) error {
+	// spec.resourceVersion is required in order to update object
+	newServiceAccount.ResourceVersion = curServiceAccount.ResourceVersion
+	// Token secrets are managed by k8s
+	newServiceAccount.Secrets = curServiceAccount.Secrets
+
+	_, err := w.c.kubeClient.CoreV1().ServiceAccounts(newServiceAccount.Namespace).Update(newServiceAccount)
+
+	if err == nil {
+		w.a.V(1).
+			WithEvent(chi, eventActionUpdate, eventReasonUpdateCompleted).
+			WithStatusAction(chi).
+			Info("Update ServiceAccount %s/%s", newServiceAccount.Namespace, newServiceAccount.Name)
+	} else {
+		w.a.WithEvent(chi, eventActionUpdate, eventReasonUpdateFailed).
+			WithStatusAction(chi).
+			WithStatusError(chi).
+			Error("Update ServiceAccount %s/%s failed with error %v", newServiceAccount.Namespace, newServiceAccount.Name, err)
+	}
+
+	return err
+}
+
+// createServiceAccount
+func (w *worker) createServiceAccount(chi *chop.ClickHouseInstallation, serviceAccount *core.ServiceAccount) error {
+	_, err := w.c.kubeClient.CoreV1().ServiceAccounts(serviceAccount.Namespace).Create(serviceAccount)
+
+	if err == nil {
+		w.a.V(1).
+			WithEvent(chi, eventActionCreate, eventReasonCreateCompleted).
+			WithStatusAction(chi).
+			Info("Create ServiceAccount %s/%s", serviceAccount.Namespace, serviceAccount.Name)
+	} else {
+		w.a.WithEvent(chi, eventActionCreate, eventReasonCreateFailed).
+			WithStatusAction(chi).
+			WithStatusError(chi).
+			Error("Create ServiceAccount %s/%s failed with error %v", serviceAccount.Namespace, serviceAccount.Name, err)
+	}
+
+	return err
+}
+
+// reconcileServiceAccount reconciles core.ServiceAccount which belongs to specified CHI
+func (w *worker) reconcileServiceAccount(chi *chop.ClickHouseInstallation, serviceAccount *core.ServiceAccount) error {
+	w.a.V(2).Info("reconcileServiceAccount() - start")
+	defer w.a.V(2).Info("reconcileServiceAccount() - end")
+
+	// Check whether this object already exists in k8s
+	curServiceAccount, err := w.c.kubeClient.CoreV1().ServiceAccounts(serviceAccount.Namespace).Get(serviceAccount.Name, newGetOptions())
+
+	if err == nil {
+		return w.updateServiceAccount(chi, curServiceAccount, serviceAccount)
+	}
+
+	if apierrors.IsNotFound(err) {
+		return w.createServiceAccount(chi, serviceAccount)
+	}
+
+	return err
+}
+
+// reconcilePodDisruptionBudget reconciles policy.PodDisruptionBudget which belongs to specified CHI
+func (w *worker) reconcilePodDisruptionBudget(chi *chop.ClickHouseInstallation, pdb *policy.PodDisruptionBudget) error {
+	w.a.V(2).Info("reconcilePodDisruptionBudget() - start")
+	defer w.a.V(2).Info("reconcilePodDisruptionBudget() - end")
+
+	// Check whether this object already exists in k8s
+	curPDB, err := w.c.kubeClient.PolicyV1beta1().PodDisruptionBudgets(pdb.Namespace).Get(pdb.Name, newGetOptions())
+
+	if err == nil {
+		return w.updatePodDisruptionBudget(chi, curPDB, pdb)
+	}
+
+	if apierrors.IsNotFound(err) {
+		return w.createPodDisruptionBudget(chi, pdb)
+	}
+
+	return err
+}
+
 // updateService
 func (w *worker) updateService(chi *chop.ClickHouseInstallation, curService, newService *core.Service) error {
 	// Updating a Service is a complicated business
 
-	// spec.resourceVersion is required in order to update object
-	newService.ResourceVersion = curService.ResourceVersion
-
 	// spec.clusterIP field is immutable, need to use already assigned value
 	// From https://kubernetes.io/docs/concepts/services-networking/service/#defining-a-service
 	// Kubernetes assigns this Service an IP address (sometimes called the “cluster IP”), which is used by the Service proxies
 	// See also https://kubernetes.io/docs/concepts/services-networking/service/#virtual-ips-and-service-proxies
 	// You can specify your own cluster IP address as part of a Service creation request. To do this, set the .spec.clusterIP
+	if (newService.Spec.ClusterIP != "") && (newService.Spec.ClusterIP != curService.Spec.ClusterIP) {
+		// Explicitly requested cluster IP differs from the assigned one, it can be applied by re-creation only
+		return w.recreateService(chi, curService, newService)
+	}
 	newService.Spec.ClusterIP = curService.Spec.ClusterIP
 
+	// spec.resourceVersion is required in order to update object
+	newService.ResourceVersion = curService.ResourceVersion
+
 	// spec.healthCheckNodePort field is used with ExternalTrafficPolicy=Local only and is immutable within ExternalTrafficPolicy=Local
 	// In case ExternalTrafficPolicy is changed it seems to be irrelevant
 	// https://kubernetes.io/docs/tasks/access-application-cluster/create-external-load-balancer/#preserving-the-client-source-ip
@@ -767,6 +1225,25 @@ func (w *worker) updateService(chi *chop.ClickHouseInstallation, curService, new
 	return err
 }
 
+// recreateService deletes current Service and creates new one in place of it.
+// Used to apply changes of immutable fields, such as spec.clusterIP, so Service is unavailable for a moment
+func (w *worker) recreateService(chi *chop.ClickHouseInstallation, curService, newService *core.Service) error {
+	w.a.V(1).
+		WithEvent(chi, eventActionUpdate, eventReasonUpdateInProgress).
+		WithStatusAction(chi).
+		Info("Update Service %s/%s - cluster IP changed from %s to %s, recreate", newService.Namespace, newService.Name, curService.Spec.ClusterIP, newService.Spec.ClusterIP)
+
+	if err := w.c.kubeClient.CoreV1().Services(curService.Namespace).Delete(curService.Name, newDeleteOptions()); err != nil && !apierrors.IsNotFound(err) {
+		w.a.WithEvent(chi, eventActionUpdate, eventReasonUpdateFailed).
+			WithStatusAction(chi).
+			WithStatusError(chi).
+			Error("Update Service %s/%s failed to delete Service with error %v", curService.Namespace, curService.Name, err)
+		return err
+	}
+
+	return w.createService(chi, newService)
+}
+
 // createService
 func (w *worker) createService(chi *chop.ClickHouseInstallation, service *core.Service) error {
 	_, err := w.c.kubeClient.CoreV1().Services(service.Namespace).Create(service)
@@ -848,6 +1325,7 @@ func (w *worker) createStatefulSet(statefulSet *apps.StatefulSet, host *chop.Chi
 		WithStatusAction(host.CHI).
 		Info("Create StatefulSet %s/%s - started", statefulSet.Namespace, statefulSet.Name)
 
+	chopmodel.SetConfigHistory(nil, statefulSet, w.c.chop.Config().ConfigHistoryLength, time.Now())
 	err := w.c.createStatefulSet(statefulSet, host)
 
 	host.CHI.Status.AddedHostsCount++
@@ -881,6 +1359,8 @@ func (w *worker) updateStatefulSet(curStatefulSet, newStatefulSet *apps.Stateful
 		WithStatusAction(host.CHI).
 		Info("Update StatefulSet(%s/%s) - started", namespace, name)
 
+	chopmodel.SetConfigHistory(curStatefulSet, newStatefulSet, w.c.chop.Config().ConfigHistoryLength, time.Now())
+	chopmodel.PreserveSettingsConfigVersion(curStatefulSet, newStatefulSet)
 	err := w.c.updateStatefulSet(curStatefulSet, newStatefulSet)
 	if err == nil {
 		host.CHI.Status.UpdatedHostsCount++
@@ -906,6 +1386,22 @@ func (w *worker) updateStatefulSet(curStatefulSet, newStatefulSet *apps.Stateful
 	return w.createStatefulSet(newStatefulSet, host)
 }
 
+// reloadHotSettings asks ClickHouse to reload config in case hot-reloadable settings changed.
+// Restart-required settings are part of Pod template, so their change rolls pods instead
+func (w *worker) reloadHotSettings(curStatefulSet, newStatefulSet *apps.StatefulSet, host *chop.ChiHost) {
+	cur := curStatefulSet.Annotations[chopmodel.AnnotationHotSettingsVersion]
+	new := newStatefulSet.Annotations[chopmodel.AnnotationHotSettingsVersion]
+	if cur == new {
+		return
+	}
+
+	w.a.V(1).Info("Update StatefulSet(%s/%s) - hot-reloadable settings changed, reload config", newStatefulSet.Namespace, newStatefulSet.Name)
+	if err := w.schemer.HostReloadConfig(host); err != nil {
+		// ClickHouse reloads changed config files on its own anyway, as soon as ConfigMap is propagated into Pod
+		w.a.V(1).Info("Update StatefulSet(%s/%s) - unable to reload config: %v", newStatefulSet.Namespace, newStatefulSet.Name, err)
+	}
+}
+
 // reconcilePVCs
 func (w *worker) reconcilePVCs(host *chop.ChiHost) error {
 	namespace := host.Address.Namespace
@@ -919,6 +1415,10 @@ func (w *worker) reconcilePVCs(host *chop.ChiHost) error {
 			// No this is not a reference to VolumeClaimTemplate
 			return
 		}
+		if volumeClaimTemplate.IsEphemeral() {
+			// Ephemeral volume has no PVC to reconcile
+			return
+		}
 
 		pvcName := chopmodel.CreatePVCName(host, volumeMount, volumeClaimTemplate)
 		w.a.V(2).Info("reconcile volumeMount (%s/%s/%s/%s) - start", namespace, host.Name, volumeMount.Name, pvcName)
@@ -933,15 +1433,15 @@ func (w *worker) reconcilePVCs(host *chop.ChiHost) error {
 			}
 			return
 		}
-		w.reconcileResources(pvc, volumeClaimTemplate)
+		w.reconcileResources(pvc, chopmodel.CreatePVCResourceRequests(host, volumeClaimTemplate))
 	})
 
 	return nil
 }
 
 // reconcileResources
-func (w *worker) reconcileResources(pvc *core.PersistentVolumeClaim, template *chop.ChiVolumeClaimTemplate) {
-	w.reconcileResourcesList(pvc, pvc.Spec.Resources.Requests, template.Spec.Resources.Requests)
+func (w *worker) reconcileResources(pvc *core.PersistentVolumeClaim, requests core.ResourceList) {
+	w.reconcileResourcesList(pvc, pvc.Spec.Resources.Requests, requests)
 }
 
 // reconcileResourcesList
@@ -991,6 +1491,18 @@ func (w *worker) reconcileResource(
 		return
 	}
 
+	if resourceName == core.ResourceStorage {
+		// PVC can only be expanded and only in case its StorageClass allows it
+		if desiredResourceQuantity.Cmp(pvcResourceQuantity) < 0 {
+			w.a.Warning("reconcileResource(%s/%s/%s) - PVC can not be shrunk, keep %s", pvc.Namespace, pvc.Name, resourceName, pvcResourceQuantity.String())
+			return
+		}
+		if !w.isPVCExpandable(pvc) {
+			w.a.Warning("reconcileResource(%s/%s/%s) - StorageClass does not allow volume expansion, keep %s", pvc.Namespace, pvc.Name, resourceName, pvcResourceQuantity.String())
+			return
+		}
+	}
+
 	w.a.V(2).Info("reconcileResource(%s/%s/%s) - unequal requests, want to update", pvc.Namespace, pvc.Name, resourceName)
 	pvcResourceList[resourceName] = desiredResourceList[resourceName]
 	_, err := w.c.kubeClient.CoreV1().PersistentVolumeClaims(pvc.Namespace).Update(pvc)
@@ -999,3 +1511,18 @@ func (w *worker) reconcileResource(
 		return
 	}
 }
+
+// isPVCExpandable checks whether StorageClass of the PVC allows volume expansion
+func (w *worker) isPVCExpandable(pvc *core.PersistentVolumeClaim) bool {
+	if (pvc.Spec.StorageClassName == nil) || (*pvc.Spec.StorageClassName == "") {
+		return false
+	}
+
+	storageClass, err := w.c.kubeClient.StorageV1().StorageClasses().Get(*pvc.Spec.StorageClassName, newGetOptions())
+	if err != nil {
+		w.a.Error("ERROR unable to get StorageClass(%s) of PVC(%s/%s) err: %v", *pvc.Spec.StorageClassName, pvc.Namespace, pvc.Name, err)
+		return false
+	}
+
+	return (storageClass.AllowVolumeExpansion != nil) && *storageClass.AllowVolumeExpansion
+}
@@ -17,13 +17,19 @@ package model
 import (
 	"bytes"
 	"fmt"
+	"strconv"
+	"strings"
+
 	chiv1 "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
 	xmlbuilder "github.com/altinity/clickhouse-operator/pkg/model/builder/xml"
 	"github.com/altinity/clickhouse-operator/pkg/util"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 const (
 	distributedDDLPathPattern = "/clickhouse/%s/task_queue/ddl"
+	// clusterDiscoveryPathPattern specifies default ZooKeeper path of cluster discovery - /clickhouse/{chi}/discovery/{cluster}
+	clusterDiscoveryPathPattern = "/clickhouse/%s/discovery/%s"
 
 	// Special auto-generated clusters. Each of these clusters lay over all replicas in CHI
 	// 1. Cluster with one shard and all replicas. Used to duplicate data over all replicas.
@@ -34,13 +40,15 @@ const (
 )
 
 type ClickHouseConfigGenerator struct {
-	chi *chiv1.ClickHouseInstallation
+	chi        *chiv1.ClickHouseInstallation
+	chopConfig *chiv1.OperatorConfig
 }
 
 // NewClickHouseConfigGenerator returns new ClickHouseConfigGenerator struct
-func NewClickHouseConfigGenerator(chi *chiv1.ClickHouseInstallation) *ClickHouseConfigGenerator {
+func NewClickHouseConfigGenerator(chi *chiv1.ClickHouseInstallation, chopConfig *chiv1.OperatorConfig) *ClickHouseConfigGenerator {
 	return &ClickHouseConfigGenerator{
-		chi: chi,
+		chi:        chi,
+		chopConfig: chopConfig,
 	}
 }
 
@@ -49,6 +57,61 @@ func (c *ClickHouseConfigGenerator) GetUsers() string {
 	return c.generateXMLConfig(c.chi.Spec.Configuration.Users, configUsers)
 }
 
+// GetUserPasswords creates data for "user_passwords.xml" - passwords of users, provided via env vars populated from Secrets,
+// so passwords do not appear in users config
+func (c *ClickHouseConfigGenerator) GetUserPasswords() string {
+	usernames := c.chi.Spec.Configuration.GetUserPasswordSecretsUsernames()
+	if len(usernames) == 0 {
+		return ""
+	}
+
+	b := &bytes.Buffer{}
+	// <yandex>
+	//     <users>
+	util.Iline(b, 0, "<"+xmlTagYandex+">")
+	util.Iline(b, 4, "<users>")
+	for _, username := range usernames {
+		// <analyst>
+		//     <password from_env="CLICKHOUSE_USER_PASSWORD_ANALYST"/>
+		// </analyst>
+		util.Iline(b, 8, "<%s>", username)
+		util.Iline(b, 8, "    <password from_env=\"%s\"/>", createUserPasswordEnvVarName(username))
+		util.Iline(b, 8, "</%s>", username)
+	}
+	//     </users>
+	// </yandex>
+	util.Iline(b, 4, "</users>")
+	util.Iline(b, 0, "</"+xmlTagYandex+">")
+
+	return b.String()
+}
+
+// GetStandby creates data for "standby.xml" - profile of default user, provided via env var, which differs between
+// hosts of regular and standby clusters, since users config is shared by all clusters of the installation
+func (c *ClickHouseConfigGenerator) GetStandby() string {
+	if !c.chi.Spec.Configuration.HasStandbyClusters() {
+		return ""
+	}
+
+	b := &bytes.Buffer{}
+	// <yandex>
+	//     <users>
+	//         <default>
+	//             <profile from_env="CLICKHOUSE_DEFAULT_USER_PROFILE"/>
+	//         </default>
+	//     </users>
+	// </yandex>
+	util.Iline(b, 0, "<"+xmlTagYandex+">")
+	util.Iline(b, 4, "<users>")
+	util.Iline(b, 4, "    <default>")
+	util.Iline(b, 4, "        <profile from_env=\"%s\"/>", defaultUserProfileEnvVarName)
+	util.Iline(b, 4, "    </default>")
+	util.Iline(b, 4, "</users>")
+	util.Iline(b, 0, "</"+xmlTagYandex+">")
+
+	return b.String()
+}
+
 // GetProfiles creates data for "profiles.xml"
 func (c *ClickHouseConfigGenerator) GetProfiles() string {
 	return c.generateXMLConfig(c.chi.Spec.Configuration.Profiles, configProfiles)
@@ -59,13 +122,577 @@ func (c *ClickHouseConfigGenerator) GetQuotas() string {
 	return c.generateXMLConfig(c.chi.Spec.Configuration.Quotas, configQuotas)
 }
 
+// GetQuotaIntervals creates data for "quota_intervals.xml" - quotas with intervals and limits within them.
+// Intervals are merged by ClickHouse into quotas specified by quotas section, if any
+func (c *ClickHouseConfigGenerator) GetQuotaIntervals() string {
+	quotas := c.chi.Spec.Configuration.QuotaIntervals
+	if len(quotas) == 0 {
+		return ""
+	}
+
+	b := &bytes.Buffer{}
+	// <yandex>
+	//     <quotas>
+	util.Iline(b, 0, "<"+xmlTagYandex+">")
+	util.Iline(b, 4, "<quotas>")
+	for i := range quotas {
+		quota := &quotas[i]
+		// <app>
+		util.Iline(b, 8, "<%s>", quota.Name)
+		for j := range quota.Intervals {
+			interval := &quota.Intervals[j]
+			// <interval>
+			//     <duration>3600</duration>
+			//     <queries>1000</queries>
+			// </interval>
+			util.Iline(b, 12, "<interval>")
+			util.Iline(b, 12, "    <duration>%s</duration>", interval.Duration)
+			for _, limit := range []struct {
+				name  string
+				value string
+			}{
+				{"queries", interval.Queries},
+				{"errors", interval.Errors},
+				{"result_rows", interval.ResultRows},
+				{"read_rows", interval.ReadRows},
+				{"execution_time", interval.ExecutionTime},
+			} {
+				if limit.value != "" {
+					util.Iline(b, 12, "    <%s>%s</%s>", limit.name, limit.value, limit.name)
+				}
+			}
+			util.Iline(b, 12, "</interval>")
+		}
+		// </app>
+		util.Iline(b, 8, "</%s>", quota.Name)
+	}
+	//     </quotas>
+	// </yandex>
+	util.Iline(b, 4, "</quotas>")
+	util.Iline(b, 0, "</"+xmlTagYandex+">")
+
+	return b.String()
+}
+
+// GetMonitoring creates data for "monitoring.xml" - restricted read-only user and profile for monitoring tools
+func (c *ClickHouseConfigGenerator) GetMonitoring() string {
+	monitoring := &c.chi.Spec.Configuration.Monitoring
+	if !monitoring.IsEnabled() {
+		return ""
+	}
+
+	b := &bytes.Buffer{}
+	// <yandex>
+	//     <profiles>
+	util.Iline(b, 0, "<"+xmlTagYandex+">")
+	util.Iline(b, 4, "<profiles>")
+
+	// <monitoring>
+	//     <readonly>1</readonly>
+	// </monitoring>
+	util.Iline(b, 8, "<%s>", monitoring.Profile)
+	util.Iline(b, 8, "    <readonly>1</readonly>")
+	util.Iline(b, 8, "</%s>", monitoring.Profile)
+
+	//     </profiles>
+	//     <users>
+	util.Iline(b, 4, "</profiles>")
+	util.Iline(b, 4, "<users>")
+
+	// <monitoring>
+	util.Iline(b, 8, "<%s>", monitoring.User)
+	// Password is provided via env var, which is populated from Secret
+	if monitoring.HasPasswordSecret() {
+		util.Iline(b, 8, "    <password from_env=\"%s\"/>", monitoringPasswordEnvVarName)
+	} else {
+		util.Iline(b, 8, "    <password></password>")
+	}
+	util.Iline(b, 8, "    <profile>%s</profile>", monitoring.Profile)
+	util.Iline(b, 8, "    <quota>%s</quota>", c.chi.Spec.Defaults.DefaultQuota)
+	// Accessible from localhost and from pods of the installation only.
+	// User without password is accessible from localhost only, which is enough for exporter sidecar
+	util.Iline(b, 8, "    <networks>")
+	util.Iline(b, 8, "        <ip>127.0.0.1</ip>")
+	util.Iline(b, 8, "        <ip>::1</ip>")
+	if monitoring.HasPasswordSecret() {
+		util.Iline(b, 8, "        <host_regexp>%s</host_regexp>", CreatePodRegexp(c.chi, c.chopConfig.CHConfigNetworksHostRegexpTemplate))
+	}
+	util.Iline(b, 8, "    </networks>")
+	if monitoring.Role != "" {
+		// Access is defined by grants of the role
+		util.Iline(b, 8, "    <grants>")
+		util.Iline(b, 8, "        <query>GRANT %s</query>", monitoring.Role)
+		util.Iline(b, 8, "    </grants>")
+	} else {
+		// Access to system.* tables only
+		util.Iline(b, 8, "    <allow_databases>")
+		util.Iline(b, 8, "        <database>system</database>")
+		util.Iline(b, 8, "    </allow_databases>")
+	}
+	util.Iline(b, 8, "</%s>", monitoring.User)
+
+	//     </users>
+	// </yandex>
+	util.Iline(b, 4, "</users>")
+	util.Iline(b, 0, "</"+xmlTagYandex+">")
+
+	return b.String()
+}
+
+// GetUserDefinedFunctions creates data for "user_defined_functions.xml" - executable functions config and scripts paths
+func (c *ClickHouseConfigGenerator) GetUserDefinedFunctions() string {
+	if !c.chi.Spec.Configuration.UserDefinedFunctions.IsDeclared() {
+		return ""
+	}
+
+	b := &bytes.Buffer{}
+	// <yandex>
+	//     <user_defined_executable_functions_config>/etc/clickhouse-server/functions/*_function.xml</user_defined_executable_functions_config>
+	//     <user_scripts_path>/etc/clickhouse-server/functions/</user_scripts_path>
+	// </yandex>
+	util.Iline(b, 0, "<"+xmlTagYandex+">")
+	util.Iline(b, 4, "<user_defined_executable_functions_config>%s*_function.xml</user_defined_executable_functions_config>", dirPathUserDefinedFunctions)
+	util.Iline(b, 4, "<user_scripts_path>%s</user_scripts_path>", dirPathUserDefinedFunctions)
+	util.Iline(b, 0, "</"+xmlTagYandex+">")
+
+	return b.String()
+}
+
+// GetFormatSchemas creates data for "format_schemas.xml" - path to schema files of Protobuf/Cap'n Proto formats
+func (c *ClickHouseConfigGenerator) GetFormatSchemas() string {
+	if !c.chi.Spec.Configuration.FormatSchemas.IsDeclared() {
+		return ""
+	}
+
+	b := &bytes.Buffer{}
+	// <yandex>
+	//     <format_schema_path>/etc/clickhouse-server/format_schemas/</format_schema_path>
+	// </yandex>
+	util.Iline(b, 0, "<"+xmlTagYandex+">")
+	util.Iline(b, 4, "<format_schema_path>%s</format_schema_path>", dirPathFormatSchemas)
+	util.Iline(b, 0, "</"+xmlTagYandex+">")
+
+	return b.String()
+}
+
+// GetKafka creates data for "kafka.xml" - defaults of Kafka table engine.
+// Broker list and consumer group are provided as named collection, which is referenced by tables as ENGINE = Kafka(kafka),
+// while security settings are passed to librdkafka via <kafka> section. SASL credentials are taken from env vars
+func (c *ClickHouseConfigGenerator) GetKafka() string {
+	kafka := &c.chi.Spec.Configuration.Kafka
+	if !kafka.IsConfigured() {
+		return ""
+	}
+
+	b := &bytes.Buffer{}
+	// <yandex>
+	util.Iline(b, 0, "<"+xmlTagYandex+">")
+
+	if (kafka.BrokerList != "") || (kafka.Group != "") {
+		// <named_collections>
+		//     <kafka>
+		//         <kafka_broker_list>broker:9092</kafka_broker_list>
+		//         <kafka_group_name>group</kafka_group_name>
+		//     </kafka>
+		// </named_collections>
+		util.Iline(b, 4, "<named_collections>")
+		util.Iline(b, 8, "<%s>", kafkaNamedCollection)
+		if kafka.BrokerList != "" {
+			util.Iline(b, 12, "<kafka_broker_list>%s</kafka_broker_list>", kafka.BrokerList)
+		}
+		if kafka.Group != "" {
+			util.Iline(b, 12, "<kafka_group_name>%s</kafka_group_name>", kafka.Group)
+		}
+		util.Iline(b, 8, "</%s>", kafkaNamedCollection)
+		util.Iline(b, 4, "</named_collections>")
+	}
+
+	// <kafka>
+	//     <security_protocol>sasl_ssl</security_protocol>
+	//     <sasl_mechanism>SCRAM-SHA-512</sasl_mechanism>
+	//     <sasl_username from_env="CLICKHOUSE_KAFKA_SASL_USERNAME"/>
+	//     <sasl_password from_env="CLICKHOUSE_KAFKA_SASL_PASSWORD"/>
+	// </kafka>
+	if (kafka.SecurityProtocol != "") || (kafka.SASLMechanism != "") || kafka.HasSASLUsernameSecret() || kafka.HasSASLPasswordSecret() {
+		util.Iline(b, 4, "<kafka>")
+		if kafka.SecurityProtocol != "" {
+			util.Iline(b, 8, "<security_protocol>%s</security_protocol>", kafka.SecurityProtocol)
+		}
+		if kafka.SASLMechanism != "" {
+			util.Iline(b, 8, "<sasl_mechanism>%s</sasl_mechanism>", kafka.SASLMechanism)
+		}
+		if kafka.HasSASLUsernameSecret() {
+			util.Iline(b, 8, "<sasl_username from_env=\"%s\"/>", kafkaSASLUsernameEnvVarName)
+		}
+		if kafka.HasSASLPasswordSecret() {
+			util.Iline(b, 8, "<sasl_password from_env=\"%s\"/>", kafkaSASLPasswordEnvVarName)
+		}
+		util.Iline(b, 4, "</kafka>")
+	}
+
+	// </yandex>
+	util.Iline(b, 0, "</"+xmlTagYandex+">")
+
+	return b.String()
+}
+
+// GetInterserverCredentials creates data for "interserver_credentials.xml" - credentials of replication fetches,
+// provided via env vars from Secrets
+func (c *ClickHouseConfigGenerator) GetInterserverCredentials() string {
+	credentials := &c.chi.Spec.Configuration.InterserverCredentials
+	if !credentials.IsConfigured() {
+		return ""
+	}
+
+	b := &bytes.Buffer{}
+
+	// <yandex>
+	//     <interserver_http_credentials>
+	//         <user from_env="CLICKHOUSE_INTERSERVER_USER"/>
+	//         <password from_env="CLICKHOUSE_INTERSERVER_PASSWORD"/>
+	//         <allow_empty>true</allow_empty>
+	//     </interserver_http_credentials>
+	// </yandex>
+	util.Iline(b, 0, "<"+xmlTagYandex+">")
+	util.Iline(b, 4, "<interserver_http_credentials>")
+	util.Iline(b, 8, "<user from_env=\"%s\"/>", interserverUserEnvVarName)
+	util.Iline(b, 8, "<password from_env=\"%s\"/>", interserverPasswordEnvVarName)
+	if credentials.IsAllowEmpty() {
+		util.Iline(b, 8, "<allow_empty>true</allow_empty>")
+	}
+	util.Iline(b, 4, "</interserver_http_credentials>")
+	util.Iline(b, 0, "</"+xmlTagYandex+">")
+
+	return b.String()
+}
+
+// xmlTextEscaper escapes characters which are not allowed in XML text, such as ones met in regexps
+var xmlTextEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// GetQueryMaskingRules creates data for "query_masking_rules.xml" - regexp-based redaction of queries written to logs
+func (c *ClickHouseConfigGenerator) GetQueryMaskingRules() string {
+	rules := c.chi.Spec.Configuration.QueryMaskingRules
+	if len(rules) == 0 {
+		return ""
+	}
+
+	b := &bytes.Buffer{}
+	// <yandex>
+	//     <query_masking_rules>
+	//         <rule>
+	//             <name>hide passwords</name>
+	//             <regexp>password\s*=\s*'[^']*'</regexp>
+	//             <replace>password = '******'</replace>
+	//         </rule>
+	//     </query_masking_rules>
+	// </yandex>
+	util.Iline(b, 0, "<"+xmlTagYandex+">")
+	util.Iline(b, 4, "<query_masking_rules>")
+	for _, rule := range rules {
+		util.Iline(b, 8, "<rule>")
+		if rule.Name != "" {
+			util.Iline(b, 12, "<name>%s</name>", xmlTextEscaper.Replace(rule.Name))
+		}
+		util.Iline(b, 12, "<regexp>%s</regexp>", xmlTextEscaper.Replace(rule.Regexp))
+		if rule.Replace != "" {
+			util.Iline(b, 12, "<replace>%s</replace>", xmlTextEscaper.Replace(rule.Replace))
+		}
+		util.Iline(b, 8, "</rule>")
+	}
+	util.Iline(b, 4, "</query_masking_rules>")
+	util.Iline(b, 0, "</"+xmlTagYandex+">")
+
+	return b.String()
+}
+
 // GetSettings creates data for "settings.xml"
 func (c *ClickHouseConfigGenerator) GetSettings(host *chiv1.ChiHost) string {
 	if host == nil {
-		return c.generateXMLConfig(c.chi.Spec.Configuration.Settings, "")
+		server, _ := splitKeeperSettings(c.chi.Spec.Configuration.Settings)
+		return c.generateXMLConfig(server, "")
+	} else {
+		server, _ := splitKeeperSettings(c.getHostSettings(host))
+		return c.generateXMLConfig(server, "")
+	}
+}
+
+// GetHostKeeper creates data for "keeper_config.xml" - <keeper_server> section of ClickHouse Keeper node,
+// which is kept separately from server settings, so role configs do not mix.
+// Each Keeper node has own server_id, thus common keeper settings are rendered per-host,
+// along with host's own keeper settings, which take precedence
+func (c *ClickHouseConfigGenerator) GetHostKeeper(host *chiv1.ChiHost) string {
+	_, keeper := splitKeeperSettings(host.Settings)
+	_, common := splitKeeperSettings(c.chi.Spec.Configuration.Settings)
+	keeper.MergeFrom(common)
+	return c.generateXMLConfig(keeper, "")
+}
+
+// splitKeeperSettings splits settings into server settings and <keeper_server> section settings
+func splitKeeperSettings(settings chiv1.Settings) (server, keeper chiv1.Settings) {
+	server = chiv1.NewSettings()
+	keeper = chiv1.NewSettings()
+	for path, setting := range settings {
+		if strings.HasPrefix(path, keeperServerSection+"/") {
+			keeper[path] = setting
+		} else {
+			server[path] = setting
+		}
+	}
+	return server, keeper
+}
+
+// getHostSettings returns settings of the host along with per-host <display_name>,
+// which is shown in clickhouse-client prompt, so it is clear which host the client is connected to.
+// display_name explicitly specified in either common or host settings is not overwritten
+func (c *ClickHouseConfigGenerator) getHostSettings(host *chiv1.ChiHost) chiv1.Settings {
+	if _, ok := c.chi.Spec.Configuration.Settings[settingDisplayName]; ok {
+		return host.Settings
+	}
+	if _, ok := host.Settings[settingDisplayName]; ok {
+		return host.Settings
+	}
+
+	settings := chiv1.NewSettings()
+	settings[settingDisplayName] = chiv1.NewScalarSetting(CreateHostDisplayName(host))
+	settings.MergeFrom(host.Settings)
+	return settings
+}
+
+// GetLogger creates data for "logger.xml" - routes logs to console in case .spec.defaults.logToConsole is set.
+// File log paths, which may be provided by operator-supplied config files, are removed, so nothing is written to volumes.
+// Log records are formatted as JSON in case .spec.defaults.logFormat is json
+func (c *ClickHouseConfigGenerator) GetLogger() string {
+	toConsole := util.IsStringBoolTrue(c.chi.Spec.Defaults.LogToConsole)
+	toJSON := c.chi.Spec.Defaults.LogFormat == logFormatJSON
+	if !toConsole && !toJSON {
+		return ""
+	}
+
+	b := &bytes.Buffer{}
+	// <yandex>
+	//     <logger>
+	//         <console>1</console>
+	//         <log remove="1"/>
+	//         <errorlog remove="1"/>
+	//         <formatting>
+	//             <type>json</type>
+	//         </formatting>
+	//     </logger>
+	// </yandex>
+	util.Iline(b, 0, "<"+xmlTagYandex+">")
+	util.Iline(b, 4, "<logger>")
+	if toConsole {
+		util.Iline(b, 8, "<console>1</console>")
+		util.Iline(b, 8, "<log remove=\"1\"/>")
+		util.Iline(b, 8, "<errorlog remove=\"1\"/>")
+	}
+	if toJSON {
+		util.Iline(b, 8, "<formatting>")
+		util.Iline(b, 12, "<type>%s</type>", logFormatJSON)
+		util.Iline(b, 8, "</formatting>")
+	}
+	util.Iline(b, 4, "</logger>")
+	util.Iline(b, 0, "</"+xmlTagYandex+">")
+
+	return b.String()
+}
+
+// GetSystemLogs creates data for "system_logs.xml" - opted in system logs, such as part_log and text_log.
+// Log tables have TTL specified, so they do not grow unbounded on data volume
+func (c *ClickHouseConfigGenerator) GetSystemLogs() string {
+	logs := &c.chi.Spec.Configuration.SystemLogs
+	if !logs.PartLog.IsEnabled() && !logs.TextLog.IsEnabled() && !logs.TraceLog.IsEnabled() && !logs.CrashLog.IsEnabled() {
+		return ""
+	}
+
+	b := &bytes.Buffer{}
+	// <yandex>
+	util.Iline(b, 0, "<"+xmlTagYandex+">")
+	if logs.PartLog.IsEnabled() {
+		c.generateSystemLog(b, "part_log", &logs.PartLog)
+	}
+	if logs.TextLog.IsEnabled() {
+		c.generateSystemLog(b, "text_log", &logs.TextLog)
+	}
+	if logs.TraceLog.IsEnabled() {
+		c.generateSystemLog(b, "trace_log", &logs.TraceLog)
+	}
+	if logs.CrashLog.IsEnabled() {
+		c.generateSystemLog(b, "crash_log", &logs.CrashLog)
+	}
+	// </yandex>
+	util.Iline(b, 0, "</"+xmlTagYandex+">")
+
+	return b.String()
+}
+
+// GetCompression creates data for "compression.xml" - compression cases in order they are specified.
+// Nothing is generated in case no cases are specified
+func (c *ClickHouseConfigGenerator) GetCompression() string {
+	cases := c.chi.Spec.Configuration.Compression
+	if len(cases) == 0 {
+		return ""
+	}
+
+	b := &bytes.Buffer{}
+	// <yandex>
+	//     <compression>
+	//         <case>
+	//             <min_part_size>10000000000</min_part_size>
+	//             <min_part_size_ratio>0.01</min_part_size_ratio>
+	//             <method>zstd</method>
+	//         </case>
+	//     </compression>
+	// </yandex>
+	util.Iline(b, 0, "<"+xmlTagYandex+">")
+	util.Iline(b, 4, "<compression>")
+	for i := range cases {
+		_case := &cases[i]
+		util.Iline(b, 8, "<case>")
+		if _case.MinPartSize != "" {
+			util.Iline(b, 12, "<min_part_size>%s</min_part_size>", _case.MinPartSize)
+		}
+		if _case.MinPartSizeRatio != "" {
+			util.Iline(b, 12, "<min_part_size_ratio>%s</min_part_size_ratio>", _case.MinPartSizeRatio)
+		}
+		util.Iline(b, 12, "<method>%s</method>", _case.Method)
+		util.Iline(b, 8, "</case>")
+	}
+	util.Iline(b, 4, "</compression>")
+	util.Iline(b, 0, "</"+xmlTagYandex+">")
+
+	return b.String()
+}
+
+// GetBackups creates data for "backups.xml" - disk allowed for BACKUP/RESTORE along with I/O threads and bandwidth limits.
+// Nothing is generated in case backups are not configured
+func (c *ClickHouseConfigGenerator) GetBackups() string {
+	backups := &c.chi.Spec.Configuration.Backups
+	if !backups.IsConfigured() {
+		return ""
+	}
+
+	b := &bytes.Buffer{}
+	// <yandex>
+	//     <backups>
+	//         <allowed_disk>backups</allowed_disk>
+	//     </backups>
+	//     <max_backups_io_thread_pool_size>4</max_backups_io_thread_pool_size>
+	//     <max_backup_bandwidth_for_server>104857600</max_backup_bandwidth_for_server>
+	// </yandex>
+	util.Iline(b, 0, "<"+xmlTagYandex+">")
+	util.Iline(b, 4, "<backups>")
+	util.Iline(b, 8, "<allowed_disk>%s</allowed_disk>", backups.Disk)
+	util.Iline(b, 4, "</backups>")
+	if backups.IOThreads != "" {
+		util.Iline(b, 4, "<max_backups_io_thread_pool_size>%s</max_backups_io_thread_pool_size>", backups.IOThreads)
+	}
+	if backups.MaxBandwidth != "" {
+		util.Iline(b, 4, "<max_backup_bandwidth_for_server>%s</max_backup_bandwidth_for_server>", backups.MaxBandwidth)
+	}
+	util.Iline(b, 0, "</"+xmlTagYandex+">")
+
+	return b.String()
+}
+
+// GetStorage creates data for "storage.xml" with disks and storage policies specified in .spec.configuration.storage
+// and filesystem cache disk layered over remote disk, along with storage policy over the cache disk, if requested
+func (c *ClickHouseConfigGenerator) GetStorage() string {
+	storage := &c.chi.Spec.Configuration.Storage
+	cache := &c.chi.Spec.Configuration.FilesystemCache
+	if storage.IsEmpty() && !cache.IsEnabled() {
+		return ""
+	}
+
+	b := &bytes.Buffer{}
+	// <yandex>
+	//   <storage_configuration>
+	util.Iline(b, 0, "<"+xmlTagYandex+">")
+	util.Iline(b, 4, "<storage_configuration>")
+	if (len(storage.Disks) > 0) || cache.IsEnabled() {
+		// <disks>
+		util.Iline(b, 8, "<disks>")
+		for i := range storage.Disks {
+			disk := &storage.Disks[i]
+			util.Iline(b, 12, "<%s>", disk.Name)
+			util.Iline(b, 16, "<path>%s</path>", disk.Path)
+			if disk.KeepFreeSpaceBytes != "" {
+				util.Iline(b, 16, "<keep_free_space_bytes>%s</keep_free_space_bytes>", disk.KeepFreeSpaceBytes)
+			}
+			util.Iline(b, 12, "</%s>", disk.Name)
+		}
+		if cache.IsEnabled() {
+			// Max size is validated by normalizer
+			maxSize := resource.MustParse(cache.MaxSize)
+			util.Iline(b, 12, "<%s>", cache.Name)
+			util.Iline(b, 16, "<type>cache</type>")
+			util.Iline(b, 16, "<disk>%s</disk>", cache.Disk)
+			util.Iline(b, 16, "<path>%s%s/</path>", dirPathClickHouseFilesystemCache, cache.Name)
+			util.Iline(b, 16, "<max_size>%d</max_size>", maxSize.Value())
+			util.Iline(b, 12, "</%s>", cache.Name)
+		}
+		// </disks>
+		util.Iline(b, 8, "</disks>")
+	}
+	if (len(storage.Policies) > 0) || (cache.IsEnabled() && (cache.Policy != "")) {
+		// <policies>
+		util.Iline(b, 8, "<policies>")
+		for i := range storage.Policies {
+			policy := &storage.Policies[i]
+			util.Iline(b, 12, "<%s>", policy.Name)
+			util.Iline(b, 16, "<volumes>")
+			for j := range policy.Volumes {
+				volume := &policy.Volumes[j]
+				util.Iline(b, 20, "<%s>", volume.Name)
+				for _, disk := range volume.Disks {
+					util.Iline(b, 24, "<disk>%s</disk>", disk)
+				}
+				util.Iline(b, 20, "</%s>", volume.Name)
+			}
+			util.Iline(b, 16, "</volumes>")
+			if policy.MoveFactor != "" {
+				util.Iline(b, 16, "<move_factor>%s</move_factor>", policy.MoveFactor)
+			}
+			util.Iline(b, 12, "</%s>", policy.Name)
+		}
+		if cache.IsEnabled() && (cache.Policy != "") {
+			util.Iline(b, 12, "<%s>", cache.Policy)
+			util.Iline(b, 16, "<volumes>")
+			util.Iline(b, 20, "<main>")
+			util.Iline(b, 24, "<disk>%s</disk>", cache.Name)
+			util.Iline(b, 20, "</main>")
+			util.Iline(b, 16, "</volumes>")
+			util.Iline(b, 12, "</%s>", cache.Policy)
+		}
+		// </policies>
+		util.Iline(b, 8, "</policies>")
+	}
+	//   </storage_configuration>
+	// </yandex>
+	util.Iline(b, 4, "</storage_configuration>")
+	util.Iline(b, 0, "</"+xmlTagYandex+">")
+
+	return b.String()
+}
+
+// generateSystemLog generates system log table section. Explicitly specified engine replaces default one along with TTL
+func (c *ClickHouseConfigGenerator) generateSystemLog(b *bytes.Buffer, table string, systemLog *chiv1.ChiSystemLog) {
+	// <part_log>
+	//     <database>system</database>
+	//     <table>part_log</table>
+	//     <engine>ENGINE = MergeTree PARTITION BY event_date ORDER BY event_time TTL event_date + INTERVAL 30 DAY</engine>
+	//     <flush_interval_milliseconds>7500</flush_interval_milliseconds>
+	// </part_log>
+	util.Iline(b, 4, "<%s>", table)
+	util.Iline(b, 4, "    <database>system</database>")
+	util.Iline(b, 4, "    <table>%s</table>", table)
+	if systemLog.Engine != "" {
+		util.Iline(b, 4, "    <engine>ENGINE = %s</engine>", xmlTextEscaper.Replace(systemLog.Engine))
 	} else {
-		return c.generateXMLConfig(host.Settings, "")
+		util.Iline(b, 4, "    <engine>ENGINE = MergeTree PARTITION BY event_date ORDER BY event_time TTL event_date + INTERVAL %s DAY</engine>", systemLog.TTLDays)
 	}
+	util.Iline(b, 4, "    <flush_interval_milliseconds>%s</flush_interval_milliseconds>", systemLog.FlushIntervalMilliseconds)
+	util.Iline(b, 4, "</%s>", table)
 }
 
 // GetFiles creates data for custom common config files
@@ -105,10 +732,14 @@ func (c *ClickHouseConfigGenerator) GetHostZookeeper(host *chiv1.ChiHost) string
 		// <node>
 		//		<host>HOST</host>
 		//		<port>PORT</port>
+		//		<secure>1</secure>
 		// </node>
 		util.Iline(b, 8, "<node>")
 		util.Iline(b, 8, "    <host>%s</host>", node.Host)
 		util.Iline(b, 8, "    <port>%d</port>", node.Port)
+		if node.IsSecure() {
+			util.Iline(b, 8, "    <secure>1</secure>")
+		}
 		util.Iline(b, 8, "</node>")
 	}
 
@@ -135,13 +766,44 @@ func (c *ClickHouseConfigGenerator) GetHostZookeeper(host *chiv1.ChiHost) string
 	// </zookeeper>
 	util.Iline(b, 4, "</zookeeper>")
 
+	// Client certificates for secure ZooKeeper connections.
+	// ClickHouse has no ZooKeeper-specific TLS config, so <openSSL><client> is server-wide
+	// and applies to all outgoing TLS connections of the server.
+	// It is not generated in case user provides own openSSL/client settings, which take precedence.
+	// <openSSL>
+	//		<client>
+	//			<certificateFile>/etc/clickhouse-server/zookeeper-tls/tls.crt</certificateFile>
+	//			<privateKeyFile>/etc/clickhouse-server/zookeeper-tls/tls.key</privateKeyFile>
+	//			<caConfig>/etc/clickhouse-server/zookeeper-tls/ca.crt</caConfig>
+	//		</client>
+	// </openSSL>
+	if zk.HasTLSSecret() && !c.hasOpenSSLClientSettings(host) {
+		util.Iline(b, 4, "<openSSL>")
+		util.Iline(b, 4, "    <client>")
+		util.Iline(b, 4, "        <certificateFile>%s%s</certificateFile>", dirPathZookeeperTLS, zkTLSCertificateFile)
+		util.Iline(b, 4, "        <privateKeyFile>%s%s</privateKeyFile>", dirPathZookeeperTLS, zkTLSPrivateKeyFile)
+		util.Iline(b, 4, "        <caConfig>%s%s</caConfig>", dirPathZookeeperTLS, zkTLSCAFile)
+		util.Iline(b, 4, "        <verificationMode>strict</verificationMode>")
+		util.Iline(b, 4, "    </client>")
+		util.Iline(b, 4, "</openSSL>")
+	}
+
 	// <distributed_ddl>
 	//      <path>/x/y/chi.name/z</path>
 	//      <profile>X</profile>
+	//      <max_tasks_in_queue>1000</max_tasks_in_queue>
+	//      <task_max_lifetime>86400</task_max_lifetime>
+	//      <cleanup_delay_period>60</cleanup_delay_period>
+	ddl := &c.chi.Spec.Defaults.DistributedDDL
 	util.Iline(b, 4, "<distributed_ddl>")
 	util.Iline(b, 4, "    <path>%s</path>", c.getDistributedDDLPath())
-	if c.chi.Spec.Defaults.DistributedDDL.Profile != "" {
-		util.Iline(b, 4, "    <profile>%s</profile>", c.chi.Spec.Defaults.DistributedDDL.Profile)
+	if ddl.Profile != "" {
+		util.Iline(b, 4, "    <profile>%s</profile>", ddl.Profile)
+	}
+	if ddl.IsCleanup() {
+		util.Iline(b, 4, "    <max_tasks_in_queue>%s</max_tasks_in_queue>", ddl.MaxTasksInQueue)
+		util.Iline(b, 4, "    <task_max_lifetime>%s</task_max_lifetime>", ddl.TaskMaxLifetime)
+		util.Iline(b, 4, "    <cleanup_delay_period>%s</cleanup_delay_period>", ddl.CleanupDelayPeriod)
 	}
 	//		</distributed_ddl>
 	// </yandex>
@@ -167,15 +829,29 @@ func (c *ClickHouseConfigGenerator) GetRemoteServers() string {
 		// <my_cluster_name>
 		util.Iline(b, 8, "<%s>", cluster.Name)
 
+		if cluster.IsDiscovery() {
+			// Hosts register themselves, shard of each host is specified in host config
+			// <discovery>
+			//		<path>/clickhouse/chi/discovery/my_cluster_name</path>
+			// </discovery>
+			util.Iline(b, 12, "<discovery>")
+			util.Iline(b, 12, "    <path>%s</path>", cluster.Discovery.Path)
+			util.Iline(b, 12, "</discovery>")
+			// </my_cluster_name>
+			util.Iline(b, 8, "</%s>", cluster.Name)
+			return nil
+		}
+
 		// Build each shard XML
 		cluster.WalkShards(func(index int, shard *chiv1.ChiShard) error {
 			// <shard>
 			//		<internal_replication>VALUE(true/false)</internal_replication>
+			// Value is resolved by normalizer - shard's value, cluster's value, or true for replicated shards with ZooKeeper
 			util.Iline(b, 12, "<shard>")
 			util.Iline(b, 16, "<internal_replication>%s</internal_replication>", shard.InternalReplication)
 
 			//		<weight>X</weight>
-			if shard.Weight > 0 {
+			if shard.Weight != shardWeightDefault {
 				util.Iline(b, 16, "<weight>%d</weight>", shard.Weight)
 			}
 
@@ -261,8 +937,46 @@ func (c *ClickHouseConfigGenerator) GetRemoteServers() string {
 	util.Iline(b, 8, "</%s>", clusterName)
 
 	// 		</remote_servers>
-	// </yandex>
 	util.Iline(b, 0, "    </remote_servers>")
+
+	if c.chi.IsClusterDiscovery() {
+		util.Iline(b, 4, "<allow_experimental_cluster_discovery>1</allow_experimental_cluster_discovery>")
+	}
+
+	// </yandex>
+	util.Iline(b, 0, "</"+xmlTagYandex+">")
+
+	return b.String()
+}
+
+// GetHostRemoteServers creates data for host's "remote_servers.xml" - shard the host registers in
+// in case cluster discovery is used
+func (c *ClickHouseConfigGenerator) GetHostRemoteServers(host *chiv1.ChiHost) string {
+	cluster := host.GetCluster()
+	if !cluster.IsDiscovery() {
+		return ""
+	}
+
+	b := &bytes.Buffer{}
+
+	// <yandex>
+	//     <remote_servers>
+	//         <my_cluster_name>
+	//             <discovery>
+	//                 <shard>1</shard>
+	//             </discovery>
+	//         </my_cluster_name>
+	//     </remote_servers>
+	// </yandex>
+	util.Iline(b, 0, "<"+xmlTagYandex+">")
+	util.Iline(b, 4, "<remote_servers>")
+	util.Iline(b, 8, "<%s>", cluster.Name)
+	util.Iline(b, 12, "<discovery>")
+	// Shards are numbered starting with 1
+	util.Iline(b, 12, "    <shard>%d</shard>", host.Address.ShardIndex+1)
+	util.Iline(b, 12, "</discovery>")
+	util.Iline(b, 8, "</%s>", cluster.Name)
+	util.Iline(b, 4, "</remote_servers>")
 	util.Iline(b, 0, "</"+xmlTagYandex+">")
 
 	return b.String()
@@ -287,16 +1001,26 @@ func (c *ClickHouseConfigGenerator) GetHostMacros(host *chiv1.ChiHost) string {
 
 	// All Shards One Replica Cluster
 	// <CLUSTER_NAME-shard>0-based shard index within all-shards-one-replica-cluster</CLUSTER_NAME-shard>
-	util.Iline(b, 8, "<%s-shard>%d</%[1]s-shard>", allShardsOneReplicaClusterName, host.Address.CHIScopeIndex)
+	util.Iline(b, 8, "<%s-shard>%d</%[1]s-shard>", allShardsOneReplicaClusterName, host.Address.CHIScopeIndex+c.chi.Spec.Defaults.ShardBaseIndex)
 
 	// <cluster> and <shard> macros are applicable to main cluster only. All aux clusters do not have ambiguous macros
 	// <cluster></cluster> macro
 	util.Iline(b, 8, "<cluster>%s</cluster>", host.Address.ClusterName)
 	// <shard></shard> macro
-	util.Iline(b, 8, "<shard>%s</shard>", host.Address.ShardName)
+	util.Iline(b, 8, "<shard>%s</shard>", c.getMacrosShard(host))
+	// <replica_index>replica index within the shard</replica_index>
+	util.Iline(b, 8, "<replica_index>%d</replica_index>", host.Address.ReplicaIndex+c.chi.Spec.Defaults.ReplicaBaseIndex)
 	// <replica>replica id = full deployment id</replica>
 	// full deployment id is unique to identify replica within the cluster
 	util.Iline(b, 8, "<replica>%s</replica>", CreatePodHostname(host))
+	// <ordinal>host ordinal</ordinal>
+	// host ordinal is unique within the installation, can be used as server_id and in replica-specific paths
+	util.Iline(b, 8, "<ordinal>%d</ordinal>", CreateHostOrdinal(host))
+
+	// Custom macros, sorted for config to be stable
+	for _, name := range util.SortedKeys(c.chi.Spec.Configuration.Macros) {
+		util.Iline(b, 8, "<%s>%s</%[1]s>", name, c.chi.Spec.Configuration.Macros[name])
+	}
 
 	// 		</macros>
 	// </yandex>
@@ -306,6 +1030,20 @@ func (c *ClickHouseConfigGenerator) GetHostMacros(host *chiv1.ChiHost) string {
 	return b.String()
 }
 
+// getMacrosShard returns value of <shard> macro. Explicitly specified shard name is used as is,
+// while auto-generated shard is numbered starting with .spec.defaults.shardBaseIndex,
+// which keeps the macro consistent with shards order in remote_servers
+func (c *ClickHouseConfigGenerator) getMacrosShard(host *chiv1.ChiHost) string {
+	if cluster := c.chi.FindCluster(host.Address.ClusterName); cluster != nil {
+		shard := cluster.GetShard(host.Address.ShardIndex)
+		if !IsAutoGeneratedShardName(host.Address.ShardName, shard, host.Address.ShardIndex) {
+			// Explicitly specified shard name
+			return host.Address.ShardName
+		}
+	}
+	return strconv.Itoa(host.Address.ShardIndex + c.chi.Spec.Defaults.ShardBaseIndex)
+}
+
 func noCustomPorts(host *chiv1.ChiHost) bool {
 	if host.TCPPort != chDefaultTCPPortNumber {
 		return false
@@ -322,6 +1060,56 @@ func noCustomPorts(host *chiv1.ChiHost) bool {
 	return true
 }
 
+// hasOpenSSLClientSettings checks whether <openSSL><client> section is specified by user settings
+func (c *ClickHouseConfigGenerator) hasOpenSSLClientSettings(host *chiv1.ChiHost) bool {
+	for _, settings := range []chiv1.Settings{
+		c.chi.Spec.Configuration.Settings,
+		host.Settings,
+	} {
+		for path := range settings {
+			if strings.HasPrefix(path, openSSLClientSettingsPrefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// GetTLS creates data for "tls.xml" - secure native and HTTPS ports along with server certificates
+func (c *ClickHouseConfigGenerator) GetTLS() string {
+	if !c.chi.Spec.Configuration.TLS.IsEnabled() {
+		return ""
+	}
+
+	b := &bytes.Buffer{}
+	// <yandex>
+	//     <tcp_port_secure>9440</tcp_port_secure>
+	//     <https_port>8443</https_port>
+	//     <openSSL>
+	//         <server>
+	//             <certificateFile>/etc/clickhouse-server/tls/tls.crt</certificateFile>
+	//             <privateKeyFile>/etc/clickhouse-server/tls/tls.key</privateKeyFile>
+	//             <caConfig>/etc/clickhouse-server/tls/ca.crt</caConfig>
+	//             <verificationMode>relaxed</verificationMode>
+	//         </server>
+	//     </openSSL>
+	// </yandex>
+	util.Iline(b, 0, "<"+xmlTagYandex+">")
+	util.Iline(b, 4, "<tcp_port_secure>%d</tcp_port_secure>", chDefaultTCPPortSecureNumber)
+	util.Iline(b, 4, "<https_port>%d</https_port>", chDefaultHTTPSPortNumber)
+	util.Iline(b, 4, "<openSSL>")
+	util.Iline(b, 4, "    <server>")
+	util.Iline(b, 4, "        <certificateFile>%s%s</certificateFile>", dirPathTLS, tlsCertificateFile)
+	util.Iline(b, 4, "        <privateKeyFile>%s%s</privateKeyFile>", dirPathTLS, tlsPrivateKeyFile)
+	util.Iline(b, 4, "        <caConfig>%s%s</caConfig>", dirPathTLS, tlsCAFile)
+	util.Iline(b, 4, "        <verificationMode>relaxed</verificationMode>")
+	util.Iline(b, 4, "    </server>")
+	util.Iline(b, 4, "</openSSL>")
+	util.Iline(b, 0, "</"+xmlTagYandex+">")
+
+	return b.String()
+}
+
 // GetHostPorts creates "ports.xml" content
 func (c *ClickHouseConfigGenerator) GetHostPorts(host *chiv1.ChiHost) string {
 
@@ -377,9 +1165,12 @@ func (c *ClickHouseConfigGenerator) getDistributedDDLPath() string {
 }
 
 // getRemoteServersReplicaHostname returns hostname (podhostname + service or FQDN) for "remote_servers.xml"
-// based on .Spec.Defaults.ReplicasUseFQDN
+// based on .Spec.Defaults.ReplicasUseFQDN and .Spec.Defaults.ServiceMesh
 func (c *ClickHouseConfigGenerator) getRemoteServersReplicaHostname(host *chiv1.ChiHost) string {
-	if util.IsStringBoolTrue(c.chi.Spec.Defaults.ReplicasUseFQDN) {
+	if c.chi.Spec.Defaults.ServiceMesh.IsEnabled() {
+		// Service mesh routes pod-to-pod traffic by pod DNS name within headless service
+		return CreatePodHeadlessServiceFQDN(host)
+	} else if util.IsStringBoolTrue(c.chi.Spec.Defaults.ReplicasUseFQDN) {
 		// In case .Spec.Defaults.ReplicasUseFQDN is set replicas would use FQDN pod hostname,
 		// otherwise hostname+service name (unique within namespace) would be used
 		// .my-dev-namespace.svc.cluster.local
@@ -14,50 +14,80 @@
 
 package model
 
-import "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
-
 const (
 	xmlTagYandex = "yandex"
 )
 
 const (
-	configMacros        = "macros"
-	configPorts         = "ports"
-	configProfiles      = "profiles"
-	configQuotas        = "quotas"
-	configRemoteServers = "remote_servers"
-	configSettings      = "settings"
-	configUsers         = "users"
-	configZookeeper     = "zookeeper"
+	// filenameTopologyJSON specifies key of topology summary within topology ConfigMap
+	filenameTopologyJSON = "topology.json"
 )
 
 const (
-	// dirPathCommonConfig specifies full path to folder, where generated common XML files for ClickHouse would be placed
-	// for the following sections:
-	// 1. remote servers
-	// 2. operator-provided additional config files
-	dirPathCommonConfig = "/etc/clickhouse-server/" + v1.CommonConfigDir + "/"
-
-	// dirPathUsersConfig specifies full path to folder, where generated users XML files for ClickHouse would be placed
-	// for the following sections:
-	// 1. users
-	// 2. quotas
-	// 3. profiles
-	// 4. operator-provided additional config files
-	dirPathUsersConfig = "/etc/clickhouse-server/" + v1.UsersConfigDir + "/"
-
-	// dirPathHostConfig specifies full path to folder, where generated host XML files for ClickHouse would be placed
-	// for the following sections:
-	// 1. macros
-	// 2. zookeeper
-	// 3. settings
-	// 4. files
-	// 5. operator-provided additional config files
-	dirPathHostConfig = "/etc/clickhouse-server/" + v1.HostConfigDir + "/"
+	configBackups        = "backups"
+	configFormatSchemas  = "format_schemas"
+	configInterserver    = "interserver_credentials"
+	configCompression    = "compression"
+	configKafka          = "kafka"
+	configKeeper         = "keeper_config"
+	configLogger         = "logger"
+	configMacros         = "macros"
+	configMonitoring     = "monitoring"
+	configPorts          = "ports"
+	configProfiles       = "profiles"
+	configQueryMasking   = "query_masking_rules"
+	configQuotas         = "quotas"
+	configQuotaIntervals = "quota_intervals"
+	configRemoteServers  = "remote_servers"
+	configSettings       = "settings"
+	configStandby        = "standby"
+	configStorage        = "storage"
+	configSystemLogs     = "system_logs"
+	configTLS            = "tls"
+	configUDF            = "user_defined_functions"
+	configUserPasswords  = "user_passwords"
+	configUsers          = "users"
+	configZookeeper      = "zookeeper"
+)
+
+const (
+	// dirPathConfig specifies default full path to ClickHouse config folder, which can be relocated with .spec.defaults.configDirPath
+	// Generated XML files for ClickHouse are placed into the following sub-folders of config folder:
+	// 1. v1.CommonConfigDir - remote servers, user defined functions and operator-provided additional config files
+	// 2. v1.UsersConfigDir - users, quotas, profiles, monitoring user and operator-provided additional config files
+	// 3. v1.HostConfigDir - macros, zookeeper, settings, files and operator-provided additional config files
+	dirPathConfig = "/etc/clickhouse-server/"
+
+	// dirPathUserDefinedFunctions specifies full path to folder, where executable user defined functions
+	// definitions (*_function.xml) and their scripts would be mounted from ConfigMap or Secret
+	dirPathUserDefinedFunctions = "/etc/clickhouse-server/functions/"
+
+	// dirPathFormatSchemas specifies full path to folder, where schema files of Protobuf/Cap'n Proto formats
+	// would be mounted from ConfigMap or Secret
+	dirPathFormatSchemas = "/etc/clickhouse-server/format_schemas/"
+
+	// dirPathZookeeperTLS specifies full path to folder, where ZooKeeper client certificates
+	// would be mounted from Secret
+	dirPathZookeeperTLS = "/etc/clickhouse-server/zookeeper-tls/"
+
+	// dirPathTLS specifies full path to folder, where server certificates of secure ports
+	// would be mounted from Secret
+	dirPathTLS = "/etc/clickhouse-server/tls/"
 
 	// dirPathClickHouseData specifies full path of data folder where ClickHouse would place its data storage
 	dirPathClickHouseData = "/var/lib/clickhouse"
 
+	// dirPathClickHouseTmp specifies full path of folder where ClickHouse would place temporary data,
+	// in case .spec.defaults.tmpVolume is specified. Has to end with '/' as required by tmp_path
+	dirPathClickHouseTmp = "/var/lib/clickhouse-tmp/"
+
+	// dirPathClickHouseFilesystemCache specifies full path of folder where ClickHouse would place filesystem cache,
+	// in case .spec.configuration.filesystemCache is specified
+	dirPathClickHouseFilesystemCache = "/var/lib/clickhouse-cache/"
+
+	// dirPathShm specifies full path of shared memory folder, in case .spec.defaults.shmVolume is specified
+	dirPathShm = "/dev/shm"
+
 	// dirPathClickHouseLog  specifies full path of data folder where ClickHouse would place its log files
 	dirPathClickHouseLog = "/var/log/clickhouse-server"
 )
@@ -72,6 +102,11 @@ const (
 	// Name of container within Pod with ClickHouse instance. Pod may have other containers included, such as monitoring
 	ClickHouseContainerName    = "clickhouse"
 	ClickHouseLogContainerName = "clickhouse-log"
+	// Name of init container, which changes owner of data volume
+	ClickHouseChownContainerName = "clickhouse-chown"
+
+	// capabilitySysResource allows ClickHouse to raise open files limit above hard limit
+	capabilitySysResource = "SYS_RESOURCE"
 )
 
 const (
@@ -84,9 +119,109 @@ const (
 	chDefaultHTTPPortNumber            = int32(8123)
 	chDefaultInterserverHTTPPortName   = "interserver"
 	chDefaultInterserverHTTPPortNumber = int32(9009)
+
+	// ClickHouse secure ports, opened in case .spec.configuration.tls is specified
+	chDefaultTCPPortSecureName   = "tcp-secure"
+	chDefaultTCPPortSecureNumber = int32(9440)
+	chDefaultHTTPSPortName       = "https"
+	chDefaultHTTPSPortNumber     = int32(8443)
+	// Files of server certificates Secret
+	tlsCertificateFile = "tls.crt"
+	tlsPrivateKeyFile  = "tls.key"
+	tlsCAFile          = "ca.crt"
+)
+const (
+	// Default name of generated monitoring user and its profile
+	monitoringDefaultUser    = "monitoring"
+	monitoringDefaultProfile = "monitoring"
+	// Env var of ClickHouse container, which provides monitoring user's password from Secret
+	monitoringPasswordEnvVarName = "CLICKHOUSE_MONITORING_PASSWORD"
 )
+
+const (
+	// Default port and endpoint of built-in Prometheus endpoint of ClickHouse
+	prometheusDefaultPort     = "9363"
+	prometheusDefaultEndpoint = "/metrics"
+	// Name of ClickHouse container and Service port of built-in Prometheus endpoint
+	prometheusPortName = "metrics"
+)
+
+const (
+	// Prefix of env vars of ClickHouse container, which provide passwords of users from Secrets
+	userPasswordEnvVarNamePrefix = "CLICKHOUSE_USER_PASSWORD_"
+)
+
+const (
+	// Name of exporter sidecar container within Pod with ClickHouse instance
+	exporterSidecarContainerName = "clickhouse-exporter"
+	// Default port of exporter sidecar to serve metrics on
+	exporterSidecarDefaultPort = "9116"
+	exporterSidecarPortName    = "exporter"
+	// Env vars of exporter sidecar container. DSN and telemetry address are passed as args referencing env vars
+	exporterUserEnvVarName             = "CLICKHOUSE_USER"
+	exporterPasswordEnvVarName         = "CLICKHOUSE_PASSWORD"
+	exporterScrapeURIEnvVarName        = "SCRAPE_URI"
+	exporterTelemetryAddressEnvVarName = "TELEMETRY_ADDRESS"
+)
+
+const (
+	// Name of named collection with Kafka broker list and consumer group
+	kafkaNamedCollection = "kafka"
+	// Env vars of ClickHouse container, which provide Kafka SASL credentials from Secrets
+	kafkaSASLUsernameEnvVarName = "CLICKHOUSE_KAFKA_SASL_USERNAME"
+	kafkaSASLPasswordEnvVarName = "CLICKHOUSE_KAFKA_SASL_PASSWORD"
+)
+
+const (
+	// Env vars of ClickHouse container, which provide interserver credentials from Secrets
+	interserverUserEnvVarName     = "CLICKHOUSE_INTERSERVER_USER"
+	interserverPasswordEnvVarName = "CLICKHOUSE_INTERSERVER_PASSWORD"
+)
+
+const (
+	// Name of pod volume with user defined functions definitions and scripts
+	userDefinedFunctionsVolumeName = "user-defined-functions"
+	// Name of pod volume with format schema files
+	formatSchemasVolumeName = "format-schemas"
+	// Name of pod volume with ZooKeeper client certificates
+	zookeeperTLSVolumeName = "zookeeper-tls"
+	// Name of pod volume with server certificates of secure ports
+	tlsVolumeName = "clickhouse-tls"
+	// Name of pod volume with ClickHouse tmp_path
+	tmpVolumeName = "clickhouse-tmp"
+	// Name of pod volume with shared memory
+	shmVolumeName = "dshm"
+	// Name of pod volume with ClickHouse filesystem cache, in case no volumeClaimTemplate is specified
+	filesystemCacheVolumeName = "clickhouse-cache"
+)
+
+const (
+	// Env var of ClickHouse container, which provides host ordinal. Can be referenced in config via from_env
+	hostOrdinalEnvVarName = "CLICKHOUSE_HOST_ORDINAL"
+	// Host ordinal is cluster index * 1000000 + shard index * 1000 + replica index + 1
+	hostOrdinalClusterFactor = 1000000
+	hostOrdinalShardFactor   = 1000
+	// Env var of ClickHouse container, which specifies home dir. Populated from .spec.defaults.container.home
+	homeEnvVarName = "HOME"
+	// Env var of ClickHouse container, which provides default user's profile, in case installation has standby clusters
+	defaultUserProfileEnvVarName = "CLICKHOUSE_DEFAULT_USER_PROFILE"
+	// Profiles of default user on hosts of regular and standby clusters
+	defaultUserProfile        = "default"
+	standbyDefaultUserProfile = "readonly"
+	// User, which preStop hook flushes system logs as. Its password, if any, is taken from userPasswordSecrets
+	flushLogsOnShutdownUser = "default"
+)
+
 const (
 	zkDefaultPort = 2181
+	// zkDefaultSecurePort specifies default ZK port in case node requires TLS connection
+	zkDefaultSecurePort = 2281
 	// zkDefaultRootTemplate specifies default ZK root - /clickhouse/{namespace}/{chi name}
 	zkDefaultRootTemplate = "/clickhouse/%s/%s"
+	// Files of ZooKeeper client certificates Secret
+	zkTLSCertificateFile = "tls.crt"
+	zkTLSPrivateKeyFile  = "tls.key"
+	zkTLSCAFile          = "ca.crt"
+	// openSSLClientSettingsPrefix specifies settings path of server-wide <openSSL><client> section
+	openSSLClientSettingsPrefix = "openSSL/client/"
 )
@@ -15,10 +15,28 @@
 package model
 
 import (
+	"fmt"
+
 	chi "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
 	"github.com/altinity/clickhouse-operator/pkg/util"
 )
 
+// ConfigValidator validates generated ClickHouse config files before they are applied.
+// It is an extension point for external validation, such as running `clickhouse-server --dump-config`
+// against generated files, without coupling config generation to a ClickHouse binary.
+type ConfigValidator interface {
+	// Validate validates config files of a section, provided as filename->content map
+	Validate(section chi.SettingsSection, files map[string]string) error
+}
+
+// noopConfigValidator accepts any config
+type noopConfigValidator struct{}
+
+// Validate accepts any config
+func (v noopConfigValidator) Validate(_ chi.SettingsSection, _ map[string]string) error {
+	return nil
+}
+
 // configSections
 type configSections struct {
 	// commonConfigSections maps section name to section XML config string
@@ -30,6 +48,8 @@ type configSections struct {
 	chConfigGenerator *ClickHouseConfigGenerator
 	// clickhouse-operator configuration
 	chopConfig *chi.OperatorConfig
+	// Validator for generated config files
+	validator ConfigValidator
 }
 
 // NewConfigSections
@@ -39,53 +59,142 @@ func NewConfigSections(chConfigGenerator *ClickHouseConfigGenerator, chopConfig
 		commonUsersConfigSections: make(map[string]string),
 		chConfigGenerator:         chConfigGenerator,
 		chopConfig:                chopConfig,
+		validator:                 noopConfigValidator{},
 	}
 }
 
+// SetValidator sets validator to be used for generated config files
+func (c *configSections) SetValidator(validator ConfigValidator) {
+	if validator == nil {
+		validator = noopConfigValidator{}
+	}
+	c.validator = validator
+}
+
 // CreateConfigsCommon
-func (c *configSections) CreateConfigsCommon() {
+func (c *configSections) CreateConfigsCommon() error {
 	// commonConfigSections maps section name to section XML chopConfig of the following sections:
 	// 1. remote servers
 	// 2. common settings
-	// 3. common files
+	// 3. logger
+	// 4. system logs
+	// 5. storage - disks, policies and filesystem cache
+	// 6. compression
+	// 7. user defined functions
+	// 8. format schemas
+	// 9. kafka
+	// 10. backups
+	// 11. query masking rules
+	// 12. interserver credentials
+	// 13. common files
 	util.IncludeNonEmpty(c.commonConfigSections, createConfigSectionFilename(configRemoteServers), c.chConfigGenerator.GetRemoteServers())
 	util.IncludeNonEmpty(c.commonConfigSections, createConfigSectionFilename(configSettings), c.chConfigGenerator.GetSettings(nil))
+	util.IncludeNonEmpty(c.commonConfigSections, createConfigSectionFilename(configLogger), c.chConfigGenerator.GetLogger())
+	util.IncludeNonEmpty(c.commonConfigSections, createConfigSectionFilename(configSystemLogs), c.chConfigGenerator.GetSystemLogs())
+	util.IncludeNonEmpty(c.commonConfigSections, createConfigSectionFilename(configStorage), c.chConfigGenerator.GetStorage())
+	util.IncludeNonEmpty(c.commonConfigSections, createConfigSectionFilename(configCompression), c.chConfigGenerator.GetCompression())
+	util.IncludeNonEmpty(c.commonConfigSections, createConfigSectionFilename(configUDF), c.chConfigGenerator.GetUserDefinedFunctions())
+	util.IncludeNonEmpty(c.commonConfigSections, createConfigSectionFilename(configFormatSchemas), c.chConfigGenerator.GetFormatSchemas())
+	util.IncludeNonEmpty(c.commonConfigSections, createConfigSectionFilename(configKafka), c.chConfigGenerator.GetKafka())
+	util.IncludeNonEmpty(c.commonConfigSections, createConfigSectionFilename(configBackups), c.chConfigGenerator.GetBackups())
+	util.IncludeNonEmpty(c.commonConfigSections, createConfigSectionFilename(configQueryMasking), c.chConfigGenerator.GetQueryMaskingRules())
+	util.IncludeNonEmpty(c.commonConfigSections, createConfigSectionFilename(configInterserver), c.chConfigGenerator.GetInterserverCredentials())
+	util.IncludeNonEmpty(c.commonConfigSections, createConfigSectionFilename(configTLS), c.chConfigGenerator.GetTLS())
 	util.MergeStringMaps(c.commonConfigSections, c.chConfigGenerator.GetFiles(chi.SectionCommon, true, nil))
 	// Extra user-specified config files
 	util.MergeStringMaps(c.commonConfigSections, c.chopConfig.CHCommonConfigs)
+
+	return c.validate(chi.SectionCommon, c.commonConfigSections)
 }
 
 // CreateConfigsUsers
-func (c *configSections) CreateConfigsUsers() {
+func (c *configSections) CreateConfigsUsers() error {
 	// commonUsersConfigSections maps section name to section XML chopConfig of the following sections:
-	// 1. users
+	// 1. profiles
 	// 2. quotas
-	// 3. profiles
-	// 4. user files
-	util.IncludeNonEmpty(c.commonUsersConfigSections, createConfigSectionFilename(configUsers), c.chConfigGenerator.GetUsers())
-	util.IncludeNonEmpty(c.commonUsersConfigSections, createConfigSectionFilename(configQuotas), c.chConfigGenerator.GetQuotas())
-	util.IncludeNonEmpty(c.commonUsersConfigSections, createConfigSectionFilename(configProfiles), c.chConfigGenerator.GetProfiles())
+	// 3. users
+	// 4. passwords of users from Secrets
+	// 5. monitoring user
+	// 6. default user's profile of standby clusters
+	// 7. quota intervals
+	// 8. user files
+	// Generated files are named to be loaded in this order and before user files, see createUsersConfigSectionFilename()
+	util.IncludeNonEmpty(c.commonUsersConfigSections, createUsersConfigSectionFilename(configProfiles), c.chConfigGenerator.GetProfiles())
+	util.IncludeNonEmpty(c.commonUsersConfigSections, createUsersConfigSectionFilename(configQuotas), c.chConfigGenerator.GetQuotas())
+	util.IncludeNonEmpty(c.commonUsersConfigSections, createUsersConfigSectionFilename(configUsers), c.chConfigGenerator.GetUsers())
+	util.IncludeNonEmpty(c.commonUsersConfigSections, createUsersConfigSectionFilename(configUserPasswords), c.chConfigGenerator.GetUserPasswords())
+	util.IncludeNonEmpty(c.commonUsersConfigSections, createUsersConfigSectionFilename(configMonitoring), c.chConfigGenerator.GetMonitoring())
+	util.IncludeNonEmpty(c.commonUsersConfigSections, createUsersConfigSectionFilename(configStandby), c.chConfigGenerator.GetStandby())
+	util.IncludeNonEmpty(c.commonUsersConfigSections, createUsersConfigSectionFilename(configQuotaIntervals), c.chConfigGenerator.GetQuotaIntervals())
 	util.MergeStringMaps(c.commonUsersConfigSections, c.chConfigGenerator.GetFiles(chi.SectionUsers, false, nil))
 	// Extra user-specified config files
 	util.MergeStringMaps(c.commonUsersConfigSections, c.chopConfig.CHUsersConfigs)
+
+	return c.validate(chi.SectionUsers, c.commonUsersConfigSections)
 }
 
 // CreateConfigsHost
-func (c *configSections) CreateConfigsHost(host *chi.ChiHost) map[string]string {
+func (c *configSections) CreateConfigsHost(host *chi.ChiHost) (map[string]string, error) {
 	// Prepare for this replica deployment chopConfig files map as filename->content
 	hostConfigSections := make(map[string]string)
 	util.IncludeNonEmpty(hostConfigSections, createConfigSectionFilename(configMacros), c.chConfigGenerator.GetHostMacros(host))
+	util.IncludeNonEmpty(hostConfigSections, createConfigSectionFilename(configRemoteServers), c.chConfigGenerator.GetHostRemoteServers(host))
 	util.IncludeNonEmpty(hostConfigSections, createConfigSectionFilename(configPorts), c.chConfigGenerator.GetHostPorts(host))
 	util.IncludeNonEmpty(hostConfigSections, createConfigSectionFilename(configZookeeper), c.chConfigGenerator.GetHostZookeeper(host))
 	util.IncludeNonEmpty(hostConfigSections, createConfigSectionFilename(configSettings), c.chConfigGenerator.GetSettings(host))
+	util.IncludeNonEmpty(hostConfigSections, createConfigSectionFilename(configKeeper), c.chConfigGenerator.GetHostKeeper(host))
 	util.MergeStringMaps(hostConfigSections, c.chConfigGenerator.GetFiles(chi.SectionHost, true, host))
 	// Extra user-specified config files
 	util.MergeStringMaps(hostConfigSections, c.chopConfig.CHHostConfigs)
 
-	return hostConfigSections
+	return hostConfigSections, c.validate(chi.SectionHost, hostConfigSections)
+}
+
+// GetCommonConfigFilenames returns filenames of common config files sorted in the order ClickHouse loads them.
+// ClickHouse merges config.d files in alphabetical order, thus load order can be controlled by filename prefixes
+func (c *configSections) GetCommonConfigFilenames() []string {
+	return util.SortedKeys(c.commonConfigSections)
+}
+
+// GetUsersConfigFilenames returns filenames of users config files sorted in the order ClickHouse loads them
+func (c *configSections) GetUsersConfigFilenames() []string {
+	return util.SortedKeys(c.commonUsersConfigSections)
+}
+
+// validate validates generated config files of a section
+func (c *configSections) validate(section chi.SettingsSection, files map[string]string) error {
+	if err := c.validator.Validate(section, files); err != nil {
+		return fmt.Errorf("generated %s config is invalid: %v", section, err)
+	}
+	return nil
 }
 
 // createConfigSectionFilename
 func createConfigSectionFilename(section string) string {
 	return "chop-generated-" + section + ".xml"
 }
+
+// usersConfigSectionsOrder specifies the order generated users.d files are loaded by ClickHouse in.
+// Later files take precedence, thus monitoring user can not be redefined by users section
+var usersConfigSectionsOrder = []string{
+	configProfiles,
+	configQuotas,
+	configUsers,
+	configUserPasswords,
+	configMonitoring,
+	configStandby,
+	// Appended later, so names of files above are kept
+	configQuotaIntervals,
+}
+
+// createUsersConfigSectionFilename creates filename of generated users.d file, such as '02-chop-generated-users.xml'.
+// ClickHouse merges users.d files in alphabetical order, so numeric prefix fixes the order of generated files
+// and makes them loaded before user files and files of users override ConfigMap, which take precedence
+func createUsersConfigSectionFilename(section string) string {
+	for i, s := range usersConfigSectionsOrder {
+		if s == section {
+			return fmt.Sprintf("%02d-%s", i, createConfigSectionFilename(section))
+		}
+	}
+	return createConfigSectionFilename(section)
+}
@@ -1,12 +1,16 @@
 package model
 
 import (
+	"encoding/json"
+	"regexp"
+	"strings"
 	"testing"
 
 	chiv1 "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
 	"github.com/altinity/clickhouse-operator/pkg/chop"
 	"github.com/kubernetes-sigs/yaml"
 	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
 )
 
 var ZookeeperOnClusterData = `
@@ -104,3 +108,751 @@ func TestGetZookeeper(t *testing.T) {
 		return nil
 	})
 }
+
+func TestGetZookeeperSettings(t *testing.T) {
+	runCHITests(t, []chiTest{
+		{
+			name: "root and identity",
+			data: `
+apiVersion: "clickhouse.altinity.com/v1"
+kind: "ClickHouseInstallation"
+metadata:
+  name: "my-chi"
+spec:
+  configuration:
+    zookeeper:
+      nodes:
+        - host: zookeeper-0.zookeepers.zoo1ns
+      root: /clickhouse/my-chi
+      identity: user:password
+    clusters:
+      - name: rooted
+      - name: plain
+        zookeeper:
+          nodes:
+            - host: zookeeper-0.zookeepers.zoo3ns
+`,
+			check: func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+				chi.WalkHosts(func(host *chiv1.ChiHost) error {
+					str := creator.chConfigGenerator.GetHostZookeeper(host)
+					if host.Address.ClusterName == "plain" {
+						require.NotContains(t, str, "<root>", "unexpected zookeeper root")
+						require.NotContains(t, str, "<identity>", "unexpected zookeeper identity")
+					} else {
+						require.Contains(t, str, "        <root>/clickhouse/my-chi</root>\n", "zookeeper root expected")
+						require.Contains(t, str, "        <identity>user:password</identity>\n", "zookeeper identity expected")
+					}
+					return nil
+				})
+			},
+		},
+		{
+			name: "TLS",
+			data: `
+apiVersion: "clickhouse.altinity.com/v1"
+kind: "ClickHouseInstallation"
+metadata:
+  name: "my-chi"
+spec:
+  configuration:
+    zookeeper:
+      nodes:
+        - host: zookeeper-0.zookeepers.zoo1ns
+          secure: "yes"
+      tls:
+        secret: zookeeper-client-tls
+    clusters:
+      - name: generated
+      - name: custom
+        settings:
+          openSSL/client/verificationMode: relaxed
+`,
+			check: func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+				chi.WalkHosts(func(host *chiv1.ChiHost) error {
+					str := creator.chConfigGenerator.GetHostZookeeper(host)
+					require.Contains(t, str, "        <port>2281</port>\n", "secure zookeeper port expected")
+					require.Contains(t, str, "        <secure>1</secure>\n", "secure zookeeper node expected")
+					if host.Address.ClusterName == "custom" {
+						// User-provided openSSL client config takes precedence over generated one
+						require.NotContains(t, str, "<openSSL>", "unexpected openSSL client config")
+					} else {
+						require.Contains(t, str, "        <caConfig>/etc/clickhouse-server/zookeeper-tls/ca.crt</caConfig>\n", "openSSL client config expected")
+					}
+					return nil
+				})
+			},
+		},
+	})
+}
+
+func TestGetRemoteServers(t *testing.T) {
+	runCHITests(t, []chiTest{
+		{
+			name: "topology",
+			data: ZookeeperOnClusterData,
+			check: func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+				str, err := creator.chConfigGenerator.GetTopology()
+				require.Nil(t, err, "failed to create topology")
+
+				topology := Topology{}
+				err = json.Unmarshal([]byte(str), &topology)
+				require.Nil(t, err, "failed to unmarshal topology")
+				require.Equal(t, "repl-06", topology.Installation, "unexpected installation")
+				require.Equal(t, 2, len(topology.Clusters), "unexpected clusters count")
+
+				// Hosts have to be listed in the order they are walked
+				var hosts []string
+				chi.WalkHosts(func(host *chiv1.ChiHost) error {
+					hosts = append(hosts, creator.chConfigGenerator.getRemoteServersReplicaHostname(host))
+					return nil
+				})
+				var topologyHosts []string
+				for _, cluster := range topology.Clusters {
+					require.Equal(t, 3, len(cluster.Shards), "unexpected shards count")
+					for _, shard := range cluster.Shards {
+						require.Equal(t, 2, len(shard.Replicas), "unexpected replicas count")
+						for _, replica := range shard.Replicas {
+							topologyHosts = append(topologyHosts, replica.Host)
+						}
+					}
+				}
+				require.Equal(t, hosts, topologyHosts, "unexpected hosts")
+
+				// Content has to be stable
+				again, err := creator.chConfigGenerator.GetTopology()
+				require.Nil(t, err, "failed to create topology")
+				require.Equal(t, str, again, "unstable topology")
+			},
+		},
+		{
+			name: "shard weight",
+			data: `
+apiVersion: "clickhouse.altinity.com/v1"
+kind: "ClickHouseInstallation"
+metadata:
+  name: "weight"
+spec:
+  configuration:
+    clusters:
+      - name: "weighted"
+        layout:
+          shards:
+            - name: "small"
+            - name: "default"
+              weight: 1
+            - name: "big"
+              weight: 3
+`,
+			check: func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+				chi.WalkShards(func(shard *chiv1.ChiShard) error {
+					if shard.Name == "big" {
+						require.Equal(t, 3, shard.Weight, "unexpected weight")
+					} else {
+						require.Equal(t, shardWeightDefault, shard.Weight, "unexpected default weight")
+					}
+					return nil
+				})
+
+				// Weight is rendered for non-default weighted shard only
+				config := creator.chConfigGenerator.GetRemoteServers()
+				require.Equal(t, 1, strings.Count(config, "<weight>"), "unexpected weights count")
+				require.Contains(t, config, "<weight>3</weight>", "no weight of big shard")
+			},
+		},
+		{
+			name: "internal replication",
+			data: `
+apiVersion: "clickhouse.altinity.com/v1"
+kind: "ClickHouseInstallation"
+metadata:
+  name: "internal-replication"
+spec:
+  configuration:
+    zookeeper:
+      nodes:
+        - host: zookeeper-0.zookeepers.zoo1ns
+    clusters:
+      - name: "single"
+        layout:
+          shardsCount: 1
+          replicasCount: 1
+      - name: "replicated"
+        layout:
+          shardsCount: 1
+          replicasCount: 2
+      - name: "overridden"
+        internalReplication: "no"
+        layout:
+          shards:
+            - name: "inherited"
+              replicasCount: 2
+            - name: "own"
+              replicasCount: 2
+              internalReplication: "yes"
+`,
+			check: func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+				expected := map[string]string{
+					"single/0":             "false",
+					"replicated/0":         "true",
+					"overridden/inherited": "false",
+					"overridden/own":       "true",
+				}
+				chi.WalkShards(func(shard *chiv1.ChiShard) error {
+					key := shard.Address.ClusterName + "/" + shard.Name
+					require.Equal(t, expected[key], shard.InternalReplication, "unexpected internal replication of %s", key)
+					return nil
+				})
+
+				// Skip autogenerated clusters
+				config := creator.chConfigGenerator.GetRemoteServers()
+				config = config[:strings.Index(config, "<!-- Autogenerated clusters -->")]
+				require.Equal(t, 2, strings.Count(config, "<internal_replication>true</internal_replication>"), "unexpected replicated shards")
+				require.Equal(t, 2, strings.Count(config, "<internal_replication>false</internal_replication>"), "unexpected non-replicated shards")
+			},
+		},
+		{
+			name: "multiple clusters",
+			modify: func(chi *chiv1.ClickHouseInstallation) {
+				chi.Spec.Configuration.Clusters = []chiv1.ChiCluster{
+					{Name: "analytics", Layout: chiv1.ChiClusterLayout{ShardsCount: 2}},
+					{Name: "realtime", Layout: chiv1.ChiClusterLayout{ReplicasCount: 2}},
+				}
+			},
+			check: func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+				config := creator.chConfigGenerator.GetRemoteServers()
+
+				// Each cluster has its own block, listing hosts of this cluster only
+				chi.WalkClusters(func(cluster *chiv1.ChiCluster) error {
+					start := strings.Index(config, "<"+cluster.Name+">")
+					end := strings.Index(config, "</"+cluster.Name+">")
+					require.True(t, (start >= 0) && (end > start), "no block of cluster %s", cluster.Name)
+					block := config[start:end]
+					chi.WalkHosts(func(host *chiv1.ChiHost) error {
+						hostname := "<host>" + creator.chConfigGenerator.getRemoteServersReplicaHostname(host) + "</host>"
+						if host.Address.ClusterName == cluster.Name {
+							require.Contains(t, block, hostname, "no host in its cluster block")
+						} else {
+							require.NotContains(t, block, hostname, "host of another cluster in cluster block")
+						}
+						return nil
+					})
+					return nil
+				})
+			},
+		},
+	})
+}
+
+func TestGetSettings(t *testing.T) {
+	runCHITests(t, []chiTest{
+		{
+			name: "nested keys",
+			data: `
+apiVersion: "clickhouse.altinity.com/v1"
+kind: "ClickHouseInstallation"
+metadata:
+  name: "settings"
+spec:
+  defaults:
+    maxOpenFiles: "100000"
+  configuration:
+    settings:
+      max_open_files: 200000
+      mark_cache_size: 5368709120
+      merge_tree/max_suspicious_broken_parts: 5
+      compression/case/method: zstd
+    clusters:
+      - name: "cluster"
+`,
+			check: func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+				config := creator.chConfigGenerator.GetSettings(nil)
+				require.Contains(t, config, "<mark_cache_size>5368709120</mark_cache_size>", "no plain setting")
+
+				// Paths are expanded into nested elements
+				require.Regexp(t, `<merge_tree>\s*<max_suspicious_broken_parts>5</max_suspicious_broken_parts>\s*</merge_tree>`, config, "merge_tree path is not expanded")
+				require.Regexp(t, `<compression>\s*<case>\s*<method>zstd</method>\s*</case>\s*</compression>`, config, "compression path is not expanded")
+
+				// Explicitly specified setting overrides the one derived by the operator
+				require.Contains(t, config, "<max_open_files>200000</max_open_files>", "explicit setting is overridden")
+				require.NotContains(t, config, "100000<", "derived setting overrides explicit one")
+			},
+		},
+		{
+			name: "background pools",
+			data: `
+apiVersion: "clickhouse.altinity.com/v1"
+kind: "ClickHouseInstallation"
+metadata:
+  name: "pools"
+  namespace: "kube-system"
+spec:
+  configuration:
+    clusters:
+      - name: "cluster"
+        layout:
+          shards:
+            - name: "small"
+            - name: "big"
+              backgroundPools:
+                poolSize: "32"
+                mergesMutationsConcurrencyRatio: "1.5"
+                fetchesPoolSize: "-1"
+              settings:
+                background_move_pool_size: 4
+              replicas:
+                - name: "replica0"
+                  backgroundPools:
+                    movePoolSize: "8"
+                    schedulePoolSize: "128"
+`,
+			check: func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+				chi.WalkHosts(func(host *chiv1.ChiHost) error {
+					settings := creator.chConfigGenerator.GetSettings(host)
+					if host.Address.ShardName == "small" {
+						require.NotContains(t, settings, "<background_", "small shard is affected by pools of big one")
+						return nil
+					}
+
+					// Pools of the shard are generated into host's personal settings along with host's own pools
+					require.Contains(t, settings, "<background_pool_size>32</background_pool_size>")
+					require.Contains(t, settings, "<background_merges_mutations_concurrency_ratio>1.5</background_merges_mutations_concurrency_ratio>")
+					require.Contains(t, settings, "<background_schedule_pool_size>128</background_schedule_pool_size>")
+					// Incorrect pool size is skipped and explicitly specified setting is not overwritten
+					require.NotContains(t, settings, "<background_fetches_pool_size>")
+					require.Contains(t, settings, "<background_move_pool_size>4</background_move_pool_size>")
+					return nil
+				})
+			},
+		},
+		{
+			name: "merge limits",
+			data: `
+apiVersion: "clickhouse.altinity.com/v1"
+kind: "ClickHouseInstallation"
+metadata:
+  name: "merges"
+spec:
+  defaults:
+    mergeLimits:
+      maxBytesToMergeAtMaxSpaceInPool: 10Gi
+      maxReplicatedMergesInQueue: "8"
+      maxReplicatedMutationsInQueue: "-2"
+      numberOfFreeEntriesInPoolToExecuteMutation: "20"
+  configuration:
+    settings:
+      background_merges_mutations_concurrency_ratio: "1.5"
+      merge_tree/max_replicated_merges_in_queue: 4
+    clusters:
+      - name: "cluster"
+`,
+			check: func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+				config := creator.chConfigGenerator.GetSettings(nil)
+				// Limits are generated nested into <merge_tree> section, sizes are converted into bytes
+				require.Regexp(t, `(?s)<merge_tree>.*<max_bytes_to_merge_at_max_space_in_pool>10737418240</max_bytes_to_merge_at_max_space_in_pool>.*</merge_tree>`, config)
+				require.Regexp(t, `(?s)<merge_tree>.*<number_of_free_entries_in_pool_to_execute_mutation>20</number_of_free_entries_in_pool_to_execute_mutation>.*</merge_tree>`, config)
+				// Explicitly specified setting is not overwritten and incorrect limit is skipped
+				require.Contains(t, config, "<max_replicated_merges_in_queue>4</max_replicated_merges_in_queue>")
+				require.NotContains(t, config, "<max_replicated_mutations_in_queue>")
+				// Concurrency ratio is not required to be an integer
+				require.Contains(t, config, "<background_merges_mutations_concurrency_ratio>1.5</background_merges_mutations_concurrency_ratio>")
+			},
+		},
+		{
+			name: "host macros",
+			modify: func(chi *chiv1.ClickHouseInstallation) {
+				chi.Spec.Configuration.Macros = map[string]string{
+					"layer":    "analytics",
+					"cluster":  "overridden",
+					"bad-name": "value",
+				}
+				chi.Spec.Configuration.Clusters[0].Name = "events"
+			},
+			check: func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+				// Reserved and incorrect macros are skipped
+				require.Equal(t, map[string]string{"layer": "analytics"}, chi.Spec.Configuration.Macros, "unexpected custom macros")
+
+				chi.WalkHosts(func(host *chiv1.ChiHost) error {
+					macros := creator.chConfigGenerator.GetHostMacros(host)
+					require.Contains(t, macros, "<cluster>events</cluster>", "no cluster macro")
+					require.Contains(t, macros, "<layer>analytics</layer>", "no custom macro")
+					require.Equal(t, 1, strings.Count(macros, "<cluster>"), "cluster macro is overwritten")
+					return nil
+				})
+			},
+		},
+		{
+			name: "standby cluster",
+			data: `
+apiVersion: "clickhouse.altinity.com/v1"
+kind: "ClickHouseInstallation"
+metadata:
+  name: "standby"
+  namespace: "kube-system"
+spec:
+  configuration:
+    clusters:
+      - name: "active"
+      - name: "dr"
+        standby: "yes"
+        layout:
+          shards:
+            - name: "small"
+            - name: "big"
+              backgroundPools:
+                poolSize: "16"
+`,
+			check: func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+				// Default user's profile is provided per-host via env var, read-only profile is available
+				generator := creator.chConfigGenerator
+				require.Contains(t, generator.GetStandby(), `<profile from_env="CLICKHOUSE_DEFAULT_USER_PROFILE"/>`)
+				require.Regexp(t, `(?s)<readonly>.*<readonly>1</readonly>.*</readonly>`, generator.GetProfiles())
+
+				chi.WalkHosts(func(host *chiv1.ChiHost) error {
+					settings := generator.GetSettings(host)
+					profile := ""
+					for _, envVar := range getTestContainer(t, creator, host).Env {
+						if envVar.Name == defaultUserProfileEnvVarName {
+							profile = envVar.Value
+						}
+					}
+
+					if host.Address.ClusterName == "active" {
+						require.Equal(t, "default", profile, "unexpected default user profile of active cluster")
+						require.NotContains(t, settings, "<background_pool_size>", "active cluster is affected by standby one")
+						require.NotContains(t, settings, "<max_replicated_merges_in_queue>", "active cluster is affected by standby one")
+						return nil
+					}
+
+					require.Equal(t, "readonly", profile, "unexpected default user profile of standby cluster")
+					require.Regexp(t, `(?s)<merge_tree>.*<max_replicated_merges_in_queue>0</max_replicated_merges_in_queue>.*</merge_tree>`, settings)
+					require.Regexp(t, `(?s)<merge_tree>.*<number_of_free_entries_in_pool_to_execute_mutation>0</number_of_free_entries_in_pool_to_execute_mutation>.*</merge_tree>`, settings)
+					require.Contains(t, settings, "<background_merges_mutations_concurrency_ratio>1</background_merges_mutations_concurrency_ratio>")
+					if host.Address.ShardName == "big" {
+						// Explicitly specified pools are not shrunk
+						require.Contains(t, settings, "<background_pool_size>16</background_pool_size>")
+					} else {
+						require.Contains(t, settings, "<background_pool_size>1</background_pool_size>")
+					}
+					return nil
+				})
+
+				// Nothing is generated without standby clusters
+				chi.Spec.Configuration.Clusters[1].Standby = "no"
+				require.Equal(t, "", NewClickHouseConfigGenerator(chi, creator.chop.Config()).GetStandby())
+			},
+		},
+		{
+			name: "storage",
+			data: `
+apiVersion: "clickhouse.altinity.com/v1"
+kind: "ClickHouseInstallation"
+metadata:
+  name: "storage"
+spec:
+  configuration:
+    storage:
+      disks:
+        - name: "hot"
+          path: "/var/lib/clickhouse/hot"
+          keepFreeSpaceBytes: "10485760"
+        - name: "cold"
+          path: "/var/lib/clickhouse/cold/"
+        - name: "relative"
+          path: "var/lib/clickhouse/relative/"
+      policies:
+        - name: "tiered"
+          moveFactor: "0.2"
+          volumes:
+            - name: "hot"
+              disks:
+                - "hot"
+            - name: "cold"
+              disks:
+                - "cold"
+                - "relative"
+    clusters:
+      - name: "cluster"
+`,
+			check: func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+				config := creator.chConfigGenerator.GetStorage()
+				require.Regexp(t, `<hot>\s*<path>/var/lib/clickhouse/hot/</path>\s*<keep_free_space_bytes>10485760</keep_free_space_bytes>\s*</hot>`, config, "no hot disk")
+				require.Regexp(t, `<cold>\s*<path>/var/lib/clickhouse/cold/</path>\s*</cold>`, config, "no cold disk")
+				require.NotContains(t, config, "relative", "disk with relative path is generated")
+				require.Regexp(t, `<tiered>\s*<volumes>\s*<hot>\s*<disk>hot</disk>\s*</hot>\s*<cold>\s*<disk>cold</disk>\s*</cold>\s*</volumes>\s*<move_factor>0.2</move_factor>\s*</tiered>`, config, "no tiered policy")
+
+				// Nothing is generated unless storage is specified
+				chi.Spec.Configuration.Storage = chiv1.ChiStorage{}
+				require.Empty(t, NewClickHouseConfigGenerator(chi, creator.chop.Config()).GetStorage())
+			},
+		},
+		{
+			name: "compression",
+			data: `
+apiVersion: "clickhouse.altinity.com/v1"
+kind: "ClickHouseInstallation"
+metadata:
+  name: "compression"
+spec:
+  configuration:
+    compression:
+      - minPartSize: "10000000000"
+        minPartSizeRatio: "0.01"
+        method: "ZSTD"
+      - method: "unknown"
+      - minPartSizeRatio: "0.5"
+        method: "lz4hc"
+      - minPartSize: "1000"
+        method: "lz4"
+    clusters:
+      - name: "cluster"
+`,
+			check: func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+				// Cases are rendered in order they are specified, since ClickHouse evaluates them sequentially
+				config := creator.chConfigGenerator.GetCompression()
+				require.Regexp(t, `<compression>\s*`+
+					`<case>\s*<min_part_size>10000000000</min_part_size>\s*<min_part_size_ratio>0.01</min_part_size_ratio>\s*<method>zstd</method>\s*</case>\s*`+
+					`<case>\s*<min_part_size_ratio>0.5</min_part_size_ratio>\s*<method>lz4hc</method>\s*</case>\s*`+
+					`<case>\s*<min_part_size>1000</min_part_size>\s*<method>lz4</method>\s*</case>\s*`+
+					`</compression>`, config)
+				require.NotContains(t, config, "unknown")
+
+				// Nothing is generated unless cases are specified
+				chi.Spec.Configuration.Compression = nil
+				require.Empty(t, NewClickHouseConfigGenerator(chi, creator.chop.Config()).GetCompression())
+			},
+		},
+	})
+}
+
+func TestNormalizeSettingsConcurrencyRatio(t *testing.T) {
+	normalizer := NewNormalizer(newTestCHOp())
+	for ratio, valid := range map[string]bool{"2": true, "0.5": true, "0": false, "-1": false, "many": false} {
+		settings := chiv1.NewSettings()
+		settings[settingBackgroundMergesMutationsConcurrencyRatio] = chiv1.NewScalarSetting(ratio)
+		normalizer.normalizeSettingsNumericValues(&settings)
+		_, ok := settings[settingBackgroundMergesMutationsConcurrencyRatio]
+		require.Equal(t, valid, ok, "unexpected validation of ratio %s", ratio)
+	}
+}
+
+func TestGetUsers(t *testing.T) {
+	monitoring := func(withSecret bool) func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+		return func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+			monitoring := creator.chConfigGenerator.GetMonitoring()
+			require.Contains(t, monitoring, "<ip>127.0.0.1</ip>")
+			if withSecret {
+				require.Contains(t, monitoring, `<password from_env="CLICKHOUSE_MONITORING_PASSWORD"/>`)
+				require.Contains(t, monitoring, "<host_regexp>", "monitoring user with password is not accessible from pods")
+			} else {
+				// User without password is not exposed to the network
+				require.NotContains(t, monitoring, "<host_regexp>", "monitoring user without password is accessible from pods")
+			}
+		}
+	}
+
+	runCHITests(t, []chiTest{
+		{
+			name: "SHA256 password",
+			data: `
+apiVersion: "clickhouse.altinity.com/v1"
+kind: "ClickHouseInstallation"
+metadata:
+  name: "users"
+spec:
+  configuration:
+    users:
+      app/password_sha256_hex: "65e84be33532fb784c48129675f9eff3a682b27168c0ea744b2cf58ee02337c5"
+      app/profile: "app"
+      app/quota: "app"
+      app/networks/ip:
+        - "10.0.0.0/8"
+        - "192.168.1.1"
+    profiles:
+      app/max_memory_usage: 10000000000
+    quotas:
+      app/interval/duration: 3600
+    clusters:
+      - name: "cluster"
+`,
+			check: func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+				require.Nil(t, ValidateCHI(chi), "failed to validate chi")
+
+				config := creator.chConfigGenerator.GetUsers()
+				// Hashed password is rendered verbatim, no plaintext password is generated
+				require.Contains(t, config, "<password_sha256_hex>65e84be33532fb784c48129675f9eff3a682b27168c0ea744b2cf58ee02337c5</password_sha256_hex>", "no hashed password")
+				require.Regexp(t, `<app>(?s:.)*<profile>app</profile>(?s:.)*</app>`, config, "no profile")
+				require.Regexp(t, `<app>(?s:.)*<quota>app</quota>(?s:.)*</app>`, config, "no quota")
+				require.Contains(t, config, "<ip>10.0.0.0/8</ip>", "no network")
+				require.Contains(t, config, "<ip>192.168.1.1</ip>", "no network")
+				require.NotRegexp(t, `<app>(?s:.)*<password>`, config, "unexpected plaintext password")
+			},
+		},
+		{
+			name: "quota intervals",
+			data: `
+apiVersion: "clickhouse.altinity.com/v1"
+kind: "ClickHouseInstallation"
+metadata:
+  name: "quotas"
+spec:
+  configuration:
+    users:
+      app/quota: "app"
+    quotas:
+      app/keyed_by_ip: 1
+      app/interval/duration: 60
+    quotaIntervals:
+      - name: "app"
+        intervals:
+          - duration: "3600"
+            queries: "1000"
+            errors: "0"
+          - duration: "86400"
+            readRows: "1000000000"
+            executionTime: "invalid"
+          - duration: "3600"
+            queries: "1"
+    clusters:
+      - name: "cluster"
+`,
+			check: func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+				require.Nil(t, ValidateCHI(chi), "user referring to quota with intervals is not valid")
+
+				config := creator.chConfigGenerator.GetQuotaIntervals()
+				// Duplicate interval is skipped
+				intervals := regexp.MustCompile(`(?s)<interval>.*?</interval>`).FindAllString(config, -1)
+				require.Len(t, intervals, 2, "unexpected intervals")
+				require.Contains(t, intervals[0], "<duration>3600</duration>")
+				require.Contains(t, intervals[0], "<queries>1000</queries>")
+				// Limit of 0 is unlimited, so it is rendered, while omitted and incorrect limits are not
+				require.Contains(t, intervals[0], "<errors>0</errors>")
+				require.NotContains(t, intervals[0], "<read_rows>")
+				require.Contains(t, intervals[1], "<duration>86400</duration>")
+				require.Contains(t, intervals[1], "<read_rows>1000000000</read_rows>")
+				require.NotContains(t, intervals[1], "<execution_time>")
+				require.Regexp(t, `<quotas>\s*<app>(?s:.)*</app>\s*</quotas>`, config, "intervals are not in quota")
+
+				// Intervals of quotas settings do not clash with generated ones, while the rest of quota is kept
+				quotas := creator.chConfigGenerator.GetQuotas()
+				require.NotContains(t, quotas, "<interval>", "intervals of quotas settings are not skipped")
+				require.Contains(t, quotas, "<keyed_by_ip>1</keyed_by_ip>")
+			},
+		},
+		{
+			name: "user password secrets",
+			modify: func(chi *chiv1.ClickHouseInstallation) {
+				chi.Spec.Configuration.Users = chiv1.Settings{
+					"analyst/password": chiv1.NewScalarSetting("plaintext-secret"),
+					"analyst/profile":  chiv1.NewScalarSetting("default"),
+				}
+				chi.Spec.Configuration.UserPasswordSecrets = map[string]corev1.SecretKeySelector{
+					"analyst":    {LocalObjectReference: corev1.LocalObjectReference{Name: "clickhouse-users"}, Key: "analyst"},
+					"report-bot": {LocalObjectReference: corev1.LocalObjectReference{Name: "clickhouse-users"}, Key: "report-bot"},
+					"incomplete": {LocalObjectReference: corev1.LocalObjectReference{Name: "clickhouse-users"}},
+				}
+			},
+			check: func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+				require.Len(t, chi.Spec.Configuration.UserPasswordSecrets, 2, "incomplete secret is not skipped")
+
+				require.Nil(t, creator.chConfigSectionsGenerator.CreateConfigsUsers(), "failed to create users configs")
+				for filename, config := range creator.chConfigSectionsGenerator.commonUsersConfigSections {
+					// Neither plaintext nor hashed passwords of users with Secrets are placed into ConfigMap
+					require.NotContains(t, config, "plaintext-secret", "plaintext password in %s", filename)
+					for _, username := range []string{"analyst", "report-bot"} {
+						for _, block := range regexp.MustCompile(`(?s)<`+username+`>.*?</`+username+`>`).FindAllString(config, -1) {
+							if strings.Contains(block, "from_env") {
+								continue
+							}
+							require.NotContains(t, block, "password", "password of %s in %s", username, filename)
+						}
+					}
+				}
+				users := creator.chConfigGenerator.GetUsers()
+				require.Regexp(t, `<report-bot>(?s:.)*<profile>default</profile>(?s:.)*</report-bot>`, users, "user with password from secret is not generated")
+				passwords := creator.chConfigGenerator.GetUserPasswords()
+				require.Contains(t, passwords, `<password from_env="CLICKHOUSE_USER_PASSWORD_ANALYST"/>`, "no analyst password reference")
+				require.Contains(t, passwords, `<password from_env="CLICKHOUSE_USER_PASSWORD_REPORT_BOT"/>`, "no report-bot password reference")
+
+				// Secrets are provided to ClickHouse container via env vars
+				chi.WalkHosts(func(host *chiv1.ChiHost) error {
+					env := map[string]string{}
+					for _, envVar := range getTestContainer(t, creator, host).Env {
+						if (envVar.ValueFrom != nil) && (envVar.ValueFrom.SecretKeyRef != nil) {
+							env[envVar.Name] = envVar.ValueFrom.SecretKeyRef.Name + "/" + envVar.ValueFrom.SecretKeyRef.Key
+						}
+					}
+					require.Equal(t, "clickhouse-users/analyst", env["CLICKHOUSE_USER_PASSWORD_ANALYST"])
+					require.Equal(t, "clickhouse-users/report-bot", env["CLICKHOUSE_USER_PASSWORD_REPORT_BOT"])
+					return nil
+				})
+			},
+		},
+		{
+			name: "monitoring user",
+			modify: func(chi *chiv1.ClickHouseInstallation) {
+				chi.Spec.Configuration.Monitoring.Enabled = "yes"
+			},
+			check: monitoring(false),
+		},
+		{
+			name: "monitoring user with password",
+			modify: func(chi *chiv1.ClickHouseInstallation) {
+				chi.Spec.Configuration.Monitoring.Enabled = "yes"
+				chi.Spec.Configuration.Monitoring.PasswordSecret = &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "clickhouse-monitoring"},
+					Key:                  "password",
+				}
+			},
+			check: monitoring(true),
+		},
+	})
+}
+
+var ProfilesInheritanceData = `
+apiVersion: "clickhouse.altinity.com/v1"
+kind: "ClickHouseInstallation"
+metadata:
+  name: "profiles"
+spec:
+  configuration:
+    profiles:
+      readonly/parent: "default"
+      readonly/readonly: 1
+      analyst/inherit: "readonly"
+      analyst/max_memory_usage: 10000000000
+    clusters:
+      - name: "cluster"
+`
+
+func TestGetProfilesInheritance(t *testing.T) {
+	creator, chi := newTestCreator(t, ProfilesInheritanceData)
+	require.Nil(t, ValidateCHI(chi), "two-level inheritance is reported as invalid")
+
+	// default -> readonly -> analyst
+	config := creator.chConfigGenerator.GetProfiles()
+	require.Regexp(t, `<readonly>(?s:.)*<profile>default</profile>(?s:.)*</readonly>`, config, "no parent of readonly")
+	require.Regexp(t, `<analyst>(?s:.)*<profile>readonly</profile>(?s:.)*</analyst>`, config, "no parent of analyst")
+	require.NotContains(t, config, "<parent>")
+	require.NotContains(t, config, "<inherit>")
+
+	// Cycle is reported instead of being rendered
+	_, chi = newTestCreator(t, ProfilesInheritanceData, func(chi *chiv1.ClickHouseInstallation) {
+		chi.Spec.Configuration.Profiles["readonly/parent"] = chiv1.NewScalarSetting("analyst")
+	})
+	err := ValidateCHI(chi)
+	require.NotNil(t, err, "inheritance cycle passed validation")
+	require.Contains(t, err.Error(), "profile inheritance cycle analyst -> readonly -> analyst")
+
+	// Stock profile, not specified in the CHI, is a valid parent
+	_, chi = newTestCreator(t, ProfilesInheritanceData, func(chi *chiv1.ClickHouseInstallation) {
+		delete(chi.Spec.Configuration.Profiles, "readonly/parent")
+		delete(chi.Spec.Configuration.Profiles, "readonly/readonly")
+	})
+	require.Nil(t, ValidateCHI(chi), "inheritance of stock profile is reported as invalid")
+
+	// Profile, which is neither stock nor specified, is reported
+	chi.Spec.Configuration.Profiles["analyst/profile"] = chiv1.NewScalarSetting("missing")
+	err = ValidateCHI(chi)
+	require.NotNil(t, err, "unknown parent passed validation")
+	require.Contains(t, err.Error(), "profile analyst inherits unknown profile missing")
+}
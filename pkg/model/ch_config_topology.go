@@ -0,0 +1,111 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"encoding/json"
+
+	chiv1 "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	"github.com/altinity/clickhouse-operator/pkg/util"
+)
+
+// Topology describes clusters, shards and replicas of a CHI. Marshalled into JSON,
+// it is exposed via topology ConfigMap for discovery by external tools
+type Topology struct {
+	Installation string            `json:"installation"`
+	Namespace    string            `json:"namespace"`
+	Clusters     []TopologyCluster `json:"clusters"`
+}
+
+// TopologyCluster describes a cluster as it is specified in remote_servers
+type TopologyCluster struct {
+	Name   string          `json:"name"`
+	Shards []TopologyShard `json:"shards"`
+}
+
+// TopologyShard describes a shard of a cluster
+type TopologyShard struct {
+	Name                string            `json:"name"`
+	Index               int               `json:"index"`
+	Macro               string            `json:"macro"`
+	Weight              int               `json:"weight,omitempty"`
+	InternalReplication bool              `json:"internalReplication"`
+	Replicas            []TopologyReplica `json:"replicas"`
+}
+
+// TopologyReplica describes a host of a shard
+type TopologyReplica struct {
+	Name                string `json:"name"`
+	Index               int    `json:"index"`
+	Ordinal             int    `json:"ordinal"`
+	Pod                 string `json:"pod"`
+	Host                string `json:"host"`
+	FQDN                string `json:"fqdn"`
+	TCPPort             int32  `json:"tcpPort"`
+	HTTPPort            int32  `json:"httpPort"`
+	InterserverHTTPPort int32  `json:"interserverHTTPPort"`
+}
+
+// GetTopology creates "topology.json" content. Topology is built from the same data as remote_servers and macros are,
+// so hosts, shard indexes and replica indexes match ones seen by ClickHouse.
+// Clusters, shards and replicas are listed in the order they are walked, thus the content is stable
+func (c *ClickHouseConfigGenerator) GetTopology() (string, error) {
+	topology := Topology{
+		Installation: c.chi.Name,
+		Namespace:    c.chi.Namespace,
+		Clusters:     make([]TopologyCluster, 0),
+	}
+
+	c.chi.WalkClusters(func(cluster *chiv1.ChiCluster) error {
+		topologyCluster := TopologyCluster{
+			Name:   cluster.Name,
+			Shards: make([]TopologyShard, 0),
+		}
+		cluster.WalkShards(func(index int, shard *chiv1.ChiShard) error {
+			topologyShard := TopologyShard{
+				Name:                shard.Name,
+				Index:               index + c.chi.Spec.Defaults.ShardBaseIndex,
+				Weight:              shard.Weight,
+				InternalReplication: util.IsStringBoolTrue(shard.InternalReplication),
+				Replicas:            make([]TopologyReplica, 0),
+			}
+			shard.WalkHosts(func(host *chiv1.ChiHost) error {
+				topologyShard.Macro = c.getMacrosShard(host)
+				topologyShard.Replicas = append(topologyShard.Replicas, TopologyReplica{
+					Name:                host.Address.ReplicaName,
+					Index:               host.Address.ReplicaIndex + c.chi.Spec.Defaults.ReplicaBaseIndex,
+					Ordinal:             CreateHostOrdinal(host),
+					Pod:                 CreatePodName(host),
+					Host:                c.getRemoteServersReplicaHostname(host),
+					FQDN:                CreatePodFQDN(host),
+					TCPPort:             host.TCPPort,
+					HTTPPort:            host.HTTPPort,
+					InterserverHTTPPort: host.InterserverHTTPPort,
+				})
+				return nil
+			})
+			topologyCluster.Shards = append(topologyCluster.Shards, topologyShard)
+			return nil
+		})
+		topology.Clusters = append(topology.Clusters, topologyCluster)
+		return nil
+	})
+
+	data, err := json.MarshalIndent(topology, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
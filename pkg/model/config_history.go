@@ -0,0 +1,97 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"strings"
+	"time"
+
+	apps "k8s.io/api/apps/v1"
+)
+
+const (
+	// Length of config checksum kept in StatefulSet annotations
+	configVersionLength = 12
+	// Separator of entries of config history annotation
+	configHistorySeparator = ","
+)
+
+// SetConfigHistory fills config-updated and config-history annotations of new StatefulSet.
+// In case config version is changed, time of the change is recorded and previous version, along with the time it was applied at,
+// is prepended to the history, which keeps `length` entries at most. Otherwise annotations of current StatefulSet are carried over.
+// cur is nil in case StatefulSet is created
+func SetConfigHistory(cur, new *apps.StatefulSet, length int, now time.Time) {
+	if new.Annotations == nil {
+		new.Annotations = make(map[string]string)
+	}
+
+	if cur == nil {
+		// New StatefulSet. It may be re-created after failed update, so history filled already is kept
+		if _, ok := new.Annotations[AnnotationConfigUpdated]; !ok {
+			new.Annotations[AnnotationConfigUpdated] = now.UTC().Format(time.RFC3339)
+		}
+		return
+	}
+
+	curVersion := cur.Annotations[AnnotationConfigVersion]
+	curUpdated := cur.Annotations[AnnotationConfigUpdated]
+	curHistory := cur.Annotations[AnnotationConfigHistory]
+
+	if curVersion == new.Annotations[AnnotationConfigVersion] {
+		// Config is not changed, carry over
+		if curUpdated != "" {
+			new.Annotations[AnnotationConfigUpdated] = curUpdated
+		}
+		if curHistory != "" {
+			new.Annotations[AnnotationConfigHistory] = curHistory
+		}
+		return
+	}
+
+	var history []string
+	if curVersion != "" {
+		history = append(history, curVersion+"@"+curUpdated)
+	}
+	if curHistory != "" {
+		history = append(history, strings.Split(curHistory, configHistorySeparator)...)
+	}
+	if len(history) > length {
+		history = history[:length]
+	}
+
+	new.Annotations[AnnotationConfigUpdated] = now.UTC().Format(time.RFC3339)
+	if len(history) > 0 {
+		new.Annotations[AnnotationConfigHistory] = strings.Join(history, configHistorySeparator)
+	} else {
+		delete(new.Annotations, AnnotationConfigHistory)
+	}
+}
+
+// PreserveSettingsConfigVersion carries settings-version label of current StatefulSet and its Pod template over to new StatefulSet
+// in case restart-required settings are not changed, so change of hot-reloadable settings is applied by config reload without rolling pods.
+// StatefulSet created by previous version of the operator has no restart-settings-version annotation and its label is kept as generated,
+// which is the same for unchanged settings
+func PreserveSettingsConfigVersion(cur, new *apps.StatefulSet) {
+	restartVersion, ok := cur.Annotations[AnnotationRestartSettingsVersion]
+	if !ok || (restartVersion != new.Annotations[AnnotationRestartSettingsVersion]) {
+		return
+	}
+	if version, ok := cur.Labels[LabelSettingsConfigVersion]; ok && (new.Labels != nil) {
+		new.Labels[LabelSettingsConfigVersion] = version
+	}
+	if version, ok := cur.Spec.Template.Labels[LabelSettingsConfigVersion]; ok && (new.Spec.Template.Labels != nil) {
+		new.Spec.Template.Labels[LabelSettingsConfigVersion] = version
+	}
+}
@@ -0,0 +1,75 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	apps "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newConfigHistoryStatefulSet(annotations map[string]string) *apps.StatefulSet {
+	return &apps.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: annotations,
+		},
+	}
+}
+
+func TestSetConfigHistory(t *testing.T) {
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	// Created StatefulSet has no history
+	created := newConfigHistoryStatefulSet(map[string]string{AnnotationConfigVersion: "v1"})
+	SetConfigHistory(nil, created, 2, now)
+	require.Equal(t, "2020-06-01T12:00:00Z", created.Annotations[AnnotationConfigUpdated])
+	require.NotContains(t, created.Annotations, AnnotationConfigHistory)
+
+	// Unchanged config carries annotations over
+	unchanged := newConfigHistoryStatefulSet(map[string]string{AnnotationConfigVersion: "v1"})
+	SetConfigHistory(created, unchanged, 2, now.Add(time.Hour))
+	require.Equal(t, "2020-06-01T12:00:00Z", unchanged.Annotations[AnnotationConfigUpdated])
+	require.NotContains(t, unchanged.Annotations, AnnotationConfigHistory)
+
+	// Changed config records previous version
+	v2 := newConfigHistoryStatefulSet(map[string]string{AnnotationConfigVersion: "v2"})
+	SetConfigHistory(unchanged, v2, 2, now.Add(time.Hour))
+	require.Equal(t, "2020-06-01T13:00:00Z", v2.Annotations[AnnotationConfigUpdated])
+	require.Equal(t, "v1@2020-06-01T12:00:00Z", v2.Annotations[AnnotationConfigHistory])
+
+	// History is bounded
+	v3 := newConfigHistoryStatefulSet(map[string]string{AnnotationConfigVersion: "v3"})
+	SetConfigHistory(v2, v3, 2, now.Add(2*time.Hour))
+	v4 := newConfigHistoryStatefulSet(map[string]string{AnnotationConfigVersion: "v4"})
+	SetConfigHistory(v3, v4, 2, now.Add(3*time.Hour))
+	require.Equal(t, "v3@2020-06-01T14:00:00Z,v2@2020-06-01T13:00:00Z", v4.Annotations[AnnotationConfigHistory])
+}
+
+func TestPreserveSettingsConfigVersion(t *testing.T) {
+	newStatefulSet := func(restartVersion, settingsVersion string) *apps.StatefulSet {
+		statefulSet := newConfigHistoryStatefulSet(map[string]string{})
+		if restartVersion != "" {
+			statefulSet.Annotations[AnnotationRestartSettingsVersion] = restartVersion
+		}
+		statefulSet.Labels = map[string]string{LabelSettingsConfigVersion: settingsVersion}
+		statefulSet.Spec.Template.Labels = map[string]string{LabelSettingsConfigVersion: settingsVersion}
+		return statefulSet
+	}
+
+	// Only hot-reloadable settings changed - pods are not rolled
+	hot := newStatefulSet("r1", "s2")
+	PreserveSettingsConfigVersion(newStatefulSet("r1", "s1"), hot)
+	require.Equal(t, "s1", hot.Labels[LabelSettingsConfigVersion])
+	require.Equal(t, "s1", hot.Spec.Template.Labels[LabelSettingsConfigVersion])
+
+	// Restart-required settings changed - pods are rolled
+	restart := newStatefulSet("r2", "s2")
+	PreserveSettingsConfigVersion(newStatefulSet("r1", "s1"), restart)
+	require.Equal(t, "s2", restart.Spec.Template.Labels[LabelSettingsConfigVersion])
+
+	// StatefulSet of previous operator version keeps label as generated
+	upgraded := newStatefulSet("r1", "s1")
+	PreserveSettingsConfigVersion(newStatefulSet("", "s1"), upgraded)
+	require.Equal(t, "s1", upgraded.Spec.Template.Labels[LabelSettingsConfigVersion])
+}
@@ -14,6 +14,10 @@
 
 package model
 
+import (
+	"regexp"
+)
+
 const (
 	// Default value for ClusterIP service
 	templateDefaultsServiceClusterIP = "None"
@@ -23,3 +27,556 @@ const (
 	// .spec.useTemplate.useType
 	useTypeMerge = "merge"
 )
+
+const (
+	// Default topology key used in pod (anti-)affinity terms - spread pods over nodes
+	topologyKeyHostname = "kubernetes.io/hostname"
+)
+
+// settingsBackgroundPoolSizes lists background merge/fetch pools settings, which require positive integer values
+var settingsBackgroundPoolSizes = []string{
+	"background_pool_size",
+	"background_fetches_pool_size",
+	"background_move_pool_size",
+	"background_schedule_pool_size",
+	"background_common_pool_size",
+}
+
+// settingBackgroundMergesMutationsConcurrencyRatio is a multiplier of background_pool_size, which requires positive number
+const settingBackgroundMergesMutationsConcurrencyRatio = "background_merges_mutations_concurrency_ratio"
+
+// settingsMergeTreeLimits lists <merge_tree> settings limiting merges and mutations, which require non-negative integer values
+var settingsMergeTreeLimits = []string{
+	"merge_tree/max_bytes_to_merge_at_max_space_in_pool",
+	"merge_tree/max_bytes_to_merge_at_min_space_in_pool",
+	"merge_tree/max_replicated_merges_in_queue",
+	"merge_tree/max_replicated_mutations_in_queue",
+	"merge_tree/number_of_free_entries_in_pool_to_lower_max_size_of_merge",
+	"merge_tree/number_of_free_entries_in_pool_to_execute_mutation",
+	"merge_tree/max_number_of_merges_with_ttl_in_pool",
+}
+
+// settingsMergeTreePartFormat lists <merge_tree> thresholds between compact and wide parts, which require non-negative integer values.
+// Part is stored in wide format in case either threshold is reached, 0 means all parts are wide
+var settingsMergeTreePartFormat = []string{
+	"merge_tree/min_bytes_for_wide_part",
+	"merge_tree/min_rows_for_wide_part",
+}
+
+// settingsMergeTreeMoves lists <merge_tree> settings controlling TTL merges and parts moves between disks,
+// which require non-negative integer values
+var settingsMergeTreeMoves = []string{
+	"merge_tree/merge_with_ttl_timeout",
+	"merge_tree/merge_with_recompression_ttl_timeout",
+	"merge_tree/min_bytes_to_rebalance_partition_over_jbod",
+}
+
+const (
+	// Insert backpressure <merge_tree> settings. Inserts are delayed once partition has more than
+	// parts_to_delay_insert active parts and are rejected once it has more than parts_to_throw_insert ones
+	settingMergeTreePartsToDelayInsert         = "merge_tree/parts_to_delay_insert"
+	settingMergeTreePartsToThrowInsert         = "merge_tree/parts_to_throw_insert"
+	settingMergeTreeInactivePartsToDelayInsert = "merge_tree/inactive_parts_to_delay_insert"
+	settingMergeTreeInactivePartsToThrowInsert = "merge_tree/inactive_parts_to_throw_insert"
+)
+
+// settingsMergeTreeInsertBackpressure lists <merge_tree> insert backpressure settings, which require positive integer values
+var settingsMergeTreeInsertBackpressure = []string{
+	settingMergeTreePartsToDelayInsert,
+	settingMergeTreePartsToThrowInsert,
+	"merge_tree/max_delay_to_insert",
+}
+
+// settingsMergeTreeInactiveParts lists <merge_tree> inactive parts thresholds, which require non-negative integer values,
+// 0 means threshold is disabled
+var settingsMergeTreeInactiveParts = []string{
+	settingMergeTreeInactivePartsToDelayInsert,
+	settingMergeTreeInactivePartsToThrowInsert,
+}
+
+// settingsMergeTreeMovesBools lists boolean <merge_tree> settings related to parts lifecycle
+var settingsMergeTreeMovesBools = []string{
+	"merge_tree/assign_part_uuids",
+	"merge_tree/ttl_only_drop_parts",
+}
+
+const (
+	// readinessProbeModePing checks ClickHouse is alive via /ping
+	readinessProbeModePing = "ping"
+	// readinessProbeModeReplicasStatus checks replication lag via /replicas_status
+	readinessProbeModeReplicasStatus = "replicas_status"
+)
+
+// stockProfiles lists profiles shipped with ClickHouse, which users may refer to without specifying them in profiles
+var stockProfiles = []string{
+	"default",
+	"readonly",
+}
+
+// readinessProbeModes lists acceptable values of .spec.defaults.readinessProbe.mode
+var readinessProbeModes = []string{
+	readinessProbeModePing,
+	readinessProbeModeReplicasStatus,
+}
+
+// Default timings of ClickHouse container probes, in seconds
+const (
+	readinessProbeDefaultInitialDelaySeconds = "10"
+	readinessProbeDefaultPeriodSeconds       = "10"
+	livenessProbeDefaultInitialDelaySeconds  = "60"
+	livenessProbeDefaultPeriodSeconds        = "3"
+	// livenessProbeFailureThreshold specifies how many failed probes in a row restart ClickHouse container
+	livenessProbeFailureThreshold = 10
+)
+
+const (
+	// logFormatPlain is ClickHouse default text log format
+	logFormatPlain = "plain"
+	// logFormatJSON makes ClickHouse emit each log record as JSON object
+	logFormatJSON = "json"
+)
+
+// logFormats lists acceptable values of .spec.defaults.logFormat
+var logFormats = []string{
+	logFormatPlain,
+	logFormatJSON,
+}
+
+const (
+	serviceMeshIstio   = "istio"
+	serviceMeshLinkerd = "linkerd"
+)
+
+// serviceMeshes lists acceptable values of .spec.defaults.serviceMesh.type
+var serviceMeshes = []string{
+	serviceMeshIstio,
+	serviceMeshLinkerd,
+}
+
+// terminationGracePeriodSecondsDefault gives ClickHouse time to flush buffers and finish in-flight merges on shutdown,
+// while Kubernetes default of 30 seconds may kill ClickHouse in the middle of it
+const terminationGracePeriodSecondsDefault = "300"
+
+const (
+	// Default owner of data volume, which is user and group of ClickHouse image
+	dataVolumeChownDefaultUID = "101"
+	dataVolumeChownDefaultGID = "101"
+)
+
+const (
+	// Default distributed DDL queue cleanup settings, applied in case cleanup is enabled
+	distributedDDLDefaultMaxTasksInQueue    = "1000"
+	distributedDDLDefaultTaskMaxLifetime    = "86400"
+	distributedDDLDefaultCleanupDelayPeriod = "60"
+)
+
+const (
+	// systemLogDefaultTTLDays specifies default retention of system log records, in days
+	systemLogDefaultTTLDays = "30"
+	// systemLogDefaultFlushIntervalMilliseconds specifies default flush interval of system logs, as in ClickHouse
+	systemLogDefaultFlushIntervalMilliseconds = "7500"
+	// crashLogDefaultFlushIntervalMilliseconds specifies default flush interval of crash log, as in ClickHouse.
+	// Crash log is flushed more often, since the server is about to terminate
+	crashLogDefaultFlushIntervalMilliseconds = "1000"
+)
+
+// settingCoreDumpSizeLimit specifies max size of core dump file
+const settingCoreDumpSizeLimit = "core_dump/size_limit"
+
+// Profile settings of .spec.configuration.profileTiers limits
+const (
+	settingMaxMemoryUsage   = "max_memory_usage"
+	settingMaxExecutionTime = "max_execution_time"
+	settingMaxRowsToRead    = "max_rows_to_read"
+)
+
+// keeperServerSection is the config section of ClickHouse Keeper node
+const keeperServerSection = "keeper_server"
+
+const (
+	// Default ClickHouse Keeper coordination settings, as recommended by ClickHouse
+	keeperDefaultOperationTimeoutMs = "10000"
+	keeperDefaultSessionTimeoutMs   = "100000"
+	keeperDefaultRaftLogsLevel      = "information"
+	keeperDefaultSnapshotDistance   = "100000"
+)
+
+// keeperRaftLogsLevels lists log levels accepted by ClickHouse Keeper as raft_logs_level
+var keeperRaftLogsLevels = []string{
+	"trace",
+	"debug",
+	"information",
+	"warning",
+	"error",
+	"fatal",
+}
+
+// settingsHotReloadable lists settings (and sections) which are reloaded by ClickHouse on the fly along with config,
+// thus do not require ClickHouse restart to be applied. All other settings are considered to require restart,
+// so setting not known to be hot-reloadable is never left unapplied
+var settingsHotReloadable = []string{
+	"display_name",
+	"macros",
+	"remote_servers",
+	"zookeeper",
+	"auxiliary_zookeepers",
+	"dictionaries_config",
+	"max_server_memory_usage",
+	"max_server_memory_usage_to_ram_ratio",
+	"max_concurrent_queries",
+	"max_concurrent_insert_queries",
+	"max_concurrent_select_queries",
+	"max_table_size_to_drop",
+	"max_partition_size_to_drop",
+}
+
+// reservedMacros lists macros generated by the operator for each host, which can not be specified in .spec.configuration.macros
+var reservedMacros = []string{
+	"installation",
+	allShardsOneReplicaClusterName + "-shard",
+	"cluster",
+	"shard",
+	"replica_index",
+	"replica",
+	"ordinal",
+}
+
+var (
+	// sqlIdentifierRegexp matches unquoted SQL identifier, such as role name
+	sqlIdentifierRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+	// grantPrivilegesRegexp matches comma-separated list of privileges, such as 'SELECT, SHOW TABLES'
+	grantPrivilegesRegexp = regexp.MustCompile(`^[A-Z]+( [A-Z]+)*(, ?[A-Z]+( [A-Z]+)*)*$`)
+	// grantTargetRegexp matches grant target, such as 'db.table', 'db.*' or '*.*'
+	grantTargetRegexp = regexp.MustCompile(`^(\*|[a-zA-Z_][a-zA-Z0-9_]*)\.(\*|[a-zA-Z_][a-zA-Z0-9_]*)$`)
+	// compressionCodecRegexp matches comma-separated chain of codecs, such as 'Delta, ZSTD(3)'
+	compressionCodecRegexp = regexp.MustCompile(`^[a-zA-Z0-9_]+(\(\s*[0-9]+(\s*,\s*[0-9]+)*\s*\))?(\s*,\s*[a-zA-Z0-9_]+(\(\s*[0-9]+(\s*,\s*[0-9]+)*\s*\))?)*$`)
+	// compressionCodecNameRegexp matches name of each codec in the chain
+	compressionCodecNameRegexp = regexp.MustCompile(`([a-zA-Z0-9_]+)(\([^)]*\))?`)
+)
+
+// compressionCodecs lists codecs known to ClickHouse
+var compressionCodecs = []string{
+	"NONE",
+	"LZ4",
+	"LZ4HC",
+	"ZSTD",
+	"ZSTD_QAT",
+	"DEFLATE_QPL",
+	"Delta",
+	"DoubleDelta",
+	"Gorilla",
+	"FPC",
+	"T64",
+	"GCD",
+	"AES_128_GCM_SIV",
+	"AES_256_GCM_SIV",
+}
+
+// compressionMethods lists methods acceptable in <compression> cases
+var compressionMethods = []string{
+	"lz4",
+	"lz4hc",
+	"zstd",
+	"deflate_qpl",
+}
+
+// settingDisplayName specifies name of the server shown in clickhouse-client prompt
+const settingDisplayName = "display_name"
+
+const (
+	// settingDefaultReplicaPath specifies ZooKeeper path of ReplicatedMergeTree tables created without explicit path
+	settingDefaultReplicaPath = "default_replica_path"
+	// settingDefaultReplicaName specifies replica name of ReplicatedMergeTree tables created without explicit replica name
+	settingDefaultReplicaName = "default_replica_name"
+)
+
+// shardAntiAffinityWeight specifies weight of preferred shard anti-affinity term
+const shardAntiAffinityWeight = 100
+
+// shardWeightDefault specifies weight of a shard in case none specified. Shards with default weight have no <weight> rendered
+const shardWeightDefault = 1
+
+// settingMaxOpenFiles specifies open files limit ClickHouse raises its own limit to on startup
+const settingMaxOpenFiles = "max_open_files"
+
+// settingCustomSettingsPrefixes specifies comma-separated prefixes of custom settings accepted by the server
+const settingCustomSettingsPrefixes = "custom_settings_prefixes"
+
+// settingAsyncMetricsUpdatePeriod specifies how often system.asynchronous_metrics are updated, in seconds
+const settingAsyncMetricsUpdatePeriod = "asynchronous_metrics_update_period_s"
+
+// settingTemporaryDataInCache specifies cache disk temporary data is spilled into
+const settingTemporaryDataInCache = "temporary_data_in_cache"
+
+// settingMaxTemporaryDataOnDiskSize specifies server-wide limit of temporary data on disk, in bytes
+const settingMaxTemporaryDataOnDiskSize = "max_temporary_data_on_disk_size"
+
+// settingCompressionCodec specifies MergeTree setting, which provides default codec for columns without explicit codec
+const settingCompressionCodec = "merge_tree/default_compression_codec"
+
+// localhostNetworks lists networks/ip values which do not expose user to the network
+var localhostNetworks = []string{
+	"127.0.0.1",
+	"127.0.0.1/32",
+	"::1",
+	"::1/128",
+}
+
+// localhostHosts lists networks/host and networks/host_regexp values which do not expose user to the network
+var localhostHosts = []string{
+	"localhost",
+	"^localhost$",
+}
+
+// settingsDropSafeguards lists settings protecting huge tables and partitions from being dropped, which require non-negative integer values
+var settingsDropSafeguards = []string{
+	"max_table_size_to_drop",
+	"max_partition_size_to_drop",
+}
+
+// Settings of .spec.defaults.dnsCache
+const (
+	settingDNSCacheUpdatePeriod      = "dns_cache_update_period"
+	settingDNSMaxConsecutiveFailures = "dns_max_consecutive_failures"
+	settingDisableInternalDNSCache   = "disable_internal_dns_cache"
+	// dnsCacheDefaultUpdatePeriod specifies DNS cache update period, seconds, short enough to catch up with pod IPs change
+	dnsCacheDefaultUpdatePeriod = "5"
+)
+
+// settingsDNSCache lists DNS cache settings, which require positive integer values
+var settingsDNSCache = []string{
+	settingDNSCacheUpdatePeriod,
+	settingDNSMaxConsecutiveFailures,
+}
+
+// settingsCaches lists server caches sizes, which require non-negative integer values
+var settingsCaches = []string{
+	"mark_cache_size",
+	"uncompressed_cache_size",
+	"mmap_cache_size",
+}
+
+// settingsAsyncInsertBools lists async insert settings, which require boolean 0/1 values
+var settingsAsyncInsertBools = []string{
+	"async_insert",
+	"wait_for_async_insert",
+}
+
+// settingsAsyncInsertLimits lists async insert buffer limits, which require positive integer values
+var settingsAsyncInsertLimits = []string{
+	"async_insert_max_data_size",
+	"async_insert_busy_timeout_ms",
+}
+
+// settingsInsertSafeguardBools lists insert safeguard settings, which require boolean 0/1 values
+var settingsInsertSafeguardBools = []string{
+	"throw_on_max_partitions_per_insert_block",
+	"insert_deduplicate",
+}
+
+// settingsInsertSafeguardLimits lists insert safeguard limits, which require non-negative integer values, 0 means no limit
+var settingsInsertSafeguardLimits = []string{
+	"max_partitions_per_insert_block",
+	"min_insert_block_size_rows",
+	"min_insert_block_size_bytes",
+	"max_insert_threads",
+}
+
+// settingsInsertBlockSizes lists insert block sizes, which require positive integer values
+var settingsInsertBlockSizes = []string{
+	"max_insert_block_size",
+}
+
+// settingsKeeperIntrospectionBools lists settings controlling reads of system.zookeeper, which require boolean 0/1 values
+var settingsKeeperIntrospectionBools = []string{
+	"allow_unrestricted_reads_from_keeper",
+}
+
+// settingsQueryProfilerPeriods lists query profiler sampling periods, in nanoseconds,
+// which require non-negative integer values, 0 means profiler is turned off
+var settingsQueryProfilerPeriods = []string{
+	"query_profiler_real_time_period_ns",
+	"query_profiler_cpu_time_period_ns",
+}
+
+// settingsDistributedReplicaError lists settings of failed replicas penalty in distributed queries, which require positive integer values
+var settingsDistributedReplicaError = []string{
+	"distributed_replica_error_half_life",
+	"distributed_replica_error_cap",
+}
+
+// settingsConcurrentQueriesForUsers lists per-user concurrency caps, which require non-negative integer values, 0 means no limit
+var settingsConcurrentQueriesForUsers = []string{
+	"max_concurrent_queries_for_user",
+	"max_concurrent_queries_for_all_users",
+}
+
+// settingsConcurrentQueries lists server-wide concurrency caps, which require non-negative integer values, 0 means no limit
+var settingsConcurrentQueries = []string{
+	settingMaxConcurrentQueries,
+	"max_concurrent_select_queries",
+	"max_concurrent_insert_queries",
+	settingMaxWaitingQueries,
+}
+
+// Server-wide admission control settings. Queries exceeding max_concurrent_queries wait in a queue,
+// limited by max_waiting_queries, instead of being rejected
+const (
+	settingMaxConcurrentQueries = "max_concurrent_queries"
+	settingMaxWaitingQueries    = "max_waiting_queries"
+)
+
+// Server setting specifying how passwords of users created via SQL (CREATE USER) are hashed
+const (
+	settingDefaultPasswordType = "default_password_type"
+	// passwordTypeDefault is used in case incorrect password type specified
+	passwordTypeDefault = "sha256_password"
+)
+
+// passwordTypes lists acceptable values of default_password_type setting
+var passwordTypes = []string{
+	"plaintext_password",
+	"sha256_password",
+	"double_sha1_password",
+	"bcrypt_password",
+}
+
+// Profile settings of .spec.defaults.filesystemRead
+const (
+	settingLocalFilesystemReadMethod = "local_filesystem_read_method"
+	settingMaxReadBufferSize         = "max_read_buffer_size"
+)
+
+// localFilesystemReadMethods lists acceptable values of local_filesystem_read_method setting
+var localFilesystemReadMethods = []string{
+	"read",
+	"pread",
+	"mmap",
+	"pread_threadpool",
+	"io_uring",
+}
+
+// settingsMemoryOvercommit lists memory overcommit tracker settings of profiles, which require non-negative integer values.
+// 0 denominators exclude queries from being chosen to be killed, 0 wait means query is stopped immediately
+var settingsMemoryOvercommit = []string{
+	"memory_overcommit_ratio_denominator",
+	"memory_overcommit_ratio_denominator_for_user",
+	"memory_usage_overcommit_max_wait_microseconds",
+}
+
+const (
+	// memoryTrackerDefaultRatio is ClickHouse default max_server_memory_usage_to_ram_ratio,
+	// used to derive max_server_memory_usage from memory limit of the container
+	memoryTrackerDefaultRatio = "0.9"
+)
+
+const (
+	settingMaxServerMemoryUsage               = "max_server_memory_usage"
+	settingMaxServerMemoryUsageToRAMRatio     = "max_server_memory_usage_to_ram_ratio"
+	settingCgroupsMemoryUsageObserverWaitTime = "cgroups_memory_usage_observer_wait_time"
+)
+
+// settingsMemoryTracker lists server memory tracker settings, which require non-negative integer values
+var settingsMemoryTracker = []string{
+	settingMaxServerMemoryUsage,
+	settingCgroupsMemoryUsageObserverWaitTime,
+}
+
+// settingsGlobalMemoryOvercommit lists server-wide memory overcommit tracker settings, which require non-negative integer values
+var settingsGlobalMemoryOvercommit = []string{
+	"global_memory_usage_overcommit_max_wait_microseconds",
+}
+
+// settingsStandby lists settings applied to hosts of standby cluster, so replicated tables do not assign merges
+// and mutations until the cluster is promoted. Merges and mutations pool is shrunk to the minimum along with
+// merge_tree free entries thresholds, since ClickHouse requires thresholds to be less than the pool size.
+// Fetches pool is kept as it is, since standby hosts fetch parts from the active cluster
+var settingsStandby = map[string]string{
+	"merge_tree/max_replicated_merges_in_queue":                            "0",
+	"merge_tree/max_replicated_mutations_in_queue":                         "0",
+	"merge_tree/number_of_free_entries_in_pool_to_lower_max_size_of_merge": "0",
+	"merge_tree/number_of_free_entries_in_pool_to_execute_mutation":        "0",
+	"background_pool_size":                                                 "1",
+	settingBackgroundMergesMutationsConcurrencyRatio:                       "1",
+}
+
+// profileStandbyReadonly specifies settings of profile, which is the default user's profile on hosts of standby cluster
+var profileStandbyReadonly = map[string]string{
+	"readonly": "1",
+}
+
+// experimentalFeatures lists known ClickHouse experimental features, toggled by allow_experimental_<feature> settings
+var experimentalFeatures = []string{
+	"analyzer",
+	"annoy_index",
+	"codecs",
+	"database_materialized_mysql",
+	"database_materialized_postgresql",
+	"dynamic_type",
+	"funnel_functions",
+	"geo_types",
+	"hash_functions",
+	"inverted_index",
+	"json_type",
+	"lightweight_delete",
+	"live_view",
+	"map_type",
+	"nlp_functions",
+	"object_type",
+	"parallel_reading_from_replicas",
+	"query_cache",
+	"refreshable_materialized_view",
+	"statistics",
+	"undrop_table_query",
+	"usearch_index",
+	"variant_type",
+	"window_view",
+}
+
+// profileConstraintValues lists kinds of profile constraints, which specify a value
+var profileConstraintValues = []string{
+	"min",
+	"max",
+}
+
+// profileConstraintFlags lists kinds of profile constraints, which are specified as empty tags
+var profileConstraintFlags = []string{
+	"readonly",
+	"const",
+	"changeable_in_readonly",
+	"writable",
+}
+
+// kafkaSecurityProtocols lists security protocols of Kafka
+var kafkaSecurityProtocols = []string{
+	"plaintext",
+	"ssl",
+	"sasl_plaintext",
+	"sasl_ssl",
+}
+
+// kafkaSASLMechanisms lists SASL mechanisms of Kafka
+var kafkaSASLMechanisms = []string{
+	"PLAIN",
+	"SCRAM-SHA-256",
+	"SCRAM-SHA-512",
+	"GSSAPI",
+	"OAUTHBEARER",
+}
+
+// distributedProductModes lists acceptable values of distributed_product_mode setting
+var distributedProductModes = []string{
+	"deny",
+	"local",
+	"global",
+	"allow",
+}
+
+// loadBalancingModes lists acceptable values of load_balancing setting
+var loadBalancingModes = []string{
+	"random",
+	"nearest_hostname",
+	"in_order",
+	"first_or_random",
+	"round_robin",
+}
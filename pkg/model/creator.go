@@ -16,6 +16,10 @@ package model
 
 import (
 	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
 	chiv1 "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
 	"github.com/altinity/clickhouse-operator/pkg/chop"
 	"github.com/altinity/clickhouse-operator/pkg/util"
@@ -23,6 +27,8 @@ import (
 
 	apps "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	policy "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	log "github.com/golang/glog"
@@ -44,13 +50,28 @@ func NewCreator(
 	creator := &Creator{
 		chop:              chop,
 		chi:               chi,
-		chConfigGenerator: NewClickHouseConfigGenerator(chi),
+		chConfigGenerator: NewClickHouseConfigGenerator(chi, chop.Config()),
 		labeler:           NewLabeler(chop, chi),
 	}
 	creator.chConfigSectionsGenerator = NewConfigSections(creator.chConfigGenerator, creator.chop.Config())
 	return creator
 }
 
+// getOwnerReferences gets owner references of generated objects, so they are garbage collected along with CHI.
+// Owner has to be in the same namespace as owned object, thus objects in other namespaces are not owned
+func (c *Creator) getOwnerReferences() []metav1.OwnerReference {
+	controller := true
+	return []metav1.OwnerReference{
+		{
+			APIVersion: chiv1.SchemeGroupVersion.String(),
+			Kind:       chiv1.ClickHouseInstallationCRDResourceKind,
+			Name:       c.chi.Name,
+			UID:        c.chi.UID,
+			Controller: &controller,
+		},
+	}
+}
+
 // CreateServiceCHI creates new corev1.Service for specified CHI
 func (c *Creator) CreateServiceCHI() *corev1.Service {
 	serviceName := CreateCHIServiceName(c.chi)
@@ -68,25 +89,26 @@ func (c *Creator) CreateServiceCHI() *corev1.Service {
 	} else {
 		// Incorrect/unknown .templates.ServiceTemplate specified
 		// Create default Service
-		return &corev1.Service{
+		service := &corev1.Service{
 			ObjectMeta: metav1.ObjectMeta{
-				Name:      serviceName,
-				Namespace: c.chi.Namespace,
-				Labels:    c.labeler.getLabelsServiceCHI(),
+				Name:            serviceName,
+				Namespace:       c.chi.Namespace,
+				Labels:          c.labeler.getLabelsServiceCHI(),
+				Annotations:     c.labeler.getAnnotationsPropagated(),
+				OwnerReferences: c.getOwnerReferences(),
 			},
 			Spec: corev1.ServiceSpec{
-				// ClusterIP: templateDefaultsServiceClusterIP,
 				Ports: []corev1.ServicePort{
 					{
 						Name:       chDefaultHTTPPortName,
 						Protocol:   corev1.ProtocolTCP,
-						Port:       chDefaultHTTPPortNumber,
+						Port:       c.chi.Spec.Defaults.Ports.HTTPPort,
 						TargetPort: intstr.FromString(chDefaultHTTPPortName),
 					},
 					{
 						Name:       chDefaultTCPPortName,
 						Protocol:   corev1.ProtocolTCP,
-						Port:       chDefaultTCPPortNumber,
+						Port:       c.chi.Spec.Defaults.Ports.TCPPort,
 						TargetPort: intstr.FromString(chDefaultTCPPortName),
 					},
 				},
@@ -95,6 +117,104 @@ func (c *Creator) CreateServiceCHI() *corev1.Service {
 				ExternalTrafficPolicy: corev1.ServiceExternalTrafficPolicyTypeLocal,
 			},
 		}
+		c.applyServiceClusterIP(service)
+		c.appendSecureServicePorts(service)
+		return service
+	}
+}
+
+// applyServiceClusterIP sets ClusterIP of default CHI Service in case .spec.defaults.serviceClusterIP is specified.
+// Service with explicit ClusterIP is not exposed via LoadBalancer
+func (c *Creator) applyServiceClusterIP(service *corev1.Service) {
+	clusterIP := c.chi.Spec.Defaults.ServiceClusterIP
+	if clusterIP == "" {
+		return
+	}
+	service.Spec.ClusterIP = clusterIP
+	service.Spec.Type = corev1.ServiceTypeClusterIP
+	// ExternalTrafficPolicy is applicable to LoadBalancer and NodePort Services only
+	service.Spec.ExternalTrafficPolicy = ""
+}
+
+// appendSecureServicePorts appends secure native and HTTPS ports to default Service in case .spec.configuration.tls is specified
+func (c *Creator) appendSecureServicePorts(service *corev1.Service) {
+	if !c.chi.Spec.Configuration.TLS.IsEnabled() {
+		return
+	}
+	service.Spec.Ports = append(service.Spec.Ports,
+		corev1.ServicePort{
+			Name:       chDefaultTCPPortSecureName,
+			Protocol:   corev1.ProtocolTCP,
+			Port:       chDefaultTCPPortSecureNumber,
+			TargetPort: intstr.FromString(chDefaultTCPPortSecureName),
+		},
+		corev1.ServicePort{
+			Name:       chDefaultHTTPSPortName,
+			Protocol:   corev1.ProtocolTCP,
+			Port:       chDefaultHTTPSPortNumber,
+			TargetPort: intstr.FromString(chDefaultHTTPSPortName),
+		},
+	)
+}
+
+// appendMetricsServicePorts appends ports of exporter sidecar and built-in Prometheus endpoint to host's Service,
+// in case they are enabled. Ports already specified in Service template are not duplicated
+func (c *Creator) appendMetricsServicePorts(service *corev1.Service) {
+	monitoring := &c.chi.Spec.Configuration.Monitoring
+	var ports []corev1.ServicePort
+	if sidecar := &monitoring.ExporterSidecar; sidecar.IsEnabled() {
+		port, _ := strconv.Atoi(sidecar.Port)
+		ports = append(ports, corev1.ServicePort{
+			Name:       exporterSidecarPortName,
+			Protocol:   corev1.ProtocolTCP,
+			Port:       int32(port),
+			TargetPort: intstr.FromString(exporterSidecarPortName),
+		})
+	}
+	if prometheus := &monitoring.Prometheus; prometheus.IsEnabled() {
+		port, _ := strconv.Atoi(prometheus.Port)
+		ports = append(ports, corev1.ServicePort{
+			Name:       prometheusPortName,
+			Protocol:   corev1.ProtocolTCP,
+			Port:       int32(port),
+			TargetPort: intstr.FromString(prometheusPortName),
+		})
+	}
+
+	for _, port := range ports {
+		specified := false
+		for i := range service.Spec.Ports {
+			if (service.Spec.Ports[i].Name == port.Name) || (service.Spec.Ports[i].Port == port.Port) {
+				specified = true
+			}
+		}
+		if !specified {
+			service.Spec.Ports = append(service.Spec.Ports, port)
+		}
+	}
+}
+
+// CreateServiceCHIExternal creates new corev1.Service of ExternalName type in .spec.serviceNamespace,
+// which points to CHI Service. Returns nil in case no serviceNamespace specified
+func (c *Creator) CreateServiceCHIExternal() *corev1.Service {
+	if c.chi.Spec.ServiceNamespace == "" {
+		return nil
+	}
+
+	serviceName := CreateCHIServiceName(c.chi)
+
+	log.V(1).Infof("CreateServiceCHIExternal(%s/%s)", c.chi.Spec.ServiceNamespace, serviceName)
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        serviceName,
+			Namespace:   c.chi.Spec.ServiceNamespace,
+			Labels:      c.labeler.getLabelsServiceCHIExternal(),
+			Annotations: c.labeler.getAnnotationsPropagated(),
+		},
+		Spec: corev1.ServiceSpec{
+			Type:         corev1.ServiceTypeExternalName,
+			ExternalName: CreateCHIServiceFQDN(c.chi),
+		},
 	}
 }
 
@@ -136,6 +256,125 @@ func (c *Creator) CreateServiceShard(shard *chiv1.ChiShard) *corev1.Service {
 	}
 }
 
+// CreateServiceShardLeader creates new corev1.Service for specified Shard, which targets first replica of the shard only
+func (c *Creator) CreateServiceShardLeader(shard *chiv1.ChiShard) *corev1.Service {
+	serviceName := CreateShardLeaderServiceName(shard)
+
+	log.V(1).Infof("CreateServiceShardLeader(%s/%s)", shard.Address.Namespace, serviceName)
+	if template, ok := shard.GetLeaderServiceTemplate(); ok {
+		// .templates.ServiceTemplate specified
+		return c.createServiceFromTemplate(
+			template,
+			shard.Address.Namespace,
+			serviceName,
+			c.labeler.getLabelsServiceShardLeader(shard),
+			c.labeler.getSelectorShardLeaderScope(shard),
+		)
+	} else {
+		return nil
+	}
+}
+
+// CreateServiceShardRead creates new corev1.Service for specified Shard, which targets all replicas of the shard.
+// Service is created in case role Services are enabled only
+func (c *Creator) CreateServiceShardRead(shard *chiv1.ChiShard) *corev1.Service {
+	serviceName := CreateShardReadServiceName(shard)
+
+	log.V(1).Infof("CreateServiceShardRead(%s/%s)", shard.Address.Namespace, serviceName)
+	return c.createServiceShardRole(
+		shard,
+		serviceName,
+		c.labeler.getLabelsServiceShardRead(shard),
+		c.labeler.getSelectorShardScope(shard),
+	)
+}
+
+// CreateServiceShardWrite creates new corev1.Service for specified Shard, which targets primary replica of the shard only.
+// Service is created in case role Services are enabled only
+func (c *Creator) CreateServiceShardWrite(shard *chiv1.ChiShard) *corev1.Service {
+	serviceName := CreateShardWriteServiceName(shard)
+
+	log.V(1).Infof("CreateServiceShardWrite(%s/%s)", shard.Address.Namespace, serviceName)
+	return c.createServiceShardRole(
+		shard,
+		serviceName,
+		c.labeler.getLabelsServiceShardWrite(shard),
+		c.labeler.getSelectorShardWriteScope(shard),
+	)
+}
+
+// createServiceShardRole creates new corev1.Service with ClickHouse ports for specified Shard, which targets replicas by selector
+func (c *Creator) createServiceShardRole(
+	shard *chiv1.ChiShard,
+	name string,
+	labels map[string]string,
+	selector map[string]string,
+) *corev1.Service {
+	if !util.IsStringBoolTrue(c.chi.Spec.Defaults.RoleServices) || (len(shard.Hosts) == 0) {
+		return nil
+	}
+
+	// All replicas of the shard share ports
+	host := shard.Hosts[0]
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       shard.Address.Namespace,
+			Labels:          labels,
+			Annotations:     c.labeler.getAnnotationsPropagated(),
+			OwnerReferences: c.getOwnerReferences(),
+		},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{
+				{
+					Name:       chDefaultHTTPPortName,
+					Protocol:   corev1.ProtocolTCP,
+					Port:       host.HTTPPort,
+					TargetPort: intstr.FromString(chDefaultHTTPPortName),
+				},
+				{
+					Name:       chDefaultTCPPortName,
+					Protocol:   corev1.ProtocolTCP,
+					Port:       host.TCPPort,
+					TargetPort: intstr.FromString(chDefaultTCPPortName),
+				},
+			},
+			Selector: selector,
+			Type:     corev1.ServiceTypeClusterIP,
+		},
+	}
+	c.appendSecureServicePorts(service)
+	return service
+}
+
+// CreatePodDisruptionBudgetShard creates new policy.PodDisruptionBudget for specified Shard.
+// All replicas but one are required to be available, so the whole shard is never evicted at once
+func (c *Creator) CreatePodDisruptionBudgetShard(shard *chiv1.ChiShard) *policy.PodDisruptionBudget {
+	if !util.IsStringBoolTrue(c.chi.Spec.Defaults.PodDisruptionBudget) {
+		return nil
+	}
+
+	name := CreateShardPodDisruptionBudgetName(shard)
+	log.V(1).Infof("CreatePodDisruptionBudgetShard(%s/%s)", shard.Address.Namespace, name)
+
+	minAvailable := intstr.FromInt(len(shard.Hosts) - 1)
+	return &policy.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       shard.Address.Namespace,
+			Labels:          c.labeler.getLabelsShardScope(shard),
+			Annotations:     c.labeler.getAnnotationsPropagated(),
+			OwnerReferences: c.getOwnerReferences(),
+		},
+		Spec: policy.PodDisruptionBudgetSpec{
+			MinAvailable: &minAvailable,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: c.labeler.getSelectorShardScope(shard),
+			},
+		},
+	}
+}
+
 // createServiceHost creates new corev1.Service for specified host
 func (c *Creator) CreateServiceHost(host *chiv1.ChiHost) *corev1.Service {
 	serviceName := CreateStatefulSetServiceName(host)
@@ -144,50 +383,77 @@ func (c *Creator) CreateServiceHost(host *chiv1.ChiHost) *corev1.Service {
 	log.V(1).Infof("CreateServiceHost(%s/%s) for Set %s", host.Address.Namespace, serviceName, statefulSetName)
 	if template, ok := host.GetServiceTemplate(); ok {
 		// .templates.ServiceTemplate specified
-		return c.createServiceFromTemplate(
+		service := c.createServiceFromTemplate(
 			template,
 			host.Address.Namespace,
 			serviceName,
 			c.labeler.getLabelsServiceHost(host),
 			c.labeler.GetSelectorHostScope(host),
 		)
+		if service != nil {
+			c.appendMetricsServicePorts(service)
+		}
+		return service
 	} else {
 		// Incorrect/unknown .templates.ServiceTemplate specified
 		// Create default Service
-		return &corev1.Service{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      serviceName,
-				Namespace: host.Address.Namespace,
-				Labels:    c.labeler.getLabelsServiceHost(host),
-			},
-			Spec: corev1.ServiceSpec{
-				Ports: []corev1.ServicePort{
-					{
-						Name:       chDefaultHTTPPortName,
-						Protocol:   corev1.ProtocolTCP,
-						Port:       host.HTTPPort,
-						TargetPort: intstr.FromString(chDefaultHTTPPortName),
-					},
-					{
-						Name:       chDefaultTCPPortName,
-						Protocol:   corev1.ProtocolTCP,
-						Port:       host.TCPPort,
-						TargetPort: intstr.FromString(chDefaultTCPPortName),
-					},
-					{
-						Name:       chDefaultInterserverHTTPPortName,
-						Protocol:   corev1.ProtocolTCP,
-						Port:       host.InterserverHTTPPort,
-						TargetPort: intstr.FromString(chDefaultInterserverHTTPPortName),
-					},
+		return c.createServiceHostDefault(host, serviceName, c.labeler.getLabelsServiceHost(host))
+	}
+}
+
+// CreateServiceHostHeadless creates new headless corev1.Service governing host's StatefulSet.
+// Returns nil in case host's Service governs the StatefulSet itself
+func (c *Creator) CreateServiceHostHeadless(host *chiv1.ChiHost) *corev1.Service {
+	serviceName := CreateStatefulSetHeadlessServiceName(host)
+	if CreateStatefulSetGoverningServiceName(host) != serviceName {
+		// StatefulSet is governed by host's Service
+		return nil
+	}
+
+	log.V(1).Infof("CreateServiceHostHeadless(%s/%s) for Set %s", host.Address.Namespace, serviceName, CreateStatefulSetName(host))
+	return c.createServiceHostDefault(host, serviceName, c.labeler.getLabelsServiceHostHeadless(host))
+}
+
+// createServiceHostDefault creates new headless corev1.Service targeting host's pod
+func (c *Creator) createServiceHostDefault(host *chiv1.ChiHost, name string, labels map[string]string) *corev1.Service {
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       host.Address.Namespace,
+			Labels:          labels,
+			Annotations:     c.labeler.appendPrometheusAnnotations(c.labeler.getAnnotationsPropagated()),
+			OwnerReferences: c.getOwnerReferences(),
+		},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{
+				{
+					Name:       chDefaultHTTPPortName,
+					Protocol:   corev1.ProtocolTCP,
+					Port:       host.HTTPPort,
+					TargetPort: intstr.FromString(chDefaultHTTPPortName),
+				},
+				{
+					Name:       chDefaultTCPPortName,
+					Protocol:   corev1.ProtocolTCP,
+					Port:       host.TCPPort,
+					TargetPort: intstr.FromString(chDefaultTCPPortName),
+				},
+				{
+					Name:       chDefaultInterserverHTTPPortName,
+					Protocol:   corev1.ProtocolTCP,
+					Port:       host.InterserverHTTPPort,
+					TargetPort: intstr.FromString(chDefaultInterserverHTTPPortName),
 				},
-				Selector:                 c.labeler.GetSelectorHostScope(host),
-				ClusterIP:                templateDefaultsServiceClusterIP,
-				Type:                     "ClusterIP",
-				PublishNotReadyAddresses: true,
 			},
-		}
+			Selector:                 c.labeler.GetSelectorHostScope(host),
+			ClusterIP:                templateDefaultsServiceClusterIP,
+			Type:                     "ClusterIP",
+			PublishNotReadyAddresses: true,
+		},
 	}
+	c.appendSecureServicePorts(service)
+	c.appendMetricsServicePorts(service)
+	return service
 }
 
 // verifyServiceTemplatePorts verifies ChiServiceTemplate to have reasonable ports specified
@@ -227,6 +493,7 @@ func (c *Creator) createServiceFromTemplate(
 	// Overwrite .name and .namespace - they are not allowed to be specified in template
 	service.Name = name
 	service.Namespace = namespace
+	service.OwnerReferences = c.getOwnerReferences()
 
 	// Append provided Labels to already specified Labels in template
 	service.Labels = util.MergeStringMaps(service.Labels, labels)
@@ -234,53 +501,156 @@ func (c *Creator) createServiceFromTemplate(
 	// Append provided Selector to already specified Selector in template
 	service.Spec.Selector = util.MergeStringMaps(service.Spec.Selector, selector)
 
+	// Append propagated CHI annotations, annotations specified in template are not overwritten
+	service.Annotations = c.labeler.propagateAnnotations(service.Annotations)
+
+	// Target ClickHouse container ports by name, so Service keeps working with remapped port numbers
+	setServicePortsTargetPortByName(service)
+
 	return service
 }
 
+// setServicePortsTargetPortByName sets targetPort of Service ports named after ClickHouse container ports
+// to the named container port. Explicitly specified targetPort is not overwritten
+func setServicePortsTargetPortByName(service *corev1.Service) {
+	for i := range service.Spec.Ports {
+		servicePort := &service.Spec.Ports[i]
+		if (servicePort.TargetPort != intstr.IntOrString{}) {
+			// Explicitly specified already
+			continue
+		}
+		switch servicePort.Name {
+		case chDefaultHTTPPortName, chDefaultTCPPortName, chDefaultInterserverHTTPPortName:
+			servicePort.TargetPort = intstr.FromString(servicePort.Name)
+		}
+	}
+}
+
+// SetConfigValidator sets validator to be used for generated ClickHouse config files
+func (c *Creator) SetConfigValidator(validator ConfigValidator) *Creator {
+	c.chConfigSectionsGenerator.SetValidator(validator)
+	return c
+}
+
+// CreateServiceAccount creates new corev1.ServiceAccount for CHI pods.
+// Returns nil in case ServiceAccount should not be generated, such as existing one is referenced
+func (c *Creator) CreateServiceAccount() *corev1.ServiceAccount {
+	if !c.chi.Spec.Defaults.ServiceAccount.IsCreate() {
+		return nil
+	}
+
+	return &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            CreateServiceAccountName(c.chi),
+			Namespace:       c.chi.Namespace,
+			Labels:          c.labeler.getLabelsCHIScope(),
+			Annotations:     c.labeler.getAnnotationsPropagated(),
+			OwnerReferences: c.getOwnerReferences(),
+		},
+	}
+}
+
 // CreateConfigMapCHICommon creates new corev1.ConfigMap
-func (c *Creator) CreateConfigMapCHICommon() *corev1.ConfigMap {
-	c.chConfigSectionsGenerator.CreateConfigsCommon()
+func (c *Creator) CreateConfigMapCHICommon() (*corev1.ConfigMap, error) {
+	if err := c.chConfigSectionsGenerator.CreateConfigsCommon(); err != nil {
+		return nil, err
+	}
+	log.V(2).Infof("CreateConfigMapCHICommon() files load order: %v", c.chConfigSectionsGenerator.GetCommonConfigFilenames())
 	return &corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      CreateConfigMapCommonName(c.chi),
-			Namespace: c.chi.Namespace,
-			Labels:    c.labeler.getLabelsConfigMapCHICommon(),
+			Name:            CreateConfigMapCommonName(c.chi),
+			Namespace:       c.chi.Namespace,
+			Labels:          c.labeler.getLabelsConfigMapCHICommon(),
+			Annotations:     c.labeler.getAnnotationsPropagated(),
+			OwnerReferences: c.getOwnerReferences(),
 		},
 		// Data contains several sections which are to be several xml chopConfig files
 		Data: c.chConfigSectionsGenerator.commonConfigSections,
-	}
+	}, nil
 }
 
 // CreateConfigMapCHICommonUsers creates new corev1.ConfigMap
-func (c *Creator) CreateConfigMapCHICommonUsers() *corev1.ConfigMap {
-	c.chConfigSectionsGenerator.CreateConfigsUsers()
+func (c *Creator) CreateConfigMapCHICommonUsers() (*corev1.ConfigMap, error) {
+	if err := c.chConfigSectionsGenerator.CreateConfigsUsers(); err != nil {
+		return nil, err
+	}
 	return &corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      CreateConfigMapCommonUsersName(c.chi),
-			Namespace: c.chi.Namespace,
-			Labels:    c.labeler.getLabelsConfigMapCHICommonUsers(),
+			Name:            CreateConfigMapCommonUsersName(c.chi),
+			Namespace:       c.chi.Namespace,
+			Labels:          c.labeler.getLabelsConfigMapCHICommonUsers(),
+			Annotations:     c.labeler.getAnnotationsPropagated(),
+			OwnerReferences: c.getOwnerReferences(),
 		},
 		// Data contains several sections which are to be several xml chopConfig files
 		Data: c.chConfigSectionsGenerator.commonUsersConfigSections,
+	}, nil
+}
+
+// CreateConfigMapCHITopology creates new corev1.ConfigMap with JSON summary of CHI topology
+func (c *Creator) CreateConfigMapCHITopology() (*corev1.ConfigMap, error) {
+	topology, err := c.chConfigGenerator.GetTopology()
+	if err != nil {
+		return nil, err
 	}
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            CreateConfigMapTopologyName(c.chi),
+			Namespace:       c.chi.Namespace,
+			Labels:          c.labeler.getLabelsConfigMapCHITopology(),
+			Annotations:     c.labeler.getAnnotationsPropagated(),
+			OwnerReferences: c.getOwnerReferences(),
+		},
+		Data: map[string]string{
+			filenameTopologyJSON: topology,
+		},
+	}, nil
 }
 
-// createConfigMapHost creates new corev1.ConfigMap
-func (c *Creator) CreateConfigMapHost(host *chiv1.ChiHost) *corev1.ConfigMap {
+// CreateConfigMapCHIExporter creates new corev1.ConfigMap with exporter sidecar config.
+// Config is passed to exporter as env vars. Returns nil in case exporter sidecar is not enabled
+func (c *Creator) CreateConfigMapCHIExporter() *corev1.ConfigMap {
+	monitoring := &c.chi.Spec.Configuration.Monitoring
+	if !monitoring.ExporterSidecar.IsEnabled() {
+		return nil
+	}
 	return &corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      CreateConfigMapPodName(host),
-			Namespace: host.Address.Namespace,
-			Labels:    c.labeler.getLabelsConfigMapHost(host),
+			Name:            CreateConfigMapExporterName(c.chi),
+			Namespace:       c.chi.Namespace,
+			Labels:          c.labeler.getLabelsConfigMapCHIExporter(),
+			Annotations:     c.labeler.getAnnotationsPropagated(),
+			OwnerReferences: c.getOwnerReferences(),
+		},
+		Data: map[string]string{
+			exporterUserEnvVarName:             monitoring.User,
+			exporterTelemetryAddressEnvVarName: ":" + monitoring.ExporterSidecar.Port,
 		},
-		Data: c.chConfigSectionsGenerator.CreateConfigsHost(host),
 	}
 }
 
+// createConfigMapHost creates new corev1.ConfigMap
+func (c *Creator) CreateConfigMapHost(host *chiv1.ChiHost) (*corev1.ConfigMap, error) {
+	data, err := c.chConfigSectionsGenerator.CreateConfigsHost(host)
+	if err != nil {
+		return nil, err
+	}
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            CreateConfigMapPodName(host),
+			Namespace:       host.Address.Namespace,
+			Labels:          c.labeler.getLabelsConfigMapHost(host),
+			Annotations:     c.labeler.getAnnotationsPropagated(),
+			OwnerReferences: c.getOwnerReferences(),
+		},
+		Data: data,
+	}, nil
+}
+
 // createStatefulSet creates new apps.StatefulSet
 func (c *Creator) CreateStatefulSet(host *chiv1.ChiHost) *apps.StatefulSet {
 	statefulSetName := CreateStatefulSetName(host)
-	serviceName := CreateStatefulSetServiceName(host)
+	serviceName := CreateStatefulSetGoverningServiceName(host)
 
 	// Create apps.StatefulSet object
 	replicasNum := host.GetReplicasNum()
@@ -288,9 +658,11 @@ func (c *Creator) CreateStatefulSet(host *chiv1.ChiHost) *apps.StatefulSet {
 	// StatefulSet has additional label - ZK config fingerprint
 	statefulSet := &apps.StatefulSet{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      statefulSetName,
-			Namespace: host.Address.Namespace,
-			Labels:    c.labeler.getLabelsHostScope(host, true),
+			Name:            statefulSetName,
+			Namespace:       host.Address.Namespace,
+			Labels:          c.labeler.getLabelsHostScope(host, true),
+			Annotations:     c.labeler.getAnnotationsStatefulSet(host),
+			OwnerReferences: c.getOwnerReferences(),
 		},
 		Spec: apps.StatefulSetSpec{
 			Replicas:    &replicasNum,
@@ -307,22 +679,48 @@ func (c *Creator) CreateStatefulSet(host *chiv1.ChiHost) *apps.StatefulSet {
 			// VolumeClaimTemplates are to be setup later
 			VolumeClaimTemplates: nil,
 
-			PodManagementPolicy: apps.OrderedReadyPodManagement,
-			UpdateStrategy: apps.StatefulSetUpdateStrategy{
-				Type: apps.RollingUpdateStatefulSetStrategyType,
-			},
+			PodManagementPolicy:  apps.OrderedReadyPodManagement,
+			UpdateStrategy:       c.getStatefulSetUpdateStrategy(host),
 			RevisionHistoryLimit: &revisionHistoryLimit,
 		},
 	}
 
 	c.setupStatefulSetPodTemplate(statefulSet, host)
 	c.setupStatefulSetVolumeClaimTemplates(statefulSet, host)
+	// Data volume is mounted along with volume claim templates
+	c.setupDataVolumeChownInitContainer(statefulSet)
 
 	host.StatefulSet = statefulSet
 
 	return statefulSet
 }
 
+// getStatefulSetUpdateStrategy gets update strategy of host's StatefulSet.
+// Each host has its own single-pod StatefulSet, so CHI-wide partition is translated into per-StatefulSet one:
+// pods of hosts with CHI-scope index lower than partition are held at previous revision
+func (c *Creator) getStatefulSetUpdateStrategy(host *chiv1.ChiHost) apps.StatefulSetUpdateStrategy {
+	strategy := &c.chi.Spec.Defaults.UpdateStrategy
+	if strategy.Type == string(apps.OnDeleteStatefulSetStrategyType) {
+		return apps.StatefulSetUpdateStrategy{
+			Type: apps.OnDeleteStatefulSetStrategyType,
+		}
+	}
+
+	result := apps.StatefulSetUpdateStrategy{
+		Type: apps.RollingUpdateStatefulSetStrategyType,
+	}
+	if strategy.Partition != "" {
+		partition := int32(0)
+		if host.Address.CHIScopeIndex < strategy.GetPartition() {
+			partition = host.GetReplicasNum()
+		}
+		result.RollingUpdate = &apps.RollingUpdateStatefulSetStrategy{
+			Partition: &partition,
+		}
+	}
+	return result
+}
+
 // PreparePersistentVolume
 func (c *Creator) PreparePersistentVolume(pv *corev1.PersistentVolume, host *chiv1.ChiHost) *corev1.PersistentVolume {
 	pv.Labels = util.MergeStringMaps(pv.Labels, c.labeler.getLabelsHostScope(host, false))
@@ -335,7 +733,7 @@ func (c *Creator) setupStatefulSetPodTemplate(statefulSet *apps.StatefulSet, hos
 	// Initial PodTemplateSpec
 	statefulSet.Spec.Template = corev1.PodTemplateSpec{
 		ObjectMeta: metav1.ObjectMeta{
-			Labels:      c.labeler.getLabelsHostScope(host, true),
+			Labels:      c.labeler.getLabelsPod(host),
 			Annotations: c.labeler.getAnnotationsHostScope(host),
 		},
 	}
@@ -349,103 +747,957 @@ func (c *Creator) setupStatefulSetPodTemplate(statefulSet *apps.StatefulSet, hos
 	c.personalizeStatefulSetTemplate(statefulSet, host)
 }
 
-func (c *Creator) ensureStatefulSetIntegrity(statefulSet *apps.StatefulSet, host *chiv1.ChiHost) {
-	ensureClickHouseContainer(statefulSet, host)
-	ensureNamedPortsSpecified(statefulSet, host)
+func (c *Creator) ensureStatefulSetIntegrity(statefulSet *apps.StatefulSet, host *chiv1.ChiHost) {
+	ensureClickHouseContainer(statefulSet, host)
+	ensureNamedPortsSpecified(statefulSet, host)
+}
+
+// ensureClickHouseContainer ensures StatefulSet has ClickHouse container.
+// Pod template without containers at all is completed with default ClickHouse container
+func ensureClickHouseContainer(statefulSet *apps.StatefulSet, host *chiv1.ChiHost) {
+	if _, ok := findClickHouseContainer(statefulSet, host.CHI.Spec.Defaults.Container.Name); !ok {
+		// No ClickHouse container available
+		log.V(1).Infof("ensureClickHouseContainer() statefulSet %s has no containers, add default ClickHouse container", statefulSet.Name)
+		addContainer(
+			&statefulSet.Spec.Template.Spec,
+			newDefaultClickHouseContainer(),
+		)
+	}
+}
+
+func (c *Creator) personalizeStatefulSetTemplate(statefulSet *apps.StatefulSet, host *chiv1.ChiHost) {
+	statefulSetName := CreateStatefulSetName(host)
+
+	// Ensure pod created by this StatefulSet has alias 127.0.0.1
+	statefulSet.Spec.Template.Spec.HostAliases = []corev1.HostAlias{
+		{
+			IP:        "127.0.0.1",
+			Hostnames: []string{CreatePodHostname(host)},
+		},
+	}
+
+	// Setup volumes based on ConfigMaps into Pod Template
+	c.setupConfigMapVolumes(statefulSet, host)
+
+	// Setup volume with user defined functions
+	c.setupUserDefinedFunctionsVolume(statefulSet)
+
+	// Setup volume with format schemas
+	c.setupFormatSchemasVolume(statefulSet)
+
+	// Setup volume with ZooKeeper client certificates
+	c.setupZookeeperTLSVolume(statefulSet, host)
+
+	// Setup volume with server certificates of secure ports
+	c.setupTLSVolume(statefulSet)
+
+	// Setup volume for tmp_path
+	c.setupTmpVolume(statefulSet)
+
+	// Setup volume for /dev/shm
+	c.setupShmVolume(statefulSet)
+
+	// Provide host ordinal
+	c.setupHostOrdinalEnvVar(statefulSet, host)
+
+	// Provide default user's profile, which differs for standby clusters
+	c.setupDefaultUserProfileEnvVar(statefulSet, host)
+
+	// Provide working dir and home according to .spec.defaults.container
+	c.setupContainerDefaults(statefulSet)
+
+	// Provide monitoring user's password from Secret
+	c.setupMonitoringPasswordEnvVar(statefulSet)
+
+	// Provide passwords of users from Secrets
+	c.setupUserPasswordEnvVars(statefulSet)
+
+	// Add exporter sidecar according to .spec.configuration.monitoring.exporterSidecar
+	c.setupExporterSidecar(statefulSet, host)
+
+	// Provide Kafka SASL credentials from Secrets
+	c.setupKafkaSASLEnvVars(statefulSet)
+
+	// Provide interserver credentials from Secrets
+	c.setupInterserverCredentialsEnvVars(statefulSet)
+
+	// Provide env vars according to .spec.defaults.container.env
+	c.setupContainerDefaultsEnvVars(statefulSet)
+
+	// Setup probes omitted in pod template
+	c.setupProbes(statefulSet)
+
+	// Setup readiness probe according to .spec.defaults.readinessProbe
+	c.setupReadinessProbe(statefulSet)
+
+	// Flush system logs before termination according to .spec.configuration.systemLogs.flushOnShutdown
+	c.setupFlushLogsOnShutdown(statefulSet, host)
+
+	// In case we have default LogVolumeClaimTemplate specified - need to append log container to Pod Template
+	if host.Templates.LogVolumeClaimTemplate != "" {
+		addContainer(&statefulSet.Spec.Template.Spec, corev1.Container{
+			Name:  ClickHouseLogContainerName,
+			Image: defaultBusyBoxDockerImage,
+			Command: []string{
+				"/bin/sh", "-c", "--",
+			},
+			Args: []string{
+				"while true; do sleep 30; done;",
+			},
+		})
+		log.V(1).Infof("setupStatefulSetPodTemplate() add log container for statefulSet %s", statefulSetName)
+	}
+}
+
+// getPodTemplate gets Pod Template to be used to create StatefulSet
+func (c *Creator) getPodTemplate(host *chiv1.ChiHost) *chiv1.ChiPodTemplate {
+	statefulSetName := CreateStatefulSetName(host)
+
+	// Which pod template would be used - either explicitly defined in or a default one
+	podTemplate, ok := host.GetPodTemplate()
+	if ok {
+		// Host references known PodTemplate
+		// Make local copy of this PodTemplate, in order not to spoil the original common-used template
+		podTemplate = podTemplate.DeepCopy()
+		log.V(1).Infof("getPodTemplate() statefulSet %s use custom template %s", statefulSetName, podTemplate.Name)
+	} else {
+		// Host references UNKNOWN PodTemplate, will use default one
+		podTemplate = newDefaultPodTemplate(statefulSetName)
+		log.V(1).Infof("getPodTemplate() statefulSet %s use default generated template", statefulSetName)
+	}
+
+	// Here we have local copy of Pod Template, to be used to create StatefulSet
+	// Now we can customize this Pod Template for particular host
+
+	c.applyDefaultPodAffinity(podTemplate)
+	c.applyDefaultNodeSelector(podTemplate)
+	c.applyDefaultTolerations(podTemplate)
+	c.applyDefaultTerminationGracePeriod(podTemplate)
+	c.applyDefaultHostNetwork(podTemplate)
+	c.applyDefaultSecurityContext(podTemplate)
+	c.applyDefaultServiceAccount(podTemplate)
+	c.applyShardAntiAffinity(podTemplate)
+	c.labeler.prepareAffinity(podTemplate, host)
+
+	return podTemplate
+}
+
+// applyDefaultPodAffinity appends terms of .spec.defaults.podAffinity to pod affinity of the local copy of Pod Template,
+// so pods can be co-located with companion workloads, such as caching proxy. Terms specified in template are kept
+func (c *Creator) applyDefaultPodAffinity(podTemplate *chiv1.ChiPodTemplate) {
+	podAffinity := c.chi.Spec.Defaults.PodAffinity
+	if podAffinity == nil {
+		return
+	}
+
+	if podTemplate.Spec.Affinity == nil {
+		podTemplate.Spec.Affinity = &corev1.Affinity{}
+	}
+	if podTemplate.Spec.Affinity.PodAffinity == nil {
+		podTemplate.Spec.Affinity.PodAffinity = &corev1.PodAffinity{}
+	}
+	dst := podTemplate.Spec.Affinity.PodAffinity
+	for i := range podAffinity.RequiredDuringSchedulingIgnoredDuringExecution {
+		dst.RequiredDuringSchedulingIgnoredDuringExecution = append(
+			dst.RequiredDuringSchedulingIgnoredDuringExecution,
+			*podAffinity.RequiredDuringSchedulingIgnoredDuringExecution[i].DeepCopy(),
+		)
+	}
+	for i := range podAffinity.PreferredDuringSchedulingIgnoredDuringExecution {
+		dst.PreferredDuringSchedulingIgnoredDuringExecution = append(
+			dst.PreferredDuringSchedulingIgnoredDuringExecution,
+			*podAffinity.PreferredDuringSchedulingIgnoredDuringExecution[i].DeepCopy(),
+		)
+	}
+}
+
+// applyDefaultNodeSelector adds entries of .spec.defaults.nodeSelector to node selector of the local copy of Pod Template.
+// Entries specified in template take precedence
+func (c *Creator) applyDefaultNodeSelector(podTemplate *chiv1.ChiPodTemplate) {
+	for key, value := range c.chi.Spec.Defaults.NodeSelector {
+		if podTemplate.Spec.NodeSelector == nil {
+			podTemplate.Spec.NodeSelector = make(map[string]string)
+		}
+		if _, ok := podTemplate.Spec.NodeSelector[key]; !ok {
+			podTemplate.Spec.NodeSelector[key] = value
+		}
+	}
+}
+
+// applyDefaultTolerations appends tolerations of .spec.defaults.tolerations to the local copy of Pod Template,
+// so pods can be scheduled onto tainted dedicated nodes. Tolerations specified in template are kept
+func (c *Creator) applyDefaultTolerations(podTemplate *chiv1.ChiPodTemplate) {
+	for i := range c.chi.Spec.Defaults.Tolerations {
+		toleration := &c.chi.Spec.Defaults.Tolerations[i]
+		found := false
+		for j := range podTemplate.Spec.Tolerations {
+			found = found || podTemplate.Spec.Tolerations[j].MatchToleration(toleration)
+		}
+		if !found {
+			podTemplate.Spec.Tolerations = append(podTemplate.Spec.Tolerations, *toleration.DeepCopy())
+		}
+	}
+}
+
+// applyDefaultTerminationGracePeriod sets termination grace period of the local copy of Pod Template
+// as specified by .spec.defaults.terminationGracePeriodSeconds. Grace period specified in template is kept
+func (c *Creator) applyDefaultTerminationGracePeriod(podTemplate *chiv1.ChiPodTemplate) {
+	if podTemplate.Spec.TerminationGracePeriodSeconds != nil {
+		return
+	}
+	seconds, err := strconv.ParseInt(c.chi.Spec.Defaults.TerminationGracePeriodSeconds, 10, 64)
+	if err != nil {
+		return
+	}
+	podTemplate.Spec.TerminationGracePeriodSeconds = &seconds
+}
+
+// applyDefaultHostNetwork enables host network of the local copy of Pod Template as specified by .spec.defaults.hostNetwork
+// and sets DNS policy as specified by .spec.defaults.dnsPolicy. Pod on host network gets ClusterFirstWithHostNet DNS policy,
+// unless specified otherwise, so it is still able to resolve in-cluster names. DNS policy specified in template is kept
+func (c *Creator) applyDefaultHostNetwork(podTemplate *chiv1.ChiPodTemplate) {
+	if util.IsStringBoolTrue(c.chi.Spec.Defaults.HostNetwork) {
+		podTemplate.Spec.HostNetwork = true
+	}
+	if podTemplate.Spec.DNSPolicy != "" {
+		return
+	}
+	switch {
+	case c.chi.Spec.Defaults.DNSPolicy != "":
+		podTemplate.Spec.DNSPolicy = c.chi.Spec.Defaults.DNSPolicy
+	case podTemplate.Spec.HostNetwork:
+		podTemplate.Spec.DNSPolicy = corev1.DNSClusterFirstWithHostNet
+	}
+}
+
+// applyDefaultSecurityContext fills pod-level security context of the local copy of Pod Template
+// as specified by .spec.defaults.securityContext. Fields specified in template are kept
+func (c *Creator) applyDefaultSecurityContext(podTemplate *chiv1.ChiPodTemplate) {
+	defaults := &c.chi.Spec.Defaults.SecurityContext
+	if (defaults.RunAsUser == "") && (defaults.RunAsGroup == "") && (defaults.FSGroup == "") && (defaults.RunAsNonRoot == "") {
+		return
+	}
+
+	if podTemplate.Spec.SecurityContext == nil {
+		podTemplate.Spec.SecurityContext = &corev1.PodSecurityContext{}
+	}
+	securityContext := podTemplate.Spec.SecurityContext
+	fill := func(dst **int64, value string) {
+		if *dst != nil {
+			return
+		}
+		if id, err := strconv.ParseInt(value, 10, 64); err == nil {
+			*dst = &id
+		}
+	}
+	fill(&securityContext.RunAsUser, defaults.RunAsUser)
+	fill(&securityContext.RunAsGroup, defaults.RunAsGroup)
+	fill(&securityContext.FSGroup, defaults.FSGroup)
+	if (securityContext.RunAsNonRoot == nil) && (defaults.RunAsNonRoot != "") {
+		runAsNonRoot := util.IsStringBoolTrue(defaults.RunAsNonRoot)
+		securityContext.RunAsNonRoot = &runAsNonRoot
+	}
+}
+
+// applyDefaultServiceAccount sets ServiceAccount of the local copy of Pod Template
+// as specified by .spec.defaults.serviceAccount. ServiceAccount specified in template is kept
+func (c *Creator) applyDefaultServiceAccount(podTemplate *chiv1.ChiPodTemplate) {
+	if podTemplate.Spec.ServiceAccountName != "" {
+		return
+	}
+	podTemplate.Spec.ServiceAccountName = c.getServiceAccountName()
+}
+
+// getServiceAccountName gets name of ServiceAccount pods run under, empty in case namespace's default one is used
+func (c *Creator) getServiceAccountName() string {
+	sa := &c.chi.Spec.Defaults.ServiceAccount
+	switch {
+	case sa.Name != "":
+		return sa.Name
+	case sa.IsCreate():
+		return CreateServiceAccountName(c.chi)
+	default:
+		return ""
+	}
+}
+
+// applyShardAntiAffinity appends pod anti-affinity term, as specified by .spec.defaults.shardAntiAffinity,
+// repelling pods of the same shard from each other, to the local copy of Pod Template. Terms specified in template are kept.
+// Term refers to shard labels via macros, which are expanded for particular host afterwards
+func (c *Creator) applyShardAntiAffinity(podTemplate *chiv1.ChiPodTemplate) {
+	mode := c.chi.Spec.Defaults.ShardAntiAffinity
+	if mode == "" {
+		return
+	}
+
+	term := corev1.PodAffinityTerm{
+		LabelSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{
+				LabelNamespace:   macrosNamespace,
+				LabelAppName:     LabelAppValue,
+				LabelCHIName:     macrosChiName,
+				LabelClusterName: macrosClusterName,
+				LabelShardName:   macrosShardName,
+			},
+		},
+		TopologyKey: topologyKeyHostname,
+	}
+
+	if podTemplate.Spec.Affinity == nil {
+		podTemplate.Spec.Affinity = &corev1.Affinity{}
+	}
+	if podTemplate.Spec.Affinity.PodAntiAffinity == nil {
+		podTemplate.Spec.Affinity.PodAntiAffinity = &corev1.PodAntiAffinity{}
+	}
+	dst := podTemplate.Spec.Affinity.PodAntiAffinity
+	switch mode {
+	case chiv1.ShardAntiAffinityRequired:
+		dst.RequiredDuringSchedulingIgnoredDuringExecution = append(dst.RequiredDuringSchedulingIgnoredDuringExecution, term)
+	case chiv1.ShardAntiAffinityPreferred:
+		dst.PreferredDuringSchedulingIgnoredDuringExecution = append(
+			dst.PreferredDuringSchedulingIgnoredDuringExecution,
+			corev1.WeightedPodAffinityTerm{
+				Weight:          shardAntiAffinityWeight,
+				PodAffinityTerm: term,
+			},
+		)
+	}
+}
+
+// setupConfigMapVolumes adds to ClickHouse container in the Pod VolumeMount objects with ConfigMaps
+func (c *Creator) setupConfigMapVolumes(statefulSetObject *apps.StatefulSet, host *chiv1.ChiHost) {
+	configMapMacrosName := CreateConfigMapPodName(host)
+	configMapCommonName := CreateConfigMapCommonName(c.chi)
+	configMapCommonUsersName := CreateConfigMapCommonUsersName(c.chi)
+
+	// Add all ConfigMap objects as Volume objects of type ConfigMap
+	statefulSetObject.Spec.Template.Spec.Volumes = append(
+		statefulSetObject.Spec.Template.Spec.Volumes,
+		newVolumeForConfigMap(configMapCommonName),
+		newVolumeForUsersConfigMaps(configMapCommonUsersName, c.chi.Spec.Configuration.UsersOverrideConfigMap),
+		newVolumeForConfigMap(configMapMacrosName),
+	)
+
+	// And reference these Volumes in ClickHouse Container via VolumeMount
+	// So Pod will have ConfigMaps mounted as Volumes.
+	// Other containers (sidecars) are left untouched and keep their own VolumeMounts only
+	container, ok := c.getClickHouseContainer(statefulSetObject)
+	if !ok {
+		return
+	}
+	// Append to ClickHouse Container current VolumeMount's to VolumeMount's declared in template
+	container.VolumeMounts = append(
+		container.VolumeMounts,
+		newReadOnlyVolumeMount(configMapCommonName, c.getDirPathConfig(chiv1.CommonConfigDir)),
+		newReadOnlyVolumeMount(configMapCommonUsersName, c.getDirPathConfig(chiv1.UsersConfigDir)),
+		newReadOnlyVolumeMount(configMapMacrosName, c.getDirPathConfig(chiv1.HostConfigDir)),
+	)
+}
+
+// getDirPathConfig returns full path to folder with generated XML files for ClickHouse,
+// located within config folder, as specified by .spec.defaults.configDirPath
+func (c *Creator) getDirPathConfig(configDir string) string {
+	dirPath := c.chi.Spec.Defaults.ConfigDirPath
+	if dirPath == "" {
+		dirPath = dirPathConfig
+	}
+	return path.Join(dirPath, configDir) + "/"
+}
+
+// setupUserDefinedFunctionsVolume mounts ConfigMap or Secret with user defined functions into ClickHouse container
+func (c *Creator) setupUserDefinedFunctionsVolume(statefulSet *apps.StatefulSet) {
+	udf := &c.chi.Spec.Configuration.UserDefinedFunctions
+	if !udf.IsDeclared() {
+		return
+	}
+
+	container, ok := c.getClickHouseContainer(statefulSet)
+	if !ok {
+		return
+	}
+
+	statefulSet.Spec.Template.Spec.Volumes = append(
+		statefulSet.Spec.Template.Spec.Volumes,
+		newVolumeForUserDefinedFunctions(udf),
+	)
+	container.VolumeMounts = append(
+		container.VolumeMounts,
+		newReadOnlyVolumeMount(userDefinedFunctionsVolumeName, dirPathUserDefinedFunctions),
+	)
+}
+
+// setupFormatSchemasVolume mounts ConfigMap or Secret with format schema files into ClickHouse container
+func (c *Creator) setupFormatSchemasVolume(statefulSet *apps.StatefulSet) {
+	schemas := &c.chi.Spec.Configuration.FormatSchemas
+	if !schemas.IsDeclared() {
+		return
+	}
+
+	container, ok := c.getClickHouseContainer(statefulSet)
+	if !ok {
+		return
+	}
+
+	statefulSet.Spec.Template.Spec.Volumes = append(
+		statefulSet.Spec.Template.Spec.Volumes,
+		newVolumeForFormatSchemas(schemas),
+	)
+	container.VolumeMounts = append(
+		container.VolumeMounts,
+		newReadOnlyVolumeMount(formatSchemasVolumeName, dirPathFormatSchemas),
+	)
+}
+
+// setupZookeeperTLSVolume mounts Secret with ZooKeeper client certificates into ClickHouse container
+func (c *Creator) setupZookeeperTLSVolume(statefulSet *apps.StatefulSet, host *chiv1.ChiHost) {
+	zk := host.GetZookeeper()
+	if !zk.HasTLSSecret() {
+		return
+	}
+
+	container, ok := c.getClickHouseContainer(statefulSet)
+	if !ok {
+		return
+	}
+
+	statefulSet.Spec.Template.Spec.Volumes = append(
+		statefulSet.Spec.Template.Spec.Volumes,
+		newVolumeForZookeeperTLS(&zk.TLS),
+	)
+	container.VolumeMounts = append(
+		container.VolumeMounts,
+		newReadOnlyVolumeMount(zookeeperTLSVolumeName, dirPathZookeeperTLS),
+	)
+}
+
+// setupTLSVolume mounts Secret with server certificates in case .spec.configuration.tls is specified
+func (c *Creator) setupTLSVolume(statefulSet *apps.StatefulSet) {
+	tls := &c.chi.Spec.Configuration.TLS
+	if !tls.IsEnabled() {
+		return
+	}
+
+	container, ok := c.getClickHouseContainer(statefulSet)
+	if !ok {
+		return
+	}
+
+	statefulSet.Spec.Template.Spec.Volumes = append(
+		statefulSet.Spec.Template.Spec.Volumes,
+		newVolumeForTLS(tls),
+	)
+	container.VolumeMounts = append(
+		container.VolumeMounts,
+		newReadOnlyVolumeMount(tlsVolumeName, dirPathTLS),
+	)
+}
+
+// setupTmpVolume mounts emptyDir volume for ClickHouse tmp_path in case it is requested by .spec.defaults.tmpVolume
+func (c *Creator) setupTmpVolume(statefulSet *apps.StatefulSet) {
+	tmp := &c.chi.Spec.Defaults.TmpVolume
+	if !tmp.IsEmptyDir() {
+		return
+	}
+
+	container, ok := c.getClickHouseContainer(statefulSet)
+	if !ok {
+		return
+	}
+
+	statefulSet.Spec.Template.Spec.Volumes = append(
+		statefulSet.Spec.Template.Spec.Volumes,
+		newVolumeForTmp(tmp),
+	)
+	container.VolumeMounts = append(
+		container.VolumeMounts,
+		newVolumeMount(tmpVolumeName, dirPathClickHouseTmp),
+	)
+}
+
+// setupShmVolume mounts in-memory emptyDir volume as /dev/shm in case it is requested by .spec.defaults.shmVolume
+func (c *Creator) setupShmVolume(statefulSet *apps.StatefulSet) {
+	shm := &c.chi.Spec.Defaults.ShmVolume
+	if !shm.IsEnabled() {
+		return
+	}
+
+	container, ok := c.getClickHouseContainer(statefulSet)
+	if !ok {
+		return
+	}
+
+	statefulSet.Spec.Template.Spec.Volumes = append(
+		statefulSet.Spec.Template.Spec.Volumes,
+		newVolumeForShm(shm),
+	)
+	container.VolumeMounts = append(
+		container.VolumeMounts,
+		newVolumeMount(shmVolumeName, dirPathShm),
+	)
+}
+
+// setupDataVolumeChownInitContainer adds init container, which changes owner of data volume,
+// in case it is requested by .spec.defaults.dataVolumeChown. Init container is placed before other init containers
+func (c *Creator) setupDataVolumeChownInitContainer(statefulSet *apps.StatefulSet) {
+	chown := &c.chi.Spec.Defaults.DataVolumeChown
+	if !chown.IsEnabled() {
+		return
+	}
+
+	container, ok := c.getClickHouseContainer(statefulSet)
+	if !ok {
+		return
+	}
+
+	// Data volume is mounted into ClickHouse container by either pod template or volume claim template
+	var dataVolumeMount *corev1.VolumeMount
+	for i := range container.VolumeMounts {
+		if strings.TrimSuffix(container.VolumeMounts[i].MountPath, "/") == dirPathClickHouseData {
+			dataVolumeMount = &container.VolumeMounts[i]
+			break
+		}
+	}
+	if dataVolumeMount == nil {
+		log.V(1).Infof("setupDataVolumeChownInitContainer() statefulSet %s has no data volume, nothing to chown", statefulSet.Name)
+		return
+	}
+
+	// chown requires root, regardless of pod security context
+	runAsUser := int64(0)
+	initContainer := corev1.Container{
+		Name:    ClickHouseChownContainerName,
+		Image:   chown.Image,
+		Command: chown.Command,
+		VolumeMounts: []corev1.VolumeMount{
+			*dataVolumeMount,
+		},
+		SecurityContext: &corev1.SecurityContext{
+			RunAsUser: &runAsUser,
+		},
+	}
+	statefulSet.Spec.Template.Spec.InitContainers = append(
+		[]corev1.Container{initContainer},
+		statefulSet.Spec.Template.Spec.InitContainers...,
+	)
+}
+
+// setupHostOrdinalEnvVar adds to ClickHouse container env var with host ordinal.
+// Env var is added only in case it is referenced in settings or files, so pods of other installations are not changed
+func (c *Creator) setupHostOrdinalEnvVar(statefulSet *apps.StatefulSet, host *chiv1.ChiHost) {
+	if !c.isHostOrdinalEnvVarReferenced(host) {
+		return
+	}
+
+	container, ok := c.getClickHouseContainer(statefulSet)
+	if !ok {
+		return
+	}
+
+	container.Env = append(container.Env, corev1.EnvVar{
+		Name:  hostOrdinalEnvVarName,
+		Value: strconv.Itoa(CreateHostOrdinal(host)),
+	})
+}
+
+// setupDefaultUserProfileEnvVar adds to ClickHouse container env var with default user's profile, which is read-only
+// on hosts of standby clusters and the one specified by users section on other hosts.
+// Env var is added only in case installation has standby clusters
+func (c *Creator) setupDefaultUserProfileEnvVar(statefulSet *apps.StatefulSet, host *chiv1.ChiHost) {
+	if !c.chi.Spec.Configuration.HasStandbyClusters() {
+		return
+	}
+
+	container, ok := c.getClickHouseContainer(statefulSet)
+	if !ok {
+		return
+	}
+
+	profile := defaultUserProfile
+	if setting, ok := c.chi.Spec.Configuration.Users["default/profile"]; ok {
+		profile = setting.String()
+	}
+	if host.GetCluster().IsStandby() {
+		profile = standbyDefaultUserProfile
+	}
+	container.Env = append(container.Env, corev1.EnvVar{
+		Name:  defaultUserProfileEnvVarName,
+		Value: profile,
+	})
+}
+
+// isHostOrdinalEnvVarReferenced checks whether common or host's settings or files refer to host ordinal env var, say via from_env
+func (c *Creator) isHostOrdinalEnvVarReferenced(host *chiv1.ChiHost) bool {
+	for _, settings := range []chiv1.Settings{
+		c.chi.Spec.Configuration.Settings,
+		c.chi.Spec.Configuration.Files,
+		host.Settings,
+		host.Files,
+	} {
+		for _, setting := range settings {
+			if strings.Contains(setting.String(), hostOrdinalEnvVarName) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// setupContainerDefaults applies .spec.defaults.container to ClickHouse container.
+// Image, pull policy and secrets, working dir and HOME env var explicitly specified in Pod Template are left untouched
+func (c *Creator) setupContainerDefaults(statefulSet *apps.StatefulSet) {
+	container, ok := c.getClickHouseContainer(statefulSet)
+	if !ok {
+		return
+	}
+
+	defaults := &c.chi.Spec.Defaults.Container
+	if container.Image == "" {
+		container.Image = defaults.Image
+	}
+	if container.ImagePullPolicy == "" {
+		container.ImagePullPolicy = defaults.ImagePullPolicy
+	}
+	// Pull secrets belong to pod, not to container
+	podSpec := &statefulSet.Spec.Template.Spec
+	if len(podSpec.ImagePullSecrets) == 0 {
+		podSpec.ImagePullSecrets = append(podSpec.ImagePullSecrets, defaults.ImagePullSecrets...)
+	}
+	if (defaults.WorkingDir != "") && (container.WorkingDir == "") {
+		container.WorkingDir = defaults.WorkingDir
+	}
+	if util.IsStringBoolTrue(defaults.SysResource) {
+		ensureContainerCapability(container, capabilitySysResource)
+	}
+
+	if defaults.Home == "" {
+		return
+	}
+	for i := range container.Env {
+		if container.Env[i].Name == homeEnvVarName {
+			// HOME is specified explicitly
+			return
+		}
+	}
+	container.Env = append(container.Env, corev1.EnvVar{
+		Name:  homeEnvVarName,
+		Value: defaults.Home,
+	})
+}
+
+// setupContainerDefaultsEnvVars adds .spec.defaults.container.env to ClickHouse container.
+// Env vars specified in Pod Template or provided by operator are left untouched
+func (c *Creator) setupContainerDefaultsEnvVars(statefulSet *apps.StatefulSet) {
+	container, ok := c.getClickHouseContainer(statefulSet)
+	if !ok {
+		return
+	}
+
+	for i := range c.chi.Spec.Defaults.Container.Env {
+		envVar := &c.chi.Spec.Defaults.Container.Env[i]
+		if hasEnvVar(container, envVar.Name) {
+			continue
+		}
+		container.Env = append(container.Env, *envVar.DeepCopy())
+	}
+}
+
+// hasEnvVar checks whether container has env var with specified name
+func hasEnvVar(container *corev1.Container, name string) bool {
+	for i := range container.Env {
+		if container.Env[i].Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ensureContainerCapability adds capability to security context of the container, unless it is added already
+func ensureContainerCapability(container *corev1.Container, capability corev1.Capability) {
+	if container.SecurityContext == nil {
+		container.SecurityContext = &corev1.SecurityContext{}
+	}
+	if container.SecurityContext.Capabilities == nil {
+		container.SecurityContext.Capabilities = &corev1.Capabilities{}
+	}
+	for _, added := range container.SecurityContext.Capabilities.Add {
+		if added == capability {
+			return
+		}
+	}
+	container.SecurityContext.Capabilities.Add = append(container.SecurityContext.Capabilities.Add, capability)
+}
+
+// setupProbes provides ClickHouse container with /ping readiness and liveness probes, in case they are omitted in pod template.
+// Probes timings are specified by .spec.defaults.readinessProbe and .spec.defaults.livenessProbe
+func (c *Creator) setupProbes(statefulSet *apps.StatefulSet) {
+	container, ok := c.getClickHouseContainer(statefulSet)
+	if !ok {
+		return
+	}
+
+	if container.ReadinessProbe == nil {
+		p := &c.chi.Spec.Defaults.ReadinessProbe
+		container.ReadinessProbe = newPingProbe(p.InitialDelaySeconds, p.PeriodSeconds)
+	}
+	if (container.LivenessProbe == nil) && c.chi.Spec.Defaults.LivenessProbe.IsEnabled() {
+		p := &c.chi.Spec.Defaults.LivenessProbe
+		container.LivenessProbe = newPingProbe(p.InitialDelaySeconds, p.PeriodSeconds)
+		container.LivenessProbe.FailureThreshold = livenessProbeFailureThreshold
+	}
+}
+
+// newPingProbe returns probe of ClickHouse /ping HTTP handler with specified timings.
+// Probe refers to HTTP port by name, so it follows host's HTTP port
+func newPingProbe(initialDelaySeconds, periodSeconds string) *corev1.Probe {
+	probe := &corev1.Probe{
+		Handler: corev1.Handler{
+			HTTPGet: &corev1.HTTPGetAction{
+				Path: "/ping",
+				Port: intstr.FromString(chDefaultHTTPPortName),
+			},
+		},
+	}
+	if seconds, err := strconv.Atoi(initialDelaySeconds); err == nil {
+		probe.InitialDelaySeconds = int32(seconds)
+	}
+	if seconds, err := strconv.Atoi(periodSeconds); err == nil {
+		probe.PeriodSeconds = int32(seconds)
+	}
+	return probe
+}
+
+// setupReadinessProbe makes ClickHouse container readiness probe target /replicas_status in case it is requested,
+// so lagging replica is marked not-ready and removed from services. Custom (not /ping) probes are left untouched
+func (c *Creator) setupReadinessProbe(statefulSet *apps.StatefulSet) {
+	if c.chi.Spec.Defaults.ReadinessProbe.Mode != readinessProbeModeReplicasStatus {
+		return
+	}
+
+	container, ok := c.getClickHouseContainer(statefulSet)
+	if !ok {
+		return
+	}
+
+	// Probe omitted in pod template is already set up as /ping one
+	probe := container.ReadinessProbe
+	if (probe == nil) || (probe.HTTPGet == nil) || (probe.HTTPGet.Path != "/ping") {
+		// Custom probe
+		return
+	}
+
+	probe.Handler = corev1.Handler{
+		HTTPGet: &corev1.HTTPGetAction{
+			Path: "/replicas_status",
+			Port: intstr.FromString(chDefaultHTTPPortName),
+		},
+	}
+	container.ReadinessProbe = probe
+}
+
+// setupFlushLogsOnShutdown adds to ClickHouse container preStop hook, which flushes buffered system logs rows
+// into tables via host's TCP port, so they are not lost on pod termination. Custom preStop hook is left untouched.
+// Hook connects as default user with password from env var, in case it is provided by userPasswordSecrets,
+// and via secure TCP port in case TLS is enabled
+func (c *Creator) setupFlushLogsOnShutdown(statefulSet *apps.StatefulSet, host *chiv1.ChiHost) {
+	if !c.chi.Spec.Configuration.SystemLogs.IsFlushOnShutdown() {
+		return
+	}
+
+	container, ok := c.getClickHouseContainer(statefulSet)
+	if !ok {
+		return
+	}
+
+	if container.Lifecycle == nil {
+		container.Lifecycle = &corev1.Lifecycle{}
+	}
+	if container.Lifecycle.PreStop != nil {
+		// Custom hook
+		return
+	}
+
+	client := []string{
+		"clickhouse-client",
+		"--user",
+		flushLogsOnShutdownUser,
+	}
+	if c.chi.Spec.Configuration.TLS.IsEnabled() {
+		client = append(client, "--secure", "--port", strconv.Itoa(int(chDefaultTCPPortSecureNumber)))
+	} else {
+		client = append(client, "--port", strconv.Itoa(int(host.TCPPort)))
+	}
+	if _, ok := c.chi.Spec.Configuration.UserPasswordSecrets[flushLogsOnShutdownUser]; ok {
+		// Exec hook is not a subject of env vars expansion, so shell expands env var injected by setupUserPasswordEnvVars
+		client = append(client, "--password", "\"$"+createUserPasswordEnvVarName(flushLogsOnShutdownUser)+"\"")
+	}
+	client = append(client, "--query", "'SYSTEM FLUSH LOGS'")
+
+	container.Lifecycle.PreStop = &corev1.Handler{
+		Exec: &corev1.ExecAction{
+			Command: []string{
+				"/bin/sh",
+				"-c",
+				strings.Join(client, " "),
+			},
+		},
+	}
 }
 
-func ensureClickHouseContainer(statefulSet *apps.StatefulSet, _ *chiv1.ChiHost) {
-	if _, ok := getClickHouseContainer(statefulSet); !ok {
-		// No ClickHouse container available
-		addContainer(
-			&statefulSet.Spec.Template.Spec,
-			newDefaultClickHouseContainer(),
-		)
+// setupMonitoringPasswordEnvVar adds to ClickHouse container env var with monitoring user's password taken from Secret
+func (c *Creator) setupMonitoringPasswordEnvVar(statefulSet *apps.StatefulSet) {
+	monitoring := &c.chi.Spec.Configuration.Monitoring
+	if !monitoring.IsEnabled() || !monitoring.HasPasswordSecret() {
+		return
 	}
-}
 
-func (c *Creator) personalizeStatefulSetTemplate(statefulSet *apps.StatefulSet, host *chiv1.ChiHost) {
-	statefulSetName := CreateStatefulSetName(host)
+	container, ok := c.getClickHouseContainer(statefulSet)
+	if !ok {
+		return
+	}
 
-	// Ensure pod created by this StatefulSet has alias 127.0.0.1
-	statefulSet.Spec.Template.Spec.HostAliases = []corev1.HostAlias{
-		{
-			IP:        "127.0.0.1",
-			Hostnames: []string{CreatePodHostname(host)},
+	container.Env = append(container.Env, corev1.EnvVar{
+		Name: monitoringPasswordEnvVarName,
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: monitoring.PasswordSecret.DeepCopy(),
 		},
+	})
+}
+
+// setupUserPasswordEnvVars adds to ClickHouse container env vars with passwords of users taken from Secrets,
+// referenced by generated users config
+func (c *Creator) setupUserPasswordEnvVars(statefulSet *apps.StatefulSet) {
+	secrets := c.chi.Spec.Configuration.UserPasswordSecrets
+	if len(secrets) == 0 {
+		return
 	}
 
-	// Setup volumes based on ConfigMaps into Pod Template
-	c.setupConfigMapVolumes(statefulSet, host)
+	container, ok := c.getClickHouseContainer(statefulSet)
+	if !ok {
+		return
+	}
 
-	// In case we have default LogVolumeClaimTemplate specified - need to append log container to Pod Template
-	if host.Templates.LogVolumeClaimTemplate != "" {
-		addContainer(&statefulSet.Spec.Template.Spec, corev1.Container{
-			Name:  ClickHouseLogContainerName,
-			Image: defaultBusyBoxDockerImage,
-			Command: []string{
-				"/bin/sh", "-c", "--",
-			},
-			Args: []string{
-				"while true; do sleep 30; done;",
+	for _, username := range c.chi.Spec.Configuration.GetUserPasswordSecretsUsernames() {
+		secret := secrets[username]
+		container.Env = append(container.Env, corev1.EnvVar{
+			Name: createUserPasswordEnvVarName(username),
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: secret.DeepCopy(),
 			},
 		})
-		log.V(1).Infof("setupStatefulSetPodTemplate() add log container for statefulSet %s", statefulSetName)
 	}
 }
 
-// getPodTemplate gets Pod Template to be used to create StatefulSet
-func (c *Creator) getPodTemplate(host *chiv1.ChiHost) *chiv1.ChiPodTemplate {
-	statefulSetName := CreateStatefulSetName(host)
+// setupExporterSidecar adds exporter sidecar container, which scrapes ClickHouse on localhost as monitoring user.
+// Exporter container explicitly specified in pod template is left untouched
+func (c *Creator) setupExporterSidecar(statefulSet *apps.StatefulSet, host *chiv1.ChiHost) {
+	monitoring := &c.chi.Spec.Configuration.Monitoring
+	sidecar := &monitoring.ExporterSidecar
+	if !sidecar.IsEnabled() {
+		return
+	}
+	if getContainerByName(statefulSet, exporterSidecarContainerName) != nil {
+		return
+	}
 
-	// Which pod template would be used - either explicitly defined in or a default one
-	podTemplate, ok := host.GetPodTemplate()
-	if ok {
-		// Host references known PodTemplate
-		// Make local copy of this PodTemplate, in order not to spoil the original common-used template
-		podTemplate = podTemplate.DeepCopy()
-		log.V(1).Infof("getPodTemplate() statefulSet %s use custom template %s", statefulSetName, podTemplate.Name)
-	} else {
-		// Host references UNKNOWN PodTemplate, will use default one
-		podTemplate = newDefaultPodTemplate(statefulSetName)
-		log.V(1).Infof("getPodTemplate() statefulSet %s use default generated template", statefulSetName)
+	port, _ := strconv.Atoi(sidecar.Port)
+	container := corev1.Container{
+		Name:  exporterSidecarContainerName,
+		Image: sidecar.Image,
+		Args: []string{
+			"-scrape_uri=$(" + exporterScrapeURIEnvVarName + ")",
+			"-telemetry.address=$(" + exporterTelemetryAddressEnvVarName + ")",
+		},
+		EnvFrom: []corev1.EnvFromSource{
+			{
+				ConfigMapRef: &corev1.ConfigMapEnvSource{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: CreateConfigMapExporterName(c.chi),
+					},
+				},
+			},
+		},
+		Env: []corev1.EnvVar{
+			{
+				// HTTP port may differ between hosts
+				Name:  exporterScrapeURIEnvVarName,
+				Value: fmt.Sprintf("http://localhost:%d/", host.HTTPPort),
+			},
+		},
+		Ports: []corev1.ContainerPort{
+			{
+				Name:          exporterSidecarPortName,
+				ContainerPort: int32(port),
+			},
+		},
+	}
+	if monitoring.HasPasswordSecret() {
+		container.Env = append(container.Env, corev1.EnvVar{
+			Name: exporterPasswordEnvVarName,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: monitoring.PasswordSecret.DeepCopy(),
+			},
+		})
 	}
+	addContainer(&statefulSet.Spec.Template.Spec, container)
+}
 
-	// Here we have local copy of Pod Template, to be used to create StatefulSet
-	// Now we can customize this Pod Template for particular host
+// setupKafkaSASLEnvVars adds to ClickHouse container env vars with Kafka SASL credentials taken from Secrets
+func (c *Creator) setupKafkaSASLEnvVars(statefulSet *apps.StatefulSet) {
+	kafka := &c.chi.Spec.Configuration.Kafka
+	if !kafka.HasSASLUsernameSecret() && !kafka.HasSASLPasswordSecret() {
+		return
+	}
 
-	c.labeler.prepareAffinity(podTemplate, host)
+	container, ok := c.getClickHouseContainer(statefulSet)
+	if !ok {
+		return
+	}
 
-	return podTemplate
+	if kafka.HasSASLUsernameSecret() {
+		container.Env = append(container.Env, corev1.EnvVar{
+			Name: kafkaSASLUsernameEnvVarName,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: kafka.SASLUsernameSecret.DeepCopy(),
+			},
+		})
+	}
+	if kafka.HasSASLPasswordSecret() {
+		container.Env = append(container.Env, corev1.EnvVar{
+			Name: kafkaSASLPasswordEnvVarName,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: kafka.SASLPasswordSecret.DeepCopy(),
+			},
+		})
+	}
 }
 
-// setupConfigMapVolumes adds to each container in the Pod VolumeMount objects with
-func (c *Creator) setupConfigMapVolumes(statefulSetObject *apps.StatefulSet, host *chiv1.ChiHost) {
-	configMapMacrosName := CreateConfigMapPodName(host)
-	configMapCommonName := CreateConfigMapCommonName(c.chi)
-	configMapCommonUsersName := CreateConfigMapCommonUsersName(c.chi)
-
-	// Add all ConfigMap objects as Volume objects of type ConfigMap
-	statefulSetObject.Spec.Template.Spec.Volumes = append(
-		statefulSetObject.Spec.Template.Spec.Volumes,
-		newVolumeForConfigMap(configMapCommonName),
-		newVolumeForConfigMap(configMapCommonUsersName),
-		newVolumeForConfigMap(configMapMacrosName),
-	)
+// setupInterserverCredentialsEnvVars provides interserver credentials from Secrets to ClickHouse container via env vars,
+// referenced by interserver_http_credentials config
+func (c *Creator) setupInterserverCredentialsEnvVars(statefulSet *apps.StatefulSet) {
+	credentials := &c.chi.Spec.Configuration.InterserverCredentials
+	if !credentials.IsConfigured() {
+		return
+	}
 
-	// And reference these Volumes in each Container via VolumeMount
-	// So Pod will have ConfigMaps mounted as Volumes
-	for i := range statefulSetObject.Spec.Template.Spec.Containers {
-		// Convenience wrapper
-		container := &statefulSetObject.Spec.Template.Spec.Containers[i]
-		// Append to each Container current VolumeMount's to VolumeMount's declared in template
-		container.VolumeMounts = append(
-			container.VolumeMounts,
-			newVolumeMount(configMapCommonName, dirPathCommonConfig),
-			newVolumeMount(configMapCommonUsersName, dirPathUsersConfig),
-			newVolumeMount(configMapMacrosName, dirPathHostConfig),
-		)
+	container, ok := c.getClickHouseContainer(statefulSet)
+	if !ok {
+		return
 	}
+
+	container.Env = append(container.Env,
+		corev1.EnvVar{
+			Name: interserverUserEnvVarName,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: credentials.UserSecret.DeepCopy(),
+			},
+		},
+		corev1.EnvVar{
+			Name: interserverPasswordEnvVarName,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: credentials.PasswordSecret.DeepCopy(),
+			},
+		},
+	)
 }
 
 // setupStatefulSetApplyVolumeMounts applies `volumeMounts` of a `container`
@@ -469,6 +1721,7 @@ func (c *Creator) setupStatefulSetApplyVolumeMounts(statefulSet *apps.StatefulSe
 }
 
 // setupStatefulSetApplyVolumeClaimTemplates applies Data and Log VolumeClaimTemplates on all containers
+// and extra VolumeClaimTemplates on ClickHouse container
 func (c *Creator) setupStatefulSetApplyVolumeClaimTemplates(statefulSet *apps.StatefulSet, host *chiv1.ChiHost) {
 	// Mount all named (data and log so far) VolumeClaimTemplates into all containers
 	for i := range statefulSet.Spec.Template.Spec.Containers {
@@ -477,12 +1730,53 @@ func (c *Creator) setupStatefulSetApplyVolumeClaimTemplates(statefulSet *apps.St
 		_ = c.setupStatefulSetApplyVolumeMount(host, statefulSet, container.Name, newVolumeMount(host.Templates.DataVolumeClaimTemplate, dirPathClickHouseData))
 		_ = c.setupStatefulSetApplyVolumeMount(host, statefulSet, container.Name, newVolumeMount(host.Templates.LogVolumeClaimTemplate, dirPathClickHouseLog))
 	}
+
+	// Mount extra VolumeClaimTemplates into ClickHouse container only
+	container, ok := c.getClickHouseContainer(statefulSet)
+	if !ok {
+		return
+	}
+	for _, mount := range host.Templates.ExtraVolumeClaimTemplates {
+		_ = c.setupStatefulSetApplyVolumeMount(host, statefulSet, container.Name, newVolumeMount(mount.Name, mount.MountPath))
+	}
 }
 
 // setupStatefulSetVolumeClaimTemplates performs VolumeClaimTemplate setup for Containers in PodTemplate of a StatefulSet
 func (c *Creator) setupStatefulSetVolumeClaimTemplates(statefulSet *apps.StatefulSet, host *chiv1.ChiHost) {
 	c.setupStatefulSetApplyVolumeMounts(statefulSet, host)
 	c.setupStatefulSetApplyVolumeClaimTemplates(statefulSet, host)
+	c.setupStatefulSetFilesystemCacheVolume(statefulSet, host)
+}
+
+// setupStatefulSetFilesystemCacheVolume mounts volume for filesystem cache in case it is requested by
+// .spec.configuration.filesystemCache. VolumeClaimTemplate is used if specified, emptyDir otherwise
+func (c *Creator) setupStatefulSetFilesystemCacheVolume(statefulSet *apps.StatefulSet, host *chiv1.ChiHost) {
+	cache := &c.chi.Spec.Configuration.FilesystemCache
+	if !cache.IsEnabled() {
+		return
+	}
+
+	container, ok := c.getClickHouseContainer(statefulSet)
+	if !ok {
+		return
+	}
+
+	if _, ok := c.chi.GetVolumeClaimTemplate(cache.VolumeClaimTemplate); ok {
+		_ = c.setupStatefulSetApplyVolumeMount(host, statefulSet, container.Name, newVolumeMount(cache.VolumeClaimTemplate, dirPathClickHouseFilesystemCache))
+		return
+	}
+
+	if cache.VolumeClaimTemplate != "" {
+		log.V(1).Infof("Can not find volumeClaimTemplate %s for filesystem cache. Use emptyDir", cache.VolumeClaimTemplate)
+	}
+	statefulSet.Spec.Template.Spec.Volumes = append(
+		statefulSet.Spec.Template.Spec.Volumes,
+		newVolumeForFilesystemCache(),
+	)
+	container.VolumeMounts = append(
+		container.VolumeMounts,
+		newVolumeMount(filesystemCacheVolumeName, dirPathClickHouseFilesystemCache),
+	)
 }
 
 // statefulSetApplyPodTemplate fills StatefulSet.Spec.Template with data from provided 'src' ChiPodTemplate
@@ -492,7 +1786,20 @@ func statefulSetApplyPodTemplate(dst *apps.StatefulSet, template *chiv1.ChiPodTe
 	dst.Spec.Template.Spec = template.Spec
 }
 
-func getClickHouseContainer(statefulSet *apps.StatefulSet) (*corev1.Container, bool) {
+// getClickHouseContainer finds ClickHouse container, named as specified by .spec.defaults.container.name
+func (c *Creator) getClickHouseContainer(statefulSet *apps.StatefulSet) (*corev1.Container, bool) {
+	return findClickHouseContainer(statefulSet, c.chi.Spec.Defaults.Container.Name)
+}
+
+// findClickHouseContainer finds ClickHouse container. Container with specified name is preferred,
+// otherwise the first container is considered to be ClickHouse container
+func findClickHouseContainer(statefulSet *apps.StatefulSet, name string) (*corev1.Container, bool) {
+	if name == "" {
+		name = ClickHouseContainerName
+	}
+	if container := getContainerByName(statefulSet, name); container != nil {
+		return container, true
+	}
 	if len(statefulSet.Spec.Template.Spec.Containers) > 0 {
 		return &statefulSet.Spec.Template.Spec.Containers[0], true
 	} else {
@@ -502,13 +1809,21 @@ func getClickHouseContainer(statefulSet *apps.StatefulSet) (*corev1.Container, b
 
 func ensureNamedPortsSpecified(statefulSet *apps.StatefulSet, host *chiv1.ChiHost) {
 	// Ensure ClickHouse container has all named ports specified
-	chContainer, ok := getClickHouseContainer(statefulSet)
+	chContainer, ok := findClickHouseContainer(statefulSet, host.CHI.Spec.Defaults.Container.Name)
 	if !ok {
 		return
 	}
 	ensurePortByName(chContainer, chDefaultTCPPortName, host.TCPPort)
 	ensurePortByName(chContainer, chDefaultHTTPPortName, host.HTTPPort)
 	ensurePortByName(chContainer, chDefaultInterserverHTTPPortName, host.InterserverHTTPPort)
+	if host.CHI.Spec.Configuration.TLS.IsEnabled() {
+		ensurePortByName(chContainer, chDefaultTCPPortSecureName, chDefaultTCPPortSecureNumber)
+		ensurePortByName(chContainer, chDefaultHTTPSPortName, chDefaultHTTPSPortNumber)
+	}
+	if prometheus := &host.CHI.Spec.Configuration.Monitoring.Prometheus; prometheus.IsEnabled() {
+		port, _ := strconv.Atoi(prometheus.Port)
+		ensurePortByName(chContainer, prometheusPortName, int32(port))
+	}
 }
 
 func ensurePortByName(container *corev1.Container, name string, port int32) {
@@ -609,6 +1924,9 @@ func (c *Creator) setupStatefulSetApplyVolumeMount(
 	if template, ok := c.chi.GetVolumeClaimTemplate(volumeClaimTemplateName); ok {
 		// Add VolumeClaimTemplate to StatefulSet
 		c.statefulSetAppendPVCTemplate(host, statefulSet, template)
+		// Apply mount options specified in VolumeClaimTemplate
+		volumeMount.ReadOnly = template.ReadOnly
+		volumeMount.MountPropagation = template.MountPropagation
 		// Add VolumeMount to ClickHouse container to `mountPath` point
 		container.VolumeMounts = append(
 			container.VolumeMounts,
@@ -632,6 +1950,12 @@ func (c *Creator) statefulSetAppendPVCTemplate(
 	statefulSet *apps.StatefulSet,
 	volumeClaimTemplate *chiv1.ChiVolumeClaimTemplate,
 ) {
+	if volumeClaimTemplate.IsEphemeral() {
+		// Ephemeral storage is provided by pod's emptyDir volume, no PVC is claimed
+		c.statefulSetAppendEmptyDirVolume(host, statefulSet, volumeClaimTemplate)
+		return
+	}
+
 	// Ensure VolumeClaimTemplates slice is in place
 	if statefulSet.Spec.VolumeClaimTemplates == nil {
 		statefulSet.Spec.VolumeClaimTemplates = make([]corev1.PersistentVolumeClaim, 0, 0)
@@ -670,10 +1994,57 @@ func (c *Creator) statefulSetAppendPVCTemplate(
 	volumeMode := corev1.PersistentVolumeFilesystem
 	persistentVolumeClaim.Spec.VolumeMode = &volumeMode
 
+	persistentVolumeClaim.Spec.Resources.Requests = CreatePVCResourceRequests(host, volumeClaimTemplate)
+
 	// Append copy of PersistentVolumeClaimSpec
 	statefulSet.Spec.VolumeClaimTemplates = append(statefulSet.Spec.VolumeClaimTemplates, persistentVolumeClaim)
 }
 
+// CreatePVCResourceRequests creates resource requests of host's PVC claimed by VolumeClaimTemplate.
+// Data volume size may be overridden per-shard/host, while the rest of VolumeClaimTemplate is shared
+func CreatePVCResourceRequests(host *chiv1.ChiHost, volumeClaimTemplate *chiv1.ChiVolumeClaimTemplate) corev1.ResourceList {
+	requests := volumeClaimTemplate.Spec.Resources.Requests.DeepCopy()
+	if (volumeClaimTemplate.Name == host.Templates.DataVolumeClaimTemplate) && (host.DataVolumeSize != "") {
+		if requests == nil {
+			requests = corev1.ResourceList{}
+		}
+		requests[corev1.ResourceStorage] = resource.MustParse(host.DataVolumeSize)
+	}
+	return requests
+}
+
+// statefulSetAppendEmptyDirVolume appends to StatefulSet's pod volumes emptyDir volume made from provided ephemeral ChiVolumeClaimTemplate
+func (c *Creator) statefulSetAppendEmptyDirVolume(
+	host *chiv1.ChiHost,
+	statefulSet *apps.StatefulSet,
+	volumeClaimTemplate *chiv1.ChiVolumeClaimTemplate,
+) {
+	// Check whether this volume is already listed in pod's volumes
+	for i := range statefulSet.Spec.Template.Spec.Volumes {
+		if statefulSet.Spec.Template.Spec.Volumes[i].Name == volumeClaimTemplate.Name {
+			return
+		}
+	}
+
+	emptyDir := volumeClaimTemplate.EmptyDir.DeepCopy()
+
+	// Data volume size may be overridden per-shard/host, it limits emptyDir size in this case
+	if (volumeClaimTemplate.Name == host.Templates.DataVolumeClaimTemplate) && (host.DataVolumeSize != "") {
+		sizeLimit := resource.MustParse(host.DataVolumeSize)
+		emptyDir.SizeLimit = &sizeLimit
+	}
+
+	statefulSet.Spec.Template.Spec.Volumes = append(
+		statefulSet.Spec.Template.Spec.Volumes,
+		corev1.Volume{
+			Name: volumeClaimTemplate.Name,
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: emptyDir,
+			},
+		},
+	)
+}
+
 // newDefaultHostTemplate returns default Host Template to be used with StatefulSet
 func newDefaultHostTemplate(name string) *chiv1.ChiHostTemplate {
 	return &chiv1.ChiHostTemplate{
@@ -732,8 +2103,7 @@ func newDefaultPodTemplate(name string) *chiv1.ChiPodTemplate {
 // newDefaultClickHouseContainer returns default ClickHouse Container
 func newDefaultClickHouseContainer() corev1.Container {
 	return corev1.Container{
-		Name:  ClickHouseContainerName,
-		Image: defaultClickHouseDockerImage,
+		Name: ClickHouseContainerName,
 		Ports: []corev1.ContainerPort{
 			{
 				Name:          chDefaultHTTPPortName,
@@ -748,16 +2118,6 @@ func newDefaultClickHouseContainer() corev1.Container {
 				ContainerPort: chDefaultInterserverHTTPPortNumber,
 			},
 		},
-		ReadinessProbe: &corev1.Probe{
-			Handler: corev1.Handler{
-				HTTPGet: &corev1.HTTPGetAction{
-					Path: "/ping",
-					Port: intstr.Parse(chDefaultHTTPPortName),
-				},
-			},
-			InitialDelaySeconds: 10,
-			PeriodSeconds:       10,
-		},
 	}
 }
 
@@ -782,6 +2142,164 @@ func newVolumeForConfigMap(name string) corev1.Volume {
 	}
 }
 
+// newVolumeForUsersConfigMaps returns corev1.Volume object with users ConfigMap. In case users override ConfigMap is specified,
+// both ConfigMaps are projected into the same volume, so files of override ConfigMap are placed into users.d along with
+// generated ones and win over them. Override ConfigMap is optional, so pods are started even in case it does not exist
+func newVolumeForUsersConfigMaps(name, override string) corev1.Volume {
+	if override == "" {
+		return newVolumeForConfigMap(name)
+	}
+
+	var defaultMode int32 = 0644
+	optional := true
+	return corev1.Volume{
+		Name: name,
+		VolumeSource: corev1.VolumeSource{
+			Projected: &corev1.ProjectedVolumeSource{
+				Sources: []corev1.VolumeProjection{
+					{
+						ConfigMap: &corev1.ConfigMapProjection{
+							LocalObjectReference: corev1.LocalObjectReference{
+								Name: name,
+							},
+						},
+					},
+					{
+						ConfigMap: &corev1.ConfigMapProjection{
+							LocalObjectReference: corev1.LocalObjectReference{
+								Name: override,
+							},
+							Optional: &optional,
+						},
+					},
+				},
+				DefaultMode: &defaultMode,
+			},
+		},
+	}
+}
+
+// newVolumeForUserDefinedFunctions returns corev1.Volume object with user defined functions from ConfigMap or Secret.
+// Scripts have to be executable
+func newVolumeForUserDefinedFunctions(udf *chiv1.ChiUserDefinedFunctions) corev1.Volume {
+	var defaultMode int32 = 0755
+	volume := corev1.Volume{
+		Name: userDefinedFunctionsVolumeName,
+	}
+	if udf.ConfigMap != "" {
+		volume.VolumeSource = corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: udf.ConfigMap,
+				},
+				DefaultMode: &defaultMode,
+			},
+		}
+	} else {
+		volume.VolumeSource = corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName:  udf.Secret,
+				DefaultMode: &defaultMode,
+			},
+		}
+	}
+	return volume
+}
+
+// newVolumeForZookeeperTLS returns corev1.Volume object with ZooKeeper client certificates from Secret
+func newVolumeForZookeeperTLS(tls *chiv1.ChiZookeeperTLS) corev1.Volume {
+	return corev1.Volume{
+		Name: zookeeperTLSVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName: tls.Secret,
+			},
+		},
+	}
+}
+
+// newVolumeForTLS returns corev1.Volume object with server certificates from Secret
+func newVolumeForTLS(tls *chiv1.ChiTLS) corev1.Volume {
+	return corev1.Volume{
+		Name: tlsVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName: tls.Secret,
+			},
+		},
+	}
+}
+
+// newVolumeForFormatSchemas returns corev1.Volume object with format schema files from ConfigMap or Secret
+func newVolumeForFormatSchemas(schemas *chiv1.ChiFormatSchemas) corev1.Volume {
+	volume := corev1.Volume{
+		Name: formatSchemasVolumeName,
+	}
+	if schemas.ConfigMap != "" {
+		volume.VolumeSource = corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: schemas.ConfigMap,
+				},
+			},
+		}
+	} else {
+		volume.VolumeSource = corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName: schemas.Secret,
+			},
+		}
+	}
+	return volume
+}
+
+// newVolumeForShm returns corev1.Volume object with in-memory emptyDir for /dev/shm
+func newVolumeForShm(shm *chiv1.ChiShmVolume) corev1.Volume {
+	emptyDir := &corev1.EmptyDirVolumeSource{
+		Medium: corev1.StorageMediumMemory,
+	}
+	if shm.SizeLimit != "" {
+		// Size limit is validated by normalizer
+		sizeLimit := resource.MustParse(shm.SizeLimit)
+		emptyDir.SizeLimit = &sizeLimit
+	}
+	return corev1.Volume{
+		Name: shmVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			EmptyDir: emptyDir,
+		},
+	}
+}
+
+// newVolumeForTmp returns corev1.Volume object with emptyDir for ClickHouse tmp_path
+func newVolumeForTmp(tmp *chiv1.ChiTmpVolume) corev1.Volume {
+	emptyDir := &corev1.EmptyDirVolumeSource{}
+	if tmp.Medium == chiv1.TmpVolumeMediumMemory {
+		emptyDir.Medium = corev1.StorageMediumMemory
+	}
+	if tmp.SizeLimit != "" {
+		// Size limit is validated by normalizer
+		sizeLimit := resource.MustParse(tmp.SizeLimit)
+		emptyDir.SizeLimit = &sizeLimit
+	}
+	return corev1.Volume{
+		Name: tmpVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			EmptyDir: emptyDir,
+		},
+	}
+}
+
+// newVolumeForFilesystemCache returns corev1.Volume object with emptyDir for ClickHouse filesystem cache
+func newVolumeForFilesystemCache() corev1.Volume {
+	return corev1.Volume{
+		Name: filesystemCacheVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			EmptyDir: &corev1.EmptyDirVolumeSource{},
+		},
+	}
+}
+
 // newVolumeMount returns corev1.VolumeMount object with name and mount path
 func newVolumeMount(name, mountPath string) corev1.VolumeMount {
 	return corev1.VolumeMount{
@@ -790,6 +2308,13 @@ func newVolumeMount(name, mountPath string) corev1.VolumeMount {
 	}
 }
 
+// newReadOnlyVolumeMount returns read-only corev1.VolumeMount object with name and mount path
+func newReadOnlyVolumeMount(name, mountPath string) corev1.VolumeMount {
+	volumeMount := newVolumeMount(name, mountPath)
+	volumeMount.ReadOnly = true
+	return volumeMount
+}
+
 // getContainerByName finds Container with specified name among all containers of Pod Template in StatefulSet
 func getContainerByName(statefulSet *apps.StatefulSet, name string) *corev1.Container {
 	for i := range statefulSet.Spec.Template.Spec.Containers {
@@ -0,0 +1,107 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"runtime"
+	"sync"
+
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	chiv1 "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+)
+
+// HostObjects contains Kubernetes objects generated for a host
+type HostObjects struct {
+	Host        *chiv1.ChiHost
+	ConfigMap   *corev1.ConfigMap
+	StatefulSet *apps.StatefulSet
+	Service     *corev1.Service
+	// HeadlessService governs StatefulSet, nil in case Service is headless itself
+	HeadlessService *corev1.Service
+	// Err is an error of ConfigMap generation
+	Err error
+}
+
+// CreateHostsObjects generates ConfigMap, StatefulSet and Service of each host of the CHI.
+// CHI is expected to be validated by the caller, see ValidateCHI.
+// Hosts are processed by up to `concurrency` workers, GOMAXPROCS in case concurrency is not positive.
+// Each worker writes into its own slot of the result, thus nothing is shared between workers
+// and the result is ordered as hosts are walked, regardless of concurrency
+func (c *Creator) CreateHostsObjects(concurrency int) ([]*HostObjects, error) {
+	var hosts []*chiv1.ChiHost
+	c.chi.WalkHosts(func(host *chiv1.ChiHost) error {
+		hosts = append(hosts, host)
+		return nil
+	})
+
+	if concurrency <= 0 {
+		concurrency = runtime.GOMAXPROCS(0)
+	}
+	if concurrency > len(hosts) {
+		concurrency = len(hosts)
+	}
+
+	result := make([]*HostObjects, len(hosts))
+	indexes := make(chan int)
+	wg := sync.WaitGroup{}
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				result[index] = c.CreateHostObjects(hosts[index])
+			}
+		}()
+	}
+	for index := range hosts {
+		indexes <- index
+	}
+	close(indexes)
+	wg.Wait()
+
+	return result, nil
+}
+
+// CreateHostObjects generates objects of one host
+func (c *Creator) CreateHostObjects(host *chiv1.ChiHost) *HostObjects {
+	objects := &HostObjects{
+		Host: host,
+	}
+	objects.ConfigMap, objects.Err = c.CreateConfigMapHost(host)
+	objects.StatefulSet = c.CreateStatefulSet(host)
+	objects.Service = c.CreateServiceHost(host)
+	objects.HeadlessService = c.CreateServiceHostHeadless(host)
+	return objects
+}
+
+// PreserveStatefulSetVolumeClaimTemplates keeps resource requests of VolumeClaimTemplates of existing StatefulSet,
+// since VolumeClaimTemplates are immutable and StatefulSet would have to be recreated in order to change them.
+// Changed size is applied by resizing existing PVCs instead. cur is nil in case StatefulSet is to be created
+func (c *Creator) PreserveStatefulSetVolumeClaimTemplates(objects *HostObjects, cur *apps.StatefulSet) {
+	if cur == nil {
+		return
+	}
+
+	for i := range objects.StatefulSet.Spec.VolumeClaimTemplates {
+		template := &objects.StatefulSet.Spec.VolumeClaimTemplates[i]
+		for j := range cur.Spec.VolumeClaimTemplates {
+			if curTemplate := &cur.Spec.VolumeClaimTemplates[j]; curTemplate.Name == template.Name {
+				template.Spec.Resources.Requests = curTemplate.Spec.Resources.Requests.DeepCopy()
+			}
+		}
+	}
+}
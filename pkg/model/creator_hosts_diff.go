@@ -0,0 +1,161 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"sort"
+
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ObjectsDiff lists objects, as 'namespace/name', which were added, removed or changed between two generations of objects
+type ObjectsDiff struct {
+	Added   []string
+	Removed []string
+	Changed []string
+}
+
+// IsEmpty checks whether nothing was added, removed or changed
+func (d *ObjectsDiff) IsEmpty() bool {
+	return (len(d.Added) == 0) && (len(d.Removed) == 0) && (len(d.Changed) == 0)
+}
+
+// HostsObjectsDiff lists differences between two generations of host objects of the same CHI, per kind of objects
+type HostsObjectsDiff struct {
+	ConfigMaps   ObjectsDiff
+	StatefulSets ObjectsDiff
+	// Services includes both host Services and headless Services
+	Services ObjectsDiff
+}
+
+// IsEmpty checks whether objects of neither kind differ
+func (d *HostsObjectsDiff) IsEmpty() bool {
+	return d.ConfigMaps.IsEmpty() && d.StatefulSets.IsEmpty() && d.Services.IsEmpty()
+}
+
+// DiffHostsObjects reports ConfigMaps, StatefulSets and Services, which were added, removed or changed
+// between old and new generations of host objects, as produced by CreateHostsObjects, so only those have to be applied.
+// Objects are matched by namespace and name. Only operator-managed fields - labels, annotations, spec and data - are compared,
+// while fields populated by the server, such as resourceVersion, status and cluster-assigned IPs and node ports, are ignored
+func DiffHostsObjects(old, new []*HostObjects) *HostsObjectsDiff {
+	oldConfigMaps, oldStatefulSets, oldServices := collectHostsObjects(old)
+	newConfigMaps, newStatefulSets, newServices := collectHostsObjects(new)
+
+	return &HostsObjectsDiff{
+		ConfigMaps: diffObjects(oldConfigMaps, newConfigMaps, func(old, new interface{}) bool {
+			return equalConfigMaps(old.(*corev1.ConfigMap), new.(*corev1.ConfigMap))
+		}),
+		StatefulSets: diffObjects(oldStatefulSets, newStatefulSets, func(old, new interface{}) bool {
+			return equalStatefulSets(old.(*apps.StatefulSet), new.(*apps.StatefulSet))
+		}),
+		Services: diffObjects(oldServices, newServices, func(old, new interface{}) bool {
+			return equalServices(old.(*corev1.Service), new.(*corev1.Service))
+		}),
+	}
+}
+
+// collectHostsObjects maps objects of each kind by 'namespace/name'
+func collectHostsObjects(hosts []*HostObjects) (configMaps, statefulSets, services map[string]interface{}) {
+	configMaps = make(map[string]interface{})
+	statefulSets = make(map[string]interface{})
+	services = make(map[string]interface{})
+	for _, objects := range hosts {
+		if objects == nil {
+			continue
+		}
+		if objects.ConfigMap != nil {
+			configMaps[objectKey(&objects.ConfigMap.ObjectMeta)] = objects.ConfigMap
+		}
+		if objects.StatefulSet != nil {
+			statefulSets[objectKey(&objects.StatefulSet.ObjectMeta)] = objects.StatefulSet
+		}
+		for _, service := range []*corev1.Service{objects.Service, objects.HeadlessService} {
+			if service != nil {
+				services[objectKey(&service.ObjectMeta)] = service
+			}
+		}
+	}
+	return configMaps, statefulSets, services
+}
+
+// diffObjects compares objects of the same kind, mapped by 'namespace/name'. Result lists are sorted
+func diffObjects(old, new map[string]interface{}, equal func(old, new interface{}) bool) ObjectsDiff {
+	var diff ObjectsDiff
+	for key, newObject := range new {
+		oldObject, ok := old[key]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, key)
+		case !equal(oldObject, newObject):
+			diff.Changed = append(diff.Changed, key)
+		}
+	}
+	for key := range old {
+		if _, ok := new[key]; !ok {
+			diff.Removed = append(diff.Removed, key)
+		}
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Changed)
+	return diff
+}
+
+// objectKey builds 'namespace/name' key of an object
+func objectKey(meta *metav1.ObjectMeta) string {
+	return meta.Namespace + "/" + meta.Name
+}
+
+// equalObjectMeta compares operator-managed fields of object meta
+func equalObjectMeta(old, new *metav1.ObjectMeta) bool {
+	return apiequality.Semantic.DeepEqual(old.Labels, new.Labels) &&
+		apiequality.Semantic.DeepEqual(old.Annotations, new.Annotations)
+}
+
+// equalConfigMaps compares labels, annotations and data of ConfigMaps
+func equalConfigMaps(old, new *corev1.ConfigMap) bool {
+	return equalObjectMeta(&old.ObjectMeta, &new.ObjectMeta) &&
+		apiequality.Semantic.DeepEqual(old.Data, new.Data) &&
+		apiequality.Semantic.DeepEqual(old.BinaryData, new.BinaryData)
+}
+
+// equalStatefulSets compares labels, annotations and spec of StatefulSets
+func equalStatefulSets(old, new *apps.StatefulSet) bool {
+	return equalObjectMeta(&old.ObjectMeta, &new.ObjectMeta) &&
+		apiequality.Semantic.DeepEqual(old.Spec, new.Spec)
+}
+
+// equalServices compares labels, annotations and spec of Services, ignoring IP and node ports assigned by the cluster
+func equalServices(old, new *corev1.Service) bool {
+	return equalObjectMeta(&old.ObjectMeta, &new.ObjectMeta) &&
+		apiequality.Semantic.DeepEqual(managedServiceSpec(old), managedServiceSpec(new))
+}
+
+// managedServiceSpec returns copy of Service spec without fields assigned by the cluster.
+// ClusterIP 'None' of headless Service is specified by operator, thus is kept
+func managedServiceSpec(service *corev1.Service) *corev1.ServiceSpec {
+	spec := service.Spec.DeepCopy()
+	if spec.ClusterIP != corev1.ClusterIPNone {
+		spec.ClusterIP = ""
+	}
+	spec.HealthCheckNodePort = 0
+	for i := range spec.Ports {
+		spec.Ports[i].NodePort = 0
+	}
+	return spec
+}
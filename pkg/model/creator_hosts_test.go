@@ -0,0 +1,286 @@
+package model
+
+import (
+	"fmt"
+	"testing"
+
+	chiv1 "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	"github.com/kubernetes-sigs/yaml"
+	"github.com/stretchr/testify/require"
+	apps "k8s.io/api/apps/v1"
+)
+
+// LargeCHIData describes CHI with 500 deployments
+var LargeCHIData = `
+apiVersion: "clickhouse.altinity.com/v1"
+kind: "ClickHouseInstallation"
+metadata:
+  name: "large"
+  namespace: "kube-system"
+spec:
+  configuration:
+    clusters:
+      - name: "cluster"
+        layout:
+          shardsCount: 100
+          replicasCount: 5
+`
+
+func TestCreateHostsObjectsOrder(t *testing.T) {
+	creator, chi := newTestCreator(t, LargeCHIData)
+
+	var names []string
+	chi.WalkHosts(func(host *chiv1.ChiHost) error {
+		names = append(names, CreateStatefulSetName(host))
+		return nil
+	})
+
+	// Objects have to be ordered as hosts are walked, regardless of concurrency
+	for _, concurrency := range []int{1, 4, 0} {
+		objects, err := creator.CreateHostsObjects(concurrency)
+		require.Nil(t, err, "failed to create objects")
+		require.Equal(t, len(names), len(objects), "unexpected objects count")
+		for i := range objects {
+			require.Nil(t, objects[i].Err, "failed to create ConfigMap")
+			require.Equal(t, names[i], objects[i].StatefulSet.Name, "unexpected objects order")
+			require.Equal(t, CreateConfigMapPodName(objects[i].Host), objects[i].ConfigMap.Name, "unexpected ConfigMap")
+		}
+	}
+}
+
+func BenchmarkCreateHostsObjects(b *testing.B) {
+	creator, _ := newTestCreator(b, LargeCHIData)
+	for _, concurrency := range []int{1, 0} {
+		b.Run(fmt.Sprintf("concurrency=%d", concurrency), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, _ = creator.CreateHostsObjects(concurrency)
+			}
+		})
+	}
+}
+
+var DeterministicCHIData = `
+apiVersion: "clickhouse.altinity.com/v1"
+kind: "ClickHouseInstallation"
+metadata:
+  name: "deterministic"
+  namespace: "kube-system"
+spec:
+  configuration:
+    users:
+      reader/profile: "readonly"
+      reader/networks/ip: ["10.0.0.0/8", "192.168.0.0/16"]
+      writer/password: "secret"
+    profiles:
+      readonly/readonly: 1
+    settings:
+      max_concurrent_queries: 200
+      merge_tree/max_suspicious_broken_parts: 5
+    files:
+      config.d/b.xml: "<yandex></yandex>"
+      config.d/a.xml: "<yandex></yandex>"
+    experimentalFeatures:
+      - profile: "default"
+        features:
+          allow_experimental_object_type: "yes"
+          object_type: "no"
+    clusters:
+      - name: "first"
+        layout:
+          shardsCount: 2
+          replicasCount: 2
+      - name: "second"
+        layout:
+          shardsCount: 3
+`
+
+func TestCreateHostsObjectsDeterministic(t *testing.T) {
+	// Identical input has to produce byte-identical objects and configs
+	generate := func() ([]byte, map[string]string) {
+		configs, err := RenderConfigs(newTestCHOp(), newTestCHI(t, DeterministicCHIData))
+		require.Nil(t, err, "failed to render configs")
+
+		creator, _ := newTestCreator(t, DeterministicCHIData)
+		objects, err := creator.CreateHostsObjects(0)
+		require.Nil(t, err, "failed to create objects")
+		var statefulSets []*apps.StatefulSet
+		for _, object := range objects {
+			statefulSets = append(statefulSets, object.StatefulSet)
+		}
+		out, err := yaml.Marshal(statefulSets)
+		require.Nil(t, err, "failed to marshal objects")
+		return out, configs
+	}
+
+	objects, configs := generate()
+	for i := 0; i < 10; i++ {
+		againObjects, againConfigs := generate()
+		require.Equal(t, string(objects), string(againObjects), "objects differ between runs")
+		require.Equal(t, configs, againConfigs, "configs differ between runs")
+	}
+}
+
+var ReorderedCHIData = []string{`
+apiVersion: "clickhouse.altinity.com/v1"
+kind: "ClickHouseInstallation"
+metadata:
+  name: "reordered"
+  namespace: "kube-system"
+spec:
+  defaults:
+    templates:
+      podTemplate: "second"
+      dataVolumeClaimTemplate: "data"
+      logVolumeClaimTemplate: "log"
+  configuration:
+    zookeeper:
+      nodes:
+        - host: "zookeeper-0"
+        - host: "zookeeper-1"
+          port: 2181
+    clusters:
+      - name: "cluster"
+        layout:
+          shardsCount: 2
+          replicasCount: 2
+  templates:
+    podTemplates:
+      - name: "first"
+        spec:
+          containers:
+            - name: "clickhouse"
+              image: "yandex/clickhouse-server:20.8"
+      - name: "second"
+        spec:
+          containers:
+            - name: "clickhouse"
+              image: "yandex/clickhouse-server:21.3"
+    volumeClaimTemplates:
+      - name: "data"
+        spec:
+          resources:
+            requests:
+              storage: 1Gi
+      - name: "log"
+        spec:
+          resources:
+            requests:
+              storage: 100Mi
+`, `
+apiVersion: "clickhouse.altinity.com/v1"
+kind: "ClickHouseInstallation"
+metadata:
+  name: "reordered"
+  namespace: "kube-system"
+spec:
+  defaults:
+    templates:
+      logVolumeClaimTemplate: "log"
+      dataVolumeClaimTemplate: "data"
+      podTemplate: "second"
+  configuration:
+    zookeeper:
+      nodes:
+        - host: "zookeeper-1"
+        - host: "zookeeper-0"
+          port: 2181
+    clusters:
+      - name: "cluster"
+        layout:
+          shardsCount: 2
+          replicasCount: 2
+  templates:
+    volumeClaimTemplates:
+      - name: "log"
+        spec:
+          resources:
+            requests:
+              storage: 100Mi
+      - name: "data"
+        spec:
+          resources:
+            requests:
+              storage: 1Gi
+    podTemplates:
+      - name: "second"
+        spec:
+          containers:
+            - name: "clickhouse"
+              image: "yandex/clickhouse-server:21.3"
+      - name: "first"
+        spec:
+          containers:
+            - name: "clickhouse"
+              image: "yandex/clickhouse-server:20.8"
+`}
+
+func TestCreateHostsObjectsReorderedSpec(t *testing.T) {
+	// Logically identical specs, which differ in order of templates and ZooKeeper nodes only,
+	// have to produce the same StatefulSets and fingerprints, so nothing is rolled out
+	generate := func(data string) (map[string]string, []byte) {
+		creator, _ := newTestCreator(t, data)
+		objects, err := creator.CreateHostsObjects(0)
+		require.Nil(t, err, "failed to create objects")
+
+		fingerprints := make(map[string]string)
+		var statefulSets []*apps.StatefulSet
+		for _, object := range objects {
+			config := &object.Host.Config
+			fingerprints[object.StatefulSet.Name] = config.ZookeeperFingerprint + config.SettingsFingerprint + config.FilesFingerprint
+			statefulSets = append(statefulSets, object.StatefulSet)
+		}
+		out, err := yaml.Marshal(statefulSets)
+		require.Nil(t, err, "failed to marshal objects")
+		return fingerprints, out
+	}
+
+	fingerprints, statefulSets := generate(ReorderedCHIData[0])
+	reorderedFingerprints, reorderedStatefulSets := generate(ReorderedCHIData[1])
+	require.Len(t, fingerprints, 4, "unexpected StatefulSets")
+	require.Equal(t, fingerprints, reorderedFingerprints, "StatefulSet names or fingerprints differ")
+	require.Equal(t, string(statefulSets), string(reorderedStatefulSets), "StatefulSets differ")
+}
+
+func TestDiffHostsObjects(t *testing.T) {
+	create := func(shardsCount int, maxConcurrentQueries string) []*HostObjects {
+		creator, _ := newTestCreator(t, TestCHIData, func(chi *chiv1.ClickHouseInstallation) {
+			chi.Spec.Configuration.Clusters[0].Layout.ShardsCount = shardsCount
+			chi.Spec.Configuration.Clusters[0].Layout.ReplicasCount = 1
+			// Cluster settings are rendered into host ConfigMaps
+			chi.Spec.Configuration.Clusters[0].Settings = chiv1.Settings{
+				"max_concurrent_queries": chiv1.NewScalarSetting(maxConcurrentQueries),
+			}
+		})
+		objects, err := creator.CreateHostsObjects(1)
+		require.Nil(t, err, "failed to create objects")
+		return objects
+	}
+
+	// Fields populated by the server are ignored
+	old := create(2, "100")
+	for _, objects := range old {
+		objects.StatefulSet.ResourceVersion = "1"
+		objects.StatefulSet.Status.Replicas = 1
+		objects.Service.ResourceVersion = "1"
+		for i := range objects.Service.Spec.Ports {
+			objects.Service.Spec.Ports[i].NodePort = 30000 + int32(i)
+		}
+	}
+	require.True(t, DiffHostsObjects(old, create(2, "100")).IsEmpty(), "unchanged objects are reported")
+
+	// Added StatefulSet
+	diff := DiffHostsObjects(create(2, "100"), create(3, "100"))
+	require.Equal(t, []string{"kube-system/chi-test-cluster-2-0"}, diff.StatefulSets.Added)
+	require.Empty(t, diff.StatefulSets.Removed)
+
+	// Removed Service
+	diff = DiffHostsObjects(create(3, "100"), create(2, "100"))
+	require.Equal(t, []string{"kube-system/chi-test-cluster-2-0"}, diff.Services.Removed)
+	require.Empty(t, diff.Services.Added)
+
+	// Changed ConfigMap
+	diff = DiffHostsObjects(create(2, "100"), create(2, "200"))
+	require.Equal(t, []string{"kube-system/chi-test-deploy-confd-cluster-0-0", "kube-system/chi-test-deploy-confd-cluster-1-0"}, diff.ConfigMaps.Changed)
+	require.Empty(t, diff.ConfigMaps.Added)
+	require.Empty(t, diff.ConfigMaps.Removed)
+}
@@ -0,0 +1,67 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	chiv1 "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	"github.com/altinity/clickhouse-operator/pkg/chop"
+)
+
+// RenderConfigs normalizes specified CHI and renders ClickHouse config files the same way they are rendered into ConfigMaps,
+// without creating any k8s objects. Result is keyed by "ConfigMap name/filename", so per-host files, such as macros,
+// are keyed by host's ConfigMap name. Intended for dry runs, such as diffing config before it is applied
+func RenderConfigs(chop *chop.CHOp, chi *chiv1.ClickHouseInstallation) (map[string]string, error) {
+	chi, err := NewNormalizer(chop).NormalizeCHI(chi)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateCHI(chi); err != nil {
+		return nil, err
+	}
+
+	creator := NewCreator(chop, chi)
+	result := make(map[string]string)
+	add := func(configMapName string, files map[string]string) {
+		for filename, content := range files {
+			result[configMapName+"/"+filename] = content
+		}
+	}
+
+	common, err := creator.CreateConfigMapCHICommon()
+	if err != nil {
+		return nil, err
+	}
+	add(common.Name, common.Data)
+
+	users, err := creator.CreateConfigMapCHICommonUsers()
+	if err != nil {
+		return nil, err
+	}
+	add(users.Name, users.Data)
+
+	err = chi.WalkHostsTillError(func(host *chiv1.ChiHost) error {
+		configMap, err := creator.CreateConfigMapHost(host)
+		if err != nil {
+			return err
+		}
+		add(configMap.Name, configMap.Data)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,1685 @@
+package model
+
+import (
+	"strconv"
+	"testing"
+
+	chiv1 "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	"github.com/stretchr/testify/require"
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// CustomPodTemplateData describes CHI with shards of generated and custom pod templates,
+// so defaults applied to the generated template can be checked against values specified in the custom one
+var CustomPodTemplateData = `
+apiVersion: "clickhouse.altinity.com/v1"
+kind: "ClickHouseInstallation"
+metadata:
+  name: "custom-pod"
+  namespace: "kube-system"
+spec:
+  defaults:
+    nodeSelector:
+      pool: "clickhouse"
+      disk: "ssd"
+    tolerations:
+      - key: "dedicated"
+        operator: "Equal"
+        value: "clickhouse"
+        effect: "NoSchedule"
+      - key: "spot"
+        operator: "Exists"
+        effect: "NoSchedule"
+    securityContext:
+      runAsUser: "101"
+      runAsGroup: "101"
+      fsGroup: "101"
+      runAsNonRoot: "yes"
+  configuration:
+    clusters:
+      - name: "cluster"
+        layout:
+          shards:
+            - name: "default"
+            - name: "custom"
+              templates:
+                podTemplate: "pod"
+  templates:
+    podTemplates:
+      - name: "pod"
+        spec:
+          nodeSelector:
+            pool: "custom"
+          tolerations:
+            - key: "spot"
+              operator: "Exists"
+              effect: "NoSchedule"
+            - key: "gpu"
+              operator: "Exists"
+          terminationGracePeriodSeconds: 60
+          dnsPolicy: "Default"
+          securityContext:
+            runAsUser: 1000
+            fsGroup: 2000
+          containers:
+            - name: "clickhouse"
+              image: "yandex/clickhouse-server:20.8"
+`
+
+// DataVolumeData describes CHI with data volume, which size is overridden by one of the shards
+var DataVolumeData = `
+apiVersion: "clickhouse.altinity.com/v1"
+kind: "ClickHouseInstallation"
+metadata:
+  name: "data-volume"
+  namespace: "kube-system"
+spec:
+  defaults:
+    templates:
+      dataVolumeClaimTemplate: "data"
+  configuration:
+    clusters:
+      - name: "cluster"
+        layout:
+          shards:
+            - name: "small"
+            - name: "large"
+              dataVolumeSize: 5Gi
+  templates:
+    volumeClaimTemplates:
+      - name: "data"
+        spec:
+          accessModes:
+            - ReadWriteOnce
+          resources:
+            requests:
+              storage: 1Gi
+`
+
+// PodDisruptionBudgetData describes CHI with single replica and replicated clusters
+var PodDisruptionBudgetData = `
+apiVersion: "clickhouse.altinity.com/v1"
+kind: "ClickHouseInstallation"
+metadata:
+  name: "pdb"
+  namespace: "kube-system"
+spec:
+  defaults:
+    podDisruptionBudget: "yes"
+  configuration:
+    clusters:
+      - name: "single"
+        layout:
+          shardsCount: 1
+          replicasCount: 1
+      - name: "replicated"
+        layout:
+          shardsCount: 1
+          replicasCount: 2
+`
+
+// HeadlessServiceData describes CHI with replica Service template
+var HeadlessServiceData = `
+apiVersion: "clickhouse.altinity.com/v1"
+kind: "ClickHouseInstallation"
+metadata:
+  name: "headless"
+  namespace: "kube-system"
+spec:
+  defaults:
+    templates:
+      replicaServiceTemplate: "replica"
+  configuration:
+    clusters:
+      - name: "cluster"
+        layout:
+          shardsCount: 1
+          replicasCount: 2
+  templates:
+    serviceTemplates:
+      - name: "replica"
+        spec:
+          type: ClusterIP
+          ports:
+            - name: http
+              port: 8123
+`
+
+// getTestContainer returns ClickHouse container of StatefulSet of the host
+func getTestContainer(t *testing.T, creator *Creator, host *chiv1.ChiHost) *corev1.Container {
+	container, ok := creator.getClickHouseContainer(creator.CreateStatefulSet(host))
+	require.True(t, ok, "no clickhouse container")
+	return container
+}
+
+// getTestMountPaths returns mount paths of container's volumes by volume name
+func getTestMountPaths(container *corev1.Container) map[string]string {
+	mountPaths := map[string]string{}
+	for _, volumeMount := range container.VolumeMounts {
+		mountPaths[volumeMount.Name] = volumeMount.MountPath
+	}
+	return mountPaths
+}
+
+// getTestServicePorts returns port names of the Service
+func getTestServicePorts(service *corev1.Service) []string {
+	var ports []string
+	for _, port := range service.Spec.Ports {
+		ports = append(ports, port.Name)
+	}
+	return ports
+}
+
+func TestCreateStatefulSet(t *testing.T) {
+	shardAntiAffinity := func(mode string) func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+		shardTerm := func(term corev1.PodAffinityTerm, host *chiv1.ChiHost) bool {
+			return term.LabelSelector != nil &&
+				term.LabelSelector.MatchLabels[LabelShardName] == host.Address.ShardName &&
+				term.TopologyKey == topologyKeyHostname
+		}
+		return func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+			chi.WalkHosts(func(host *chiv1.ChiHost) error {
+				affinity := creator.CreateStatefulSet(host).Spec.Template.Spec.Affinity
+				require.NotNil(t, affinity, "no affinity")
+				require.NotNil(t, affinity.PodAntiAffinity, "no pod anti-affinity")
+				antiAffinity := affinity.PodAntiAffinity
+
+				found := false
+				switch mode {
+				case chiv1.ShardAntiAffinityRequired:
+					for _, term := range antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution {
+						found = found || shardTerm(term, host)
+					}
+				case chiv1.ShardAntiAffinityPreferred:
+					for _, term := range antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution {
+						found = found || (term.Weight == shardAntiAffinityWeight && shardTerm(term.PodAffinityTerm, host))
+					}
+				}
+				require.True(t, found, "no shard anti-affinity term for host %s", host.Name)
+
+				if chi.Spec.Defaults.Templates.PodTemplate != "" {
+					// Template's own terms are kept along with the generated one
+					require.Len(t, antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution, 2, "template affinity is not merged")
+					require.Equal(t, "zookeeper", antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution[0].LabelSelector.MatchLabels["app"], "template affinity is lost")
+				}
+				return nil
+			})
+		}
+	}
+	shardAntiAffinityData := `
+apiVersion: "clickhouse.altinity.com/v1"
+kind: "ClickHouseInstallation"
+metadata:
+  name: "anti"
+  namespace: "kube-system"
+spec:
+  defaults:
+    shardAntiAffinity: "required"
+  configuration:
+    clusters:
+      - name: "cluster"
+        layout:
+          shardsCount: 2
+          replicasCount: 2
+  templates:
+    podTemplates:
+      - name: "zone"
+        spec:
+          affinity:
+            podAntiAffinity:
+              requiredDuringSchedulingIgnoredDuringExecution:
+                - labelSelector:
+                    matchLabels:
+                      app: "zookeeper"
+                  topologyKey: "kubernetes.io/hostname"
+          containers:
+            - name: "clickhouse"
+              image: "yandex/clickhouse-server:20.8"
+`
+
+	replicaAntiAffinity := func(expected string) func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+		return func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+			require.Equal(t, expected, chi.Spec.Defaults.ReplicaAntiAffinityTopologyKey, "unexpected default topology key")
+			chi.WalkHosts(func(host *chiv1.ChiHost) error {
+				affinity := creator.CreateStatefulSet(host).Spec.Template.Spec.Affinity
+				require.NotNil(t, affinity, "no affinity")
+				require.NotNil(t, affinity.PodAntiAffinity, "no pod anti-affinity")
+
+				found := false
+				for _, term := range affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution {
+					if (term.LabelSelector != nil) && (term.LabelSelector.MatchLabels[LabelReplicaName] == host.Address.ReplicaName) {
+						require.Equal(t, expected, term.TopologyKey, "unexpected topology key of replica term")
+						found = true
+					}
+				}
+				require.True(t, found, "no replica anti-affinity term for host %s", host.Name)
+				return nil
+			})
+		}
+	}
+	replicaAntiAffinityData := `
+apiVersion: "clickhouse.altinity.com/v1"
+kind: "ClickHouseInstallation"
+metadata:
+  name: "replicas"
+  namespace: "kube-system"
+spec:
+  defaults:
+    templates:
+      podTemplate: "spread"
+  configuration:
+    clusters:
+      - name: "cluster"
+        layout:
+          shardsCount: 1
+          replicasCount: 2
+  templates:
+    podTemplates:
+      - name: "spread"
+        podDistribution:
+          - type: "ReplicaAntiAffinity"
+        spec:
+          containers:
+            - name: "clickhouse"
+              image: "yandex/clickhouse-server:20.8"
+`
+
+	dataVolumeChown := func(command []string) func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+		return func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+			chi.WalkHosts(func(host *chiv1.ChiHost) error {
+				statefulSet := creator.CreateStatefulSet(host)
+				container, ok := creator.getClickHouseContainer(statefulSet)
+				require.True(t, ok, "no clickhouse container")
+
+				// Init container goes ahead of the ones specified in pod template
+				initContainers := statefulSet.Spec.Template.Spec.InitContainers
+				require.Len(t, initContainers, 2, "unexpected init containers")
+				chown := initContainers[0]
+				require.Equal(t, ClickHouseChownContainerName, chown.Name, "chown init container is not the first one")
+				require.Equal(t, defaultBusyBoxDockerImage, chown.Image, "unexpected chown image")
+				if command == nil {
+					require.Equal(t, []string{"chown", "-R", "101:101", dirPathClickHouseData}, chown.Command, "unexpected default chown command")
+				} else {
+					require.Equal(t, command, chown.Command, "specified command is not used")
+				}
+
+				// Init container shares data volume mount of ClickHouse container
+				require.Len(t, chown.VolumeMounts, 1, "unexpected chown volume mounts")
+				require.Contains(t, container.VolumeMounts, chown.VolumeMounts[0], "chown volume mount does not match data volume")
+				require.Equal(t, "data", chown.VolumeMounts[0].Name, "chown does not mount data volume")
+				return nil
+			})
+		}
+	}
+	dataVolumeChownData := `
+apiVersion: "clickhouse.altinity.com/v1"
+kind: "ClickHouseInstallation"
+metadata:
+  name: "chown"
+  namespace: "kube-system"
+spec:
+  defaults:
+    dataVolumeChown:
+      enabled: "yes"
+    templates:
+      podTemplate: "pod"
+      dataVolumeClaimTemplate: "data"
+  configuration:
+    clusters:
+      - name: "cluster"
+        layout:
+          shardsCount: 1
+          replicasCount: 1
+  templates:
+    podTemplates:
+      - name: "pod"
+        spec:
+          initContainers:
+            - name: "sysctl"
+              image: "busybox"
+          containers:
+            - name: "clickhouse"
+              image: "yandex/clickhouse-server:20.8"
+    volumeClaimTemplates:
+      - name: "data"
+        spec:
+          accessModes:
+            - ReadWriteOnce
+          resources:
+            requests:
+              storage: 1Gi
+`
+
+	updateStrategy := func(expected func(host *chiv1.ChiHost) apps.StatefulSetUpdateStrategy) func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+		return func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+			chi.WalkHosts(func(host *chiv1.ChiHost) error {
+				require.Equal(t, expected(host), creator.CreateStatefulSet(host).Spec.UpdateStrategy, "unexpected update strategy of host %d", host.Address.CHIScopeIndex)
+				return nil
+			})
+		}
+	}
+
+	configDirPath := func(expected string) func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+		return func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+			require.Equal(t, expected, chi.Spec.Defaults.ConfigDirPath)
+			chi.WalkHosts(func(host *chiv1.ChiHost) error {
+				mountPaths := getTestMountPaths(getTestContainer(t, creator, host))
+				require.Equal(t, expected+chiv1.CommonConfigDir+"/", mountPaths[CreateConfigMapCommonName(chi)])
+				require.Equal(t, expected+chiv1.UsersConfigDir+"/", mountPaths[CreateConfigMapCommonUsersName(chi)])
+				require.Equal(t, expected+chiv1.HostConfigDir+"/", mountPaths[CreateConfigMapPodName(host)])
+				return nil
+			})
+		}
+	}
+
+	terminationGracePeriod := func(expected int64) func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+		return func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+			chi.WalkHosts(func(host *chiv1.ChiHost) error {
+				seconds := creator.CreateStatefulSet(host).Spec.Template.Spec.TerminationGracePeriodSeconds
+				require.NotNil(t, seconds, "no termination grace period")
+				switch host.Address.ShardName {
+				case "default":
+					// Default is applied to generated template
+					require.Equal(t, expected, *seconds)
+				case "custom":
+					// Grace period specified in template is kept
+					require.Equal(t, int64(60), *seconds)
+				}
+				return nil
+			})
+		}
+	}
+
+	hostNetwork := func(hostNetwork string, dnsPolicy, expected corev1.DNSPolicy) chiTest {
+		return chiTest{
+			name: "host network " + hostNetwork + " dns policy " + string(dnsPolicy),
+			data: CustomPodTemplateData,
+			modify: func(chi *chiv1.ClickHouseInstallation) {
+				chi.Spec.Defaults.HostNetwork = hostNetwork
+				chi.Spec.Defaults.DNSPolicy = dnsPolicy
+			},
+			check: func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+				chi.WalkHosts(func(host *chiv1.ChiHost) error {
+					podSpec := creator.CreateStatefulSet(host).Spec.Template.Spec
+					require.Equal(t, hostNetwork == "yes", podSpec.HostNetwork, "unexpected hostNetwork")
+					switch host.Address.ShardName {
+					case "default":
+						// DNS policy is derived for generated template
+						require.Equal(t, expected, podSpec.DNSPolicy, "unexpected dnsPolicy")
+					case "custom":
+						// DNS policy specified in template is kept
+						require.Equal(t, corev1.DNSDefault, podSpec.DNSPolicy)
+					}
+					return nil
+				})
+			},
+		}
+	}
+
+	serviceAccount := func(expected string) func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+		return func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+			serviceAccount := creator.CreateServiceAccount()
+			if chi.Spec.Defaults.ServiceAccount.Name == "" {
+				// ServiceAccount is generated and referenced
+				require.NotNil(t, serviceAccount, "no ServiceAccount generated")
+				require.Equal(t, expected, serviceAccount.Name)
+				require.Equal(t, chi.Namespace, serviceAccount.Namespace)
+				require.True(t, IsCHOPGeneratedObject(&serviceAccount.ObjectMeta), "ServiceAccount is not labeled")
+			} else {
+				// Existing ServiceAccount is referenced only
+				require.Nil(t, serviceAccount, "ServiceAccount is generated in spite of existing one")
+			}
+
+			chi.WalkHosts(func(host *chiv1.ChiHost) error {
+				require.Equal(t, expected, creator.CreateStatefulSet(host).Spec.Template.Spec.ServiceAccountName)
+				return nil
+			})
+		}
+	}
+
+	containerEnvData := `
+apiVersion: "clickhouse.altinity.com/v1"
+kind: "ClickHouseInstallation"
+metadata:
+  name: "env"
+  namespace: "kube-system"
+spec:
+  defaults:
+    container:
+      env:
+        - name: "CLICKHOUSE_DO_NOT_CHOWN"
+          value: "1"
+        - name: "S3_SECRET"
+          valueFrom:
+            secretKeyRef:
+              name: "s3"
+              key: "secret"
+        - name: "TZ"
+          value: "UTC"
+        - name: "CLICKHOUSE_HOST_ORDINAL"
+          value: "100"
+    templates:
+      podTemplate: "pod"
+  configuration:
+    files:
+      config.d/keeper_server_id.xml: |
+        <yandex><keeper_server><server_id from_env="CLICKHOUSE_HOST_ORDINAL"/></keeper_server></yandex>
+    clusters:
+      - name: "cluster"
+  templates:
+    podTemplates:
+      - name: "pod"
+        spec:
+          containers:
+            - name: "clickhouse"
+              image: "yandex/clickhouse-server:20.8"
+              env:
+                - name: "TZ"
+                  value: "Europe/Amsterdam"
+`
+
+	probes := func(liveness bool) func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+		return func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+			chi.WalkHosts(func(host *chiv1.ChiHost) error {
+				container := getTestContainer(t, creator, host)
+
+				// Probes refer to HTTP port by name
+				require.NotNil(t, container.ReadinessProbe, "no readiness probe")
+				require.Equal(t, intstr.FromString(chDefaultHTTPPortName), container.ReadinessProbe.HTTPGet.Port)
+				if !liveness {
+					// Liveness probe is opted in explicitly
+					require.Nil(t, container.LivenessProbe, "unexpected liveness probe")
+					return nil
+				}
+				require.NotNil(t, container.LivenessProbe, "no liveness probe")
+				require.Equal(t, "/ping", container.LivenessProbe.HTTPGet.Path)
+				require.Equal(t, intstr.FromString(chDefaultHTTPPortName), container.LivenessProbe.HTTPGet.Port)
+				require.Equal(t, int32(60), container.LivenessProbe.InitialDelaySeconds)
+				return nil
+			})
+		}
+	}
+
+	flushLogs := func(expected string) func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+		return func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+			chi.WalkHosts(func(host *chiv1.ChiHost) error {
+				container := getTestContainer(t, creator, host)
+				require.NotNil(t, container.Lifecycle, "no preStop hook")
+				require.NotNil(t, container.Lifecycle.PreStop, "no preStop hook")
+				command := container.Lifecycle.PreStop.Exec.Command
+				require.Equal(t, []string{"/bin/sh", "-c"}, command[:2])
+				require.Equal(t, expected, command[2])
+
+				// Password env var referenced by preStop hook is injected
+				for _, envVar := range container.Env {
+					if envVar.Name == "CLICKHOUSE_USER_PASSWORD_DEFAULT" {
+						return nil
+					}
+				}
+				require.NotContains(t, expected, "$CLICKHOUSE_USER_PASSWORD_DEFAULT", "password env var is not injected")
+				return nil
+			})
+		}
+	}
+	flushLogsData := `
+apiVersion: "clickhouse.altinity.com/v1"
+kind: "ClickHouseInstallation"
+metadata:
+  name: "flush"
+  namespace: "kube-system"
+spec:
+  configuration:
+    systemLogs:
+      flushOnShutdown: "yes"
+    userPasswordSecrets:
+      default:
+        name: "clickhouse-users"
+        key: "default"
+    clusters:
+      - name: "cluster"
+`
+
+	runCHITests(t, []chiTest{
+		{
+			name: "config mounts order",
+			check: func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+				chi.WalkHosts(func(host *chiv1.ChiHost) error {
+					// Mounts have to be the same, in the same order, each time StatefulSet is created
+					for i := 0; i < 3; i++ {
+						var mounts []string
+						for _, volumeMount := range getTestContainer(t, creator, host).VolumeMounts {
+							mounts = append(mounts, volumeMount.MountPath)
+						}
+						require.Equal(t, []string{
+							"/etc/clickhouse-server/config.d/",
+							"/etc/clickhouse-server/users.d/",
+							"/etc/clickhouse-server/conf.d/",
+						}, mounts, "unexpected mounts")
+					}
+					return nil
+				})
+			},
+		},
+		{
+			name: "empty containers pod template",
+			data: `
+apiVersion: "clickhouse.altinity.com/v1"
+kind: "ClickHouseInstallation"
+metadata:
+  name: "empty-containers"
+  namespace: "kube-system"
+spec:
+  defaults:
+    templates:
+      podTemplate: "no-containers"
+      dataVolumeClaimTemplate: "data"
+  configuration:
+    clusters:
+      - name: "cluster"
+  templates:
+    podTemplates:
+      - name: "no-containers"
+        spec:
+          containers: []
+    volumeClaimTemplates:
+      - name: "data"
+        spec:
+          accessModes:
+            - ReadWriteOnce
+          resources:
+            requests:
+              storage: 1Gi
+`,
+			check: func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+				chi.WalkHosts(func(host *chiv1.ChiHost) error {
+					// Pod template without containers has to be completed with default ClickHouse container
+					statefulSet := creator.CreateStatefulSet(host)
+					require.Len(t, statefulSet.Spec.Template.Spec.Containers, 1, "unexpected containers")
+					container := getTestContainer(t, creator, host)
+					require.Equal(t, ClickHouseContainerName, container.Name, "unexpected container")
+
+					// Data volume has to be mounted into injected container
+					require.Equal(t, dirPathClickHouseData, getTestMountPaths(container)["data"], "data volume is not mounted")
+					return nil
+				})
+			},
+		},
+		{
+			name: "container image",
+			data: `
+apiVersion: "clickhouse.altinity.com/v1"
+kind: "ClickHouseInstallation"
+metadata:
+  name: "image"
+  namespace: "kube-system"
+spec:
+  defaults:
+    container:
+      image: " registry.example.com/clickhouse-server:21.8 "
+      imagePullPolicy: "IfNotPresent"
+      imagePullSecrets:
+        - name: "registry-credentials"
+  configuration:
+    clusters:
+      - name: "cluster"
+`,
+			check: func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+				chi.WalkHosts(func(host *chiv1.ChiHost) error {
+					container := getTestContainer(t, creator, host)
+					require.Equal(t, "registry.example.com/clickhouse-server:21.8", container.Image, "unexpected image")
+					require.Equal(t, corev1.PullIfNotPresent, container.ImagePullPolicy, "unexpected pull policy")
+					// Pull secrets are attached to the pod
+					require.Equal(t, []corev1.LocalObjectReference{{Name: "registry-credentials"}}, creator.CreateStatefulSet(host).Spec.Template.Spec.ImagePullSecrets, "unexpected pull secrets")
+					return nil
+				})
+
+				chi.Spec.Defaults.Container.Image = ""
+				require.Error(t, ValidateCHI(chi), "CHI without image is valid")
+			},
+		},
+		{
+			name:  "required shard anti-affinity",
+			data:  shardAntiAffinityData,
+			check: shardAntiAffinity(chiv1.ShardAntiAffinityRequired),
+		},
+		{
+			name: "preferred shard anti-affinity",
+			data: shardAntiAffinityData,
+			modify: func(chi *chiv1.ClickHouseInstallation) {
+				chi.Spec.Defaults.ShardAntiAffinity = chiv1.ShardAntiAffinityPreferred
+			},
+			check: shardAntiAffinity(chiv1.ShardAntiAffinityPreferred),
+		},
+		{
+			name: "shard anti-affinity merged into template",
+			data: shardAntiAffinityData,
+			modify: func(chi *chiv1.ClickHouseInstallation) {
+				chi.Spec.Defaults.Templates.PodTemplate = "zone"
+			},
+			check: shardAntiAffinity(chiv1.ShardAntiAffinityRequired),
+		},
+		{
+			name:  "default replica anti-affinity topology key",
+			data:  replicaAntiAffinityData,
+			check: replicaAntiAffinity(topologyKeyHostname),
+		},
+		{
+			name: "replica anti-affinity topology key",
+			data: replicaAntiAffinityData,
+			modify: func(chi *chiv1.ClickHouseInstallation) {
+				chi.Spec.Defaults.ReplicaAntiAffinityTopologyKey = "topology.kubernetes.io/zone"
+			},
+			check: replicaAntiAffinity("topology.kubernetes.io/zone"),
+		},
+		{
+			name: "preserve volume claim templates",
+			data: DataVolumeData,
+			check: func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+				storage := func(requests corev1.ResourceList) string {
+					quantity := requests[corev1.ResourceStorage]
+					return quantity.String()
+				}
+				expectedSizes := map[string]string{"small": "1Gi", "large": "5Gi"}
+				chi.WalkHosts(func(host *chiv1.ChiHost) error {
+					volumeClaimTemplate, ok := chi.GetVolumeClaimTemplate("data")
+					require.True(t, ok, "no data VolumeClaimTemplate")
+					requests := CreatePVCResourceRequests(host, volumeClaimTemplate)
+					require.Equal(t, expectedSizes[host.Address.ShardName], storage(requests), "unexpected PVC size")
+
+					// New StatefulSet claims PVC of the host's size
+					objects := creator.CreateHostObjects(host)
+					creator.PreserveStatefulSetVolumeClaimTemplates(objects, nil)
+					require.Len(t, objects.StatefulSet.Spec.VolumeClaimTemplates, 1)
+					require.Equal(t, expectedSizes[host.Address.ShardName], storage(objects.StatefulSet.Spec.VolumeClaimTemplates[0].Spec.Resources.Requests))
+
+					// Existing StatefulSet keeps its VolumeClaimTemplates, PVCs are resized instead
+					cur := creator.CreateStatefulSet(host)
+					cur.Spec.VolumeClaimTemplates[0].Spec.Resources.Requests[corev1.ResourceStorage] = resource.MustParse("500Mi")
+					objects = creator.CreateHostObjects(host)
+					creator.PreserveStatefulSetVolumeClaimTemplates(objects, cur)
+					require.Equal(t, "500Mi", storage(objects.StatefulSet.Spec.VolumeClaimTemplates[0].Spec.Resources.Requests), "VolumeClaimTemplate of existing StatefulSet is changed")
+					require.Equal(t, expectedSizes[host.Address.ShardName], storage(requests), "PVC size is affected by existing StatefulSet")
+					return nil
+				})
+			},
+		},
+		{
+			name: "ephemeral storage",
+			data: DataVolumeData,
+			modify: func(chi *chiv1.ClickHouseInstallation) {
+				template := &chi.Spec.Templates.VolumeClaimTemplates[0]
+				template.Spec = corev1.PersistentVolumeClaimSpec{}
+				sizeLimit := resource.MustParse("1Gi")
+				template.EmptyDir = &corev1.EmptyDirVolumeSource{SizeLimit: &sizeLimit}
+			},
+			check: func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+				expectedSizeLimits := map[string]string{"small": "1Gi", "large": "5Gi"}
+				chi.WalkHosts(func(host *chiv1.ChiHost) error {
+					statefulSet := creator.CreateStatefulSet(host)
+					require.Empty(t, statefulSet.Spec.VolumeClaimTemplates, "ephemeral storage should not claim PVC")
+
+					var volume *corev1.Volume
+					for i := range statefulSet.Spec.Template.Spec.Volumes {
+						if statefulSet.Spec.Template.Spec.Volumes[i].Name == "data" {
+							volume = &statefulSet.Spec.Template.Spec.Volumes[i]
+						}
+					}
+					require.NotNil(t, volume, "no data volume")
+					require.NotNil(t, volume.EmptyDir, "data volume is not emptyDir")
+					require.Equal(t, expectedSizeLimits[host.Address.ShardName], volume.EmptyDir.SizeLimit.String(), "unexpected emptyDir size limit")
+
+					container := getTestContainer(t, creator, host)
+					require.Contains(t, container.VolumeMounts, newVolumeMount("data", dirPathClickHouseData), "data volume is not mounted")
+					return nil
+				})
+			},
+		},
+		{
+			name: "extra volume claim templates",
+			data: `
+apiVersion: "clickhouse.altinity.com/v1"
+kind: "ClickHouseInstallation"
+metadata:
+  name: "extra-volumes"
+  namespace: "kube-system"
+spec:
+  defaults:
+    templates:
+      dataVolumeClaimTemplate: "data"
+      extraVolumeClaimTemplates:
+        - name: "logs"
+          mountPath: "/var/log/clickhouse-server"
+        - name: "cold"
+          mountPath: "/var/lib/clickhouse-cold"
+  configuration:
+    clusters:
+      - name: "cluster"
+  templates:
+    volumeClaimTemplates:
+      - name: "data"
+        spec:
+          resources:
+            requests:
+              storage: 10Gi
+      - name: "logs"
+        spec:
+          resources:
+            requests:
+              storage: 1Gi
+      - name: "cold"
+        spec:
+          resources:
+            requests:
+              storage: 100Gi
+`,
+			check: func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+				require.Nil(t, ValidateCHI(chi), "failed to validate chi")
+				chi.WalkHosts(func(host *chiv1.ChiHost) error {
+					var claims []string
+					for _, pvc := range creator.CreateStatefulSet(host).Spec.VolumeClaimTemplates {
+						claims = append(claims, pvc.Name)
+					}
+					require.Equal(t, []string{"data", "logs", "cold"}, claims, "unexpected PVCs")
+
+					mountPaths := getTestMountPaths(getTestContainer(t, creator, host))
+					require.Equal(t, dirPathClickHouseData, mountPaths["data"])
+					require.Equal(t, "/var/log/clickhouse-server", mountPaths["logs"])
+					require.Equal(t, "/var/lib/clickhouse-cold", mountPaths["cold"])
+					return nil
+				})
+			},
+		},
+		{
+			name: "default update strategy",
+			check: updateStrategy(func(host *chiv1.ChiHost) apps.StatefulSetUpdateStrategy {
+				// Unspecified strategy keeps rolling all pods out
+				return apps.StatefulSetUpdateStrategy{Type: apps.RollingUpdateStatefulSetStrategyType}
+			}),
+		},
+		{
+			name: "OnDelete update strategy",
+			modify: func(chi *chiv1.ClickHouseInstallation) {
+				chi.Spec.Defaults.UpdateStrategy = chiv1.ChiUpdateStrategy{Type: "OnDelete"}
+			},
+			check: updateStrategy(func(host *chiv1.ChiHost) apps.StatefulSetUpdateStrategy {
+				return apps.StatefulSetUpdateStrategy{Type: apps.OnDeleteStatefulSetStrategyType}
+			}),
+		},
+		{
+			name: "partitioned update strategy",
+			modify: func(chi *chiv1.ClickHouseInstallation) {
+				chi.Spec.Defaults.UpdateStrategy = chiv1.ChiUpdateStrategy{Type: "RollingUpdate", Partition: "3"}
+			},
+			check: updateStrategy(func(host *chiv1.ChiHost) apps.StatefulSetUpdateStrategy {
+				// Hosts below partition are held, the rest are rolled out
+				partition := int32(0)
+				if host.Address.CHIScopeIndex < 3 {
+					partition = 1
+				}
+				return apps.StatefulSetUpdateStrategy{
+					Type:          apps.RollingUpdateStatefulSetStrategyType,
+					RollingUpdate: &apps.RollingUpdateStatefulSetStrategy{Partition: &partition},
+				}
+			}),
+		},
+		{
+			name:  "data volume chown init container",
+			data:  dataVolumeChownData,
+			check: dataVolumeChown(nil),
+		},
+		{
+			name: "data volume chown init container command",
+			data: dataVolumeChownData,
+			modify: func(chi *chiv1.ClickHouseInstallation) {
+				chi.Spec.Defaults.DataVolumeChown.Command = []string{"sh", "-c", "chmod -R g+w /var/lib/clickhouse"}
+			},
+			check: dataVolumeChown([]string{"sh", "-c", "chmod -R g+w /var/lib/clickhouse"}),
+		},
+		{
+			name: "default node selector and tolerations",
+			data: CustomPodTemplateData,
+			check: func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+				chi.WalkHosts(func(host *chiv1.ChiHost) error {
+					podSpec := creator.CreateStatefulSet(host).Spec.Template.Spec
+					tolerationKeys := []string{}
+					for _, toleration := range podSpec.Tolerations {
+						tolerationKeys = append(tolerationKeys, toleration.Key)
+					}
+
+					switch host.Address.ShardName {
+					case "default":
+						// Defaults are injected into generated template
+						require.Equal(t, map[string]string{"pool": "clickhouse", "disk": "ssd"}, podSpec.NodeSelector)
+						require.Equal(t, []string{"dedicated", "spot"}, tolerationKeys)
+					case "custom":
+						// Template values are kept, defaults fill the rest
+						require.Equal(t, map[string]string{"pool": "custom", "disk": "ssd"}, podSpec.NodeSelector)
+						require.Equal(t, []string{"spot", "gpu", "dedicated"}, tolerationKeys)
+					}
+					return nil
+				})
+			},
+		},
+		{
+			name: "security context",
+			data: CustomPodTemplateData,
+			check: func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+				chi.WalkHosts(func(host *chiv1.ChiHost) error {
+					securityContext := creator.CreateStatefulSet(host).Spec.Template.Spec.SecurityContext
+					require.NotNil(t, securityContext, "no security context")
+					require.NotNil(t, securityContext.RunAsUser, "no runAsUser")
+					require.NotNil(t, securityContext.RunAsGroup, "no runAsGroup")
+					require.NotNil(t, securityContext.FSGroup, "no fsGroup")
+					require.NotNil(t, securityContext.RunAsNonRoot, "no runAsNonRoot")
+					require.True(t, *securityContext.RunAsNonRoot)
+					require.Equal(t, int64(101), *securityContext.RunAsGroup)
+					switch host.Address.ShardName {
+					case "default":
+						// Defaults are applied to generated template
+						require.Equal(t, int64(101), *securityContext.RunAsUser)
+						require.Equal(t, int64(101), *securityContext.FSGroup)
+					case "custom":
+						// Fields specified in template are kept, unset ones are filled
+						require.Equal(t, int64(1000), *securityContext.RunAsUser)
+						require.Equal(t, int64(2000), *securityContext.FSGroup)
+					}
+					return nil
+				})
+			},
+		},
+		{
+			name:  "default config dir path",
+			check: configDirPath("/etc/clickhouse-server/"),
+		},
+		{
+			name: "config dir path",
+			modify: func(chi *chiv1.ClickHouseInstallation) {
+				chi.Spec.Defaults.ConfigDirPath = "/etc/clickhouse"
+			},
+			check: configDirPath("/etc/clickhouse/"),
+		},
+		{
+			name: "config dir path with trailing slash",
+			modify: func(chi *chiv1.ClickHouseInstallation) {
+				chi.Spec.Defaults.ConfigDirPath = "/opt/ch/config/"
+			},
+			check: configDirPath("/opt/ch/config/"),
+		},
+		{
+			name: "relative config dir path",
+			modify: func(chi *chiv1.ClickHouseInstallation) {
+				chi.Spec.Defaults.ConfigDirPath = "relative/config/"
+			},
+			check: configDirPath("/etc/clickhouse-server/"),
+		},
+		{
+			name:  "default termination grace period",
+			data:  CustomPodTemplateData,
+			check: terminationGracePeriod(300),
+		},
+		{
+			name: "termination grace period",
+			data: CustomPodTemplateData,
+			modify: func(chi *chiv1.ClickHouseInstallation) {
+				chi.Spec.Defaults.TerminationGracePeriodSeconds = "600"
+			},
+			check: terminationGracePeriod(600),
+		},
+		{
+			name: "invalid termination grace period",
+			data: CustomPodTemplateData,
+			modify: func(chi *chiv1.ClickHouseInstallation) {
+				chi.Spec.Defaults.TerminationGracePeriodSeconds = "invalid"
+			},
+			check: terminationGracePeriod(300),
+		},
+		hostNetwork("", "", ""),
+		hostNetwork("yes", "", corev1.DNSClusterFirstWithHostNet),
+		hostNetwork("yes", corev1.DNSClusterFirst, corev1.DNSClusterFirst),
+		hostNetwork("no", corev1.DNSNone, corev1.DNSNone),
+		hostNetwork("yes", "invalid", corev1.DNSClusterFirstWithHostNet),
+		{
+			name: "generated service account",
+			modify: func(chi *chiv1.ClickHouseInstallation) {
+				chi.Spec.Defaults.ServiceAccount.Create = "yes"
+			},
+			check: serviceAccount("chi-test"),
+		},
+		{
+			name: "existing service account",
+			modify: func(chi *chiv1.ClickHouseInstallation) {
+				chi.Spec.Defaults.ServiceAccount.Create = "yes"
+				chi.Spec.Defaults.ServiceAccount.Name = "clickhouse-identity"
+			},
+			check: serviceAccount("clickhouse-identity"),
+		},
+		{
+			name: "sidecar container",
+			data: `
+apiVersion: "clickhouse.altinity.com/v1"
+kind: "ClickHouseInstallation"
+metadata:
+  name: "sidecar"
+  namespace: "kube-system"
+spec:
+  defaults:
+    container:
+      name: "server"
+    templates:
+      podTemplate: "pod"
+  configuration:
+    clusters:
+      - name: "cluster"
+  templates:
+    podTemplates:
+      - name: "pod"
+        spec:
+          containers:
+            - name: "logs"
+              image: "fluent/fluent-bit:1.9"
+              volumeMounts:
+                - name: "logs-config"
+                  mountPath: "/fluent-bit/etc/"
+            - name: "server"
+              image: "yandex/clickhouse-server:20.8"
+`,
+			check: func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+				podTemplate, ok := chi.GetPodTemplate("pod")
+				require.True(t, ok, "no pod template")
+				sidecar := podTemplate.Spec.Containers[0].DeepCopy()
+
+				chi.WalkHosts(func(host *chiv1.ChiHost) error {
+					container := getTestContainer(t, creator, host)
+					require.Equal(t, "server", container.Name, "sidecar is considered to be ClickHouse container")
+
+					mountPaths := getTestMountPaths(container)
+					require.Equal(t, "/etc/clickhouse-server/"+chiv1.CommonConfigDir+"/", mountPaths[CreateConfigMapCommonName(chi)])
+					require.Equal(t, "/etc/clickhouse-server/"+chiv1.UsersConfigDir+"/", mountPaths[CreateConfigMapCommonUsersName(chi)])
+					require.Equal(t, "/etc/clickhouse-server/"+chiv1.HostConfigDir+"/", mountPaths[CreateConfigMapPodName(host)])
+
+					// Sidecar is left untouched
+					require.Equal(t, *sidecar, *getContainerByName(creator.CreateStatefulSet(host), "logs"), "sidecar container is modified")
+					return nil
+				})
+			},
+		},
+		{
+			name: "container env",
+			data: containerEnvData,
+			check: func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+				chi.WalkHosts(func(host *chiv1.ChiHost) error {
+					env := map[string]corev1.EnvVar{}
+					for _, envVar := range getTestContainer(t, creator, host).Env {
+						_, duplicate := env[envVar.Name]
+						require.False(t, duplicate, "duplicate env var %s", envVar.Name)
+						env[envVar.Name] = envVar
+					}
+					require.Equal(t, "1", env["CLICKHOUSE_DO_NOT_CHOWN"].Value)
+					require.NotNil(t, env["S3_SECRET"].ValueFrom)
+					require.Equal(t, &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "s3"},
+						Key:                  "secret",
+					}, env["S3_SECRET"].ValueFrom.SecretKeyRef)
+					// Pod Template and operator take precedence
+					require.Equal(t, "Europe/Amsterdam", env["TZ"].Value)
+					require.Equal(t, strconv.Itoa(CreateHostOrdinal(host)), env[hostOrdinalEnvVarName].Value)
+					return nil
+				})
+			},
+		},
+		{
+			name: "unreferenced ordinal env var",
+			data: containerEnvData,
+			modify: func(chi *chiv1.ClickHouseInstallation) {
+				chi.Spec.Configuration.Files = nil
+				chi.Spec.Defaults.Container.Env = nil
+			},
+			check: func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+				// Ordinal env var is not injected unless it is referenced
+				chi.WalkHosts(func(host *chiv1.ChiHost) error {
+					for _, envVar := range getTestContainer(t, creator, host).Env {
+						require.NotEqual(t, hostOrdinalEnvVarName, envVar.Name, "unreferenced ordinal env var is injected")
+					}
+					return nil
+				})
+			},
+		},
+		{
+			name: "probes",
+			modify: func(chi *chiv1.ClickHouseInstallation) {
+				chi.Spec.Defaults.LivenessProbe.Enabled = "yes"
+			},
+			check: probes(true),
+		},
+		{
+			name:  "probes without liveness probe",
+			check: probes(false),
+		},
+		{
+			// Password of default user is passed from env var populated from Secret
+			name:  "flush logs on shutdown",
+			data:  flushLogsData,
+			check: flushLogs(`clickhouse-client --user default --port 9000 --password "$CLICKHOUSE_USER_PASSWORD_DEFAULT" --query 'SYSTEM FLUSH LOGS'`),
+		},
+		{
+			name: "flush logs on shutdown without password",
+			data: flushLogsData,
+			modify: func(chi *chiv1.ClickHouseInstallation) {
+				chi.Spec.Configuration.UserPasswordSecrets = nil
+			},
+			check: flushLogs(`clickhouse-client --user default --port 9000 --query 'SYSTEM FLUSH LOGS'`),
+		},
+		{
+			// Secure port is used along with TLS
+			name: "flush logs on shutdown with TLS",
+			data: flushLogsData,
+			modify: func(chi *chiv1.ClickHouseInstallation) {
+				chi.Spec.Configuration.TLS.Secret = "clickhouse-tls"
+			},
+			check: flushLogs(`clickhouse-client --user default --secure --port 9440 --password "$CLICKHOUSE_USER_PASSWORD_DEFAULT" --query 'SYSTEM FLUSH LOGS'`),
+		},
+	})
+}
+
+func TestCreateServices(t *testing.T) {
+	securePorts := func(tls bool) func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+		return func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+			plain := []string{chDefaultHTTPPortName, chDefaultTCPPortName}
+			secure := []string{chDefaultTCPPortSecureName, chDefaultHTTPSPortName}
+			expected := plain
+			if tls {
+				// Both plaintext and secure ports are exposed
+				expected = append(plain, secure...)
+			}
+			require.Equal(t, expected, getTestServicePorts(creator.CreateServiceCHI()), "unexpected CHI service ports")
+
+			chi.WalkHosts(func(host *chiv1.ChiHost) error {
+				hostPorts := getTestServicePorts(creator.CreateServiceHost(host))
+				require.Subset(t, hostPorts, append(plain, chDefaultInterserverHTTPPortName), "no plaintext host service ports")
+
+				var containerPorts []string
+				for _, port := range getTestContainer(t, creator, host).Ports {
+					containerPorts = append(containerPorts, port.Name)
+				}
+				require.Subset(t, containerPorts, plain, "no plaintext container ports")
+
+				if tls {
+					require.Subset(t, hostPorts, secure, "no secure host service ports")
+					require.Subset(t, containerPorts, secure, "no secure container ports")
+				} else {
+					require.NotContains(t, hostPorts, chDefaultTCPPortSecureName, "unexpected secure host service port")
+					require.NotContains(t, containerPorts, chDefaultHTTPSPortName, "unexpected secure container port")
+				}
+				return nil
+			})
+
+			config := creator.chConfigGenerator.GetTLS()
+			if tls {
+				require.Contains(t, config, "<tcp_port_secure>9440</tcp_port_secure>", "no secure native port")
+				require.Contains(t, config, "<https_port>8443</https_port>", "no HTTPS port")
+				require.Contains(t, config, dirPathTLS+tlsCertificateFile, "no certificate path")
+			} else {
+				require.Empty(t, config, "unexpected TLS config")
+			}
+		}
+	}
+
+	roleServices := func(enabled bool) func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+		return func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+			chi.WalkShards(func(shard *chiv1.ChiShard) error {
+				read := creator.CreateServiceShardRead(shard)
+				write := creator.CreateServiceShardWrite(shard)
+				if !enabled {
+					require.Nil(t, read, "unexpected read Service")
+					require.Nil(t, write, "unexpected write Service")
+					// Pods are not labeled with role, so they are not rolled unless role Services are enabled
+					for _, host := range shard.Hosts {
+						podLabels := creator.CreateStatefulSet(host).Spec.Template.Labels
+						require.Equal(t, creator.labeler.getLabelsHostScope(host, true), podLabels, "unexpected pod labels")
+					}
+					return nil
+				}
+
+				require.NotNil(t, read, "no read Service")
+				require.NotNil(t, write, "no write Service")
+				// Write selector is read selector narrowed down to primary replica
+				require.Equal(t, len(read.Spec.Selector)+1, len(write.Spec.Selector), "write selector is not narrower")
+				for name, value := range read.Spec.Selector {
+					require.Equal(t, value, write.Spec.Selector[name], "write selector does not include read selector")
+				}
+				require.Equal(t, labelReplicaRoleValuePrimary, write.Spec.Selector[LabelReplicaRole])
+
+				readPods, writePods := 0, 0
+				for _, host := range shard.Hosts {
+					podLabels := labels.Set(creator.CreateStatefulSet(host).Spec.Template.Labels)
+					if labels.SelectorFromSet(read.Spec.Selector).Matches(podLabels) {
+						readPods++
+					}
+					if labels.SelectorFromSet(write.Spec.Selector).Matches(podLabels) {
+						writePods++
+					}
+				}
+				require.Equal(t, 3, readPods, "read Service has to target all replicas")
+				require.Equal(t, 1, writePods, "write Service has to target primary replica only")
+				return nil
+			})
+		}
+	}
+
+	headless := func(withTemplate bool) func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+		return func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+			chi.WalkHosts(func(host *chiv1.ChiHost) error {
+				objects := creator.CreateHostObjects(host)
+				governing := objects.HeadlessService
+				if withTemplate {
+					// ClusterIP Service is kept for direct access, while separate headless Service governs StatefulSet
+					require.NotEqual(t, corev1.ClusterIPNone, objects.Service.Spec.ClusterIP, "host Service is headless")
+					require.NotNil(t, governing, "no headless Service")
+					require.NotEqual(t, objects.Service.Name, governing.Name, "headless Service has the same name as host Service")
+				} else {
+					// Default host Service is headless itself
+					require.Nil(t, governing, "unexpected headless Service")
+					governing = objects.Service
+				}
+
+				require.Equal(t, corev1.ClusterIPNone, governing.Spec.ClusterIP, "governing Service is not headless")
+				require.Equal(t, governing.Name, objects.StatefulSet.Spec.ServiceName, "StatefulSet is not governed by headless Service")
+				require.Equal(t, objects.StatefulSet.Spec.Selector.MatchLabels, governing.Spec.Selector, "governing Service does not select StatefulSet pods")
+				require.Contains(t, CreatePodHeadlessServiceFQDN(host), "."+governing.Name+".", "pod FQDN is not within governing Service")
+
+				// Existing StatefulSet keeps its serviceName, along with Service governing it and pod FQDN within it
+				for _, serviceName := range []string{CreateStatefulSetServiceName(host), CreateStatefulSetHeadlessServiceName(host)} {
+					host.Config.StatefulSetServiceName = serviceName
+					objects := creator.CreateHostObjects(host)
+					require.Equal(t, serviceName, objects.StatefulSet.Spec.ServiceName, "serviceName of existing StatefulSet is changed")
+					require.Contains(t, CreatePodHeadlessServiceFQDN(host), "."+serviceName+".", "pod FQDN is not within governing Service")
+					if serviceName == CreateStatefulSetServiceName(host) {
+						require.Nil(t, objects.HeadlessService, "unexpected headless Service")
+					} else {
+						require.NotNil(t, objects.HeadlessService, "headless Service governing existing StatefulSet is dropped")
+						require.Equal(t, serviceName, objects.HeadlessService.Name)
+					}
+				}
+				host.Config.StatefulSetServiceName = ""
+				return nil
+			})
+		}
+	}
+
+	serviceClusterIP := func(clusterIP, expectedClusterIP string, expectedType corev1.ServiceType) chiTest {
+		return chiTest{
+			name: "service cluster IP " + clusterIP,
+			modify: func(chi *chiv1.ClickHouseInstallation) {
+				chi.Spec.Defaults.ServiceClusterIP = clusterIP
+			},
+			check: func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+				service := creator.CreateServiceCHI()
+				require.Equal(t, expectedClusterIP, service.Spec.ClusterIP, "unexpected clusterIP")
+				require.Equal(t, expectedType, service.Spec.Type, "unexpected type")
+				if expectedType == corev1.ServiceTypeLoadBalancer {
+					require.Equal(t, corev1.ServiceExternalTrafficPolicyTypeLocal, service.Spec.ExternalTrafficPolicy)
+				} else {
+					require.Empty(t, service.Spec.ExternalTrafficPolicy)
+				}
+			},
+		}
+	}
+
+	pdb := func(enabled bool) func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+		return func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+			chi.WalkShards(func(shard *chiv1.ChiShard) error {
+				pdb := creator.CreatePodDisruptionBudgetShard(shard)
+				if !enabled {
+					require.Nil(t, pdb, "unexpected PodDisruptionBudget")
+					return nil
+				}
+
+				require.NotNil(t, pdb, "no PodDisruptionBudget")
+				require.Equal(t, creator.labeler.getSelectorShardScope(shard), pdb.Spec.Selector.MatchLabels, "unexpected selector")
+				switch shard.Address.ClusterName {
+				case "single":
+					// The only replica may be evicted
+					require.Equal(t, 0, pdb.Spec.MinAvailable.IntValue(), "single replica shard disruption is not allowed")
+				case "replicated":
+					// One of two replicas has to stay available
+					require.Equal(t, 1, pdb.Spec.MinAvailable.IntValue(), "replicated shard may be disrupted entirely")
+				}
+				return nil
+			})
+		}
+	}
+
+	runCHITests(t, []chiTest{
+		{
+			name: "secure ports",
+			modify: func(chi *chiv1.ClickHouseInstallation) {
+				chi.Spec.Configuration.TLS.Secret = "clickhouse-tls"
+			},
+			check: securePorts(true),
+		},
+		{
+			name:  "plaintext ports",
+			check: securePorts(false),
+		},
+		{
+			name: "default ports",
+			modify: func(chi *chiv1.ClickHouseInstallation) {
+				chi.Spec.Defaults.Ports.HTTPPort = 8124
+			},
+			check: func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+				require.Equal(t, chDefaultTCPPortNumber, chi.Spec.Defaults.Ports.TCPPort, "unspecified port is not defaulted")
+
+				servicePort := func(service *corev1.Service, name string) int32 {
+					for _, port := range service.Spec.Ports {
+						if port.Name == name {
+							return port.Port
+						}
+					}
+					return 0
+				}
+				require.Equal(t, int32(8124), servicePort(creator.CreateServiceCHI(), chDefaultHTTPPortName), "CHI Service port is not overridden")
+				require.Equal(t, chDefaultTCPPortNumber, servicePort(creator.CreateServiceCHI(), chDefaultTCPPortName))
+				chi.WalkHosts(func(host *chiv1.ChiHost) error {
+					require.Equal(t, int32(8124), host.HTTPPort)
+					require.Equal(t, int32(8124), servicePort(creator.CreateServiceHost(host), chDefaultHTTPPortName), "host Service port is not overridden")
+
+					containerPorts := map[string]int32{}
+					for _, port := range getTestContainer(t, creator, host).Ports {
+						containerPorts[port.Name] = port.ContainerPort
+					}
+					require.Equal(t, int32(8124), containerPorts[chDefaultHTTPPortName], "container port is not overridden")
+					require.Equal(t, chDefaultTCPPortNumber, containerPorts[chDefaultTCPPortName])
+
+					require.Contains(t, creator.chConfigGenerator.GetHostPorts(host), "<http_port>8124</http_port>", "ClickHouse port is not overridden")
+					return nil
+				})
+			},
+		},
+		{
+			name:  "pod disruption budget",
+			data:  PodDisruptionBudgetData,
+			check: pdb(true),
+		},
+		{
+			name: "no pod disruption budget",
+			data: PodDisruptionBudgetData,
+			modify: func(chi *chiv1.ClickHouseInstallation) {
+				chi.Spec.Defaults.PodDisruptionBudget = ""
+			},
+			check: pdb(false),
+		},
+		{
+			name: "role services",
+			modify: func(chi *chiv1.ClickHouseInstallation) {
+				chi.Spec.Defaults.RoleServices = "yes"
+				chi.Spec.Configuration.Clusters[0].Layout.ReplicasCount = 3
+			},
+			check: roleServices(true),
+		},
+		{
+			name:  "no role services",
+			check: roleServices(false),
+		},
+		{
+			name:  "headless service with replica service template",
+			data:  HeadlessServiceData,
+			check: headless(true),
+		},
+		{
+			name: "headless host service",
+			data: HeadlessServiceData,
+			modify: func(chi *chiv1.ClickHouseInstallation) {
+				chi.Spec.Defaults.Templates.ReplicaServiceTemplate = ""
+			},
+			check: headless(false),
+		},
+		{
+			name: "metrics ports",
+			data: HeadlessServiceData,
+			modify: func(chi *chiv1.ClickHouseInstallation) {
+				monitoring := &chi.Spec.Configuration.Monitoring
+				monitoring.Enabled = "yes"
+				monitoring.ExporterSidecar = chiv1.ChiExporterSidecar{Enabled: "yes", Image: "f1yegor/clickhouse-exporter:test"}
+				monitoring.Prometheus = chiv1.ChiPrometheus{Enabled: "yes"}
+			},
+			check: func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+				require.Nil(t, ValidateCHI(chi), "failed to validate chi")
+				chi.WalkHosts(func(host *chiv1.ChiHost) error {
+					// Both template-based and default host Services expose metrics
+					objects := creator.CreateHostObjects(host)
+					for _, service := range []*corev1.Service{objects.Service, creator.createServiceHostDefault(host, objects.Service.Name, nil)} {
+						ports := getTestServicePorts(service)
+						require.Contains(t, ports, exporterSidecarPortName, "no exporter port")
+						require.Contains(t, ports, prometheusPortName, "no metrics port")
+					}
+					return nil
+				})
+
+				// Exporter image has to be specified explicitly
+				chi.Spec.Configuration.Monitoring.ExporterSidecar.Image = ""
+				require.Error(t, ValidateCHI(chi), "CHI without exporter image is valid")
+			},
+		},
+		serviceClusterIP("", "", corev1.ServiceTypeLoadBalancer),
+		serviceClusterIP("10.96.0.100", "10.96.0.100", corev1.ServiceTypeClusterIP),
+		serviceClusterIP("none", corev1.ClusterIPNone, corev1.ServiceTypeClusterIP),
+		serviceClusterIP("invalid", "", corev1.ServiceTypeLoadBalancer),
+	})
+}
+
+func TestCreateConfigs(t *testing.T) {
+	runCHITests(t, []chiTest{
+		{
+			name: "common configs order",
+			modify: func(chi *chiv1.ClickHouseInstallation) {
+				chi.Spec.Configuration.Files = chiv1.Settings{
+					"config.d/zz-override.xml": chiv1.NewScalarSetting("<yandex></yandex>"),
+					"config.d/aa-base.xml":     chiv1.NewScalarSetting("<yandex></yandex>"),
+				}
+			},
+			check: func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+				// Files are loaded by ClickHouse in the order of filenames
+				require.Nil(t, creator.chConfigSectionsGenerator.CreateConfigsCommon(), "failed to create common configs")
+				require.Equal(t, []string{
+					"aa-base.xml",
+					"chop-generated-remote_servers.xml",
+					"zz-override.xml",
+				}, creator.chConfigSectionsGenerator.GetCommonConfigFilenames(), "unexpected common config files")
+			},
+		},
+		{
+			name: "keeper configs per host",
+			modify: func(chi *chiv1.ClickHouseInstallation) {
+				chi.Spec.Configuration.Settings = chiv1.Settings{
+					"keeper_server/tcp_port":  chiv1.NewScalarSetting("9181"),
+					"keeper_server/log_level": chiv1.NewScalarSetting("warning"),
+				}
+			},
+			check: func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+				// Keeper settings are not rendered into common config.d, since each Keeper node has own server_id
+				require.Nil(t, creator.chConfigSectionsGenerator.CreateConfigsCommon(), "failed to create common configs")
+				require.NotContains(t, creator.chConfigSectionsGenerator.GetCommonConfigFilenames(), createConfigSectionFilename(configKeeper))
+
+				chi.WalkHosts(func(host *chiv1.ChiHost) error {
+					host.Settings = chiv1.Settings{
+						"keeper_server/tcp_port": chiv1.NewScalarSetting("9182"),
+					}
+					configs, err := creator.chConfigSectionsGenerator.CreateConfigsHost(host)
+					require.Nil(t, err, "failed to create host configs")
+					keeper := configs[createConfigSectionFilename(configKeeper)]
+					require.Contains(t, keeper, "<tcp_port>9182</tcp_port>", "host keeper setting is not rendered")
+					require.NotContains(t, keeper, "9181", "common keeper setting overrides host one")
+					require.Contains(t, keeper, "<log_level>warning</log_level>", "common keeper setting is not rendered")
+					require.NotContains(t, configs[createConfigSectionFilename(configSettings)], "keeper_server", "keeper settings are mixed into server settings")
+					return nil
+				})
+			},
+		},
+		{
+			name: "users configs order",
+			data: `
+apiVersion: "clickhouse.altinity.com/v1"
+kind: "ClickHouseInstallation"
+metadata:
+  name: "users-order"
+  namespace: "kube-system"
+spec:
+  configuration:
+    usersOverrideConfigMap: "users-override"
+    users:
+      test/password: "secret"
+    profiles:
+      test/max_threads: "4"
+    quotas:
+      test/interval/duration: "3600"
+    files:
+      users.d/aa-users.xml: "<yandex></yandex>"
+    clusters:
+      - name: "cluster"
+`,
+			check: func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+				// Generated files are loaded in the intended order and before user files, which take precedence
+				require.Nil(t, creator.chConfigSectionsGenerator.CreateConfigsUsers(), "failed to create users configs")
+				require.Equal(t, []string{
+					"00-chop-generated-profiles.xml",
+					"01-chop-generated-quotas.xml",
+					"02-chop-generated-users.xml",
+					"aa-users.xml",
+				}, creator.chConfigSectionsGenerator.GetUsersConfigFilenames(), "unexpected users config files")
+
+				// Override ConfigMap is projected into users.d along with generated files, so its files win over them
+				chi.WalkHosts(func(host *chiv1.ChiHost) error {
+					for _, volume := range creator.CreateStatefulSet(host).Spec.Template.Spec.Volumes {
+						if volume.Name != CreateConfigMapCommonUsersName(chi) {
+							continue
+						}
+						require.NotNil(t, volume.Projected, "users volume is not projected")
+						require.Len(t, volume.Projected.Sources, 2, "unexpected users volume sources")
+						require.Equal(t, CreateConfigMapCommonUsersName(chi), volume.Projected.Sources[0].ConfigMap.Name, "unexpected users ConfigMap")
+						require.Equal(t, "users-override", volume.Projected.Sources[1].ConfigMap.Name, "unexpected users override ConfigMap")
+						require.True(t, *volume.Projected.Sources[1].ConfigMap.Optional, "users override ConfigMap has to be optional")
+						return nil
+					}
+					require.Fail(t, "no users volume")
+					return nil
+				})
+			},
+		},
+	})
+}
+
+func TestRenderConfigs(t *testing.T) {
+	chi := newTestCHI(t, PodDisruptionBudgetData)
+	configs, err := RenderConfigs(newTestCHOp(), chi)
+	require.Nil(t, err, "failed to render configs")
+	require.Contains(t, configs, CreateConfigMapCommonName(chi)+"/"+createConfigSectionFilename(configRemoteServers), "no remote servers")
+	require.Contains(t, configs, CreateConfigMapCommonUsersName(chi)+"/"+createUsersConfigSectionFilename(configUsers), "no users")
+
+	// Each host has its own macros
+	_, normalized := newTestCreator(t, PodDisruptionBudgetData)
+	normalized.WalkHosts(func(host *chiv1.ChiHost) error {
+		macros := configs[CreateConfigMapPodName(host)+"/"+createConfigSectionFilename(configMacros)]
+		require.Contains(t, macros, "<replica>"+CreatePodHostname(host)+"</replica>", "unexpected host macros")
+		return nil
+	})
+}
+
+func TestObjectMeta(t *testing.T) {
+	runCHITests(t, []chiTest{
+		{
+			name: "owner references",
+			data: PodDisruptionBudgetData,
+			modify: func(chi *chiv1.ClickHouseInstallation) {
+				chi.UID = "d9fa2b14-0e35-4b7c-a3e1-8f2c6d1a5b07"
+			},
+			check: func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+				var objects []*metav1.ObjectMeta
+				objects = append(objects, &creator.CreateServiceCHI().ObjectMeta)
+				common, err := creator.CreateConfigMapCHICommon()
+				require.Nil(t, err, "failed to create common config map")
+				users, err := creator.CreateConfigMapCHICommonUsers()
+				require.Nil(t, err, "failed to create users config map")
+				topology, err := creator.CreateConfigMapCHITopology()
+				require.Nil(t, err, "failed to create topology config map")
+				objects = append(objects, &common.ObjectMeta, &users.ObjectMeta, &topology.ObjectMeta)
+				chi.WalkShards(func(shard *chiv1.ChiShard) error {
+					objects = append(objects, &creator.CreatePodDisruptionBudgetShard(shard).ObjectMeta)
+					return nil
+				})
+				hostsObjects, err := creator.CreateHostsObjects(0)
+				require.Nil(t, err, "failed to create hosts objects")
+				for _, hostObjects := range hostsObjects {
+					objects = append(objects, &hostObjects.ConfigMap.ObjectMeta, &hostObjects.StatefulSet.ObjectMeta, &hostObjects.Service.ObjectMeta)
+				}
+
+				// Each object has exactly one controller owner - the CHI
+				for _, object := range objects {
+					require.Len(t, object.OwnerReferences, 1, "unexpected owner references of %s", object.Name)
+					owner := metav1.GetControllerOf(object)
+					require.NotNil(t, owner, "no controller owner of %s", object.Name)
+					require.Equal(t, chi.UID, owner.UID, "unexpected owner UID of %s", object.Name)
+					require.Equal(t, chiv1.ClickHouseInstallationCRDResourceKind, owner.Kind, "unexpected owner kind of %s", object.Name)
+					require.Equal(t, chiv1.SchemeGroupVersion.String(), owner.APIVersion, "unexpected owner APIVersion of %s", object.Name)
+					require.Equal(t, chi.Name, owner.Name, "unexpected owner name of %s", object.Name)
+				}
+			},
+		},
+		{
+			name: "spec labels and annotations",
+			data: PodDisruptionBudgetData,
+			modify: func(chi *chiv1.ClickHouseInstallation) {
+				chi.Spec.Labels = map[string]string{
+					"example.com/cost-center":     "analytics",
+					"clickhouse.altinity.com/app": "other",
+					LabelShardName:                "other",
+				}
+				chi.Spec.Annotations = map[string]string{
+					"example.com/owner":     "team",
+					AnnotationConfigVersion: "other",
+				}
+			},
+			check: func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+				var objects []*metav1.ObjectMeta
+				objects = append(objects, &creator.CreateServiceCHI().ObjectMeta)
+				common, err := creator.CreateConfigMapCHICommon()
+				require.Nil(t, err, "failed to create common config map")
+				objects = append(objects, &common.ObjectMeta)
+				chi.WalkShards(func(shard *chiv1.ChiShard) error {
+					objects = append(objects, &creator.CreatePodDisruptionBudgetShard(shard).ObjectMeta)
+					return nil
+				})
+				chi.WalkHosts(func(host *chiv1.ChiHost) error {
+					statefulSet := creator.CreateStatefulSet(host)
+					objects = append(objects, &statefulSet.ObjectMeta, &statefulSet.Spec.Template.ObjectMeta, &creator.CreateServiceHost(host).ObjectMeta)
+
+					// Pod template labels still match StatefulSet's selector
+					for key, value := range statefulSet.Spec.Selector.MatchLabels {
+						require.Equal(t, value, statefulSet.Spec.Template.Labels[key], "pod template label %s does not match selector", key)
+					}
+					require.Equal(t, host.Address.ShardName, statefulSet.Spec.Template.Labels[LabelShardName], "shard label is overwritten")
+					require.Equal(t, getConfigVersion(host), statefulSet.Annotations[AnnotationConfigVersion], "config version annotation is overwritten")
+					return nil
+				})
+
+				for _, object := range objects {
+					require.Equal(t, "analytics", object.Labels["example.com/cost-center"], "no spec label on %s", object.Name)
+					require.Equal(t, "team", object.Annotations["example.com/owner"], "no spec annotation on %s", object.Name)
+					// Operator-owned labels win
+					require.Equal(t, LabelAppValue, object.Labels[LabelAppName], "operator label is overwritten on %s", object.Name)
+				}
+			},
+		},
+	})
+}
+
+func TestPrometheusScrape(t *testing.T) {
+	create := func(prometheus chiv1.ChiPrometheus) []*HostObjects {
+		creator, chi := newTestCreator(t, TestCHIData, func(chi *chiv1.ClickHouseInstallation) {
+			chi.Spec.Configuration.Monitoring.Prometheus = prometheus
+		})
+		objects := []*HostObjects{}
+		chi.WalkHosts(func(host *chiv1.ChiHost) error {
+			objects = append(objects, creator.CreateHostObjects(host))
+			return nil
+		})
+		return objects
+	}
+
+	// Disabled prometheus section leaves generated objects unchanged
+	require.Equal(t, create(chiv1.ChiPrometheus{}), create(chiv1.ChiPrometheus{Enabled: "no"}))
+
+	for _, objects := range create(chiv1.ChiPrometheus{Enabled: "yes", Port: "9100", Endpoint: "/prom"}) {
+		expected := map[string]string{
+			annotationPrometheusScrape: "true",
+			annotationPrometheusPort:   "9100",
+			annotationPrometheusPath:   "/prom",
+		}
+		for key, value := range expected {
+			require.Equal(t, value, objects.StatefulSet.Spec.Template.Annotations[key], "pod annotation %s", key)
+			require.Equal(t, value, objects.Service.Annotations[key], "service annotation %s", key)
+		}
+
+		container, ok := findClickHouseContainer(objects.StatefulSet, ClickHouseContainerName)
+		require.True(t, ok, "no clickhouse container")
+		containerPorts := map[string]int32{}
+		for _, port := range container.Ports {
+			containerPorts[port.Name] = port.ContainerPort
+		}
+		require.Equal(t, int32(9100), containerPorts[prometheusPortName], "no metrics container port")
+
+		servicePorts := map[string]int32{}
+		for _, port := range objects.Service.Spec.Ports {
+			servicePorts[port.Name] = port.Port
+		}
+		require.Equal(t, int32(9100), servicePorts[prometheusPortName], "no metrics service port")
+	}
+}
+
+func TestCertRotationToken(t *testing.T) {
+	annotations := func(token string, secret *corev1.SecretKeySelector, fingerprint string) []string {
+		creator, chi := newTestCreator(t, TestCHIData, func(chi *chiv1.ClickHouseInstallation) {
+			chi.Spec.Defaults.CertRotationToken = token
+			chi.Spec.Defaults.CertRotationTokenSecret = secret
+		})
+		if secret != nil {
+			require.Equal(t, secret.Key != "", chi.Spec.Defaults.HasCertRotationTokenSecret(), "incomplete secret is not skipped")
+		}
+
+		result := []string{}
+		chi.WalkHosts(func(host *chiv1.ChiHost) error {
+			// Fingerprint of the Secret is resolved by the worker on reconcile
+			host.Config.CertRotationTokenFingerprint = fingerprint
+			objects := creator.CreateHostObjects(host)
+			result = append(result, objects.StatefulSet.Spec.Template.Annotations[AnnotationCertRotationToken])
+			return nil
+		})
+		return result
+	}
+
+	secret := &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "tls"}, Key: "tls.crt"}
+	for _, annotation := range annotations("", nil, "") {
+		require.Empty(t, annotation, "annotation without token")
+	}
+	for _, annotation := range annotations("v1", nil, "") {
+		require.Equal(t, "v1", annotation)
+	}
+	for _, annotation := range annotations("", secret, "abc") {
+		require.Equal(t, "abc", annotation)
+	}
+	for _, annotation := range annotations("v1", secret, "abc") {
+		require.Equal(t, "v1-abc", annotation)
+	}
+	annotations("", &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "tls"}}, "")
+}
+
+func TestCreateHostOrdinal(t *testing.T) {
+	ordinals := func(shardsCount int) map[string]int {
+		_, chi := newTestCreator(t, StableNamesData, func(chi *chiv1.ClickHouseInstallation) {
+			chi.Spec.Configuration.Clusters[0].Layout.ShardsCount = shardsCount
+		})
+
+		result := map[string]int{}
+		chi.WalkHosts(func(host *chiv1.ChiHost) error {
+			ordinal := CreateHostOrdinal(host)
+			require.True(t, ordinal > 0, "ordinal has to be positive")
+			for name, other := range result {
+				require.NotEqual(t, other, ordinal, "ordinal of %s is not unique", name)
+			}
+			result[CreateStatefulSetName(host)] = ordinal
+			return nil
+		})
+		return result
+	}
+
+	// Adding shard to the first cluster does not change ordinals of hosts of another cluster, as well as of existing shards
+	before := ordinals(2)
+	after := ordinals(3)
+	for name, ordinal := range before {
+		require.Equal(t, ordinal, after[name], "ordinal of %s changed", name)
+	}
+}
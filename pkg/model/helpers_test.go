@@ -0,0 +1,79 @@
+package model
+
+import (
+	"testing"
+
+	chiv1 "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	"github.com/altinity/clickhouse-operator/pkg/chop"
+	"github.com/kubernetes-sigs/yaml"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCHIData describes plain CHI, which test cases modify to their needs
+var TestCHIData = `
+apiVersion: "clickhouse.altinity.com/v1"
+kind: "ClickHouseInstallation"
+metadata:
+  name: "test"
+  namespace: "kube-system"
+spec:
+  configuration:
+    clusters:
+      - name: "cluster"
+        layout:
+          shardsCount: 2
+          replicasCount: 2
+`
+
+// chiTest describes test case, which checks CHI specified by data, modified in advance, after normalization
+type chiTest struct {
+	name   string
+	data   string
+	modify func(chi *chiv1.ClickHouseInstallation)
+	check  func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation)
+}
+
+// runCHITests runs each test case as a subtest
+func runCHITests(t *testing.T, tests []chiTest) {
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			data := test.data
+			if data == "" {
+				data = TestCHIData
+			}
+			var modify []func(chi *chiv1.ClickHouseInstallation)
+			if test.modify != nil {
+				modify = append(modify, test.modify)
+			}
+			creator, chi := newTestCreator(t, data, modify...)
+			test.check(t, creator, chi)
+		})
+	}
+}
+
+// newTestCHOp creates operator with default configuration
+func newTestCHOp() *chop.CHOp {
+	CHOp := chop.NewCHOp("", nil, "")
+	CHOp.Init()
+	return CHOp
+}
+
+// newTestCHI unmarshals CHI specified by data and applies modifiers to it
+func newTestCHI(t require.TestingT, data string, modify ...func(chi *chiv1.ClickHouseInstallation)) *chiv1.ClickHouseInstallation {
+	chi := new(chiv1.ClickHouseInstallation)
+	err := yaml.Unmarshal([]byte(data), chi)
+	require.Nil(t, err, "failed to unmarshal chi")
+	for _, f := range modify {
+		f(chi)
+	}
+	return chi
+}
+
+// newTestCreator normalizes CHI specified by data, modified in advance, and creates its Creator
+func newTestCreator(t require.TestingT, data string, modify ...func(chi *chiv1.ClickHouseInstallation)) (*Creator, *chiv1.ClickHouseInstallation) {
+	CHOp := newTestCHOp()
+	chi, err := NewNormalizer(CHOp).NormalizeCHI(newTestCHI(t, data, modify...))
+	require.Nil(t, err, "failed to normalize chi")
+	return NewCreator(CHOp, chi), chi
+}
@@ -16,6 +16,8 @@ package model
 
 import (
 	"fmt"
+	"strconv"
+
 	"github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com"
 	chi "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
 	"github.com/altinity/clickhouse-operator/pkg/chop"
@@ -49,15 +51,48 @@ const (
 	labelConfigMapValueCHICommon      = "ChiCommon"
 	labelConfigMapValueCHICommonUsers = "ChiCommonUsers"
 	labelConfigMapValueHost           = "Host"
+	labelConfigMapValueCHITopology    = "ChiTopology"
+	labelConfigMapValueCHIExporter    = "ChiExporter"
 	LabelService                      = clickhousealtinitycom.GroupName + "/Service"
 	labelServiceValueCHI              = "chi"
+	labelServiceValueCHIExternal      = "chi-external"
 	labelServiceValueCluster          = "cluster"
 	labelServiceValueShard            = "shard"
+	labelServiceValueShardLeader      = "shard-leader"
+	labelServiceValueShardRead        = "shard-read"
+	labelServiceValueShardWrite       = "shard-write"
 	labelServiceValueHost             = "host"
+	labelServiceValueHostHeadless     = "host-headless"
+	LabelReplicaRole                  = clickhousealtinitycom.GroupName + "/role"
+	labelReplicaRoleValuePrimary      = "primary"
+	labelReplicaRoleValueReplica      = "replica"
 
 	// Supplementary service labels - used to cooperate with k8s
 	LabelZookeeperConfigVersion = clickhousealtinitycom.GroupName + "/zookeeper-version"
 	LabelSettingsConfigVersion  = clickhousealtinitycom.GroupName + "/settings-version"
+
+	// Pod template annotation which rolls pods whenever certificates are rotated
+	AnnotationCertRotationToken = clickhousealtinitycom.GroupName + "/cert-rotation-token"
+	// StatefulSet annotation specifying naming scheme version StatefulSet name was created with
+	AnnotationNameSchemeVersion = clickhousealtinitycom.GroupName + "/name-scheme-version"
+	// StatefulSet annotations with fingerprints of restart-required and hot-reloadable settings
+	AnnotationRestartSettingsVersion = clickhousealtinitycom.GroupName + "/restart-settings-version"
+	AnnotationHotSettingsVersion     = clickhousealtinitycom.GroupName + "/hot-settings-version"
+	// StatefulSet annotations with checksum of the whole host's config, time it was applied at and previous checksums
+	AnnotationConfigVersion = clickhousealtinitycom.GroupName + "/config-version"
+	AnnotationConfigUpdated = clickhousealtinitycom.GroupName + "/config-updated"
+	AnnotationConfigHistory = clickhousealtinitycom.GroupName + "/config-history"
+
+	// Service mesh port exclusion annotations
+	annotationIstioExcludeInboundPorts  = "traffic.sidecar.istio.io/excludeInboundPorts"
+	annotationIstioExcludeOutboundPorts = "traffic.sidecar.istio.io/excludeOutboundPorts"
+	annotationLinkerdSkipInboundPorts   = "config.linkerd.io/skip-inbound-ports"
+	annotationLinkerdSkipOutboundPorts  = "config.linkerd.io/skip-outbound-ports"
+
+	// Prometheus scrape annotations
+	annotationPrometheusScrape = "prometheus.io/scrape"
+	annotationPrometheusPort   = "prometheus.io/port"
+	annotationPrometheusPath   = "prometheus.io/path"
 )
 
 // Labeler is an entity which can label CHI artifacts
@@ -94,6 +129,24 @@ func (l *Labeler) getLabelsConfigMapCHICommonUsers() map[string]string {
 		})
 }
 
+// getLabelsConfigMapCHITopology
+func (l *Labeler) getLabelsConfigMapCHITopology() map[string]string {
+	return util.MergeStringMaps(
+		l.getLabelsCHIScope(),
+		map[string]string{
+			LabelConfigMap: labelConfigMapValueCHITopology,
+		})
+}
+
+// getLabelsConfigMapCHIExporter
+func (l *Labeler) getLabelsConfigMapCHIExporter() map[string]string {
+	return util.MergeStringMaps(
+		l.getLabelsCHIScope(),
+		map[string]string{
+			LabelConfigMap: labelConfigMapValueCHIExporter,
+		})
+}
+
 // getLabelsConfigMapHost
 func (l *Labeler) getLabelsConfigMapHost(host *chi.ChiHost) map[string]string {
 	return util.MergeStringMaps(
@@ -112,6 +165,15 @@ func (l *Labeler) getLabelsServiceCHI() map[string]string {
 		})
 }
 
+// getLabelsServiceCHIExternal
+func (l *Labeler) getLabelsServiceCHIExternal() map[string]string {
+	return util.MergeStringMaps(
+		l.getLabelsCHIScope(),
+		map[string]string{
+			LabelService: labelServiceValueCHIExternal,
+		})
+}
+
 // getLabelsServiceCluster
 func (l *Labeler) getLabelsServiceCluster(cluster *chi.ChiCluster) map[string]string {
 	return util.MergeStringMaps(
@@ -130,6 +192,33 @@ func (l *Labeler) getLabelsServiceShard(shard *chi.ChiShard) map[string]string {
 		})
 }
 
+// getLabelsServiceShardLeader
+func (l *Labeler) getLabelsServiceShardLeader(shard *chi.ChiShard) map[string]string {
+	return util.MergeStringMaps(
+		l.getLabelsShardScope(shard),
+		map[string]string{
+			LabelService: labelServiceValueShardLeader,
+		})
+}
+
+// getLabelsServiceShardRead
+func (l *Labeler) getLabelsServiceShardRead(shard *chi.ChiShard) map[string]string {
+	return util.MergeStringMaps(
+		l.getLabelsShardScope(shard),
+		map[string]string{
+			LabelService: labelServiceValueShardRead,
+		})
+}
+
+// getLabelsServiceShardWrite
+func (l *Labeler) getLabelsServiceShardWrite(shard *chi.ChiShard) map[string]string {
+	return util.MergeStringMaps(
+		l.getLabelsShardScope(shard),
+		map[string]string{
+			LabelService: labelServiceValueShardWrite,
+		})
+}
+
 // getLabelsServiceHost
 func (l *Labeler) getLabelsServiceHost(host *chi.ChiHost) map[string]string {
 	return util.MergeStringMaps(
@@ -139,6 +228,15 @@ func (l *Labeler) getLabelsServiceHost(host *chi.ChiHost) map[string]string {
 		})
 }
 
+// getLabelsServiceHostHeadless
+func (l *Labeler) getLabelsServiceHostHeadless(host *chi.ChiHost) map[string]string {
+	return util.MergeStringMaps(
+		l.getLabelsHostScope(host, false),
+		map[string]string{
+			LabelService: labelServiceValueHostHeadless,
+		})
+}
+
 // getLabelsCHIScope gets labels for CHI-scoped object
 func (l *Labeler) getLabelsCHIScope() map[string]string {
 	// Combine generated labels and CHI-provided labels
@@ -205,6 +303,42 @@ func (l *Labeler) getSelectorShardScope(shard *chi.ChiShard) map[string]string {
 	}
 }
 
+// getSelectorShardLeaderScope gets labels to select first replica of a Shard
+func (l *Labeler) getSelectorShardLeaderScope(shard *chi.ChiShard) map[string]string {
+	// Do not include CHI-provided labels
+	return util.MergeStringMaps(
+		l.getSelectorShardScope(shard),
+		map[string]string{
+			// Replica index within the shard
+			LabelShardScopeIndex: "0",
+		})
+}
+
+// getSelectorShardWriteScope gets labels to select primary replica of a Shard
+func (l *Labeler) getSelectorShardWriteScope(shard *chi.ChiShard) map[string]string {
+	// Do not include CHI-provided labels
+	return util.MergeStringMaps(
+		l.getSelectorShardScope(shard),
+		map[string]string{
+			LabelReplicaRole: labelReplicaRoleValuePrimary,
+		})
+}
+
+// getLabelsPod gets labels for host's pod, which are host-scope labels along with replica role, in case role Services are enabled
+func (l *Labeler) getLabelsPod(host *chi.ChiHost) map[string]string {
+	labels := l.getLabelsHostScope(host, true)
+	if !util.IsStringBoolTrue(l.chi.Spec.Defaults.RoleServices) {
+		return labels
+	}
+	// First replica of the shard is the primary one
+	if host.Address.ShardScopeIndex == 0 {
+		labels[LabelReplicaRole] = labelReplicaRoleValuePrimary
+	} else {
+		labels[LabelReplicaRole] = labelReplicaRoleValueReplica
+	}
+	return labels
+}
+
 // getLabelsHostScope gets labels for Host-scoped object
 func (l *Labeler) getLabelsHostScope(host *chi.ChiHost, applySupplementaryServiceLabels bool) map[string]string {
 	// Combine generated labels and CHI-provided labels
@@ -230,6 +364,7 @@ func (l *Labeler) getLabelsHostScope(host *chi.ChiHost, applySupplementaryServic
 		// TODO
 		// When we'll have Cluster Discovery functionality we can refactor this properly
 		labels[LabelZookeeperConfigVersion] = host.Config.ZookeeperFingerprint
+		// Hot-reloadable settings are not included, so their change does not roll pods
 		labels[LabelSettingsConfigVersion] = util.Fingerprint(host.Config.SettingsFingerprint + host.Config.FilesFingerprint)
 	}
 	return l.appendCHILabels(labels)
@@ -250,15 +385,132 @@ func (l *Labeler) GetSelectorHostScope(host *chi.ChiHost) map[string]string {
 	}
 }
 
-// appendCHILabels appends CHI-provided labels to labels set
+// appendCHILabels appends CHI-provided labels to labels set.
+// Labels specified in .spec.labels do not overwrite labels already present in the set, such as operator-managed ones
 func (l *Labeler) appendCHILabels(dst map[string]string) map[string]string {
-	return util.MergeStringMaps(dst, l.chi.Labels)
+	labels := util.MergeStringMaps(util.MergeStringMaps(nil, l.chi.Spec.Labels), dst)
+	return util.MergeStringMaps(labels, l.chi.Labels)
+}
+
+// appendSpecAnnotations appends to annotations set annotations specified in .spec.annotations.
+// Annotations already present in the set, such as operator-managed ones, are not overwritten
+func (l *Labeler) appendSpecAnnotations(dst map[string]string) map[string]string {
+	if dst == nil {
+		dst = make(map[string]string)
+	}
+	for key, value := range l.chi.Spec.Annotations {
+		if _, ok := dst[key]; !ok {
+			dst[key] = value
+		}
+	}
+	return dst
+}
+
+// propagateAnnotations appends to annotations set CHI annotations listed in .spec.propagateAnnotations.
+// Annotations already present in the set, such as operator-managed ones, are not overwritten
+func (l *Labeler) propagateAnnotations(dst map[string]string) map[string]string {
+	if dst == nil {
+		dst = make(map[string]string)
+	}
+	for _, key := range l.chi.Spec.PropagateAnnotations {
+		if _, ok := dst[key]; ok {
+			continue
+		}
+		if value, ok := l.chi.Annotations[key]; ok {
+			dst[key] = value
+		}
+	}
+	return l.appendSpecAnnotations(dst)
+}
+
+// getAnnotationsPropagated gets CHI annotations to be propagated to generated objects
+func (l *Labeler) getAnnotationsPropagated() map[string]string {
+	return l.propagateAnnotations(nil)
+}
+
+// getCertRotationToken gets cert rotation token of the host - either user-provided token,
+// or fingerprint of certificates Secret, or both of them
+func (l *Labeler) getCertRotationToken(host *chi.ChiHost) string {
+	token := host.CHI.Spec.Defaults.CertRotationToken
+	fingerprint := host.Config.CertRotationTokenFingerprint
+	switch {
+	case token == "":
+		return fingerprint
+	case fingerprint == "":
+		return token
+	default:
+		return token + "-" + fingerprint
+	}
 }
 
 // getAnnotationsHostScope gets annotations for Host-scoped object
 func (l *Labeler) getAnnotationsHostScope(host *chi.ChiHost) map[string]string {
-	// We may want to append some annotations in here
-	return host.GetAnnotations()
+	annotations := host.GetAnnotations()
+	if token := l.getCertRotationToken(host); token != "" {
+		// Changed token changes pod template and thus rolls pods, so ClickHouse picks up new certificates
+		annotations[AnnotationCertRotationToken] = token
+	}
+	if mesh := &host.CHI.Spec.Defaults.ServiceMesh; mesh.IsInterserverPortExcluded() {
+		// Replication traffic goes directly between pods, bypassing mesh sidecars
+		port := strconv.Itoa(int(host.InterserverHTTPPort))
+		switch mesh.Type {
+		case serviceMeshIstio:
+			annotations[annotationIstioExcludeInboundPorts] = port
+			annotations[annotationIstioExcludeOutboundPorts] = port
+		case serviceMeshLinkerd:
+			annotations[annotationLinkerdSkipInboundPorts] = port
+			annotations[annotationLinkerdSkipOutboundPorts] = port
+		}
+	}
+	return l.appendSpecAnnotations(l.appendPrometheusAnnotations(annotations))
+}
+
+// appendPrometheusAnnotations appends Prometheus scrape annotations in case built-in Prometheus endpoint is enabled.
+// Annotations already present in the set are not overwritten
+func (l *Labeler) appendPrometheusAnnotations(dst map[string]string) map[string]string {
+	prometheus := &l.chi.Spec.Configuration.Monitoring.Prometheus
+	if !prometheus.IsEnabled() {
+		return dst
+	}
+	if dst == nil {
+		dst = make(map[string]string)
+	}
+	for key, value := range map[string]string{
+		annotationPrometheusScrape: "true",
+		annotationPrometheusPort:   prometheus.Port,
+		annotationPrometheusPath:   prometheus.Endpoint,
+	} {
+		if _, ok := dst[key]; !ok {
+			dst[key] = value
+		}
+	}
+	return dst
+}
+
+// getAnnotationsStatefulSet gets annotations of a host's StatefulSet.
+// CHI annotations are combined with shard/replica/host-specific ones, operator's annotations are applied on top
+func (l *Labeler) getAnnotationsStatefulSet(host *chi.ChiHost) map[string]string {
+	annotations := util.MergeStringMaps(host.GetAnnotations(), host.StatefulSetAnnotations)
+	annotations = util.MergeStringMaps(annotations, map[string]string{
+		AnnotationNameSchemeVersion:      nameSchemeVersion,
+		AnnotationRestartSettingsVersion: util.Fingerprint(host.Config.RestartSettingsFingerprint + host.Config.FilesFingerprint),
+		AnnotationHotSettingsVersion:     host.Config.HotSettingsFingerprint,
+		AnnotationConfigVersion:          getConfigVersion(host),
+	})
+	if host.Config.StatefulSetNameAlias != "" {
+		// StatefulSet keeps the name of previous naming scheme, so it has to stay outdated
+		delete(annotations, AnnotationNameSchemeVersion)
+	}
+	return l.appendSpecAnnotations(annotations)
+}
+
+// getConfigVersion returns short checksum of the whole host's config
+func getConfigVersion(host *chi.ChiHost) string {
+	version := util.Fingerprint(host.Config.ZookeeperFingerprint + host.Config.SettingsFingerprint + host.Config.FilesFingerprint)
+	if len(version) > configVersionLength {
+		return version[:configVersionLength]
+	}
+	return version
 }
 
 // prepareAffinity
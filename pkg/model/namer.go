@@ -17,6 +17,7 @@ package model
 import (
 	"fmt"
 	"k8s.io/api/core/v1"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -93,6 +94,18 @@ const (
 	macrosClusterScopeCycleHeadPointsToPreviousCycleTail = "{clusterScopeCycleHeadPointsToPreviousCycleTail}"
 )
 
+// nameSchemeVersion is a version of StatefulSet naming scheme, which consists of name patterns, name parts length
+// and ID hashing. StatefulSet names have to be stable across operator versions, since renamed StatefulSet leads to
+// the whole cluster being recreated with data lost. In case the scheme has to be changed, bump the version,
+// so StatefulSets created with previous scheme, annotated with previous version, can be found and migrated.
+const nameSchemeVersion = "1"
+
+// IsStatefulSetNameSchemeOutdated checks whether StatefulSet was named with naming scheme other than the current one.
+// StatefulSets created before naming scheme was versioned have no version annotation at all
+func IsStatefulSetNameSchemeOutdated(statefulSet *apps.StatefulSet) bool {
+	return statefulSet.Annotations[AnnotationNameSchemeVersion] != nameSchemeVersion
+}
+
 const (
 	// chiServiceNamePattern is a template of CHI Service name. "clickhouse-{chi}"
 	chiServiceNamePattern = "clickhouse-" + macrosChiName
@@ -103,6 +116,18 @@ const (
 	// shardServiceNamePattern is a template of shard Service name. "shard-{chi}-{cluster}-{shard}"
 	shardServiceNamePattern = "shard-" + macrosChiName + "-" + macrosClusterName + "-" + macrosShardName
 
+	// shardLeaderServiceNamePattern is a template of shard's first replica Service name. "leader-{chi}-{cluster}-{shard}"
+	shardLeaderServiceNamePattern = "leader-" + macrosChiName + "-" + macrosClusterName + "-" + macrosShardName
+
+	// shardReadServiceNamePattern is a template of shard's all replicas Service name. "read-{chi}-{cluster}-{shard}"
+	shardReadServiceNamePattern = "read-" + macrosChiName + "-" + macrosClusterName + "-" + macrosShardName
+
+	// shardWriteServiceNamePattern is a template of shard's primary replica Service name. "write-{chi}-{cluster}-{shard}"
+	shardWriteServiceNamePattern = "write-" + macrosChiName + "-" + macrosClusterName + "-" + macrosShardName
+
+	// shardPodDisruptionBudgetNamePattern is a template of shard's PodDisruptionBudget name. "pdb-{chi}-{cluster}-{shard}"
+	shardPodDisruptionBudgetNamePattern = "pdb-" + macrosChiName + "-" + macrosClusterName + "-" + macrosShardName
+
 	// replicaServiceNamePattern is a template of replica Service name. "shard-{chi}-{cluster}-{replica}"
 	replicaServiceNamePattern = "shard-" + macrosChiName + "-" + macrosClusterName + "-" + macrosReplicaName
 
@@ -112,12 +137,24 @@ const (
 	// statefulSetServiceNamePattern is a template of hosts's StatefulSet's Service name. "chi-{chi}-{cluster}-{shard}-{host}"
 	statefulSetServiceNamePattern = "chi-" + macrosChiName + "-" + macrosClusterName + "-" + macrosHostName
 
+	// statefulSetHeadlessServiceNameSuffix is appended to host's Service name to make a name of StatefulSet's headless governing Service
+	statefulSetHeadlessServiceNameSuffix = "-headless"
+
 	// configMapCommonNamePattern is a template of common settings for the CHI ConfigMap. "chi-{chi}-common-configd"
 	configMapCommonNamePattern = "chi-" + macrosChiName + "-common-configd"
 
 	// configMapCommonUsersNamePattern is a template of common users settings for the CHI ConfigMap. "chi-{chi}-common-usersd"
 	configMapCommonUsersNamePattern = "chi-" + macrosChiName + "-common-usersd"
 
+	// configMapTopologyNamePattern is a template of topology summary ConfigMap. "chi-{chi}-topology"
+	configMapTopologyNamePattern = "chi-" + macrosChiName + "-topology"
+
+	// serviceAccountNamePattern is a template of CHI ServiceAccount name. "chi-{chi}"
+	serviceAccountNamePattern = "chi-" + macrosChiName
+
+	// configMapExporterNamePattern is a template of exporter sidecar config ConfigMap. "chi-{chi}-exporter"
+	configMapExporterNamePattern = "chi-" + macrosChiName + "-exporter"
+
 	// configMapDeploymentNamePattern is a template of macros ConfigMap. "chi-{chi}-deploy-confd-{cluster}-{shard}-{host}"
 	configMapDeploymentNamePattern = "chi-" + macrosChiName + "-deploy-confd-" + macrosClusterName + "-" + macrosHostName
 
@@ -491,6 +528,21 @@ func CreateConfigMapCommonUsersName(chi *chop.ClickHouseInstallation) string {
 	return newNameMacroReplacerChi(chi).Replace(configMapCommonUsersNamePattern)
 }
 
+// CreateConfigMapTopologyName returns a name for a ConfigMap with CHI topology summary
+func CreateConfigMapTopologyName(chi *chop.ClickHouseInstallation) string {
+	return newNameMacroReplacerChi(chi).Replace(configMapTopologyNamePattern)
+}
+
+// CreateConfigMapExporterName returns a name for a ConfigMap with exporter sidecar config
+func CreateConfigMapExporterName(chi *chop.ClickHouseInstallation) string {
+	return newNameMacroReplacerChi(chi).Replace(configMapExporterNamePattern)
+}
+
+// CreateServiceAccountName returns a name of ServiceAccount generated for CHI
+func CreateServiceAccountName(chi *chop.ClickHouseInstallation) string {
+	return newNameMacroReplacerChi(chi).Replace(serviceAccountNamePattern)
+}
+
 // CreateCHIServiceName creates a name of a Installation Service resource
 func CreateCHIServiceName(chi *chop.ClickHouseInstallation) string {
 	// Name can be generated either from default name pattern,
@@ -554,6 +606,11 @@ func CreateClusterServiceName(cluster *chop.ChiCluster) string {
 	return newNameMacroReplacerCluster(cluster).Replace(pattern)
 }
 
+// CreateShardPodDisruptionBudgetName returns a name of a shard's PodDisruptionBudget
+func CreateShardPodDisruptionBudgetName(shard *chop.ChiShard) string {
+	return newNameMacroReplacerShard(shard).Replace(shardPodDisruptionBudgetNamePattern)
+}
+
 // CreateShardServiceName returns a name of a shard's Service
 func CreateShardServiceName(shard *chop.ChiShard) string {
 	// Name can be generated either from default name pattern,
@@ -575,6 +632,37 @@ func CreateShardServiceName(shard *chop.ChiShard) string {
 	return newNameMacroReplacerShard(shard).Replace(pattern)
 }
 
+// CreateShardLeaderServiceName returns a name of a shard's Service, which targets first replica of the shard only
+func CreateShardLeaderServiceName(shard *chop.ChiShard) string {
+	// Name can be generated either from default name pattern,
+	// or from personal name pattern provided in ServiceTemplate
+
+	// Start with default name pattern
+	pattern := shardLeaderServiceNamePattern
+
+	// ServiceTemplate may have personal name pattern specified
+	if template, ok := shard.GetLeaderServiceTemplate(); ok {
+		// ServiceTemplate available
+		if template.GenerateName != "" {
+			// ServiceTemplate has explicitly specified name pattern
+			pattern = template.GenerateName
+		}
+	}
+
+	// Create Service name based on name pattern available
+	return newNameMacroReplacerShard(shard).Replace(pattern)
+}
+
+// CreateShardReadServiceName returns a name of a shard's Service, which targets all replicas of the shard
+func CreateShardReadServiceName(shard *chop.ChiShard) string {
+	return newNameMacroReplacerShard(shard).Replace(shardReadServiceNamePattern)
+}
+
+// CreateShardWriteServiceName returns a name of a shard's Service, which targets primary replica of the shard only
+func CreateShardWriteServiceName(shard *chop.ChiShard) string {
+	return newNameMacroReplacerShard(shard).Replace(shardWriteServiceNamePattern)
+}
+
 // CreateShardName return a name of a shard
 func CreateShardName(shard *chop.ChiShard, index int) string {
 	return strconv.Itoa(index)
@@ -631,6 +719,11 @@ func IsAutoGeneratedHostName(
 
 // CreateStatefulSetName creates a name of a StatefulSet for ClickHouse instance
 func CreateStatefulSetName(host *chop.ChiHost) string {
+	if host.Config.StatefulSetNameAlias != "" {
+		// StatefulSet created with previous naming scheme keeps its name
+		return host.Config.StatefulSetNameAlias
+	}
+
 	// Name can be generated either from default name pattern,
 	// or from personal name pattern provided in PodTemplate
 
@@ -671,6 +764,34 @@ func CreateStatefulSetServiceName(host *chop.ChiHost) string {
 	return newNameMacroReplacerHost(host).Replace(pattern)
 }
 
+// CreateStatefulSetHeadlessServiceName returns a name of a headless Service governing host's StatefulSet,
+// which is created in case host's Service is not headless itself
+func CreateStatefulSetHeadlessServiceName(host *chop.ChiHost) string {
+	return CreateStatefulSetServiceName(host) + statefulSetHeadlessServiceNameSuffix
+}
+
+// CreateStatefulSetGoverningServiceName returns a name of a headless Service governing host's StatefulSet,
+// which provides pods with stable DNS names
+func CreateStatefulSetGoverningServiceName(host *chop.ChiHost) string {
+	if host.Config.StatefulSetServiceName != "" {
+		// Existing StatefulSet keeps Service governing it, since serviceName is immutable
+		return host.Config.StatefulSetServiceName
+	}
+	if IsServiceHostHeadless(host) {
+		return CreateStatefulSetServiceName(host)
+	}
+	return CreateStatefulSetHeadlessServiceName(host)
+}
+
+// IsServiceHostHeadless checks whether host's Service is headless, and thus can govern host's StatefulSet.
+// Default host's Service is headless, while Service template may specify any ClusterIP
+func IsServiceHostHeadless(host *chop.ChiHost) bool {
+	if template, ok := host.GetServiceTemplate(); ok {
+		return template.Spec.ClusterIP == v1.ClusterIPNone
+	}
+	return true
+}
+
 // CreatePodHostname returns a name of a Pod of a ClickHouse instance
 func CreatePodHostname(host *chop.ChiHost) string {
 	// Pod has no own hostname - redirect to appropriate Service
@@ -680,6 +801,11 @@ func CreatePodHostname(host *chop.ChiHost) string {
 // CreatePodFQDN creates a fully qualified domain name of a pod
 // ss-1eb454-2-0.my-dev-domain.svc.cluster.local
 func CreatePodFQDN(host *chop.ChiHost) string {
+	return createHostFQDN(host, CreatePodHostname(host))
+}
+
+// createHostFQDN creates a fully qualified domain name of specified hostname within host's namespace
+func createHostFQDN(host *chop.ChiHost, hostname string) string {
 	// FQDN can be generated either from default pattern,
 	// or from personal pattern provided
 
@@ -694,11 +820,18 @@ func CreatePodFQDN(host *chop.ChiHost) string {
 	// Create FQDN based on pattern available
 	return fmt.Sprintf(
 		pattern,
-		CreatePodHostname(host),
+		hostname,
 		host.Address.Namespace,
 	)
 }
 
+// CreatePodHeadlessServiceFQDN creates a fully qualified domain name of a pod within its headless service,
+// which is stable pod's DNS name compatible with service meshes
+// chi-a82946-2946-0-0-0.chi-a82946-2946-0-0.my-dev-domain.svc.cluster.local
+func CreatePodHeadlessServiceFQDN(host *chop.ChiHost) string {
+	return CreatePodName(host) + "." + createHostFQDN(host, CreateStatefulSetGoverningServiceName(host))
+}
+
 // CreatePodFQDNsOfCluster creates fully qualified domain names of all pods in a cluster
 func CreatePodFQDNsOfCluster(cluster *chop.ChiCluster) []string {
 	fqdns := make([]string, 0)
@@ -729,12 +862,40 @@ func CreatePodFQDNsOfCHI(chi *chop.ClickHouseInstallation) []string {
 	return fqdns
 }
 
+// ListPodFQDNs lists sorted fully qualified domain names of all pods of a normalized CHI.
+// Each host is deployed as a StatefulSet with one pod, so there is one FQDN per host
+func ListPodFQDNs(chi *chop.ClickHouseInstallation) ([]string, error) {
+	if chi == nil {
+		return nil, fmt.Errorf("unable to list pod FQDNs of nil CHI")
+	}
+	if chi.HostsCount() == 0 {
+		return nil, fmt.Errorf("CHI %s/%s has no hosts, has it been normalized?", chi.Namespace, chi.Name)
+	}
+
+	fqdns := CreatePodFQDNsOfCHI(chi)
+	sort.Strings(fqdns)
+	return fqdns, nil
+}
+
 // template is defined in operator config:
 // CHConfigNetworksHostRegexpTemplate: chi-{chi}-[^.]+\\d+-\\d+\\.{namespace}.svc.cluster.local$"
 func CreatePodRegexp(chi *chop.ClickHouseInstallation, template string) string {
 	return newNameMacroReplacerChi(chi).Replace(template)
 }
 
+// CreateHostOrdinal returns ordinal of a host, which is unique within CHI.
+// Each StatefulSet has only 1 pod, so pod's ordinal within StatefulSet is always 0 and can not be used
+// to distinguish hosts. Ordinal is built of cluster, shard and replica indexes, so it does not change when shards
+// or replicas are added to or removed from the tail of another shard or cluster, and is positive, as keeper's server_id has to be
+func CreateHostOrdinal(host *chop.ChiHost) int {
+	return host.Address.ClusterIndex*hostOrdinalClusterFactor + host.Address.ShardIndex*hostOrdinalShardFactor + host.Address.ReplicaIndex + 1
+}
+
+// CreateHostDisplayName returns ClickHouse <display_name> of a host, such as 'chi/cluster/shard/replica'
+func CreateHostDisplayName(host *chop.ChiHost) string {
+	return fmt.Sprintf("%s/%s/%s/%s", host.Address.CHIName, host.Address.ClusterName, host.Address.ShardName, host.Address.ReplicaName)
+}
+
 // CreatePodName create Pod name based on specified StatefulSet or Replica
 func CreatePodName(obj interface{}) string {
 	switch obj.(type) {
@@ -752,3 +913,18 @@ func CreatePodName(obj interface{}) string {
 func CreatePVCName(host *chop.ChiHost, _ *v1.VolumeMount, template *chop.ChiVolumeClaimTemplate) string {
 	return template.Name + "-" + CreatePodName(host)
 }
+
+// createUserPasswordEnvVarName creates name of env var, which provides password of a user from Secret,
+// such as CLICKHOUSE_USER_PASSWORD_ANALYST for 'analyst' user
+func createUserPasswordEnvVarName(username string) string {
+	return userPasswordEnvVarNamePrefix + strings.Map(func(r rune) rune {
+		switch {
+		case (r >= 'a') && (r <= 'z'):
+			return r - 'a' + 'A'
+		case ((r >= 'A') && (r <= 'Z')) || ((r >= '0') && (r <= '9')):
+			return r
+		default:
+			return '_'
+		}
+	}, username)
+}
@@ -149,6 +149,22 @@ func TestCreatePodFQDN(t *testing.T) {
 	})
 }
 
+func TestListPodFQDNs(t *testing.T) {
+	// Hosts are not known before normalization
+	_, err := ListPodFQDNs(newTestCHI(t, TestCHIData))
+	require.NotNil(t, err, "expected error for not normalized chi")
+
+	_, chi := newTestCreator(t, TestCHIData)
+	fqdns, err := ListPodFQDNs(chi)
+	require.Nil(t, err, "failed to list pod fqdns")
+	require.Equal(t, []string{
+		"chi-test-cluster-0-0.kube-system.svc.cluster.local",
+		"chi-test-cluster-0-1.kube-system.svc.cluster.local",
+		"chi-test-cluster-1-0.kube-system.svc.cluster.local",
+		"chi-test-cluster-1-1.kube-system.svc.cluster.local",
+	}, fqdns, "unexpected pod fqdns")
+}
+
 func TestCreateStatefulSetName(t *testing.T) {
 	CHOp := chop.NewCHOp("", nil, "")
 	CHOp.Init()
@@ -176,3 +192,68 @@ func TestCreateStatefulSetName(t *testing.T) {
 		return nil
 	})
 }
+
+var StableNamesData = `
+apiVersion: "clickhouse.altinity.com/v1"
+kind: "ClickHouseInstallation"
+metadata:
+  name: "stable-names"
+  namespace: "kube-system"
+spec:
+  configuration:
+    clusters:
+      - name: "default-names"
+        layout:
+          shardsCount: 2
+          replicasCount: 2
+      - name: "id-names"
+        templates:
+          podTemplate: clickhouse-pod-template
+        layout:
+          shardsCount: 1
+          replicasCount: 2
+  templates:
+    podTemplates:
+      - name: "clickhouse-pod-template"
+        generateName: "id-{clusterID}-{shardID}-{replicaID}"
+        spec:
+          containers:
+            - name: clickhouse
+              image: yandex/clickhouse-server:19.16.10.44
+`
+
+// TestStableStatefulSetNames ensures StatefulSet names do not change for a fixed CHI.
+// Changed names lead to the whole cluster being recreated, thus nameSchemeVersion has to be bumped along with this test.
+func TestStableStatefulSetNames(t *testing.T) {
+	_, chi := newTestCreator(t, StableNamesData)
+
+	var names []string
+	chi.WalkHostsTillError(func(host *chiv1.ChiHost) error {
+		names = append(names, CreateStatefulSetName(host))
+		return nil
+	})
+	require.Equal(t, []string{
+		"chi-stable-names-default-names-0-0",
+		"chi-stable-names-default-names-0-1",
+		"chi-stable-names-default-names-1-0",
+		"chi-stable-names-default-names-1-1",
+		"id-7b2e00ffd29dbd7-2d40ab994e8410c-2d40ab994e8410c",
+		"id-7b2e00ffd29dbd7-2d40ab994e8410c-28d46e6395428ab",
+	}, names, "unexpected statefulset names")
+	require.Equal(t, "1", nameSchemeVersion, "unexpected name scheme version")
+}
+
+func TestStatefulSetNameAlias(t *testing.T) {
+	creator, chi := newTestCreator(t, StableNamesData)
+	chi.WalkHosts(func(host *chiv1.ChiHost) error {
+		statefulSet := creator.CreateStatefulSet(host)
+		require.False(t, IsStatefulSetNameSchemeOutdated(statefulSet), "statefulset is created with outdated scheme")
+
+		// StatefulSet of previous naming scheme keeps its name and stays outdated
+		host.Config.StatefulSetNameAlias = "legacy-" + statefulSet.Name
+		aliased := creator.CreateStatefulSet(host)
+		require.Equal(t, "legacy-"+statefulSet.Name, aliased.Name, "alias is not used")
+		require.True(t, IsStatefulSetNameSchemeOutdated(aliased), "aliased statefulset is not outdated")
+		return nil
+	})
+}
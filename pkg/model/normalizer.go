@@ -18,14 +18,23 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"net"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 
 	log "github.com/golang/glog"
 	// log "k8s.io/klog"
 
 	"gopkg.in/d4l3k/messagediff.v1"
+	apps "k8s.io/api/apps/v1"
 	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
 
 	chiv1 "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
 	"github.com/altinity/clickhouse-operator/pkg/chop"
@@ -104,6 +113,10 @@ func (n *Normalizer) NormalizeCHI(chi *chiv1.ClickHouseInstallation) (*chiv1.Cli
 	// Walk over ChiSpec datatype fields
 	n.normalizeUseTemplates(&n.chi.Spec.UseTemplates)
 	n.normalizeStop(&n.chi.Spec.Stop)
+	n.normalizeServiceNamespace(&n.chi.Spec.ServiceNamespace)
+	n.normalizePropagateAnnotations(&n.chi.Spec.PropagateAnnotations)
+	n.normalizeLabels(&n.chi.Spec.Labels)
+	n.normalizeAnnotations(&n.chi.Spec.Annotations)
 	n.normalizeDefaults(&n.chi.Spec.Defaults)
 	n.normalizeConfiguration(&n.chi.Spec.Configuration)
 	n.normalizeTemplates(&n.chi.Spec.Templates)
@@ -111,9 +124,144 @@ func (n *Normalizer) NormalizeCHI(chi *chiv1.ClickHouseInstallation) (*chiv1.Cli
 	n.finalizeCHI()
 	n.fillStatus()
 
+	if err := n.validateUsersSecurity(); err != nil {
+		return n.chi, err
+	}
+
+	if err := n.validateLayoutLimits(); err != nil {
+		return n.chi, err
+	}
+
+	if unknown := getUnknownTemplateReferences(n.chi); len(unknown) > 0 {
+		// CHI is still normalized, so it can be compared with previous one. Objects are not generated for it, see ValidateCHI
+		log.V(1).Infof("WARNING: CHI %s/%s refers to unknown templates: %s", n.chi.Namespace, n.chi.Name, strings.Join(unknown, ","))
+	}
+
 	return n.chi, nil
 }
 
+// ValidateCHI normalizes CHI the same way as NormalizeCHI does and additionally validates normalized CHI with ValidateCHI.
+// Returned CHI is fully resolved, so it can be used to validate CHI before any object is generated,
+// for example, by admission webhook or external validation tooling
+func (n *Normalizer) ValidateCHI(chi *chiv1.ClickHouseInstallation) (*chiv1.ClickHouseInstallation, error) {
+	chi, err := n.NormalizeCHI(chi)
+	if err != nil {
+		return chi, err
+	}
+
+	return chi, ValidateCHI(chi)
+}
+
+// validateUsersSecurity checks whether all users are protected either by password or by localhost-only networks.
+// In secure-by-default mode open users are reported as an error, otherwise they are just logged
+func (n *Normalizer) validateUsersSecurity() error {
+	var open []string
+	for _, username := range getUsernames(n.chi.Spec.Configuration.Users) {
+		if n.isUserOpen(username) {
+			open = append(open, username)
+		}
+	}
+
+	if len(open) == 0 {
+		return nil
+	}
+
+	if util.IsStringBoolTrue(n.chi.Spec.Defaults.SecureByDefault) {
+		return fmt.Errorf("CHI %s/%s has users without password and accessible from network: %s", n.chi.Namespace, n.chi.Name, strings.Join(open, ","))
+	}
+
+	log.V(1).Infof("WARNING: CHI %s/%s has users without password and accessible from network: %s", n.chi.Namespace, n.chi.Name, strings.Join(open, ","))
+	return nil
+}
+
+// validateLayoutLimits checks clusters do not request more shards and replicas than allowed by operator config.
+// Layouts exceeding limits are truncated already in case operator config allows so
+func (n *Normalizer) validateLayoutLimits() error {
+	maxShards := n.chop.Config().CHIMaxShardsCount
+	maxReplicas := n.chop.Config().CHIMaxReplicasCount
+
+	var errs []error
+	n.chi.WalkClusters(func(cluster *chiv1.ChiCluster) error {
+		if (maxShards > 0) && (cluster.Layout.ShardsCount > maxShards) {
+			errs = append(errs, fmt.Errorf("cluster %s requests %d shards, %d allowed", cluster.Name, cluster.Layout.ShardsCount, maxShards))
+		}
+		if (maxReplicas > 0) && (cluster.Layout.ReplicasCount > maxReplicas) {
+			errs = append(errs, fmt.Errorf("cluster %s requests %d replicas, %d allowed", cluster.Name, cluster.Layout.ReplicasCount, maxReplicas))
+		}
+		return nil
+	})
+
+	if err := utilerrors.NewAggregate(errs); err != nil {
+		return fmt.Errorf("CHI %s/%s exceeds limits: %v", n.chi.Namespace, n.chi.Name, err)
+	}
+	return nil
+}
+
+// getUsernames extracts sorted list of usernames from users settings paths
+func getUsernames(users chiv1.Settings) []string {
+	usernameMap := make(map[string]bool)
+	for path := range users {
+		// Split 'admin/password'
+		tags := strings.Split(path, "/")
+
+		// Basic sanity check - need to have at least "username/something" pair
+		if len(tags) < 2 {
+			// Skip incorrect entry
+			continue
+		}
+
+		usernameMap[tags[0]] = true
+	}
+
+	usernames := make([]string, 0, len(usernameMap))
+	for username := range usernameMap {
+		usernames = append(usernames, username)
+	}
+	sort.Strings(usernames)
+
+	return usernames
+}
+
+// isUserOpen checks whether user is accessible from the network without password.
+// User is protected either by password, specified explicitly or provided via Secret,
+// or by networks restricted to localhost and the installation's own pods
+func (n *Normalizer) isUserOpen(username string) bool {
+	if _, ok := n.chi.Spec.Configuration.UserPasswordSecrets[username]; ok {
+		return false
+	}
+
+	users := n.chi.Spec.Configuration.Users
+	for _, name := range []string{"password", "password_sha256_hex", "password_double_sha1_hex"} {
+		if setting, ok := users[username+"/"+name]; ok {
+			if value := setting.String(); (value != "") && (value != "_removed_") {
+				return false
+			}
+		}
+	}
+
+	if setting, ok := users[username+"/networks/ip"]; ok {
+		for _, ip := range setting.AsVector() {
+			if !util.InArray(ip, localhostNetworks) {
+				return true
+			}
+		}
+	}
+
+	// Installation's own pods are matched by host_regexp generated by the operator
+	podRegexp := CreatePodRegexp(n.chi, n.chop.Config().CHConfigNetworksHostRegexpTemplate)
+	for _, name := range []string{"host", "host_regexp"} {
+		if setting, ok := users[username+"/networks/"+name]; ok {
+			for _, host := range setting.AsVector() {
+				if (host != "") && (host != podRegexp) && !util.InArray(host, localhostHosts) {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
 // finalizeCHI performs some finalization tasks, which should be done after CHI is normalized
 func (n *Normalizer) finalizeCHI() {
 	n.chi.FillAddressInfo()
@@ -121,6 +269,8 @@ func (n *Normalizer) finalizeCHI() {
 	n.chi.WalkHosts(func(host *chiv1.ChiHost) error {
 		hostTemplate := n.getHostTemplate(host)
 		hostApplyHostTemplate(host, hostTemplate)
+		// Pod templates are known by now, so memory limit of the container can be taken into account
+		n.applyMemoryTrackerToHostSettings(host)
 		return nil
 	})
 	n.chi.WalkHosts(func(host *chiv1.ChiHost) error {
@@ -182,21 +332,21 @@ func hostApplyHostTemplate(host *chiv1.ChiHost, template *chiv1.ChiHostTemplate)
 			}
 		case chiv1.PortDistributionClusterScopeIndex:
 			if host.TCPPort == chPortNumberMustBeAssignedLater {
-				base := chDefaultTCPPortNumber
+				base := host.CHI.Spec.Defaults.Ports.TCPPort
 				if template.Spec.TCPPort != chPortNumberMustBeAssignedLater {
 					base = template.Spec.TCPPort
 				}
 				host.TCPPort = base + int32(host.Address.ClusterScopeIndex)
 			}
 			if host.HTTPPort == chPortNumberMustBeAssignedLater {
-				base := chDefaultHTTPPortNumber
+				base := host.CHI.Spec.Defaults.Ports.HTTPPort
 				if template.Spec.HTTPPort != chPortNumberMustBeAssignedLater {
 					base = template.Spec.HTTPPort
 				}
 				host.HTTPPort = base + int32(host.Address.ClusterScopeIndex)
 			}
 			if host.InterserverHTTPPort == chPortNumberMustBeAssignedLater {
-				base := chDefaultInterserverHTTPPortNumber
+				base := host.CHI.Spec.Defaults.Ports.InterserverHTTPPort
 				if template.Spec.InterserverHTTPPort != chPortNumberMustBeAssignedLater {
 					base = template.Spec.InterserverHTTPPort
 				}
@@ -210,12 +360,13 @@ func hostApplyHostTemplate(host *chiv1.ChiHost, template *chiv1.ChiHostTemplate)
 	host.InheritTemplatesFrom(nil, nil, template)
 }
 
-// hostApplyPortsFromSettings
+// hostApplyPortsFromSettings assigns ports, which are not specified explicitly, either from settings or from .spec.defaults.ports
 func hostApplyPortsFromSettings(host *chiv1.ChiHost) {
 	settings := host.GetSettings()
-	ensurePortValue(&host.TCPPort, settings.GetTCPPort(), chDefaultTCPPortNumber)
-	ensurePortValue(&host.HTTPPort, settings.GetHTTPPort(), chDefaultHTTPPortNumber)
-	ensurePortValue(&host.InterserverHTTPPort, settings.GetInterserverHTTPPort(), chDefaultInterserverHTTPPortNumber)
+	ports := &host.CHI.Spec.Defaults.Ports
+	ensurePortValue(&host.TCPPort, settings.GetTCPPort(), ports.TCPPort)
+	ensurePortValue(&host.HTTPPort, settings.GetHTTPPort(), ports.HTTPPort)
+	ensurePortValue(&host.InterserverHTTPPort, settings.GetInterserverHTTPPort(), ports.InterserverHTTPPort)
 }
 
 // ensurePortValue
@@ -259,10 +410,112 @@ func (n *Normalizer) normalizeStop(stop *string) {
 	}
 }
 
+// normalizeServiceNamespace normalizes .spec.serviceNamespace
+func (n *Normalizer) normalizeServiceNamespace(namespace *string) {
+	if *namespace == "" {
+		return
+	}
+
+	if *namespace == n.chi.Namespace {
+		// CHI Service is located in CHI namespace anyway
+		*namespace = ""
+		return
+	}
+
+	if errs := validation.IsDNS1123Label(*namespace); len(errs) > 0 {
+		log.V(1).Infof("Incorrect serviceNamespace %s. Skip it. Errs: %v", *namespace, errs)
+		*namespace = ""
+		return
+	}
+
+	// Service selector can not select pods from another namespace, so CHI Service stays in CHI namespace
+	// and ExternalName Service, pointing to CHI Service, is created in serviceNamespace
+	log.V(1).Infof("CHI %s/%s Service is exposed in namespace %s via ExternalName Service", n.chi.Namespace, n.chi.Name, *namespace)
+}
+
+// normalizePropagateAnnotations normalizes .spec.propagateAnnotations
+// Incorrect and duplicate annotation keys are skipped
+func (n *Normalizer) normalizePropagateAnnotations(keys *[]string) {
+	var normalized []string
+	for _, key := range *keys {
+		key = strings.TrimSpace(key)
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			log.V(1).Infof("Incorrect annotation key %s to propagate. Skip it. Errs: %v", key, errs)
+			continue
+		}
+		if util.InArray(key, normalized) {
+			continue
+		}
+		normalized = append(normalized, key)
+	}
+	*keys = normalized
+}
+
+// normalizeLabels normalizes labels, such as .spec.labels
+// Labels with incorrect keys or values are skipped
+func (n *Normalizer) normalizeLabels(labels *map[string]string) {
+	for key, value := range *labels {
+		errs := append(validation.IsQualifiedName(key), validation.IsValidLabelValue(value)...)
+		if len(errs) > 0 {
+			log.V(1).Infof("Incorrect label %s=%s. Skip it. Errs: %v", key, value, errs)
+			delete(*labels, key)
+		}
+	}
+}
+
+// normalizeAnnotations normalizes .spec.annotations
+// Annotations with incorrect keys are skipped
+func (n *Normalizer) normalizeAnnotations(annotations *map[string]string) {
+	for key := range *annotations {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			log.V(1).Infof("Incorrect annotation key %s. Skip it. Errs: %v", key, errs)
+			delete(*annotations, key)
+		}
+	}
+}
+
 // normalizeDefaults normalizes .spec.defaults
 func (n *Normalizer) normalizeDefaults(defaults *chiv1.ChiDefaults) {
 	// Set defaults for CHI object properties
 	n.normalizeDefaultsReplicasUseFQDN(defaults)
+	n.normalizeDefaultsReplicaAntiAffinityTopologyKey(defaults)
+	n.normalizeDefaultsShardAntiAffinity(defaults)
+	n.normalizeDefaultsDistributedDDL(defaults)
+	n.normalizeDefaultsDistributedQueries(defaults)
+	n.normalizeDefaultsFilesystemRead(defaults)
+	n.normalizeDefaultsFlags(defaults)
+	n.normalizeDefaultsLogFormat(defaults)
+	n.normalizeDefaultsInterserverListenHost(defaults)
+	n.normalizeDefaultsMaxOpenFiles(defaults)
+	n.normalizeDefaultsReadinessProbe(defaults)
+	n.normalizeDefaultsLivenessProbe(defaults)
+	n.normalizeDefaultsProfileAndQuota(defaults)
+	n.normalizeDefaultsServiceMesh(defaults)
+	n.normalizeDefaultsCertRotationTokenSecret(defaults)
+	n.normalizeDefaultsDropSafeguards(defaults)
+	n.normalizeDefaultsCaches(defaults)
+	n.normalizeDefaultsMergeLimits(defaults)
+	n.normalizeDefaultsDNSCache(defaults)
+	n.normalizeDefaultsMemoryTracker(defaults)
+	n.normalizeDefaultsScaleDownSafeguards(defaults)
+	n.normalizeDefaultsDefaultDatabase(defaults)
+	n.normalizeDefaultsCompressionCodec(defaults)
+	n.normalizeDefaultsReplicaPathAndName(defaults)
+	n.normalizeDefaultsContainer(defaults)
+	n.normalizeDefaultsTmpVolume(defaults)
+	n.normalizeDefaultsShmVolume(defaults)
+	n.normalizeDefaultsDataVolumeChown(defaults)
+	n.normalizeDefaultsSecurityContext(defaults)
+	n.normalizeDefaultsServiceAccount(defaults)
+	n.normalizeDefaultsUpdateStrategy(defaults)
+	n.normalizeLabels(&defaults.NodeSelector)
+	n.normalizeDefaultsBaseIndexes(defaults)
+	n.normalizeDefaultsStorageSize(defaults)
+	n.normalizeDefaultsConfigDirPath(defaults)
+	n.normalizeDefaultsTerminationGracePeriodSeconds(defaults)
+	n.normalizeDefaultsHostNetwork(defaults)
+	n.normalizeDefaultsServiceClusterIP(defaults)
+	n.normalizeDefaultsPorts(defaults)
 	n.normalizeDefaultsTemplates(defaults)
 }
 
@@ -270,11 +523,58 @@ func (n *Normalizer) normalizeDefaults(defaults *chiv1.ChiDefaults) {
 func (n *Normalizer) normalizeConfiguration(conf *chiv1.Configuration) {
 	n.normalizeConfigurationZookeeper(&conf.Zookeeper)
 
+	// Profile tiers generate profiles, which may be referenced by users, thus are applied in advance
+	n.normalizeConfigurationProfileTiers(&conf.ProfileTiers)
+	n.applyProfileTiersToProfiles(&conf.Profiles, &conf.ProfileTiers)
+	// Users with password from Secret have no password in users config
+	n.normalizeConfigurationUserPasswordSecrets(&conf.UserPasswordSecrets)
 	n.normalizeConfigurationUsers(&conf.Users)
 	n.normalizeConfigurationProfiles(&conf.Profiles)
+	n.applyExperimentalFeaturesToProfiles(&conf.Profiles, conf.ExperimentalFeatures)
+	n.applyStandbyToProfiles(&conf.Profiles)
 	n.normalizeConfigurationQuotas(&conf.Quotas)
+	n.normalizeConfigurationQuotaIntervals(&conf.QuotaIntervals)
+	n.applyQuotaIntervalsToQuotas(&conf.Quotas, conf.QuotaIntervals)
+	// Filesystem cache may be referenced by settings, thus is normalized in advance
+	n.normalizeConfigurationFilesystemCache(&conf.FilesystemCache)
+	n.normalizeConfigurationStorage(&conf.Storage)
 	n.normalizeConfigurationSettings(&conf.Settings)
+	n.normalizeSettingsTemporaryData(&conf.Settings)
+	n.applyReadinessProbeToSettings(&conf.Settings)
+	n.applyDropSafeguardsToSettings(&conf.Settings)
+	n.applyCachesToSettings(&conf.Settings)
+	n.applyMergeLimitsToSettings(&conf.Settings)
+	n.applyDNSCacheToSettings(&conf.Settings)
+	n.applyMemoryTrackerToSettings(&conf.Settings)
+	n.normalizeConfigurationCoreDump(&conf.CoreDump)
+	n.applyCoreDumpToSettings(&conf.Settings)
+	n.applyDistributedQueriesToSettings(&conf.Settings)
+	n.applyCompressionCodecToSettings(&conf.Settings)
+	n.applyReplicaPathAndNameToSettings(&conf.Settings)
+	n.applyTmpVolumeToSettings(&conf.Settings)
+	n.applyInterserverListenHostToSettings(&conf.Settings)
+	n.applyMaxOpenFilesToSettings(&conf.Settings)
+	n.normalizeConfigurationCustomSettingsPrefixes(&conf.CustomSettingsPrefixes)
+	n.applyCustomSettingsPrefixesToSettings(&conf.Settings, conf.CustomSettingsPrefixes)
+	n.normalizeConfigurationQueryMaskingRules(&conf.QueryMaskingRules)
+	n.normalizeConfigurationMacros(&conf.Macros)
+	n.normalizeSettingsNumericValues(&conf.Settings)
+	n.normalizeSettingsDefaultPasswordType(&conf.Settings)
 	n.normalizeConfigurationFiles(&conf.Files)
+	n.normalizeConfigurationRoles(&conf.Roles)
+	n.normalizeConfigurationUsersOverrideConfigMap(&conf.UsersOverrideConfigMap)
+	n.applyDefaultRolesToUsers(&conf.Users)
+	n.normalizeConfigurationMonitoring(&conf.Monitoring)
+	n.applyMonitoringToSettings(&conf.Settings)
+	n.normalizeConfigurationUserDefinedFunctions(&conf.UserDefinedFunctions)
+	n.normalizeConfigurationFormatSchemas(&conf.FormatSchemas)
+	n.normalizeConfigurationKafka(&conf.Kafka)
+	n.normalizeConfigurationInterserverCredentials(&conf.InterserverCredentials)
+	n.normalizeConfigurationBackups(&conf.Backups)
+	n.normalizeConfigurationCompression(&conf.Compression)
+	n.normalizeConfigurationSystemLogs(&conf.SystemLogs)
+	n.normalizeConfigurationKeeper(&conf.Keeper)
+	n.applyKeeperToSettings(&conf.Settings)
 
 	// Configuration.Clusters
 	n.normalizeClusters()
@@ -643,6 +943,7 @@ func (n *Normalizer) newPodAffinity(template *chiv1.ChiPodTemplate) *v1.PodAffin
 				map[string]string{
 					LabelClusterScopeIndex: macrosClusterScopeCycleHeadPointsToPreviousCycleTail,
 				},
+				topologyKeyHostname,
 			)
 			podAffinity.PreferredDuringSchedulingIgnoredDuringExecution = n.addWeightedPodAffinityTermWithMatchLabels(
 				podAffinity.PreferredDuringSchedulingIgnoredDuringExecution,
@@ -772,6 +1073,7 @@ func (n *Normalizer) newPodAntiAffinity(template *chiv1.ChiPodTemplate) *v1.PodA
 			map[string]string{
 				LabelAppName: LabelAppValue,
 			},
+			topologyKeyHostname,
 		)
 	}
 
@@ -788,6 +1090,7 @@ func (n *Normalizer) newPodAntiAffinity(template *chiv1.ChiPodTemplate) *v1.PodA
 						LabelAppName: LabelAppValue,
 					},
 				),
+				topologyKeyHostname,
 			)
 		case chiv1.PodDistributionMaxNumberPerNode:
 			podAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution = n.addPodAffinityTermWithMatchLabels(
@@ -798,6 +1101,7 @@ func (n *Normalizer) newPodAntiAffinity(template *chiv1.ChiPodTemplate) *v1.PodA
 						LabelClusterScopeCycleIndex: macrosClusterScopeCycleIndex,
 					},
 				),
+				topologyKeyHostname,
 			)
 		case chiv1.PodDistributionShardAntiAffinity:
 			podAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution = n.addPodAffinityTermWithMatchLabels(
@@ -808,6 +1112,7 @@ func (n *Normalizer) newPodAntiAffinity(template *chiv1.ChiPodTemplate) *v1.PodA
 						LabelShardName: macrosShardName,
 					},
 				),
+				topologyKeyHostname,
 			)
 		case chiv1.PodDistributionReplicaAntiAffinity:
 			podAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution = n.addPodAffinityTermWithMatchLabels(
@@ -818,6 +1123,7 @@ func (n *Normalizer) newPodAntiAffinity(template *chiv1.ChiPodTemplate) *v1.PodA
 						LabelReplicaName: macrosReplicaName,
 					},
 				),
+				n.chi.Spec.Defaults.ReplicaAntiAffinityTopologyKey,
 			)
 		case chiv1.PodDistributionAnotherNamespaceAntiAffinity:
 			podAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution = n.addPodAffinityTermWithMatchExpressions(
@@ -924,7 +1230,11 @@ func (n *Normalizer) mergePodAntiAffinity(dst *v1.PodAntiAffinity, src *v1.PodAn
 }
 
 // addPodAffinityTermWithMatchLabels
-func (n *Normalizer) addPodAffinityTermWithMatchLabels(terms []v1.PodAffinityTerm, matchLabels map[string]string) []v1.PodAffinityTerm {
+func (n *Normalizer) addPodAffinityTermWithMatchLabels(
+	terms []v1.PodAffinityTerm,
+	matchLabels map[string]string,
+	topologyKey string,
+) []v1.PodAffinityTerm {
 	return append(terms,
 		v1.PodAffinityTerm{
 			LabelSelector: &v12.LabelSelector{
@@ -944,7 +1254,7 @@ func (n *Normalizer) addPodAffinityTermWithMatchLabels(terms []v1.PodAffinityTer
 				//	},
 				//},
 			},
-			TopologyKey: "kubernetes.io/hostname",
+			TopologyKey: topologyKey,
 		},
 	)
 }
@@ -969,7 +1279,7 @@ func (n *Normalizer) addPodAffinityTermWithMatchExpressions(terms []v1.PodAffini
 				//},
 				MatchExpressions: matchExpressions,
 			},
-			TopologyKey: "kubernetes.io/hostname",
+			TopologyKey: topologyKeyHostname,
 		},
 	)
 }
@@ -1001,7 +1311,7 @@ func (n *Normalizer) addWeightedPodAffinityTermWithMatchLabels(
 					//	},
 					//},
 				},
-				TopologyKey: "kubernetes.io/hostname",
+				TopologyKey: topologyKeyHostname,
 			},
 		},
 	)
@@ -1014,7 +1324,18 @@ func (n *Normalizer) normalizeVolumeClaimTemplate(template *chiv1.ChiVolumeClaim
 	if !template.PVCReclaimPolicy.IsValid() {
 		template.PVCReclaimPolicy = chiv1.PVCReclaimPolicyDelete
 	}
+	// Check MountPropagation
+	if template.MountPropagation != nil {
+		switch *template.MountPropagation {
+		case v1.MountPropagationNone, v1.MountPropagationHostToContainer, v1.MountPropagationBidirectional:
+			// Known value
+		default:
+			log.V(1).Infof("volumeClaimTemplate %s has unknown mountPropagation %s. Skip it.", template.Name, *template.MountPropagation)
+			template.MountPropagation = nil
+		}
+	}
 	// Check Spec
+	n.normalizeVolumeClaimTemplateStorage(template)
 
 	// Ensure map is in place
 	if n.chi.Spec.Templates.VolumeClaimTemplatesIndex == nil {
@@ -1023,6 +1344,31 @@ func (n *Normalizer) normalizeVolumeClaimTemplate(template *chiv1.ChiVolumeClaim
 	n.chi.Spec.Templates.VolumeClaimTemplatesIndex[template.Name] = template
 }
 
+// normalizeVolumeClaimTemplateStorage ensures volumeClaimTemplate requests positive storage size.
+// Template without storage request gets .spec.defaults.storageSize
+func (n *Normalizer) normalizeVolumeClaimTemplateStorage(template *chiv1.ChiVolumeClaimTemplate) {
+	if template.IsEphemeral() {
+		// No PVC is made from ephemeral volumeClaimTemplate
+		return
+	}
+
+	if storage, ok := template.Spec.Resources.Requests[v1.ResourceStorage]; ok && (storage.Sign() > 0) {
+		// Storage is requested explicitly
+		return
+	}
+
+	if n.chi.Spec.Defaults.StorageSize == "" {
+		log.V(1).Infof("volumeClaimTemplate %s has no positive storage request and no storageSize is specified in defaults. PVC would be rejected.", template.Name)
+		return
+	}
+
+	if template.Spec.Resources.Requests == nil {
+		template.Spec.Resources.Requests = make(v1.ResourceList)
+	}
+	// Storage size is validated by normalizeDefaultsStorageSize
+	template.Spec.Resources.Requests[v1.ResourceStorage] = resource.MustParse(n.chi.Spec.Defaults.StorageSize)
+}
+
 // normalizeServiceTemplate normalizes .spec.templates.serviceTemplates
 func (n *Normalizer) normalizeServiceTemplate(template *chiv1.ChiServiceTemplate) {
 	// Check name
@@ -1096,7 +1442,7 @@ func (n *Normalizer) ensureCluster() {
 
 // calcFingerprints calculates fingerprints for ClickHouse configuration data
 func (n *Normalizer) calcFingerprints(host *chiv1.ChiHost) error {
-	host.Config.ZookeeperFingerprint = util.Fingerprint(*host.GetZookeeper())
+	host.Config.ZookeeperFingerprint = util.Fingerprint(getZookeeperCanonical(host.GetZookeeper()))
 	host.Config.SettingsFingerprint = util.Fingerprint(
 		fmt.Sprintf("%s%s",
 			util.Fingerprint(n.chi.Spec.Configuration.Settings.AsSortedSliceOfStrings()),
@@ -1122,20 +1468,84 @@ func (n *Normalizer) calcFingerprints(host *chiv1.ChiHost) error {
 		),
 	)
 
+	host.Config.RestartSettingsFingerprint = util.Fingerprint(
+		fmt.Sprintf("%s%s",
+			util.Fingerprint(filterSettingsByRestart(n.chi.Spec.Configuration.Settings, true).AsSortedSliceOfStrings()),
+			util.Fingerprint(filterSettingsByRestart(host.Settings, true).AsSortedSliceOfStrings()),
+		),
+	)
+	host.Config.HotSettingsFingerprint = util.Fingerprint(
+		fmt.Sprintf("%s%s",
+			util.Fingerprint(filterSettingsByRestart(n.chi.Spec.Configuration.Settings, false).AsSortedSliceOfStrings()),
+			util.Fingerprint(filterSettingsByRestart(host.Settings, false).AsSortedSliceOfStrings()),
+		),
+	)
+	if macros := n.chi.Spec.Configuration.Macros; len(macros) > 0 {
+		// Custom macros are reloaded along with config. Fingerprint is kept as is in case no custom macros specified
+		var pairs []string
+		for _, name := range util.SortedKeys(macros) {
+			pairs = append(pairs, name, macros[name])
+		}
+		host.Config.HotSettingsFingerprint = util.Fingerprint(host.Config.HotSettingsFingerprint + util.Fingerprint(pairs))
+	}
+
 	return nil
 }
 
+// getZookeeperCanonical returns copy of ZooKeeper config with nodes sorted.
+// By default ClickHouse picks ZooKeeper node at random, regardless of the order nodes are listed in,
+// so reordered nodes should not change fingerprint and thus should not roll pods
+func getZookeeperCanonical(zk *chiv1.ChiZookeeperConfig) chiv1.ChiZookeeperConfig {
+	canonical := *zk
+	if len(zk.Nodes) == 0 {
+		return canonical
+	}
+	canonical.Nodes = append([]chiv1.ChiZookeeperNode{}, zk.Nodes...)
+	sort.SliceStable(canonical.Nodes, func(i, j int) bool {
+		a, b := &canonical.Nodes[i], &canonical.Nodes[j]
+		if a.Host != b.Host {
+			return a.Host < b.Host
+		}
+		return a.Port < b.Port
+	})
+	return canonical
+}
+
+// filterSettingsByRestart returns either restart-required or hot-reloadable settings
+func filterSettingsByRestart(settings chiv1.Settings, restartRequired bool) chiv1.Settings {
+	res := chiv1.NewSettings()
+	for path, setting := range settings {
+		// Setting is classified by its top-level name, so whole sections, such as 'logger/*', are classified together
+		name := strings.Split(strings.TrimPrefix(path, "/"), "/")[0]
+		if util.InArray(name, settingsHotReloadable) != restartRequired {
+			res[path] = setting
+		}
+	}
+	return res
+}
+
 // normalizeConfigurationZookeeper normalizes .spec.configuration.zookeeper
 func (n *Normalizer) normalizeConfigurationZookeeper(zk *chiv1.ChiZookeeperConfig) {
 	// In case no ZK port specified - assign default
 	for i := range zk.Nodes {
 		// Convenience wrapper
 		node := &zk.Nodes[i]
+		if !util.IsStringBool(node.Secure) {
+			node.Secure = util.StringBoolFalseLowercase
+		}
 		if node.Port == 0 {
-			node.Port = zkDefaultPort
+			if node.IsSecure() {
+				node.Port = zkDefaultSecurePort
+			} else {
+				node.Port = zkDefaultPort
+			}
 		}
 	}
 
+	if (zk.TLS.Secret != "") && !zk.IsSecure() {
+		log.V(1).Infof("ZooKeeper TLS secret %s is specified, but none of nodes is secure. Ignore", zk.TLS.Secret)
+	}
+
 	// In case no ZK root specified - assign '/clickhouse/{namespace}/{chi name}'
 	//if zk.Root == "" {
 	//	zk.Root = fmt.Sprintf(zkDefaultRootTemplate, n.chi.Namespace, n.chi.Name)
@@ -1158,18 +1568,9 @@ func (n *Normalizer) normalizeConfigurationUsers(users *chiv1.Settings) {
 
 	// Extract username from path
 	usernameMap := make(map[string]bool)
-	for path := range *users {
-		// Split 'admin/password'
-		tags := strings.Split(path, "/")
-
-		// Basic sanity check - need to have at least "username/something" pair
-		if len(tags) < 2 {
-			// Skip incorrect entry
-			continue
-		}
-
-		username := tags[0]
+	for _, username := range getUsernames(*users) {
 		usernameMap[username] = true
+		n.normalizeUserSettings(users, username)
 	}
 
 	// Ensure "must have" sections are in place, which are
@@ -1179,14 +1580,22 @@ func (n *Normalizer) normalizeConfigurationUsers(users *chiv1.Settings) {
 	// 4. user/password_sha256_hex
 
 	usernameMap["default"] = true // we need default user here in order to secure host_regexp
+	for username := range n.chi.Spec.Configuration.UserPasswordSecrets {
+		usernameMap[username] = true
+	}
 	for username := range usernameMap {
 		if _, ok := (*users)[username+"/profile"]; !ok {
 			// No 'user/profile' section
-			(*users)[username+"/profile"] = chiv1.NewScalarSetting(n.chop.Config().CHConfigUserDefaultProfile)
+			(*users)[username+"/profile"] = chiv1.NewScalarSetting(n.chi.Spec.Defaults.DefaultProfile)
 		}
+		n.normalizeUserProfile(users, username)
 		if _, ok := (*users)[username+"/quota"]; !ok {
 			// No 'user/quota' section
-			(*users)[username+"/quota"] = chiv1.NewScalarSetting(n.chop.Config().CHConfigUserDefaultQuota)
+			(*users)[username+"/quota"] = chiv1.NewScalarSetting(n.chi.Spec.Defaults.DefaultQuota)
+		}
+		if _, ok := (*users)[username+"/default_database"]; !ok && util.IsStringBoolTrue(n.chi.Spec.Defaults.UseDefaultDatabase) {
+			// No 'user/default_database' section
+			(*users)[username+"/default_database"] = chiv1.NewScalarSetting(n.chi.Spec.Defaults.DefaultDatabase)
 		}
 		if _, ok := (*users)[username+"/networks/ip"]; !ok {
 			// No 'user/networks/ip' section
@@ -1197,6 +1606,14 @@ func (n *Normalizer) normalizeConfigurationUsers(users *chiv1.Settings) {
 			(*users)[username+"/networks/host_regexp"] = chiv1.NewScalarSetting(CreatePodRegexp(n.chi, n.chop.Config().CHConfigNetworksHostRegexpTemplate))
 		}
 
+		if _, ok := n.chi.Spec.Configuration.UserPasswordSecrets[username]; ok {
+			// Password is provided via Secret, no password is placed into users config
+			delete(*users, username+"/password")
+			delete(*users, username+"/password_sha256_hex")
+			delete(*users, username+"/password_double_sha1_hex")
+			continue
+		}
+
 		var pass = ""
 		_pass, okPassword := (*users)[username+"/password"]
 		if okPassword {
@@ -1225,6 +1642,55 @@ func (n *Normalizer) normalizeConfigurationUsers(users *chiv1.Settings) {
 	}
 }
 
+// normalizeConfigurationUserPasswordSecrets normalizes .spec.configuration.userPasswordSecrets
+// Secrets without name or key are skipped, as well as users, whose names map to the env var of another user
+func (n *Normalizer) normalizeConfigurationUserPasswordSecrets(secrets *map[string]v1.SecretKeySelector) {
+	envVarNames := make(map[string]string)
+	for _, username := range n.chi.Spec.Configuration.GetUserPasswordSecretsUsernames() {
+		secret := (*secrets)[username]
+		envVarName := createUserPasswordEnvVarName(username)
+		switch {
+		case (username == "") || strings.Contains(username, "/"):
+			log.V(1).Infof("Incorrect username %s in userPasswordSecrets. Skip it.", username)
+		case (secret.Name == "") || (secret.Key == ""):
+			log.V(1).Infof("Password secret of user %s has to specify name and key. Skip it.", username)
+		case envVarNames[envVarName] != "":
+			log.V(1).Infof("Password secret of user %s clashes with user %s. Skip it.", username, envVarNames[envVarName])
+		default:
+			envVarNames[envVarName] = username
+			continue
+		}
+		delete(*secrets, username)
+	}
+}
+
+// normalizeUserSettings normalizes per-user settings, specified as 'user/settings/name' paths,
+// which override user's profile settings for this user only.
+// Settings without name or with nested paths are skipped, boolean values are emitted as 0/1
+func (n *Normalizer) normalizeUserSettings(users *chiv1.Settings, username string) {
+	prefix := username + "/settings"
+	for path, setting := range *users {
+		if (path != prefix) && !strings.HasPrefix(path, prefix+"/") {
+			// Not a user's setting
+			continue
+		}
+
+		name := strings.TrimPrefix(strings.TrimPrefix(path, prefix), "/")
+		if (name == "") || strings.Contains(name, "/") || !setting.IsScalar() {
+			log.V(1).Infof("User %s has incorrect setting %s. Skip it.", username, path)
+			delete(*users, path)
+			continue
+		}
+
+		if util.IsStringBool(setting.Scalar()) {
+			(*users)[path] = chiv1.NewScalarSetting(util.CastStringBoolTo01(setting.Scalar(), false))
+		}
+	}
+
+	// Concurrency caps may be specified for particular user as well
+	n.ensureSettingsIntegers(users, prefixSettingsNames(prefix+"/", settingsConcurrentQueriesForUsers), 0)
+}
+
 // normalizeConfigurationProfiles normalizes .spec.configuration.profiles
 func (n *Normalizer) normalizeConfigurationProfiles(profiles *chiv1.Settings) {
 
@@ -1237,76 +1703,1410 @@ func (n *Normalizer) normalizeConfigurationProfiles(profiles *chiv1.Settings) {
 		*profiles = chiv1.NewSettings()
 	}
 	(*profiles).Normalize()
-}
 
-// normalizeConfigurationQuotas normalizes .spec.configuration.quotas
-func (n *Normalizer) normalizeConfigurationQuotas(quotas *chiv1.Settings) {
-
-	if quotas == nil {
-		// Do not know what to do in this case
-		return
+	n.applyDistributedQueriesToProfiles(profiles)
+	n.applyFilesystemReadToProfiles(profiles)
+	for _, profile := range getSettingsSectionNames(*profiles) {
+		n.normalizeProfileParent(profiles, profile)
+		n.ensureSettingsValues(profiles, []string{profile + "/" + settingLocalFilesystemReadMethod}, localFilesystemReadMethods)
+		n.ensureSettingsIntegers(profiles, []string{profile + "/" + settingMaxReadBufferSize}, 1)
+		n.normalizeProfileConstraints(profiles, profile)
+		n.normalizeSettingsAsyncInsert(profiles, profile+"/")
+		n.normalizeSettingsInsertSafeguards(profiles, profile+"/")
+		n.ensureSettingsIntegers(profiles, prefixSettingsNames(profile+"/", settingsQueryProfilerPeriods), 0)
+		n.ensureSettingsBools(profiles, prefixSettingsNames(profile+"/", settingsKeeperIntrospectionBools))
+		n.ensureSettingsIntegers(profiles, prefixSettingsNames(profile+"/", settingsConcurrentQueriesForUsers), 0)
+		n.ensureSettingsIntegers(profiles, prefixSettingsNames(profile+"/", settingsMemoryOvercommit), 0)
 	}
+}
 
-	if *quotas == nil {
-		*quotas = chiv1.NewSettings()
+// normalizeProfileParent ensures parent profiles, specified either as 'profile/parent' or 'profile/inherit',
+// are specified as 'profile/profile', which is rendered as <profile>parent</profile> and makes ClickHouse
+// apply parent's settings first. Explicitly specified 'profile/profile' takes precedence.
+// Unknown parents and inheritance cycles are reported by ValidateCHI
+func (n *Normalizer) normalizeProfileParent(profiles *chiv1.Settings, profile string) {
+	for _, alias := range []string{"parent", "inherit"} {
+		path := profile + "/" + alias
+		setting, ok := (*profiles)[path]
+		if !ok {
+			continue
+		}
+		delete(*profiles, path)
+		if _, ok := (*profiles)[profile+"/profile"]; ok {
+			log.V(1).Infof("Profile %s has parent specified more than once. Skip %s.", profile, path)
+			continue
+		}
+		(*profiles)[profile+"/profile"] = setting
 	}
+}
 
-	(*quotas).Normalize()
+// normalizeUserProfile warns about profile assigned to the user, which is not a known single profile name.
+// Profile is never replaced, so the user does not silently get privileges or constraints of some other profile
+func (n *Normalizer) normalizeUserProfile(users *chiv1.Settings, username string) {
+	setting := (*users)[username+"/profile"]
+	switch {
+	case !setting.IsScalar():
+		log.V(1).Infof("WARNING: profile %s of user %s is not a single name", setting.String(), username)
+	case !isKnownProfile(n.chi, setting.Scalar()):
+		log.V(1).Infof("WARNING: user %s refers to unknown profile %s", username, setting.Scalar())
+	}
 }
 
-// normalizeConfigurationSettings normalizes .spec.configuration.settings
-func (n *Normalizer) normalizeConfigurationSettings(settings *chiv1.Settings) {
+// normalizeProfileConstraints ensures <constraints> of the profile are specified as 'constraints/<setting>/<kind>',
+// where kind is either a min/max value or a readonly/const/changeable_in_readonly/writable flag.
+// Incorrect constraints are skipped
+func (n *Normalizer) normalizeProfileConstraints(profiles *chiv1.Settings, profile string) {
+	prefix := profile + "/constraints/"
+	for path, setting := range *profiles {
+		if !strings.HasPrefix(path, prefix) {
+			continue
+		}
 
-	if settings == nil {
-		// Do not know what to do in this case
-		return
-	}
+		parts := strings.Split(strings.TrimPrefix(path, prefix), "/")
+		if len(parts) == 2 {
+			switch kind := parts[1]; {
+			case util.InArray(kind, profileConstraintValues) && setting.IsScalar():
+				// Looks reasonable
+				continue
+			case util.InArray(kind, profileConstraintFlags):
+				// Flag is specified as empty tag, value is ignored
+				(*profiles)[path] = chiv1.NewScalarSetting("")
+				continue
+			}
+		}
 
-	if *settings == nil {
-		*settings = chiv1.NewSettings()
+		log.V(1).Infof("Incorrect constraint %s of profile %s. Skip it.", path, profile)
+		delete(*profiles, path)
 	}
+}
 
-	(*settings).Normalize()
+// applyExperimentalFeaturesToProfiles applies .spec.configuration.experimentalFeatures to profiles
+// as allow_experimental_<feature> settings with 0/1 values. Feature may be specified with or without 'allow_experimental_' prefix.
+// Unknown features and non-boolean toggles are skipped. Explicitly specified profile settings are not overwritten
+func (n *Normalizer) applyExperimentalFeaturesToProfiles(profiles *chiv1.Settings, toggles []chiv1.ChiExperimentalFeatures) {
+	for _, toggle := range toggles {
+		if toggle.Profile == "" {
+			log.V(1).Infof("experimentalFeatures has to specify profile. Skip it.")
+			continue
+		}
+		// Features are walked in sorted order, so the same feature specified with and without prefix is resolved the same way every time
+		for _, feature := range util.SortedKeys(toggle.Features) {
+			value := toggle.Features[feature]
+			feature = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(feature)), "allow_experimental_")
+			if !util.InArray(feature, experimentalFeatures) {
+				log.V(1).Infof("Unknown experimental feature %s in profile %s. Skip it.", feature, toggle.Profile)
+				continue
+			}
+			if !util.IsStringBool(value) {
+				log.V(1).Infof("Experimental feature %s in profile %s has to be a boolean, got %s. Skip it.", feature, toggle.Profile, value)
+				continue
+			}
+			setSettingIfNotSpecified(*profiles, toggle.Profile+"/allow_experimental_"+feature, util.CastStringBoolTo01(value, false))
+		}
+	}
 }
 
-// normalizeConfigurationFiles normalizes .spec.configuration.files
-func (n *Normalizer) normalizeConfigurationFiles(files *chiv1.Settings) {
+// normalizeConfigurationProfileTiers normalizes .spec.configuration.profileTiers
+// Incorrect base limits and tiers are skipped
+func (n *Normalizer) normalizeConfigurationProfileTiers(tiers *chiv1.ChiProfileTiers) {
+	base := &tiers.Base
+	if base.MaxMemoryUsage != "" {
+		quantity, err := resource.ParseQuantity(base.MaxMemoryUsage)
+		if (err != nil) || (quantity.Sign() < 0) {
+			log.V(1).Infof("profileTiers.base.maxMemoryUsage has to be a non-negative size, got %s. Skip it.", base.MaxMemoryUsage)
+			base.MaxMemoryUsage = ""
+		} else {
+			base.MaxMemoryUsage = strconv.FormatInt(quantity.Value(), 10)
+		}
+	}
+	if base.MaxExecutionTime != "" {
+		if _, err := strconv.ParseUint(base.MaxExecutionTime, 10, 64); err != nil {
+			log.V(1).Infof("profileTiers.base.maxExecutionTime has to be a non-negative number of seconds, got %s. Skip it.", base.MaxExecutionTime)
+			base.MaxExecutionTime = ""
+		}
+	}
+	if base.MaxRowsToRead != "" {
+		if _, err := strconv.ParseUint(base.MaxRowsToRead, 10, 64); err != nil {
+			log.V(1).Infof("profileTiers.base.maxRowsToRead has to be a non-negative number, got %s. Skip it.", base.MaxRowsToRead)
+			base.MaxRowsToRead = ""
+		}
+	}
 
-	if files == nil {
-		// Do not know what to do in this case
+	names := make(map[string]bool)
+	normalized := make([]chiv1.ChiProfileTier, 0, len(tiers.Tiers))
+	for _, tier := range tiers.Tiers {
+		if !isSQLIdentifier(tier.Name) {
+			log.V(1).Infof("Incorrect profileTiers tier name %s. Skip it.", tier.Name)
+			continue
+		}
+		if names[tier.Name] {
+			log.V(1).Infof("Duplicate profileTiers tier %s. Skip it.", tier.Name)
+			continue
+		}
+		if tier.Multiplier == "" {
+			tier.Multiplier = "1"
+		}
+		if multiplier, err := strconv.ParseFloat(tier.Multiplier, 64); (err != nil) || (multiplier <= 0) {
+			log.V(1).Infof("profileTiers tier %s multiplier has to be a positive number, got %s. Skip it.", tier.Name, tier.Multiplier)
+			continue
+		}
+		names[tier.Name] = true
+		normalized = append(normalized, tier)
+	}
+	tiers.Tiers = normalized
+}
+
+// applyProfileTiersToProfiles generates a profile per tier with base limits multiplied by tier multiplier.
+// Explicitly specified profile settings are not overwritten
+func (n *Normalizer) applyProfileTiersToProfiles(profiles *chiv1.Settings, tiers *chiv1.ChiProfileTiers) {
+	if len(tiers.Tiers) == 0 {
 		return
 	}
+	if *profiles == nil {
+		*profiles = chiv1.NewSettings()
+	}
+	(*profiles).Normalize()
 
-	if *files == nil {
-		*files = chiv1.NewSettings()
+	for _, tier := range tiers.Tiers {
+		multiplier, _ := strconv.ParseFloat(tier.Multiplier, 64)
+		apply := func(name, base string) {
+			if base == "" {
+				// Not specified
+				return
+			}
+			path := tier.Name + "/" + name
+			if _, ok := (*profiles)[path]; ok {
+				// Explicitly specified in profile already
+				return
+			}
+			value, _ := strconv.ParseUint(base, 10, 64)
+			(*profiles)[path] = chiv1.NewScalarSetting(strconv.FormatUint(uint64(float64(value)*multiplier), 10))
+		}
+		apply(settingMaxMemoryUsage, tiers.Base.MaxMemoryUsage)
+		apply(settingMaxExecutionTime, tiers.Base.MaxExecutionTime)
+		apply(settingMaxRowsToRead, tiers.Base.MaxRowsToRead)
 	}
+}
 
-	(*files).Normalize()
+// applyDistributedQueriesToProfiles applies .spec.defaults.distributedQueries to the default profile
+func (n *Normalizer) applyDistributedQueriesToProfiles(profiles *chiv1.Settings) {
+	q := &n.chi.Spec.Defaults.DistributedQueries
+	profile := n.chop.Config().CHConfigUserDefaultProfile
+
+	setSettingIfNotSpecified(*profiles, profile+"/distributed_product_mode", q.ProductMode)
+	if q.PreferLocalhostReplica != "" {
+		setSettingIfNotSpecified(*profiles, profile+"/prefer_localhost_replica", util.CastStringBoolTo01(q.PreferLocalhostReplica, true))
+	}
+	setSettingIfNotSpecified(*profiles, profile+"/load_balancing", q.LoadBalancing)
 }
 
-// normalizeCluster normalizes cluster and returns deployments usage counters for this cluster
-func (n *Normalizer) normalizeCluster(cluster *chiv1.ChiCluster) error {
-	cluster.FillShardReplicaSpecified()
+// applyFilesystemReadToProfiles applies .spec.defaults.filesystemRead to the default profile
+func (n *Normalizer) applyFilesystemReadToProfiles(profiles *chiv1.Settings) {
+	r := &n.chi.Spec.Defaults.FilesystemRead
+	profile := n.chop.Config().CHConfigUserDefaultProfile
 
-	// Inherit from .spec.configuration.zookeeper
-	cluster.InheritZookeeperFrom(n.chi)
-	// Inherit from .spec.configuration.files
-	cluster.InheritFilesFrom(n.chi)
-	// Inherit from .spec.defaults
-	cluster.InheritTemplatesFrom(n.chi)
+	setSettingIfNotSpecified(*profiles, profile+"/"+settingLocalFilesystemReadMethod, r.Method)
+	setSettingIfNotSpecified(*profiles, profile+"/"+settingMaxReadBufferSize, r.MaxReadBufferSize)
+}
 
-	n.normalizeConfigurationZookeeper(&cluster.Zookeeper)
-	n.normalizeConfigurationSettings(&cluster.Settings)
-	n.normalizeConfigurationFiles(&cluster.Files)
+// applyDistributedQueriesToSettings applies server-wide settings of .spec.defaults.distributedQueries,
+// which control how failed replicas are penalized and recovered. Explicitly specified settings are not overwritten
+func (n *Normalizer) applyDistributedQueriesToSettings(settings *chiv1.Settings) {
+	q := &n.chi.Spec.Defaults.DistributedQueries
 
-	n.normalizeClusterLayoutShardsCountAndReplicasCount(&cluster.Layout)
+	setSettingIfNotSpecified(*settings, "distributed_replica_error_half_life", q.ReplicaErrorHalfLife)
+	setSettingIfNotSpecified(*settings, "distributed_replica_error_cap", q.ReplicaErrorCap)
+}
 
-	n.ensureClusterLayoutShards(&cluster.Layout)
-	n.ensureClusterLayoutReplicas(&cluster.Layout)
+// applyReadinessProbeToSettings applies acceptable replication lag of .spec.defaults.readinessProbe.
+// /replicas_status reports a replica as not Ok when its delay reaches <merge_tree><min_absolute_delay_to_close>
+func (n *Normalizer) applyReadinessProbeToSettings(settings *chiv1.Settings) {
+	p := &n.chi.Spec.Defaults.ReadinessProbe
+	if (p.Mode != readinessProbeModeReplicasStatus) || (p.MaxReplicaDelay == "") {
+		return
+	}
 
-	n.createHostsField(cluster)
+	setSettingIfNotSpecified(*settings, "merge_tree/min_absolute_delay_to_close", p.MaxReplicaDelay)
+}
 
-	// Loop over all shards and replicas inside shards and fill structure
+// setSettingIfNotSpecified sets non-empty value of the setting, unless the setting is explicitly specified already.
+// Used to apply .spec.defaults sections to settings and profiles, so only specified values are applied
+// and explicitly specified settings are not overwritten
+func setSettingIfNotSpecified(settings chiv1.Settings, name, value string) {
+	if value == "" {
+		// Not specified
+		return
+	}
+	if _, ok := settings[name]; ok {
+		// Explicitly specified already
+		return
+	}
+	settings[name] = chiv1.NewScalarSetting(value)
+}
+
+// applyDropSafeguardsToSettings applies .spec.defaults.dropSafeguards to settings
+func (n *Normalizer) applyDropSafeguardsToSettings(settings *chiv1.Settings) {
+	d := &n.chi.Spec.Defaults.DropSafeguards
+
+	setSettingIfNotSpecified(*settings, "max_table_size_to_drop", d.MaxTableSizeToDrop)
+	setSettingIfNotSpecified(*settings, "max_partition_size_to_drop", d.MaxPartitionSizeToDrop)
+}
+
+// applyReplicaPathAndNameToSettings applies .spec.defaults.replicaPath and replicaName to settings,
+// so ReplicatedMergeTree tables can be created without explicit path and replica name.
+// Nothing is emitted unless specified, so ClickHouse's own defaults, based on {uuid}, stay in effect
+func (n *Normalizer) applyReplicaPathAndNameToSettings(settings *chiv1.Settings) {
+	setSettingIfNotSpecified(*settings, settingDefaultReplicaPath, n.chi.Spec.Defaults.ReplicaPath)
+	setSettingIfNotSpecified(*settings, settingDefaultReplicaName, n.chi.Spec.Defaults.ReplicaName)
+}
+
+// normalizeConfigurationUsersOverrideConfigMap ensures .spec.configuration.usersOverrideConfigMap is a valid ConfigMap name
+func (n *Normalizer) normalizeConfigurationUsersOverrideConfigMap(name *string) {
+	if *name == "" {
+		return
+	}
+	if errs := validation.IsDNS1123Subdomain(*name); len(errs) > 0 {
+		log.V(1).Infof("Incorrect usersOverrideConfigMap %s. Skip it. errs: %v", *name, errs)
+		*name = ""
+	}
+}
+
+// normalizeConfigurationQueryMaskingRules normalizes .spec.configuration.queryMaskingRules
+// Rules with regexp which does not compile are skipped, since ClickHouse would refuse to start with such a rule.
+// Go regexp is RE2, as ClickHouse's one is, thus it is a reasonable sanity check
+func (n *Normalizer) normalizeConfigurationQueryMaskingRules(rules *[]chiv1.ChiQueryMaskingRule) {
+	var normalized []chiv1.ChiQueryMaskingRule
+	for _, rule := range *rules {
+		if rule.Regexp == "" {
+			log.V(1).Infof("Query masking rule %s has to specify regexp. Skip it.", rule.Name)
+			continue
+		}
+		if _, err := regexp.Compile(rule.Regexp); err != nil {
+			log.V(1).Infof("Query masking rule %s has incorrect regexp %s. err: %v. Skip it.", rule.Name, rule.Regexp, err)
+			continue
+		}
+		normalized = append(normalized, rule)
+	}
+	*rules = normalized
+}
+
+// normalizeConfigurationMacros normalizes .spec.configuration.macros
+// Macros with incorrect names or empty values are skipped, as well as macros generated by the operator,
+// which can not be overwritten
+func (n *Normalizer) normalizeConfigurationMacros(macros *map[string]string) {
+	for name, value := range *macros {
+		switch {
+		case !isSQLIdentifier(name):
+			log.V(1).Infof("Incorrect macro name %s. Skip it.", name)
+		case util.InArray(name, reservedMacros):
+			log.V(1).Infof("Macro %s is generated by the operator and can not be overwritten. Skip it.", name)
+		case strings.TrimSpace(value) == "":
+			log.V(1).Infof("Macro %s has empty value. Skip it.", name)
+		default:
+			continue
+		}
+		delete(*macros, name)
+	}
+}
+
+// normalizeConfigurationCustomSettingsPrefixes normalizes .spec.configuration.customSettingsPrefixes
+// Incorrect and duplicated prefixes are skipped
+func (n *Normalizer) normalizeConfigurationCustomSettingsPrefixes(prefixes *[]string) {
+	var normalized []string
+	for _, prefix := range *prefixes {
+		prefix = strings.TrimSpace(prefix)
+		if !isSQLIdentifier(prefix) {
+			log.V(1).Infof("Incorrect custom settings prefix %s. Skip it.", prefix)
+			continue
+		}
+		if util.InArray(prefix, normalized) {
+			continue
+		}
+		normalized = append(normalized, prefix)
+	}
+	*prefixes = normalized
+}
+
+// applyCustomSettingsPrefixesToSettings applies .spec.configuration.customSettingsPrefixes to settings,
+// so settings with these prefixes, such as 'SET custom_foo = 1', are not rejected as unknown. Explicitly specified setting is not overwritten
+func (n *Normalizer) applyCustomSettingsPrefixesToSettings(settings *chiv1.Settings, prefixes []string) {
+	if len(prefixes) == 0 {
+		return
+	}
+	setSettingIfNotSpecified(*settings, settingCustomSettingsPrefixes, strings.Join(prefixes, ","))
+}
+
+// applyDNSCacheToSettings applies .spec.defaults.dnsCache to settings
+func (n *Normalizer) applyDNSCacheToSettings(settings *chiv1.Settings) {
+	c := &n.chi.Spec.Defaults.DNSCache
+
+	setSettingIfNotSpecified(*settings, settingDNSCacheUpdatePeriod, c.UpdatePeriod)
+	setSettingIfNotSpecified(*settings, settingDNSMaxConsecutiveFailures, c.MaxConsecutiveFailures)
+	if c.DisableInternal != "" {
+		setSettingIfNotSpecified(*settings, settingDisableInternalDNSCache, util.CastStringBoolTo01(c.DisableInternal, false))
+	}
+}
+
+// applyCachesToSettings applies .spec.defaults.caches to settings
+func (n *Normalizer) applyCachesToSettings(settings *chiv1.Settings) {
+	c := &n.chi.Spec.Defaults.Caches
+
+	setSettingIfNotSpecified(*settings, "mark_cache_size", c.MarkCacheSize)
+	setSettingIfNotSpecified(*settings, "uncompressed_cache_size", c.UncompressedCacheSize)
+	setSettingIfNotSpecified(*settings, "mmap_cache_size", c.MmapCacheSize)
+}
+
+// applyMergeLimitsToSettings applies .spec.defaults.mergeLimits to settings, limits are located in <merge_tree> section
+func (n *Normalizer) applyMergeLimitsToSettings(settings *chiv1.Settings) {
+	l := &n.chi.Spec.Defaults.MergeLimits
+
+	setSettingIfNotSpecified(*settings, "merge_tree/max_bytes_to_merge_at_max_space_in_pool", l.MaxBytesToMergeAtMaxSpaceInPool)
+	setSettingIfNotSpecified(*settings, "merge_tree/max_bytes_to_merge_at_min_space_in_pool", l.MaxBytesToMergeAtMinSpaceInPool)
+	setSettingIfNotSpecified(*settings, "merge_tree/max_replicated_merges_in_queue", l.MaxReplicatedMergesInQueue)
+	setSettingIfNotSpecified(*settings, "merge_tree/max_replicated_mutations_in_queue", l.MaxReplicatedMutationsInQueue)
+	setSettingIfNotSpecified(*settings, "merge_tree/number_of_free_entries_in_pool_to_lower_max_size_of_merge", l.NumberOfFreeEntriesInPoolToLowerMaxSizeOfMerge)
+	setSettingIfNotSpecified(*settings, "merge_tree/number_of_free_entries_in_pool_to_execute_mutation", l.NumberOfFreeEntriesInPoolToExecuteMutation)
+	setSettingIfNotSpecified(*settings, "merge_tree/max_number_of_merges_with_ttl_in_pool", l.MaxNumberOfMergesWithTTLInPool)
+}
+
+// applyMemoryTrackerToSettings applies .spec.defaults.memoryTracker to settings
+func (n *Normalizer) applyMemoryTrackerToSettings(settings *chiv1.Settings) {
+	t := &n.chi.Spec.Defaults.MemoryTracker
+
+	setSettingIfNotSpecified(*settings, settingMaxServerMemoryUsageToRAMRatio, t.MaxServerMemoryUsageToRAMRatio)
+	setSettingIfNotSpecified(*settings, settingCgroupsMemoryUsageObserverWaitTime, t.CgroupsMemoryUsageObserverWaitTime)
+}
+
+// applyMemoryTrackerToHostSettings derives max_server_memory_usage of the host from memory limit of ClickHouse container
+// in case .spec.defaults.memoryTracker.fromContainerLimit is enabled. Limit is scaled by maxServerMemoryUsageToRAMRatio,
+// 0.9 by default. Hosts without memory limit, as well as explicitly specified settings, are not touched
+func (n *Normalizer) applyMemoryTrackerToHostSettings(host *chiv1.ChiHost) {
+	t := &n.chi.Spec.Defaults.MemoryTracker
+	if !t.IsFromContainerLimit() {
+		return
+	}
+	if _, ok := n.chi.Spec.Configuration.Settings[settingMaxServerMemoryUsage]; ok {
+		// Explicitly specified in common settings already
+		return
+	}
+	if _, ok := host.Settings[settingMaxServerMemoryUsage]; ok {
+		// Explicitly specified in host settings already
+		return
+	}
+
+	podTemplate, ok := host.GetPodTemplate()
+	if !ok {
+		return
+	}
+	var container *v1.Container
+	for i := range podTemplate.Spec.Containers {
+		if podTemplate.Spec.Containers[i].Name == n.chi.Spec.Defaults.Container.Name {
+			container = &podTemplate.Spec.Containers[i]
+			break
+		}
+	}
+	if (container == nil) && (len(podTemplate.Spec.Containers) > 0) {
+		container = &podTemplate.Spec.Containers[0]
+	}
+	if container == nil {
+		return
+	}
+	limit := container.Resources.Limits.Memory()
+	if limit.IsZero() {
+		return
+	}
+
+	ratio := t.MaxServerMemoryUsageToRAMRatio
+	if ratio == "" {
+		ratio = memoryTrackerDefaultRatio
+	}
+	r, _ := strconv.ParseFloat(ratio, 64)
+	if host.Settings == nil {
+		host.Settings = chiv1.NewSettings()
+	}
+	host.Settings[settingMaxServerMemoryUsage] = chiv1.NewScalarSetting(strconv.FormatInt(int64(float64(limit.Value())*r), 10))
+}
+
+// applyCompressionCodecToSettings applies .spec.defaults.compressionCodec to settings as default codec of MergeTree tables.
+// Explicitly specified codec is not overwritten, but is skipped in case it is incorrect
+func (n *Normalizer) applyCompressionCodecToSettings(settings *chiv1.Settings) {
+	if setting, ok := (*settings)[settingCompressionCodec]; ok {
+		if setting.IsScalar() && isCompressionCodec(setting.Scalar()) {
+			// Explicitly specified in settings already
+			return
+		}
+		log.V(1).Infof("Incorrect %s %s. Skip it.", settingCompressionCodec, setting.String())
+		delete(*settings, settingCompressionCodec)
+	}
+
+	if codec := n.chi.Spec.Defaults.CompressionCodec; codec != "" {
+		(*settings)[settingCompressionCodec] = chiv1.NewScalarSetting(codec)
+	}
+}
+
+// normalizeSettingsTemporaryData ensures settings bounding temporary data, spilled by large joins and sorts, have proper values.
+// temporary_data_in_cache has to reference either .spec.configuration.filesystemCache or cache disk specified in settings
+func (n *Normalizer) normalizeSettingsTemporaryData(settings *chiv1.Settings) {
+	n.ensureSettingsIntegers(settings, []string{settingMaxTemporaryDataOnDiskSize}, 0)
+
+	setting, ok := (*settings)[settingTemporaryDataInCache]
+	if !ok {
+		return
+	}
+	if setting.IsScalar() && n.isCacheDisk(*settings, setting.Scalar()) {
+		return
+	}
+	log.V(1).Infof("%s %s does not reference configured cache disk. Skip it.", settingTemporaryDataInCache, setting.String())
+	delete(*settings, settingTemporaryDataInCache)
+}
+
+// isCacheDisk checks whether disk is a cache disk, either configured by .spec.configuration.filesystemCache or specified in settings
+func (n *Normalizer) isCacheDisk(settings chiv1.Settings, disk string) bool {
+	if cache := &n.chi.Spec.Configuration.FilesystemCache; cache.IsEnabled() && (cache.Name == disk) {
+		return true
+	}
+	if setting, ok := settings["storage_configuration/disks/"+disk+"/type"]; ok {
+		return setting.IsScalar() && (setting.Scalar() == "cache")
+	}
+	return false
+}
+
+// applyTmpVolumeToSettings points tmp_path to the mount of .spec.defaults.tmpVolume.
+// Explicitly specified tmp_path is not overwritten. Nothing is applied in case tmpVolume is not specified,
+// so ClickHouse uses its default tmp_path, which is located on data volume
+func (n *Normalizer) applyTmpVolumeToSettings(settings *chiv1.Settings) {
+	if !n.chi.Spec.Defaults.TmpVolume.IsEmptyDir() {
+		return
+	}
+	if _, ok := (*settings)[settingTemporaryDataInCache]; ok {
+		log.V(1).Infof("%s is specified, tmpVolume is not used for tmp_path", settingTemporaryDataInCache)
+		return
+	}
+	setSettingIfNotSpecified(*settings, "tmp_path", dirPathClickHouseTmp)
+}
+
+// applyMaxOpenFilesToSettings applies .spec.defaults.maxOpenFiles as max_open_files,
+// explicitly specified setting is not overwritten, but has to be a positive integer as well
+func (n *Normalizer) applyMaxOpenFilesToSettings(settings *chiv1.Settings) {
+	maxOpenFiles := n.chi.Spec.Defaults.MaxOpenFiles
+	if _, ok := (*settings)[settingMaxOpenFiles]; !ok && (maxOpenFiles != "") {
+		(*settings)[settingMaxOpenFiles] = chiv1.NewScalarSetting(maxOpenFiles)
+	}
+	n.ensureSettingsIntegers(settings, []string{settingMaxOpenFiles}, 1)
+}
+
+// applyInterserverListenHostToSettings applies .spec.defaults.interserverListenHost as interserver_listen_host,
+// so replication traffic is bound to separate interface. Explicitly specified setting is not overwritten
+func (n *Normalizer) applyInterserverListenHostToSettings(settings *chiv1.Settings) {
+	setSettingIfNotSpecified(*settings, "interserver_listen_host", n.chi.Spec.Defaults.InterserverListenHost)
+}
+
+// normalizeConfigurationQuotas normalizes .spec.configuration.quotas
+func (n *Normalizer) normalizeConfigurationQuotas(quotas *chiv1.Settings) {
+
+	if quotas == nil {
+		// Do not know what to do in this case
+		return
+	}
+
+	if *quotas == nil {
+		*quotas = chiv1.NewSettings()
+	}
+
+	(*quotas).Normalize()
+}
+
+// normalizeConfigurationQuotaIntervals normalizes .spec.configuration.quotaIntervals
+// Intervals with incorrect or duplicate duration are skipped, as well as incorrect limits. Quotas without intervals are skipped
+func (n *Normalizer) normalizeConfigurationQuotaIntervals(quotas *[]chiv1.ChiQuotaIntervals) {
+	var normalized []chiv1.ChiQuotaIntervals
+	for _, quota := range *quotas {
+		if (quota.Name == "") || strings.Contains(quota.Name, "/") {
+			log.V(1).Infof("Incorrect quota name %s. Skip it.", quota.Name)
+			continue
+		}
+
+		var intervals []chiv1.ChiQuotaInterval
+		durations := make(map[uint64]bool)
+		for _, interval := range quota.Intervals {
+			duration, err := strconv.ParseUint(interval.Duration, 10, 64)
+			if (err != nil) || (duration == 0) || durations[duration] {
+				log.V(1).Infof("Quota %s has incorrect or duplicate interval duration %s. Skip interval.", quota.Name, interval.Duration)
+				continue
+			}
+			durations[duration] = true
+			interval.Duration = strconv.FormatUint(duration, 10)
+			for _, limit := range []*string{
+				&interval.Queries,
+				&interval.Errors,
+				&interval.ResultRows,
+				&interval.ReadRows,
+				&interval.ExecutionTime,
+			} {
+				if *limit == "" {
+					continue
+				}
+				if _, err := strconv.ParseUint(*limit, 10, 64); err != nil {
+					log.V(1).Infof("Quota %s has incorrect limit %s. Skip it.", quota.Name, *limit)
+					*limit = ""
+				}
+			}
+			intervals = append(intervals, interval)
+		}
+		if len(intervals) == 0 {
+			log.V(1).Infof("Quota %s has no correct intervals. Skip it.", quota.Name)
+			continue
+		}
+		quota.Intervals = intervals
+
+		normalized = append(normalized, quota)
+	}
+	*quotas = normalized
+}
+
+// applyQuotaIntervalsToQuotas removes 'quota/interval/*' paths of quotas specified in quotaIntervals,
+// since intervals of such quotas are generated from quotaIntervals
+func (n *Normalizer) applyQuotaIntervalsToQuotas(quotas *chiv1.Settings, quotaIntervals []chiv1.ChiQuotaIntervals) {
+	for _, quota := range quotaIntervals {
+		for path := range *quotas {
+			if strings.HasPrefix(strings.TrimPrefix(path, "/"), quota.Name+"/interval/") {
+				log.V(1).Infof("Quota %s is specified in quotaIntervals. Skip %s.", quota.Name, path)
+				delete(*quotas, path)
+			}
+		}
+	}
+}
+
+// normalizeConfigurationSettings normalizes .spec.configuration.settings
+func (n *Normalizer) normalizeConfigurationSettings(settings *chiv1.Settings) {
+
+	if settings == nil {
+		// Do not know what to do in this case
+		return
+	}
+
+	if *settings == nil {
+		*settings = chiv1.NewSettings()
+	}
+
+	(*settings).Normalize()
+}
+
+// normalizeSettingsNumericValues ensures well-known numeric settings, if specified, have reasonable values.
+// These settings can be specified on any level - common settings or shard/replica/host settings,
+// so heterogeneous shards can be tuned individually
+func (n *Normalizer) normalizeSettingsNumericValues(settings *chiv1.Settings) {
+	// Background pool sizes have to be positive
+	n.ensureSettingsIntegers(settings, settingsBackgroundPoolSizes, 1)
+	// Merges and mutations concurrency ratio is a multiplier, it has to be positive, but not necessarily integer
+	n.ensureSettingsPositiveNumbers(settings, []string{settingBackgroundMergesMutationsConcurrencyRatio})
+	// Merges and mutations limits, located in <merge_tree> section, have to be non-negative
+	n.ensureSettingsIntegers(settings, settingsMergeTreeLimits, 0)
+	// Compact/wide part thresholds, located in <merge_tree> section, have to be non-negative
+	n.ensureSettingsIntegers(settings, settingsMergeTreePartFormat, 0)
+	// TTL merges and parts moves settings, located in <merge_tree> section, have to be non-negative
+	n.ensureSettingsIntegers(settings, settingsMergeTreeMoves, 0)
+	n.ensureSettingsBools(settings, settingsMergeTreeMovesBools)
+	// Insert backpressure settings, located in <merge_tree> section, have to be positive and delay has to precede throw
+	n.ensureSettingsIntegers(settings, settingsMergeTreeInsertBackpressure, 1)
+	n.ensureSettingsIntegers(settings, settingsMergeTreeInactiveParts, 0)
+	n.ensureSettingsIntegersLess(settings, settingMergeTreePartsToDelayInsert, settingMergeTreePartsToThrowInsert)
+	n.ensureSettingsIntegersLess(settings, settingMergeTreeInactivePartsToDelayInsert, settingMergeTreeInactivePartsToThrowInsert)
+	// Drop safeguards have to be non-negative, 0 means no limit
+	n.ensureSettingsIntegers(settings, settingsDropSafeguards, 0)
+	// Caches sizes have to be non-negative
+	n.ensureSettingsIntegers(settings, settingsCaches, 0)
+	// DNS cache update period and failures count have to be positive
+	n.ensureSettingsIntegers(settings, settingsDNSCache, 1)
+	n.ensureSettingsBools(settings, []string{settingDisableInternalDNSCache})
+	// Failed replicas penalty settings have to be positive
+	n.ensureSettingsIntegers(settings, settingsDistributedReplicaError, 1)
+	// Server-wide concurrency caps have to be non-negative, 0 means no limit
+	n.ensureSettingsIntegers(settings, settingsConcurrentQueries, 0)
+	n.checkSettingsIntegersNotGreater(settings, settingMaxWaitingQueries, settingMaxConcurrentQueries)
+	// Memory overcommit tracker wait has to be non-negative
+	n.ensureSettingsIntegers(settings, settingsGlobalMemoryOvercommit, 0)
+	// Server memory limit and cgroups observer wait have to be non-negative, 0 means no limit
+	n.ensureSettingsIntegers(settings, settingsMemoryTracker, 0)
+	// Async insert settings are usually specified in profiles, but can be specified as settings as well
+	n.normalizeSettingsAsyncInsert(settings, "")
+}
+
+// normalizeSettingsDefaultPasswordType ensures default_password_type setting, if present, is one of acceptable password types.
+// Incorrect value is replaced with sha256_password, so passwords of SQL-created users are never stored in plaintext by mistake
+func (n *Normalizer) normalizeSettingsDefaultPasswordType(settings *chiv1.Settings) {
+	if (settings == nil) || (*settings == nil) {
+		return
+	}
+
+	setting, ok := (*settings)[settingDefaultPasswordType]
+	if !ok {
+		// Not specified, ClickHouse default would be used
+		return
+	}
+
+	if setting.IsScalar() && util.InArray(setting.Scalar(), passwordTypes) {
+		// Looks reasonable
+		return
+	}
+
+	log.V(1).Infof("Setting %s has to be one of %v, got %s. Use %s.", settingDefaultPasswordType, passwordTypes, setting.String(), passwordTypeDefault)
+	(*settings)[settingDefaultPasswordType] = chiv1.NewScalarSetting(passwordTypeDefault)
+}
+
+// normalizeSettingsAsyncInsert ensures async insert settings, if present, have proper values.
+// Boolean settings are emitted as 0/1, buffer limits have to be positive integers.
+// prefix specifies section, such as profile, settings are located in
+func (n *Normalizer) normalizeSettingsAsyncInsert(settings *chiv1.Settings, prefix string) {
+	if (settings == nil) || (*settings == nil) {
+		return
+	}
+
+	n.ensureSettingsBools(settings, prefixSettingsNames(prefix, settingsAsyncInsertBools))
+	n.ensureSettingsIntegers(settings, prefixSettingsNames(prefix, settingsAsyncInsertLimits), 1)
+}
+
+// normalizeSettingsInsertSafeguards ensures insert safeguard settings, such as max_partitions_per_insert_block,
+// if present, have proper values. Boolean settings are emitted as 0/1, limits have to be non-negative integers.
+// prefix specifies section, such as profile, settings are located in
+func (n *Normalizer) normalizeSettingsInsertSafeguards(settings *chiv1.Settings, prefix string) {
+	n.ensureSettingsBools(settings, prefixSettingsNames(prefix, settingsInsertSafeguardBools))
+	n.ensureSettingsIntegers(settings, prefixSettingsNames(prefix, settingsInsertSafeguardLimits), 0)
+	n.ensureSettingsIntegers(settings, prefixSettingsNames(prefix, settingsInsertBlockSizes), 1)
+}
+
+// prefixSettingsNames returns settings names prefixed with section prefix, such as 'default/'
+func prefixSettingsNames(prefix string, names []string) []string {
+	var res []string
+	for _, name := range names {
+		res = append(res, prefix+name)
+	}
+	return res
+}
+
+// ensureSettingsBools ensures specified settings, if present, are booleans and casts them to 0/1.
+// Incorrect settings are skipped
+func (n *Normalizer) ensureSettingsBools(settings *chiv1.Settings, names []string) {
+	if (settings == nil) || (*settings == nil) {
+		return
+	}
+
+	for _, name := range names {
+		setting, ok := (*settings)[name]
+		if !ok {
+			// Not specified, ClickHouse default would be used
+			continue
+		}
+
+		if setting.IsScalar() && util.IsStringBool(setting.Scalar()) {
+			(*settings)[name] = chiv1.NewScalarSetting(util.CastStringBoolTo01(setting.Scalar(), false))
+			continue
+		}
+
+		log.V(1).Infof("Setting %s has to be a boolean, got %s. Skip it.", name, setting.String())
+		delete(*settings, name)
+	}
+}
+
+// ensureSettingsIntegers ensures specified settings, if present, are integers not less than min.
+// Incorrect settings are skipped
+func (n *Normalizer) ensureSettingsIntegers(settings *chiv1.Settings, names []string, min int64) {
+	if (settings == nil) || (*settings == nil) {
+		return
+	}
+
+	for _, name := range names {
+		setting, ok := (*settings)[name]
+		if !ok {
+			// Not specified, ClickHouse default would be used
+			continue
+		}
+
+		if setting.IsScalar() {
+			if value, err := strconv.ParseInt(setting.Scalar(), 10, 64); (err == nil) && (value >= min) {
+				// Looks reasonable
+				continue
+			}
+		}
+
+		log.V(1).Infof("Setting %s has to be an integer not less than %d, got %s. Skip it.", name, min, setting.String())
+		delete(*settings, name)
+	}
+}
+
+// ensureSettingsPositiveNumbers ensures specified settings, if present, are positive numbers, fractional numbers are accepted.
+// Incorrect settings are skipped
+func (n *Normalizer) ensureSettingsPositiveNumbers(settings *chiv1.Settings, names []string) {
+	if (settings == nil) || (*settings == nil) {
+		return
+	}
+
+	for _, name := range names {
+		setting, ok := (*settings)[name]
+		if !ok {
+			// Not specified, ClickHouse default would be used
+			continue
+		}
+
+		if setting.IsScalar() {
+			if value, err := strconv.ParseFloat(setting.Scalar(), 64); (err == nil) && (value > 0) {
+				// Looks reasonable
+				continue
+			}
+		}
+
+		log.V(1).Infof("Setting %s has to be a positive number, got %s. Skip it.", name, setting.String())
+		delete(*settings, name)
+	}
+}
+
+// ensureSettingsValues ensures specified settings, if present, have one of acceptable values.
+// Incorrect settings are skipped
+func (n *Normalizer) ensureSettingsValues(settings *chiv1.Settings, names []string, values []string) {
+	if (settings == nil) || (*settings == nil) {
+		return
+	}
+
+	for _, name := range names {
+		setting, ok := (*settings)[name]
+		if !ok {
+			// Not specified, ClickHouse default would be used
+			continue
+		}
+
+		if setting.IsScalar() && util.InArray(setting.Scalar(), values) {
+			// Looks reasonable
+			continue
+		}
+
+		log.V(1).Infof("Setting %s has to be one of %v, got %s. Skip it.", name, values, setting.String())
+		delete(*settings, name)
+	}
+}
+
+// ensureSettingsIntegersLess ensures integer setting less, if present along with setting greater, is less than greater one.
+// Settings with 0 value are considered to be disabled and are not compared. Incorrect less setting is skipped
+func (n *Normalizer) ensureSettingsIntegersLess(settings *chiv1.Settings, less, greater string) {
+	if (settings == nil) || (*settings == nil) {
+		return
+	}
+
+	lessSetting, ok1 := (*settings)[less]
+	greaterSetting, ok2 := (*settings)[greater]
+	if !ok1 || !ok2 || !lessSetting.IsScalar() || !greaterSetting.IsScalar() {
+		// Nothing to compare
+		return
+	}
+	lessValue, err1 := strconv.ParseInt(lessSetting.Scalar(), 10, 64)
+	greaterValue, err2 := strconv.ParseInt(greaterSetting.Scalar(), 10, 64)
+	if (err1 != nil) || (err2 != nil) || (lessValue == 0) || (greaterValue == 0) {
+		return
+	}
+
+	if lessValue >= greaterValue {
+		log.V(1).Infof("Setting %s has to be less than %s=%d, got %d. Skip it.", less, greater, greaterValue, lessValue)
+		delete(*settings, less)
+	}
+}
+
+// checkSettingsIntegersNotGreater checks integer setting 'notGreater', if present, does not exceed integer setting 'greater'.
+// Zero values mean no limit and are not compared. Exceeding setting is reported, but kept as specified
+func (n *Normalizer) checkSettingsIntegersNotGreater(settings *chiv1.Settings, notGreater, greater string) {
+	if (settings == nil) || (*settings == nil) {
+		return
+	}
+
+	notGreaterSetting, ok1 := (*settings)[notGreater]
+	greaterSetting, ok2 := (*settings)[greater]
+	if !ok1 || !ok2 || !notGreaterSetting.IsScalar() || !greaterSetting.IsScalar() {
+		// Nothing to compare
+		return
+	}
+	notGreaterValue, err1 := strconv.ParseInt(notGreaterSetting.Scalar(), 10, 64)
+	greaterValue, err2 := strconv.ParseInt(greaterSetting.Scalar(), 10, 64)
+	if (err1 != nil) || (err2 != nil) || (notGreaterValue == 0) || (greaterValue == 0) {
+		return
+	}
+
+	if notGreaterValue > greaterValue {
+		log.Warningf("Setting %s is not expected to be greater than %s=%d, got %d. Keep it as specified.", notGreater, greater, greaterValue, notGreaterValue)
+	}
+}
+
+// normalizeConfigurationFiles normalizes .spec.configuration.files
+func (n *Normalizer) normalizeConfigurationFiles(files *chiv1.Settings) {
+
+	if files == nil {
+		// Do not know what to do in this case
+		return
+	}
+
+	if *files == nil {
+		*files = chiv1.NewSettings()
+	}
+
+	(*files).Normalize()
+}
+
+// normalizeConfigurationMonitoring normalizes .spec.configuration.monitoring
+func (n *Normalizer) normalizeConfigurationMonitoring(monitoring *chiv1.ChiMonitoring) {
+	// Monitoring user is not generated by default
+	monitoring.Enabled = util.CastStringBoolToStringTrueFalse(monitoring.Enabled, false)
+	if monitoring.User == "" {
+		monitoring.User = monitoringDefaultUser
+	}
+	if monitoring.IsEnabled() && !monitoring.HasPasswordSecret() {
+		log.V(1).Infof("WARNING: monitoring.passwordSecret is not specified. Monitoring user %s is accessible from localhost only.", monitoring.User)
+	}
+	if monitoring.Profile == "" {
+		monitoring.Profile = monitoringDefaultProfile
+	}
+	if (monitoring.Role != "") && !n.hasRole(monitoring.Role) {
+		log.V(1).Infof("monitoring.role %s is not specified in roles. Skip it.", monitoring.Role)
+		monitoring.Role = ""
+	}
+	if monitoring.AsyncMetricsUpdatePeriod != "" {
+		if value, err := strconv.ParseUint(monitoring.AsyncMetricsUpdatePeriod, 10, 64); (err != nil) || (value == 0) {
+			log.V(1).Infof("Incorrect monitoring.asyncMetricsUpdatePeriod %s. Skip it.", monitoring.AsyncMetricsUpdatePeriod)
+			monitoring.AsyncMetricsUpdatePeriod = ""
+		}
+	}
+	n.normalizeConfigurationMonitoringExporterSidecar(monitoring)
+	n.normalizeConfigurationMonitoringPrometheus(&monitoring.Prometheus)
+}
+
+// normalizeConfigurationMonitoringPrometheus normalizes .spec.configuration.monitoring.prometheus
+func (n *Normalizer) normalizeConfigurationMonitoringPrometheus(prometheus *chiv1.ChiPrometheus) {
+	prometheus.Enabled = util.CastStringBoolToStringTrueFalse(prometheus.Enabled, false)
+	if !prometheus.IsEnabled() {
+		return
+	}
+
+	if prometheus.Port != "" {
+		if port, err := strconv.ParseUint(prometheus.Port, 10, 16); (err != nil) || (port == 0) {
+			log.V(1).Infof("Incorrect monitoring.prometheus.port %s. Use %s", prometheus.Port, prometheusDefaultPort)
+			prometheus.Port = ""
+		}
+	}
+	if prometheus.Port == "" {
+		prometheus.Port = prometheusDefaultPort
+	}
+	if !strings.HasPrefix(prometheus.Endpoint, "/") {
+		if prometheus.Endpoint != "" {
+			log.V(1).Infof("Incorrect monitoring.prometheus.endpoint %s. Use %s", prometheus.Endpoint, prometheusDefaultEndpoint)
+		}
+		prometheus.Endpoint = prometheusDefaultEndpoint
+	}
+}
+
+// normalizeConfigurationMonitoringExporterSidecar normalizes .spec.configuration.monitoring.exporterSidecar
+// Exporter scrapes ClickHouse as monitoring user, thus requires monitoring user to be generated
+func (n *Normalizer) normalizeConfigurationMonitoringExporterSidecar(monitoring *chiv1.ChiMonitoring) {
+	sidecar := &monitoring.ExporterSidecar
+	sidecar.Enabled = util.CastStringBoolToStringTrueFalse(sidecar.Enabled, false)
+	if !sidecar.IsEnabled() {
+		return
+	}
+
+	if !monitoring.IsEnabled() {
+		log.V(1).Infof("monitoring.exporterSidecar requires monitoring user to be enabled. Skip it.")
+		sidecar.Enabled = util.StringBoolFalseLowercase
+		return
+	}
+	if sidecar.Port != "" {
+		if port, err := strconv.ParseUint(sidecar.Port, 10, 16); (err != nil) || (port == 0) {
+			log.V(1).Infof("Incorrect monitoring.exporterSidecar.port %s. Use %s", sidecar.Port, exporterSidecarDefaultPort)
+			sidecar.Port = ""
+		}
+	}
+	if sidecar.Port == "" {
+		sidecar.Port = exporterSidecarDefaultPort
+	}
+}
+
+// applyMonitoringToSettings applies .spec.configuration.monitoring.asyncMetricsUpdatePeriod, which controls
+// freshness and overhead of asynchronous metrics exported to monitoring. Explicitly specified setting is not overwritten
+func (n *Normalizer) applyMonitoringToSettings(settings *chiv1.Settings) {
+	period := n.chi.Spec.Configuration.Monitoring.AsyncMetricsUpdatePeriod
+	if _, ok := (*settings)[settingAsyncMetricsUpdatePeriod]; !ok && (period != "") {
+		(*settings)[settingAsyncMetricsUpdatePeriod] = chiv1.NewScalarSetting(period)
+	}
+	// Update period has to be positive, either specified explicitly or applied
+	n.ensureSettingsIntegers(settings, []string{settingAsyncMetricsUpdatePeriod}, 1)
+
+	// Built-in Prometheus endpoint serves metrics, events and asynchronous metrics
+	if prometheus := &n.chi.Spec.Configuration.Monitoring.Prometheus; prometheus.IsEnabled() {
+		for path, value := range map[string]string{
+			"prometheus/endpoint":             prometheus.Endpoint,
+			"prometheus/port":                 prometheus.Port,
+			"prometheus/metrics":              "1",
+			"prometheus/events":               "1",
+			"prometheus/asynchronous_metrics": "1",
+		} {
+			if _, ok := (*settings)[path]; !ok {
+				(*settings)[path] = chiv1.NewScalarSetting(value)
+			}
+		}
+	}
+}
+
+// normalizeConfigurationSystemLogs normalizes .spec.configuration.systemLogs
+func (n *Normalizer) normalizeConfigurationSystemLogs(logs *chiv1.ChiSystemLogs) {
+	n.normalizeSystemLog(&logs.PartLog, "partLog")
+	n.normalizeSystemLog(&logs.TextLog, "textLog")
+	n.normalizeSystemLog(&logs.TraceLog, "traceLog")
+	if logs.CrashLog.FlushIntervalMilliseconds == "" {
+		logs.CrashLog.FlushIntervalMilliseconds = crashLogDefaultFlushIntervalMilliseconds
+	}
+	n.normalizeSystemLog(&logs.CrashLog, "crashLog")
+	logs.FlushOnShutdown = util.CastStringBoolToStringTrueFalse(logs.FlushOnShutdown, false)
+}
+
+// normalizeSystemLog ensures system log has proper values. System log is disabled by default
+func (n *Normalizer) normalizeSystemLog(systemLog *chiv1.ChiSystemLog, name string) {
+	systemLog.Enabled = util.CastStringBoolToStringTrueFalse(systemLog.Enabled, false)
+	if !systemLog.IsEnabled() {
+		return
+	}
+
+	normalizeUintString("systemLogs."+name+".ttlDays", &systemLog.TTLDays, 1, 64, systemLogDefaultTTLDays)
+	normalizeUintString("systemLogs."+name+".flushIntervalMilliseconds", &systemLog.FlushIntervalMilliseconds, 1, 64, systemLogDefaultFlushIntervalMilliseconds)
+
+	systemLog.Engine = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(systemLog.Engine), "ENGINE ="))
+	if (systemLog.Engine != "") && !systemLogEngineRegexp.MatchString(systemLog.Engine) {
+		log.V(1).Infof("systemLogs.%s.engine has to be of MergeTree family, got %s. Use default one.", name, systemLog.Engine)
+		systemLog.Engine = ""
+	}
+}
+
+// systemLogEngineRegexp matches MergeTree family engines, which are accepted by system logs
+var systemLogEngineRegexp = regexp.MustCompile(`^[A-Za-z]*MergeTree\b`)
+
+// normalizeConfigurationCoreDump normalizes .spec.configuration.coreDump
+// Size limit is converted into bytes, incorrect one is skipped
+func (n *Normalizer) normalizeConfigurationCoreDump(coreDump *chiv1.ChiCoreDump) {
+	if coreDump.SizeLimit == "" {
+		return
+	}
+	quantity, err := resource.ParseQuantity(coreDump.SizeLimit)
+	if (err != nil) || (quantity.Sign() < 0) {
+		log.V(1).Infof("coreDump.sizeLimit has to be a non-negative size, got %s. Skip it.", coreDump.SizeLimit)
+		coreDump.SizeLimit = ""
+		return
+	}
+	coreDump.SizeLimit = strconv.FormatInt(quantity.Value(), 10)
+}
+
+// applyCoreDumpToSettings applies .spec.configuration.coreDump to settings as <core_dump><size_limit>.
+// Explicitly specified setting is not overwritten
+func (n *Normalizer) applyCoreDumpToSettings(settings *chiv1.Settings) {
+	setSettingIfNotSpecified(*settings, settingCoreDumpSizeLimit, n.chi.Spec.Configuration.CoreDump.SizeLimit)
+}
+
+// normalizeConfigurationKeeper normalizes .spec.configuration.keeper
+// Coordination settings not specified or incorrect fall back to values recommended by ClickHouse
+func (n *Normalizer) normalizeConfigurationKeeper(keeper *chiv1.ChiKeeper) {
+	s := &keeper.CoordinationSettings
+
+	normalizeUintString("keeper.coordinationSettings.operationTimeoutMs", &s.OperationTimeoutMs, 1, 64, keeperDefaultOperationTimeoutMs)
+	normalizeUintString("keeper.coordinationSettings.sessionTimeoutMs", &s.SessionTimeoutMs, 1, 64, keeperDefaultSessionTimeoutMs)
+	normalizeUintString("keeper.coordinationSettings.snapshotDistance", &s.SnapshotDistance, 1, 64, keeperDefaultSnapshotDistance)
+
+	operationTimeout, _ := strconv.ParseUint(s.OperationTimeoutMs, 10, 64)
+	sessionTimeout, _ := strconv.ParseUint(s.SessionTimeoutMs, 10, 64)
+	if sessionTimeout < operationTimeout {
+		log.V(1).Infof("keeper.coordinationSettings.sessionTimeoutMs %s is less than operationTimeoutMs %s. Use %s.", s.SessionTimeoutMs, s.OperationTimeoutMs, s.OperationTimeoutMs)
+		s.SessionTimeoutMs = s.OperationTimeoutMs
+	}
+
+	s.RaftLogsLevel = strings.ToLower(strings.TrimSpace(s.RaftLogsLevel))
+	if s.RaftLogsLevel == "" {
+		s.RaftLogsLevel = keeperDefaultRaftLogsLevel
+	} else if !util.InArray(s.RaftLogsLevel, keeperRaftLogsLevels) {
+		log.V(1).Infof("keeper.coordinationSettings.raftLogsLevel %s is unknown. Use %s.", s.RaftLogsLevel, keeperDefaultRaftLogsLevel)
+		s.RaftLogsLevel = keeperDefaultRaftLogsLevel
+	}
+}
+
+// applyKeeperToSettings applies .spec.configuration.keeper.coordinationSettings as keeper_server/coordination_settings
+// to settings which configure ClickHouse Keeper node, i.e. have 'keeper_server' section.
+// Settings without keeper are not touched. Explicitly specified settings are not overwritten
+func (n *Normalizer) applyKeeperToSettings(settings *chiv1.Settings) {
+	if !util.InArray(keeperServerSection, getSettingsSectionNames(*settings)) {
+		return
+	}
+
+	s := &n.chi.Spec.Configuration.Keeper.CoordinationSettings
+	for name, value := range map[string]string{
+		"operation_timeout_ms": s.OperationTimeoutMs,
+		"session_timeout_ms":   s.SessionTimeoutMs,
+		"raft_logs_level":      s.RaftLogsLevel,
+		"snapshot_distance":    s.SnapshotDistance,
+	} {
+		setSettingIfNotSpecified(*settings, keeperServerSection+"/coordination_settings/"+name, value)
+	}
+}
+
+// normalizeConfigurationFilesystemCache normalizes .spec.configuration.filesystemCache
+// Filesystem cache with incorrect disk, name or size is skipped as a whole
+func (n *Normalizer) normalizeConfigurationFilesystemCache(cache *chiv1.ChiFilesystemCache) {
+	if (cache.Disk == "") && (cache.MaxSize == "") {
+		// Not configured
+		return
+	}
+
+	if cache.Name == "" {
+		cache.Name = cache.Disk + "_cache"
+	}
+
+	skip := func(reason string, args ...interface{}) {
+		log.V(1).Infof("filesystemCache: "+reason+". Skip it.", args...)
+		*cache = chiv1.ChiFilesystemCache{}
+	}
+	switch {
+	case !isSQLIdentifier(cache.Disk):
+		skip("incorrect disk name %s", cache.Disk)
+	case !isSQLIdentifier(cache.Name):
+		skip("incorrect cache disk name %s", cache.Name)
+	case cache.Name == cache.Disk:
+		skip("cache disk name %s has to differ from disk name", cache.Name)
+	case !isPositiveQuantity(cache.MaxSize):
+		skip("maxSize has to be a positive quantity, got %s", cache.MaxSize)
+	case (cache.Policy != "") && !isSQLIdentifier(cache.Policy):
+		skip("incorrect policy name %s", cache.Policy)
+	}
+}
+
+// normalizeConfigurationStorage normalizes .spec.configuration.storage
+// Disks with incorrect or duplicate names or relative paths are skipped, as well as volumes without known disks
+// and policies without volumes
+func (n *Normalizer) normalizeConfigurationStorage(storage *chiv1.ChiStorage) {
+	// Filesystem cache disk and policy are generated along with specified ones, thus their names are reserved
+	cache := &n.chi.Spec.Configuration.FilesystemCache
+
+	disks := make(map[string]bool)
+	var normalizedDisks []chiv1.ChiStorageDisk
+	for _, disk := range storage.Disks {
+		switch {
+		case !isSQLIdentifier(disk.Name) || disks[disk.Name] || (cache.IsEnabled() && (cache.Name == disk.Name)):
+			log.V(1).Infof("Incorrect or duplicate disk name %s. Skip it.", disk.Name)
+			continue
+		case !path.IsAbs(disk.Path):
+			log.V(1).Infof("Disk %s has to have an absolute path, got %s. Skip it.", disk.Name, disk.Path)
+			continue
+		}
+		disk.Path = strings.TrimSuffix(path.Clean(disk.Path), "/") + "/"
+		if disk.KeepFreeSpaceBytes != "" {
+			if _, err := strconv.ParseUint(disk.KeepFreeSpaceBytes, 10, 64); err != nil {
+				log.V(1).Infof("Disk %s has incorrect keepFreeSpaceBytes %s. Skip it.", disk.Name, disk.KeepFreeSpaceBytes)
+				disk.KeepFreeSpaceBytes = ""
+			}
+		}
+		disks[disk.Name] = true
+		normalizedDisks = append(normalizedDisks, disk)
+	}
+	storage.Disks = normalizedDisks
+
+	policies := make(map[string]bool)
+	var normalizedPolicies []chiv1.ChiStoragePolicy
+	for _, policy := range storage.Policies {
+		if !isSQLIdentifier(policy.Name) || policies[policy.Name] || (cache.IsEnabled() && (cache.Policy == policy.Name)) {
+			log.V(1).Infof("Incorrect or duplicate storage policy name %s. Skip it.", policy.Name)
+			continue
+		}
+		if policy.MoveFactor != "" {
+			if factor, err := strconv.ParseFloat(policy.MoveFactor, 64); (err != nil) || (factor < 0) || (factor > 1) {
+				log.V(1).Infof("Storage policy %s has to have moveFactor within [0, 1], got %s. Skip it.", policy.Name, policy.MoveFactor)
+				policy.MoveFactor = ""
+			}
+		}
+
+		volumes := make(map[string]bool)
+		var normalizedVolumes []chiv1.ChiStorageVolume
+		for _, volume := range policy.Volumes {
+			if !isSQLIdentifier(volume.Name) || volumes[volume.Name] {
+				log.V(1).Infof("Storage policy %s has incorrect or duplicate volume name %s. Skip it.", policy.Name, volume.Name)
+				continue
+			}
+			var volumeDisks []string
+			for _, disk := range volume.Disks {
+				if !disks[disk] && !n.isKnownStorageDisk(disk) {
+					log.V(1).Infof("Volume %s of storage policy %s refers to unknown disk %s. Skip it.", volume.Name, policy.Name, disk)
+					continue
+				}
+				volumeDisks = append(volumeDisks, disk)
+			}
+			if len(volumeDisks) == 0 {
+				log.V(1).Infof("Volume %s of storage policy %s has no disks. Skip it.", volume.Name, policy.Name)
+				continue
+			}
+			volume.Disks = volumeDisks
+			volumes[volume.Name] = true
+			normalizedVolumes = append(normalizedVolumes, volume)
+		}
+		if len(normalizedVolumes) == 0 {
+			log.V(1).Infof("Storage policy %s has no volumes. Skip it.", policy.Name)
+			continue
+		}
+		policy.Volumes = normalizedVolumes
+
+		policies[policy.Name] = true
+		normalizedPolicies = append(normalizedPolicies, policy)
+	}
+	storage.Policies = normalizedPolicies
+}
+
+// isKnownStorageDisk checks whether disk is either ClickHouse default disk, filesystem cache disk or is specified in settings
+func (n *Normalizer) isKnownStorageDisk(disk string) bool {
+	if disk == "default" {
+		return true
+	}
+	if cache := &n.chi.Spec.Configuration.FilesystemCache; cache.IsEnabled() && (cache.Name == disk) {
+		return true
+	}
+	for p := range n.chi.Spec.Configuration.Settings {
+		if strings.HasPrefix(strings.TrimPrefix(p, "/"), "storage_configuration/disks/"+disk+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeConfigurationRoles normalizes .spec.configuration.roles
+// Roles and grants with incorrect names or targets are skipped
+func (n *Normalizer) normalizeConfigurationRoles(roles *[]chiv1.ChiRole) {
+	var normalized []chiv1.ChiRole
+	for _, role := range *roles {
+		if !isSQLIdentifier(role.Name) {
+			log.V(1).Infof("Incorrect role name %s. Skip it.", role.Name)
+			continue
+		}
+
+		var grants []chiv1.ChiGrant
+		for _, grant := range role.Grants {
+			grant.Privileges = strings.ToUpper(strings.TrimSpace(grant.Privileges))
+			grant.On = strings.TrimSpace(grant.On)
+			if !isGrantPrivileges(grant.Privileges) || !isGrantTarget(grant.On) {
+				log.V(1).Infof("Incorrect grant %s ON %s of role %s. Skip it.", grant.Privileges, grant.On, role.Name)
+				continue
+			}
+			grants = append(grants, grant)
+		}
+		role.Grants = grants
+
+		normalized = append(normalized, role)
+	}
+	*roles = normalized
+}
+
+// applyDefaultRolesToUsers converts 'user/default_roles' into 'user/grants/query' role grants.
+// Roles granted to user specified in users config are default ones, so 'SET ROLE' is not needed in each session.
+// Roles not specified in .spec.configuration.roles are skipped
+func (n *Normalizer) applyDefaultRolesToUsers(users *chiv1.Settings) {
+	for _, username := range getUsernames(*users) {
+		path := username + "/default_roles"
+		setting, ok := (*users)[path]
+		if !ok {
+			continue
+		}
+		delete(*users, path)
+
+		var roles []string
+		if setting.IsScalar() {
+			roles = strings.Split(setting.Scalar(), ",")
+		} else {
+			roles = setting.Vector()
+		}
+
+		var grants []string
+		if queries, ok := (*users)[username+"/grants/query"]; ok {
+			grants = queries.AsVector()
+		}
+		for _, role := range roles {
+			role = strings.TrimSpace(role)
+			if !n.hasRole(role) {
+				log.V(1).Infof("User %s default role %s is not specified in roles. Skip it.", username, role)
+				continue
+			}
+			grants = append(grants, "GRANT "+role)
+		}
+		if len(grants) > 0 {
+			(*users)[username+"/grants/query"] = chiv1.NewVectorSetting(grants)
+		}
+	}
+}
+
+// hasRole checks whether role is specified in .spec.configuration.roles
+func (n *Normalizer) hasRole(name string) bool {
+	for i := range n.chi.Spec.Configuration.Roles {
+		if n.chi.Spec.Configuration.Roles[i].Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// isSQLIdentifier checks whether name can be used as unquoted SQL identifier
+func isSQLIdentifier(name string) bool {
+	return sqlIdentifierRegexp.MatchString(name)
+}
+
+// isCompressionCodec checks whether codec is comma-separated chain of known codecs, such as 'Delta, ZSTD(3)'
+func isCompressionCodec(codec string) bool {
+	if !compressionCodecRegexp.MatchString(codec) {
+		return false
+	}
+	for _, match := range compressionCodecNameRegexp.FindAllStringSubmatch(codec, -1) {
+		known := false
+		for _, name := range compressionCodecs {
+			if strings.EqualFold(match[1], name) {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return false
+		}
+	}
+	return true
+}
+
+// isGrantPrivileges checks whether privileges are comma-separated list of privileges, such as 'SELECT, SHOW TABLES'
+func isGrantPrivileges(privileges string) bool {
+	return grantPrivilegesRegexp.MatchString(privileges)
+}
+
+// isGrantTarget checks whether grant target is either 'db.table', 'db.*' or '*.*'
+func isGrantTarget(target string) bool {
+	return grantTargetRegexp.MatchString(target)
+}
+
+// normalizeConfigurationUserDefinedFunctions normalizes .spec.configuration.userDefinedFunctions
+func (n *Normalizer) normalizeConfigurationUserDefinedFunctions(udf *chiv1.ChiUserDefinedFunctions) {
+	if (udf.ConfigMap != "") && (udf.Secret != "") {
+		log.V(1).Infof("userDefinedFunctions has both configMap %s and secret %s specified. Use configMap.", udf.ConfigMap, udf.Secret)
+		udf.Secret = ""
+	}
+}
+
+// normalizeConfigurationFormatSchemas normalizes .spec.configuration.formatSchemas
+func (n *Normalizer) normalizeConfigurationFormatSchemas(schemas *chiv1.ChiFormatSchemas) {
+	if (schemas.ConfigMap != "") && (schemas.Secret != "") {
+		log.V(1).Infof("formatSchemas has both configMap %s and secret %s specified. Use configMap.", schemas.ConfigMap, schemas.Secret)
+		schemas.Secret = ""
+	}
+}
+
+// normalizeConfigurationInterserverCredentials normalizes .spec.configuration.interserverCredentials
+// Both user and password Secrets are required, otherwise credentials are not used
+func (n *Normalizer) normalizeConfigurationInterserverCredentials(credentials *chiv1.ChiInterserverCredentials) {
+	credentials.AllowEmpty = util.CastStringBoolToStringTrueFalse(credentials.AllowEmpty, false)
+	if credentials.HasUserSecret() != credentials.HasPasswordSecret() {
+		log.V(1).Infof("interserverCredentials requires both userSecret and passwordSecret. Credentials are not used.")
+	}
+}
+
+// normalizeConfigurationKafka normalizes .spec.configuration.kafka
+func (n *Normalizer) normalizeConfigurationKafka(kafka *chiv1.ChiKafka) {
+	kafka.SecurityProtocol = strings.ToLower(kafka.SecurityProtocol)
+	if (kafka.SecurityProtocol != "") && !util.InArray(kafka.SecurityProtocol, kafkaSecurityProtocols) {
+		log.V(1).Infof("Unknown kafka.securityProtocol %s. Skip it.", kafka.SecurityProtocol)
+		kafka.SecurityProtocol = ""
+	}
+	kafka.SASLMechanism = strings.ToUpper(kafka.SASLMechanism)
+	if (kafka.SASLMechanism != "") && !util.InArray(kafka.SASLMechanism, kafkaSASLMechanisms) {
+		log.V(1).Infof("Unknown kafka.saslMechanism %s. Skip it.", kafka.SASLMechanism)
+		kafka.SASLMechanism = ""
+	}
+	if (kafka.SASLMechanism != "") || kafka.HasSASLUsernameSecret() || kafka.HasSASLPasswordSecret() {
+		if !strings.HasPrefix(kafka.SecurityProtocol, "sasl_") {
+			log.V(1).Infof("kafka SASL is specified, but securityProtocol %s is not SASL one. SASL is not used.", kafka.SecurityProtocol)
+		}
+	}
+}
+
+// normalizeConfigurationCompression normalizes .spec.configuration.compression
+// Cases with unknown method or incorrect conditions are skipped as a whole, order of cases is kept
+func (n *Normalizer) normalizeConfigurationCompression(cases *[]chiv1.ChiCompressionCase) {
+	var normalized []chiv1.ChiCompressionCase
+	for _, _case := range *cases {
+		_case.Method = strings.ToLower(strings.TrimSpace(_case.Method))
+		if !util.InArray(_case.Method, compressionMethods) {
+			log.V(1).Infof("Unknown compression method %s. Skip case.", _case.Method)
+			continue
+		}
+		if _case.MinPartSize != "" {
+			if _, err := strconv.ParseUint(_case.MinPartSize, 10, 64); err != nil {
+				log.V(1).Infof("Incorrect compression minPartSize %s. Skip case.", _case.MinPartSize)
+				continue
+			}
+		}
+		if _case.MinPartSizeRatio != "" {
+			if ratio, err := strconv.ParseFloat(_case.MinPartSizeRatio, 64); (err != nil) || (ratio < 0) || (ratio > 1) {
+				log.V(1).Infof("Compression minPartSizeRatio has to be within [0, 1], got %s. Skip case.", _case.MinPartSizeRatio)
+				continue
+			}
+		}
+		normalized = append(normalized, _case)
+	}
+	*cases = normalized
+}
+
+// normalizeConfigurationBackups normalizes .spec.configuration.backups
+// Incorrect limits are skipped, while backups with incorrect disk name are not configured at all
+func (n *Normalizer) normalizeConfigurationBackups(backups *chiv1.ChiBackups) {
+	if (backups.Disk != "") && !isSQLIdentifier(backups.Disk) {
+		log.V(1).Infof("Incorrect backups.disk %s. Skip backups.", backups.Disk)
+		*backups = chiv1.ChiBackups{}
+		return
+	}
+	if !backups.IsConfigured() {
+		if (backups.IOThreads != "") || (backups.MaxBandwidth != "") {
+			log.V(1).Infof("backups.disk is not specified. Skip backups.")
+		}
+		*backups = chiv1.ChiBackups{}
+		return
+	}
+	if backups.IOThreads != "" {
+		if value, err := strconv.ParseUint(backups.IOThreads, 10, 64); (err != nil) || (value == 0) {
+			log.V(1).Infof("Incorrect backups.ioThreads %s. Skip it.", backups.IOThreads)
+			backups.IOThreads = ""
+		}
+	}
+	if backups.MaxBandwidth != "" {
+		if _, err := strconv.ParseUint(backups.MaxBandwidth, 10, 64); err != nil {
+			log.V(1).Infof("Incorrect backups.maxBandwidth %s. Skip it.", backups.MaxBandwidth)
+			backups.MaxBandwidth = ""
+		}
+	}
+}
+
+// normalizeCluster normalizes cluster and returns deployments usage counters for this cluster
+func (n *Normalizer) normalizeCluster(cluster *chiv1.ChiCluster) error {
+	cluster.FillShardReplicaSpecified()
+	cluster.Standby = util.CastStringBoolToStringTrueFalse(cluster.Standby, false)
+
+	// Inherit from .spec.configuration.zookeeper
+	cluster.InheritZookeeperFrom(n.chi)
+	// Inherit from .spec.configuration.files
+	cluster.InheritFilesFrom(n.chi)
+	// Inherit from .spec.defaults
+	cluster.InheritTemplatesFrom(n.chi)
+
+	n.normalizeConfigurationZookeeper(&cluster.Zookeeper)
+	n.normalizeConfigurationSettings(&cluster.Settings)
+	n.normalizeConfigurationFiles(&cluster.Files)
+	n.normalizeClusterDiscovery(cluster)
+
+	n.normalizeClusterLayoutShardsCountAndReplicasCount(&cluster.Layout)
+	n.truncateClusterLayout(cluster)
+
+	n.ensureClusterLayoutShards(&cluster.Layout)
+	n.ensureClusterLayoutReplicas(&cluster.Layout)
+
+	n.createHostsField(cluster)
+
+	// Loop over all shards and replicas inside shards and fill structure
 	cluster.WalkShards(func(index int, shard *chiv1.ChiShard) error {
 		n.normalizeShard(shard, cluster, index)
 		return nil
@@ -1325,6 +3125,30 @@ func (n *Normalizer) normalizeCluster(cluster *chiv1.ChiCluster) error {
 	return nil
 }
 
+// normalizeClusterDiscovery normalizes .spec.configuration.clusters[n].discovery
+// Discovery requires ZooKeeper, path is derived from CHI and cluster names by default
+func (n *Normalizer) normalizeClusterDiscovery(cluster *chiv1.ChiCluster) {
+	discovery := &cluster.Discovery
+	discovery.Enabled = util.CastStringBoolToStringTrueFalse(discovery.Enabled, false)
+	if !cluster.IsDiscovery() {
+		return
+	}
+
+	if cluster.Zookeeper.IsEmpty() {
+		log.V(1).Infof("Cluster %s discovery requires ZooKeeper. Hosts are listed in remote_servers instead.", cluster.Name)
+		discovery.Enabled = util.StringBoolFalseLowercase
+		return
+	}
+
+	if (discovery.Path != "") && !strings.HasPrefix(discovery.Path, "/") {
+		log.V(1).Infof("Cluster %s discovery path has to be absolute, got %s. Use default one.", cluster.Name, discovery.Path)
+		discovery.Path = ""
+	}
+	if discovery.Path == "" {
+		discovery.Path = fmt.Sprintf(clusterDiscoveryPathPattern, n.chi.Name, cluster.Name)
+	}
+}
+
 // createHostsField
 func (n *Normalizer) createHostsField(cluster *chiv1.ChiCluster) {
 	cluster.Layout.HostsField = chiv1.NewHostsField(cluster.Layout.ShardsCount, cluster.Layout.ReplicasCount)
@@ -1407,6 +3231,48 @@ func (n *Normalizer) normalizeClusterLayoutShardsCountAndReplicasCount(layout *c
 	}
 }
 
+// truncateClusterLayout truncates shards and replicas of the cluster exceeding limits of operator config,
+// in case operator config allows truncation. Otherwise layout is left untouched to be rejected by validateLayoutLimits
+func (n *Normalizer) truncateClusterLayout(cluster *chiv1.ChiCluster) {
+	if n.chop.Config().OnCHILimitsExceededAction != chiv1.OnCHILimitsExceededActionTruncate {
+		return
+	}
+
+	layout := &cluster.Layout
+	if max := n.chop.Config().CHIMaxShardsCount; (max > 0) && (layout.ShardsCount > max) {
+		log.V(1).Infof("WARNING: cluster %s requests %d shards, %d allowed. Truncate it.", cluster.Name, layout.ShardsCount, max)
+		layout.ShardsCount = max
+		if len(layout.Shards) > max {
+			layout.Shards = layout.Shards[:max]
+		}
+		for i := range layout.Replicas {
+			replica := &layout.Replicas[i]
+			if replica.ShardsCount > max {
+				replica.ShardsCount = max
+			}
+			if len(replica.Hosts) > max {
+				replica.Hosts = replica.Hosts[:max]
+			}
+		}
+	}
+	if max := n.chop.Config().CHIMaxReplicasCount; (max > 0) && (layout.ReplicasCount > max) {
+		log.V(1).Infof("WARNING: cluster %s requests %d replicas, %d allowed. Truncate it.", cluster.Name, layout.ReplicasCount, max)
+		layout.ReplicasCount = max
+		if len(layout.Replicas) > max {
+			layout.Replicas = layout.Replicas[:max]
+		}
+		for i := range layout.Shards {
+			shard := &layout.Shards[i]
+			if shard.ReplicasCount > max {
+				shard.ReplicasCount = max
+			}
+			if len(shard.Hosts) > max {
+				shard.Hosts = shard.Hosts[:max]
+			}
+		}
+	}
+}
+
 // ensureClusterLayoutShards ensures slice layout.Shards is in place
 func (n *Normalizer) ensureClusterLayoutShards(layout *chiv1.ChiClusterLayout) {
 	// Disposition of shards in slice would be
@@ -1445,7 +3311,7 @@ func (n *Normalizer) normalizeShard(shard *chiv1.ChiShard, cluster *chiv1.ChiClu
 	n.normalizeShardReplicasCount(shard, cluster.Layout.ReplicasCount)
 	n.normalizeShardHosts(shard, cluster, shardIndex)
 	// Internal replication uses ReplicasCount thus it has to be normalized after shard ReplicaCount normalized
-	n.normalizeShardInternalReplication(shard)
+	n.normalizeShardInternalReplication(shard, cluster)
 }
 
 // normalizeReplica normalizes a replica - walks over all fields
@@ -1528,8 +3394,11 @@ func (n *Normalizer) normalizeReplicaName(replica *chiv1.ChiReplica, index int)
 	replica.Name = CreateReplicaName(replica, index)
 }
 
-// normalizeShardName normalizes shard weight
+// normalizeShardWeight normalizes shard weight
 func (n *Normalizer) normalizeShardWeight(shard *chiv1.ChiShard) {
+	if shard.Weight <= 0 {
+		shard.Weight = shardWeightDefault
+	}
 }
 
 // normalizeShardHosts normalizes all replicas of specified shard
@@ -1579,9 +3448,94 @@ func (n *Normalizer) normalizeHost(
 	}
 	host.InheritSettingsFrom(s, r)
 	n.normalizeConfigurationSettings(&host.Settings)
+	n.normalizeSettingsNumericValues(&host.Settings)
+	n.normalizeSettingsDefaultPasswordType(&host.Settings)
+	// Keeper nodes may be configured per-host, since each node has own server_id
+	n.applyKeeperToSettings(&host.Settings)
 	host.InheritFilesFrom(s, r)
 	n.normalizeConfigurationSettings(&host.Files)
 	host.InheritTemplatesFrom(s, r, nil)
+	// Data volume size is specified per-shard, regardless of cluster layout
+	host.InheritDataVolumeSizeFrom(shard)
+	n.normalizeHostDataVolumeSize(host)
+	// Background pools are specified per-shard, regardless of cluster layout, and land in host's personal settings
+	host.InheritBackgroundPoolsFrom(shard)
+	n.normalizeHostBackgroundPools(host)
+	n.applyBackgroundPoolsToHostSettings(host)
+	// Standby settings are applied after background pools, so explicitly specified pools are not shrunk
+	if cluster.IsStandby() {
+		n.applyStandbyToSettings(&host.Settings)
+	}
+	// StatefulSet annotations are specified per-shard and per-replica, regardless of cluster layout
+	host.InheritStatefulSetAnnotationsFrom(shard, replica)
+}
+
+// applyStandbyToSettings applies settings of standby cluster to host settings.
+// Explicitly specified settings are not overwritten
+func (n *Normalizer) applyStandbyToSettings(settings *chiv1.Settings) {
+	for path, value := range settingsStandby {
+		setSettingIfNotSpecified(*settings, path, value)
+	}
+}
+
+// applyStandbyToProfiles ensures profile, which is the default user's profile on hosts of standby clusters, is specified.
+// Explicitly specified profile settings are not overwritten
+func (n *Normalizer) applyStandbyToProfiles(profiles *chiv1.Settings) {
+	if !n.chi.Spec.Configuration.HasStandbyClusters() {
+		return
+	}
+	if *profiles == nil {
+		*profiles = chiv1.NewSettings()
+	}
+	for name, value := range profileStandbyReadonly {
+		setSettingIfNotSpecified(*profiles, standbyDefaultUserProfile+"/"+name, value)
+	}
+}
+
+// normalizeHostBackgroundPools ensures host.BackgroundPools has proper values.
+// Pool sizes have to be positive integers, concurrency ratio has to be a positive number
+func (n *Normalizer) normalizeHostBackgroundPools(host *chiv1.ChiHost) {
+	p := &host.BackgroundPools
+	prefix := "Host " + host.Name + " backgroundPools."
+	normalizeUintString(prefix+"poolSize", &p.PoolSize, 1, 64, "")
+	normalizeUintString(prefix+"fetchesPoolSize", &p.FetchesPoolSize, 1, 64, "")
+	normalizeUintString(prefix+"movePoolSize", &p.MovePoolSize, 1, 64, "")
+	normalizeUintString(prefix+"schedulePoolSize", &p.SchedulePoolSize, 1, 64, "")
+	normalizeUintString(prefix+"commonPoolSize", &p.CommonPoolSize, 1, 64, "")
+
+	if p.MergesMutationsConcurrencyRatio != "" {
+		if ratio, err := strconv.ParseFloat(p.MergesMutationsConcurrencyRatio, 64); (err != nil) || (ratio <= 0) {
+			log.V(1).Infof("Host %s has incorrect backgroundPools.mergesMutationsConcurrencyRatio %s. Skip it.", host.Name, p.MergesMutationsConcurrencyRatio)
+			p.MergesMutationsConcurrencyRatio = ""
+		}
+	}
+}
+
+// applyBackgroundPoolsToHostSettings applies host.BackgroundPools to host's settings
+func (n *Normalizer) applyBackgroundPoolsToHostSettings(host *chiv1.ChiHost) {
+	if host.Settings == nil {
+		host.Settings = chiv1.NewSettings()
+	}
+	p := &host.BackgroundPools
+
+	setSettingIfNotSpecified(host.Settings, "background_pool_size", p.PoolSize)
+	setSettingIfNotSpecified(host.Settings, settingBackgroundMergesMutationsConcurrencyRatio, p.MergesMutationsConcurrencyRatio)
+	setSettingIfNotSpecified(host.Settings, "background_fetches_pool_size", p.FetchesPoolSize)
+	setSettingIfNotSpecified(host.Settings, "background_move_pool_size", p.MovePoolSize)
+	setSettingIfNotSpecified(host.Settings, "background_schedule_pool_size", p.SchedulePoolSize)
+	setSettingIfNotSpecified(host.Settings, "background_common_pool_size", p.CommonPoolSize)
+}
+
+// normalizeHostDataVolumeSize ensures host.DataVolumeSize is a valid resource.Quantity
+func (n *Normalizer) normalizeHostDataVolumeSize(host *chiv1.ChiHost) {
+	if host.DataVolumeSize == "" {
+		return
+	}
+
+	if !isPositiveQuantity(host.DataVolumeSize) {
+		log.V(1).Infof("Host %s has incorrect dataVolumeSize %s, has to be a positive quantity. Ignore it.", host.Name, host.DataVolumeSize)
+		host.DataVolumeSize = ""
+	}
 }
 
 // normalizeHostTemplateSpec is the same as normalizeHost but for a template
@@ -1627,10 +3581,17 @@ func (n *Normalizer) normalizeHostPorts(host *chiv1.ChiHost) {
 
 // normalizeShardInternalReplication ensures reasonable values in
 // .spec.configuration.clusters.layout.shards.internalReplication
-func (n *Normalizer) normalizeShardInternalReplication(shard *chiv1.ChiShard) {
-	// Shards with replicas are expected to have internal replication on by default
+// Value is resolved in the following order:
+//  1. shard's own internalReplication
+//  2. cluster's internalReplication
+//  3. true in case shard has more than one replica and ZooKeeper is configured, so ReplicatedMergeTree tables
+//     replicate via ZooKeeper and not via Distributed engine. False otherwise
+func (n *Normalizer) normalizeShardInternalReplication(shard *chiv1.ChiShard, cluster *chiv1.ChiCluster) {
+	if shard.InternalReplication == "" {
+		shard.InternalReplication = cluster.InternalReplication
+	}
 	defaultInternalReplication := false
-	if shard.ReplicasCount > 1 {
+	if (shard.ReplicasCount > 1) && !cluster.Zookeeper.IsEmpty() {
 		defaultInternalReplication = true
 	}
 	shard.InternalReplication = util.CastStringBoolToStringTrueFalse(shard.InternalReplication, defaultInternalReplication)
@@ -1642,6 +3603,648 @@ func (n *Normalizer) normalizeDefaultsReplicasUseFQDN(d *chiv1.ChiDefaults) {
 	d.ReplicasUseFQDN = util.CastStringBoolToStringTrueFalse(d.ReplicasUseFQDN, false)
 }
 
+// normalizeDefaultsFlags ensures boolean flags of chiv1.ChiDefaults have proper values.
+// All flags are disabled by default:
+// - secureByDefault - plain ports are used
+// - podDisruptionBudget - no PodDisruptionBudgets generated
+// - roleServices - no role labels and read/write Services generated
+// - logToConsole - log into files
+func (n *Normalizer) normalizeDefaultsFlags(d *chiv1.ChiDefaults) {
+	for _, flag := range []*string{
+		&d.SecureByDefault,
+		&d.PodDisruptionBudget,
+		&d.RoleServices,
+		&d.LogToConsole,
+	} {
+		*flag = util.CastStringBoolToStringTrueFalse(*flag, false)
+	}
+}
+
+// normalizeDefaultsLogFormat ensures chiv1.ChiDefaults.LogFormat has proper value
+func (n *Normalizer) normalizeDefaultsLogFormat(d *chiv1.ChiDefaults) {
+	d.LogFormat = strings.ToLower(d.LogFormat)
+	if d.LogFormat == "" {
+		d.LogFormat = logFormatPlain
+	}
+	if !util.InArray(d.LogFormat, logFormats) {
+		log.V(1).Infof("Unknown logFormat %s. Use %s.", d.LogFormat, logFormatPlain)
+		d.LogFormat = logFormatPlain
+	}
+}
+
+// normalizeDefaultsMaxOpenFiles ensures chiv1.ChiDefaults.MaxOpenFiles has proper value
+func (n *Normalizer) normalizeDefaultsMaxOpenFiles(d *chiv1.ChiDefaults) {
+	if d.MaxOpenFiles == "" {
+		return
+	}
+	if value, err := strconv.ParseUint(d.MaxOpenFiles, 10, 64); (err != nil) || (value == 0) {
+		log.V(1).Infof("Incorrect maxOpenFiles %s. Skip it.", d.MaxOpenFiles)
+		d.MaxOpenFiles = ""
+	}
+}
+
+// normalizeDefaultsInterserverListenHost ensures chiv1.ChiDefaults.InterserverListenHost is either IP address or hostname
+func (n *Normalizer) normalizeDefaultsInterserverListenHost(d *chiv1.ChiDefaults) {
+	host := d.InterserverListenHost
+	if host == "" {
+		// Not specified, listen_host is used for inter-server communication
+		return
+	}
+	if (net.ParseIP(host) == nil) && (len(validation.IsDNS1123Subdomain(host)) > 0) {
+		log.V(1).Infof("interserverListenHost %s is neither IP address nor hostname. Skip it.", host)
+		d.InterserverListenHost = ""
+	}
+}
+
+// normalizeDefaultsShardAntiAffinity ensures chiv1.ChiDefaults.ShardAntiAffinity has proper value
+func (n *Normalizer) normalizeDefaultsShardAntiAffinity(d *chiv1.ChiDefaults) {
+	switch d.ShardAntiAffinity {
+	case "", chiv1.ShardAntiAffinityRequired, chiv1.ShardAntiAffinityPreferred:
+		// Known value, all is fine
+	default:
+		log.V(1).Infof("Unknown shardAntiAffinity %s. Skip it.", d.ShardAntiAffinity)
+		d.ShardAntiAffinity = ""
+	}
+}
+
+// normalizeDefaultsReplicaAntiAffinityTopologyKey ensures chiv1.ChiDefaults.ReplicaAntiAffinityTopologyKey has proper value
+func (n *Normalizer) normalizeDefaultsReplicaAntiAffinityTopologyKey(d *chiv1.ChiDefaults) {
+	// Spread replicas over nodes by default
+	if d.ReplicaAntiAffinityTopologyKey == "" {
+		d.ReplicaAntiAffinityTopologyKey = topologyKeyHostname
+	}
+}
+
+// normalizeDefaultsDistributedDDL ensures chiv1.ChiDefaults.DistributedDDL section has proper values.
+// Queue cleanup settings not specified or incorrect fall back to defaults, in case cleanup is enabled
+func (n *Normalizer) normalizeDefaultsDistributedDDL(d *chiv1.ChiDefaults) {
+	ddl := &d.DistributedDDL
+	ddl.Cleanup = util.CastStringBoolToStringTrueFalse(ddl.Cleanup, false)
+	if !ddl.IsCleanup() {
+		return
+	}
+
+	normalizeUintString("distributedDDL.maxTasksInQueue", &ddl.MaxTasksInQueue, 1, 64, distributedDDLDefaultMaxTasksInQueue)
+	normalizeUintString("distributedDDL.taskMaxLifetime", &ddl.TaskMaxLifetime, 1, 64, distributedDDLDefaultTaskMaxLifetime)
+	normalizeUintString("distributedDDL.cleanupDelayPeriod", &ddl.CleanupDelayPeriod, 1, 64, distributedDDLDefaultCleanupDelayPeriod)
+}
+
+// normalizeDefaultsFilesystemRead ensures chiv1.ChiDefaults.FilesystemRead section has proper values
+func (n *Normalizer) normalizeDefaultsFilesystemRead(d *chiv1.ChiDefaults) {
+	r := &d.FilesystemRead
+	if (r.Method != "") && !util.InArray(r.Method, localFilesystemReadMethods) {
+		log.V(1).Infof("Unknown filesystemRead.method %s. Skip it.", r.Method)
+		r.Method = ""
+	}
+	if r.MaxReadBufferSize != "" {
+		quantity, err := resource.ParseQuantity(r.MaxReadBufferSize)
+		if (err != nil) || (quantity.Sign() <= 0) {
+			log.V(1).Infof("filesystemRead.maxReadBufferSize has to be a positive size, got %s. Skip it.", r.MaxReadBufferSize)
+			r.MaxReadBufferSize = ""
+		} else {
+			r.MaxReadBufferSize = strconv.FormatInt(quantity.Value(), 10)
+		}
+	}
+}
+
+// normalizeDefaultsDistributedQueries ensures chiv1.ChiDefaults.DistributedQueries section has proper values
+func (n *Normalizer) normalizeDefaultsDistributedQueries(d *chiv1.ChiDefaults) {
+	q := &d.DistributedQueries
+	if (q.ProductMode != "") && !util.InArray(q.ProductMode, distributedProductModes) {
+		log.V(1).Infof("Unknown distributedQueries.productMode %s. Skip it.", q.ProductMode)
+		q.ProductMode = ""
+	}
+	if (q.PreferLocalhostReplica != "") && !util.IsStringBool(q.PreferLocalhostReplica) {
+		log.V(1).Infof("Unknown distributedQueries.preferLocalhostReplica %s. Skip it.", q.PreferLocalhostReplica)
+		q.PreferLocalhostReplica = ""
+	}
+	if (q.LoadBalancing != "") && !util.InArray(q.LoadBalancing, loadBalancingModes) {
+		log.V(1).Infof("Unknown distributedQueries.loadBalancing %s. Skip it.", q.LoadBalancing)
+		q.LoadBalancing = ""
+	}
+	if q.ReplicaErrorHalfLife != "" {
+		if value, err := strconv.ParseUint(q.ReplicaErrorHalfLife, 10, 64); (err != nil) || (value == 0) {
+			log.V(1).Infof("Incorrect distributedQueries.replicaErrorHalfLife %s. Skip it.", q.ReplicaErrorHalfLife)
+			q.ReplicaErrorHalfLife = ""
+		}
+	}
+	if q.ReplicaErrorCap != "" {
+		if value, err := strconv.ParseUint(q.ReplicaErrorCap, 10, 64); (err != nil) || (value == 0) {
+			log.V(1).Infof("Incorrect distributedQueries.replicaErrorCap %s. Skip it.", q.ReplicaErrorCap)
+			q.ReplicaErrorCap = ""
+		}
+	}
+}
+
+// normalizeDefaultsReadinessProbe ensures chiv1.ChiDefaults.ReadinessProbe section has proper values
+func (n *Normalizer) normalizeDefaultsReadinessProbe(d *chiv1.ChiDefaults) {
+	p := &d.ReadinessProbe
+	if !util.InArray(p.Mode, readinessProbeModes) {
+		if p.Mode != "" {
+			log.V(1).Infof("Unknown readinessProbe.mode %s. Use %s", p.Mode, readinessProbeModePing)
+		}
+		p.Mode = readinessProbeModePing
+	}
+	if p.MaxReplicaDelay != "" {
+		if delay, err := strconv.ParseUint(p.MaxReplicaDelay, 10, 64); (err != nil) || (delay == 0) {
+			log.V(1).Infof("Incorrect readinessProbe.maxReplicaDelay %s. Skip it.", p.MaxReplicaDelay)
+			p.MaxReplicaDelay = ""
+		}
+	}
+	normalizeUintString("readinessProbe.initialDelaySeconds", &p.InitialDelaySeconds, 0, 31, readinessProbeDefaultInitialDelaySeconds)
+	normalizeUintString("readinessProbe.periodSeconds", &p.PeriodSeconds, 1, 31, readinessProbeDefaultPeriodSeconds)
+}
+
+// normalizeDefaultsLivenessProbe ensures chiv1.ChiDefaults.LivenessProbe section has proper values
+func (n *Normalizer) normalizeDefaultsLivenessProbe(d *chiv1.ChiDefaults) {
+	p := &d.LivenessProbe
+	// Default value set to false - liveness probe may kill slow-starting hosts, thus it is opted in explicitly
+	p.Enabled = util.CastStringBoolToStringTrueFalse(p.Enabled, false)
+	normalizeUintString("livenessProbe.initialDelaySeconds", &p.InitialDelaySeconds, 0, 31, livenessProbeDefaultInitialDelaySeconds)
+	normalizeUintString("livenessProbe.periodSeconds", &p.PeriodSeconds, 1, 31, livenessProbeDefaultPeriodSeconds)
+}
+
+// normalizeUintString ensures value is an unsigned integer of bitSize bits, which is not less than min.
+// Value not specified or incorrect falls back to _default, empty _default means incorrect value is skipped
+func normalizeUintString(name string, value *string, min uint64, bitSize int, _default string) {
+	*value = strings.TrimSpace(*value)
+	if *value != "" {
+		if v, err := strconv.ParseUint(*value, 10, bitSize); (err != nil) || (v < min) {
+			kind := "a non-negative"
+			if min > 0 {
+				kind = "a positive"
+			}
+			if _default == "" {
+				log.V(1).Infof("%s has to be %s number, got %s. Skip it.", name, kind, *value)
+			} else {
+				log.V(1).Infof("%s has to be %s number, got %s. Use %s.", name, kind, *value, _default)
+			}
+			*value = ""
+		}
+	}
+	if *value == "" {
+		*value = _default
+	}
+}
+
+// normalizeDefaultsProfileAndQuota ensures chiv1.ChiDefaults.DefaultProfile and DefaultQuota have proper values.
+// Profile and quota assigned to users by default have to be either the ones specified in operator's config,
+// which are expected to be present in ClickHouse config, or to be specified in .spec.configuration
+func (n *Normalizer) normalizeDefaultsProfileAndQuota(d *chiv1.ChiDefaults) {
+	chopProfile := n.chop.Config().CHConfigUserDefaultProfile
+	if (d.DefaultProfile != "") && (d.DefaultProfile != chopProfile) && !hasSettingsSection(n.chi.Spec.Configuration.Profiles, d.DefaultProfile) {
+		log.V(1).Infof("defaultProfile %s is not specified in profiles. Use %s", d.DefaultProfile, chopProfile)
+		d.DefaultProfile = ""
+	}
+	if d.DefaultProfile == "" {
+		d.DefaultProfile = chopProfile
+	}
+
+	chopQuota := n.chop.Config().CHConfigUserDefaultQuota
+	if (d.DefaultQuota != "") && (d.DefaultQuota != chopQuota) && !hasSettingsSection(n.chi.Spec.Configuration.Quotas, d.DefaultQuota) &&
+		!n.chi.Spec.Configuration.HasQuotaIntervals(d.DefaultQuota) {
+		log.V(1).Infof("defaultQuota %s is not specified in quotas. Use %s", d.DefaultQuota, chopQuota)
+		d.DefaultQuota = ""
+	}
+	if d.DefaultQuota == "" {
+		d.DefaultQuota = chopQuota
+	}
+}
+
+// normalizeDefaultsCertRotationTokenSecret ensures chiv1.ChiDefaults.CertRotationTokenSecret specifies both name and key
+func (n *Normalizer) normalizeDefaultsCertRotationTokenSecret(d *chiv1.ChiDefaults) {
+	if (d.CertRotationTokenSecret != nil) && !d.HasCertRotationTokenSecret() {
+		log.V(1).Infof("certRotationTokenSecret has to specify name and key. Skip it.")
+		d.CertRotationTokenSecret = nil
+	}
+}
+
+// normalizeDefaultsServiceMesh ensures chiv1.ChiDefaults.ServiceMesh section has proper values
+func (n *Normalizer) normalizeDefaultsServiceMesh(d *chiv1.ChiDefaults) {
+	mesh := &d.ServiceMesh
+	if (mesh.Type != "") && !util.InArray(mesh.Type, serviceMeshes) {
+		log.V(1).Infof("Unknown serviceMesh.type %s. Skip it.", mesh.Type)
+		mesh.Type = ""
+	}
+	// Inter-server port is excluded from mesh by default
+	mesh.ExcludeInterserverPort = util.CastStringBoolToStringTrueFalse(mesh.ExcludeInterserverPort, true)
+}
+
+// normalizeDefaultsDropSafeguards ensures chiv1.ChiDefaults.DropSafeguards section has proper values
+func (n *Normalizer) normalizeDefaultsDropSafeguards(d *chiv1.ChiDefaults) {
+	normalizeUintString("dropSafeguards.maxTableSizeToDrop", &d.DropSafeguards.MaxTableSizeToDrop, 0, 64, "")
+	normalizeUintString("dropSafeguards.maxPartitionSizeToDrop", &d.DropSafeguards.MaxPartitionSizeToDrop, 0, 64, "")
+}
+
+// normalizeDefaultsDNSCache ensures chiv1.ChiDefaults.DNSCache section has proper values.
+// In case section is specified and internal DNS cache is not disabled, short update period is used by default
+func (n *Normalizer) normalizeDefaultsDNSCache(d *chiv1.ChiDefaults) {
+	c := &d.DNSCache
+	if (*c == chiv1.ChiDNSCache{}) {
+		// Not specified, nothing to emit
+		return
+	}
+
+	normalizeUintString("dnsCache.updatePeriod", &c.UpdatePeriod, 1, 64, "")
+	normalizeUintString("dnsCache.maxConsecutiveFailures", &c.MaxConsecutiveFailures, 1, 64, "")
+
+	if (c.DisableInternal != "") && !util.IsStringBool(c.DisableInternal) {
+		log.V(1).Infof("dnsCache.disableInternal has to be a boolean, got %s. Skip it.", c.DisableInternal)
+		c.DisableInternal = ""
+	}
+
+	if (c.UpdatePeriod == "") && !util.IsStringBoolTrue(c.DisableInternal) {
+		c.UpdatePeriod = dnsCacheDefaultUpdatePeriod
+	}
+}
+
+// normalizeDefaultsCaches ensures chiv1.ChiDefaults.Caches section has proper values.
+// Cache sizes can be specified as resource.Quantity, such as 5Gi, and are converted into bytes
+func (n *Normalizer) normalizeDefaultsCaches(d *chiv1.ChiDefaults) {
+	ensureBytes := func(name string, value *string) {
+		if *value == "" {
+			return
+		}
+		quantity, err := resource.ParseQuantity(*value)
+		if (err != nil) || (quantity.Sign() < 0) {
+			log.V(1).Infof("caches.%s has to be a non-negative size, got %s. Skip it.", name, *value)
+			*value = ""
+			return
+		}
+		*value = strconv.FormatInt(quantity.Value(), 10)
+	}
+	ensureBytes("markCacheSize", &d.Caches.MarkCacheSize)
+	ensureBytes("uncompressedCacheSize", &d.Caches.UncompressedCacheSize)
+
+	if d.Caches.MmapCacheSize != "" {
+		if _, err := strconv.ParseUint(d.Caches.MmapCacheSize, 10, 64); err != nil {
+			log.V(1).Infof("caches.mmapCacheSize has to be a non-negative number of files, got %s. Skip it.", d.Caches.MmapCacheSize)
+			d.Caches.MmapCacheSize = ""
+		}
+	}
+}
+
+// normalizeDefaultsMergeLimits ensures chiv1.ChiDefaults.MergeLimits section has proper values.
+// Merge sizes can be specified as resource.Quantity, such as 150Gi, and are converted into bytes
+func (n *Normalizer) normalizeDefaultsMergeLimits(d *chiv1.ChiDefaults) {
+	l := &d.MergeLimits
+
+	ensureBytes := func(name string, value *string) {
+		if *value == "" {
+			return
+		}
+		quantity, err := resource.ParseQuantity(*value)
+		if (err != nil) || (quantity.Sign() < 0) {
+			log.V(1).Infof("mergeLimits.%s has to be a non-negative size, got %s. Skip it.", name, *value)
+			*value = ""
+			return
+		}
+		*value = strconv.FormatInt(quantity.Value(), 10)
+	}
+	ensureBytes("maxBytesToMergeAtMaxSpaceInPool", &l.MaxBytesToMergeAtMaxSpaceInPool)
+	ensureBytes("maxBytesToMergeAtMinSpaceInPool", &l.MaxBytesToMergeAtMinSpaceInPool)
+
+	normalizeUintString("mergeLimits.maxReplicatedMergesInQueue", &l.MaxReplicatedMergesInQueue, 0, 64, "")
+	normalizeUintString("mergeLimits.maxReplicatedMutationsInQueue", &l.MaxReplicatedMutationsInQueue, 0, 64, "")
+	normalizeUintString("mergeLimits.numberOfFreeEntriesInPoolToLowerMaxSizeOfMerge", &l.NumberOfFreeEntriesInPoolToLowerMaxSizeOfMerge, 0, 64, "")
+	normalizeUintString("mergeLimits.numberOfFreeEntriesInPoolToExecuteMutation", &l.NumberOfFreeEntriesInPoolToExecuteMutation, 0, 64, "")
+	normalizeUintString("mergeLimits.maxNumberOfMergesWithTTLInPool", &l.MaxNumberOfMergesWithTTLInPool, 0, 64, "")
+}
+
+// normalizeDefaultsMemoryTracker ensures chiv1.ChiDefaults.MemoryTracker section has proper values
+func (n *Normalizer) normalizeDefaultsMemoryTracker(d *chiv1.ChiDefaults) {
+	t := &d.MemoryTracker
+	if t.MaxServerMemoryUsageToRAMRatio != "" {
+		if ratio, err := strconv.ParseFloat(t.MaxServerMemoryUsageToRAMRatio, 64); (err != nil) || (ratio <= 0) {
+			log.V(1).Infof("memoryTracker.maxServerMemoryUsageToRAMRatio has to be a positive number, got %s. Skip it.", t.MaxServerMemoryUsageToRAMRatio)
+			t.MaxServerMemoryUsageToRAMRatio = ""
+		}
+	}
+	if t.CgroupsMemoryUsageObserverWaitTime != "" {
+		if _, err := strconv.ParseUint(t.CgroupsMemoryUsageObserverWaitTime, 10, 64); err != nil {
+			log.V(1).Infof("memoryTracker.cgroupsMemoryUsageObserverWaitTime has to be a non-negative number of seconds, got %s. Skip it.", t.CgroupsMemoryUsageObserverWaitTime)
+			t.CgroupsMemoryUsageObserverWaitTime = ""
+		}
+	}
+	t.FromContainerLimit = util.CastStringBoolToStringTrueFalse(t.FromContainerLimit, false)
+}
+
+// normalizeDefaultsScaleDownSafeguards ensures chiv1.ChiDefaults.ScaleDownSafeguards section has proper values
+func (n *Normalizer) normalizeDefaultsScaleDownSafeguards(d *chiv1.ChiDefaults) {
+	s := &d.ScaleDownSafeguards
+	if s.MinReplicasCount < 0 {
+		log.V(1).Infof("scaleDownSafeguards.minReplicasCount has to be non-negative, got %d. Skip it.", s.MinReplicasCount)
+		s.MinReplicasCount = 0
+	}
+	s.AllowDataLoss = util.CastStringBoolToStringTrueFalse(s.AllowDataLoss, false)
+}
+
+// normalizeDefaultsDefaultDatabase ensures chiv1.ChiDefaults.DefaultDatabase and UseDefaultDatabase have proper values
+func (n *Normalizer) normalizeDefaultsDefaultDatabase(d *chiv1.ChiDefaults) {
+	d.UseDefaultDatabase = util.CastStringBoolToStringTrueFalse(d.UseDefaultDatabase, false)
+	if (d.DefaultDatabase != "") && !isSQLIdentifier(d.DefaultDatabase) {
+		log.V(1).Infof("Incorrect defaultDatabase %s. Skip it.", d.DefaultDatabase)
+		d.DefaultDatabase = ""
+	}
+	if d.DefaultDatabase == "" {
+		// Nothing to assign to users
+		d.UseDefaultDatabase = util.StringBoolFalseLowercase
+	}
+}
+
+// normalizeDefaultsCompressionCodec ensures chiv1.ChiDefaults.CompressionCodec is a chain of known codecs
+func (n *Normalizer) normalizeDefaultsCompressionCodec(d *chiv1.ChiDefaults) {
+	if (d.CompressionCodec != "") && !isCompressionCodec(d.CompressionCodec) {
+		log.V(1).Infof("Incorrect compressionCodec %s. Skip it.", d.CompressionCodec)
+		d.CompressionCodec = ""
+	}
+}
+
+// normalizeDefaultsReplicaPathAndName ensures chiv1.ChiDefaults.ReplicaPath and ReplicaName, if specified, have proper values.
+// Incorrect templates are skipped
+func (n *Normalizer) normalizeDefaultsReplicaPathAndName(d *chiv1.ChiDefaults) {
+	if (d.ReplicaPath != "") && (!strings.HasPrefix(d.ReplicaPath, "/") || !strings.Contains(d.ReplicaPath, "{shard}")) {
+		log.V(1).Infof("replicaPath %s has to be absolute path with {shard} macro. Skip it.", d.ReplicaPath)
+		d.ReplicaPath = ""
+	}
+	if (d.ReplicaName != "") && !strings.Contains(d.ReplicaName, "{replica}") {
+		log.V(1).Infof("replicaName %s has to contain {replica} macro. Skip it.", d.ReplicaName)
+		d.ReplicaName = ""
+	}
+}
+
+// normalizeDefaultsDataVolumeChown ensures chiv1.ChiDefaults.DataVolumeChown section has proper values.
+// Owner not specified or incorrect falls back to user and group of ClickHouse image.
+// Command not specified falls back to chown of data volume
+func (n *Normalizer) normalizeDefaultsDataVolumeChown(d *chiv1.ChiDefaults) {
+	c := &d.DataVolumeChown
+	c.Enabled = util.CastStringBoolToStringTrueFalse(c.Enabled, false)
+	if !c.IsEnabled() {
+		return
+	}
+
+	normalizeUintString("dataVolumeChown.uid", &c.UID, 0, 32, dataVolumeChownDefaultUID)
+	normalizeUintString("dataVolumeChown.gid", &c.GID, 0, 32, dataVolumeChownDefaultGID)
+
+	c.Image = strings.TrimSpace(c.Image)
+	if c.Image == "" {
+		c.Image = defaultBusyBoxDockerImage
+	}
+	if len(c.Command) == 0 {
+		c.Command = []string{"chown", "-R", c.UID + ":" + c.GID, dirPathClickHouseData}
+	}
+}
+
+// normalizeDefaultsSecurityContext ensures chiv1.ChiDefaults.SecurityContext section has proper values.
+// Incorrect IDs are dropped, so they are not applied to pods
+func (n *Normalizer) normalizeDefaultsSecurityContext(d *chiv1.ChiDefaults) {
+	c := &d.SecurityContext
+	normalizeUintString("securityContext.runAsUser", &c.RunAsUser, 0, 32, "")
+	normalizeUintString("securityContext.runAsGroup", &c.RunAsGroup, 0, 32, "")
+	normalizeUintString("securityContext.fsGroup", &c.FSGroup, 0, 32, "")
+	if c.RunAsNonRoot != "" {
+		c.RunAsNonRoot = util.CastStringBoolToStringTrueFalse(c.RunAsNonRoot, false)
+	}
+}
+
+// normalizeDefaultsServiceAccount ensures chiv1.ChiDefaults.ServiceAccount section has proper values
+func (n *Normalizer) normalizeDefaultsServiceAccount(d *chiv1.ChiDefaults) {
+	sa := &d.ServiceAccount
+	// Default value set to false - pods run under namespace's default ServiceAccount
+	sa.Create = util.CastStringBoolToStringTrueFalse(sa.Create, false)
+	sa.Name = strings.TrimSpace(sa.Name)
+	if (sa.Name != "") && util.IsStringBoolTrue(sa.Create) {
+		log.V(1).Infof("serviceAccount.name %s is specified. Reference it instead of generating one.", sa.Name)
+	}
+}
+
+// normalizeDefaultsUpdateStrategy ensures chiv1.ChiDefaults.UpdateStrategy section has proper values.
+// Not specified or unknown type falls back to RollingUpdate, partition is applicable to RollingUpdate only
+func (n *Normalizer) normalizeDefaultsUpdateStrategy(d *chiv1.ChiDefaults) {
+	s := &d.UpdateStrategy
+	switch apps.StatefulSetUpdateStrategyType(s.Type) {
+	case apps.RollingUpdateStatefulSetStrategyType, apps.OnDeleteStatefulSetStrategyType:
+		// Known type, all is fine
+	default:
+		if s.Type != "" {
+			log.V(1).Infof("Unknown updateStrategy.type %s. Use %s.", s.Type, apps.RollingUpdateStatefulSetStrategyType)
+		}
+		s.Type = string(apps.RollingUpdateStatefulSetStrategyType)
+	}
+
+	if s.Partition == "" {
+		return
+	}
+	if s.Type != string(apps.RollingUpdateStatefulSetStrategyType) {
+		log.V(1).Infof("updateStrategy.partition is applicable to %s only. Skip it.", apps.RollingUpdateStatefulSetStrategyType)
+		s.Partition = ""
+		return
+	}
+	if partition, err := strconv.Atoi(s.Partition); (err != nil) || (partition < 0) {
+		log.V(1).Infof("updateStrategy.partition has to be a non-negative number, got %s. Skip it.", s.Partition)
+		s.Partition = ""
+	}
+}
+
+// normalizeDefaultsContainer ensures chiv1.ChiDefaults.Container section has proper values
+func (n *Normalizer) normalizeDefaultsContainer(d *chiv1.ChiDefaults) {
+	c := &d.Container
+	c.Name = strings.TrimSpace(c.Name)
+	if c.Name == "" {
+		c.Name = ClickHouseContainerName
+	}
+	c.Image = strings.TrimSpace(c.Image)
+	if c.Image == "" {
+		c.Image = defaultClickHouseDockerImage
+	}
+	switch c.ImagePullPolicy {
+	case "", v1.PullAlways, v1.PullIfNotPresent, v1.PullNever:
+		// Known pull policy, all is fine
+	default:
+		log.V(1).Infof("Unknown container.imagePullPolicy %s. Use Kubernetes default.", c.ImagePullPolicy)
+		c.ImagePullPolicy = ""
+	}
+	var secrets []v1.LocalObjectReference
+	for _, secret := range c.ImagePullSecrets {
+		if secret.Name != "" {
+			secrets = append(secrets, secret)
+		}
+	}
+	c.ImagePullSecrets = secrets
+	var env []v1.EnvVar
+	for _, envVar := range c.Env {
+		envVar.Name = strings.TrimSpace(envVar.Name)
+		if envVar.Name == "" {
+			log.V(1).Infof("Env var without name in container defaults. Skip it.")
+			continue
+		}
+		env = append(env, envVar)
+	}
+	c.Env = env
+}
+
+// normalizeDefaultsTmpVolume ensures chiv1.ChiDefaults.TmpVolume section has proper values
+func (n *Normalizer) normalizeDefaultsTmpVolume(d *chiv1.ChiDefaults) {
+	v := &d.TmpVolume
+	if (v.Type != "") && (v.Type != chiv1.TmpVolumeTypeEmptyDir) {
+		log.V(1).Infof("Unknown tmpVolume.type %s. Skip it.", v.Type)
+		v.Type = ""
+	}
+	if (v.Medium != "") && (v.Medium != chiv1.TmpVolumeMediumMemory) {
+		log.V(1).Infof("Unknown tmpVolume.medium %s. Use node disk.", v.Medium)
+		v.Medium = ""
+	}
+	if v.SizeLimit != "" {
+		if _, err := resource.ParseQuantity(v.SizeLimit); err != nil {
+			log.V(1).Infof("Incorrect tmpVolume.sizeLimit %s. Ignore it. Err: %v", v.SizeLimit, err)
+			v.SizeLimit = ""
+		}
+	}
+}
+
+// normalizeDefaultsShmVolume ensures chiv1.ChiDefaults.ShmVolume section has proper values
+func (n *Normalizer) normalizeDefaultsShmVolume(d *chiv1.ChiDefaults) {
+	v := &d.ShmVolume
+	v.Enabled = util.CastStringBoolToStringTrueFalse(v.Enabled, false)
+	if v.SizeLimit != "" {
+		if quantity, err := resource.ParseQuantity(v.SizeLimit); (err != nil) || (quantity.Sign() <= 0) {
+			log.V(1).Infof("Incorrect shmVolume.sizeLimit %s. Ignore it. Err: %v", v.SizeLimit, err)
+			v.SizeLimit = ""
+		}
+	}
+}
+
+// normalizeDefaultsBaseIndexes ensures chiv1.ChiDefaults.ShardBaseIndex and ReplicaBaseIndex have proper values
+func (n *Normalizer) normalizeDefaultsBaseIndexes(d *chiv1.ChiDefaults) {
+	// Numbering starts with 0 by default
+	if d.ShardBaseIndex < 0 {
+		log.V(1).Infof("shardBaseIndex has to be non-negative, got %d. Use 0.", d.ShardBaseIndex)
+		d.ShardBaseIndex = 0
+	}
+	if d.ReplicaBaseIndex < 0 {
+		log.V(1).Infof("replicaBaseIndex has to be non-negative, got %d. Use 0.", d.ReplicaBaseIndex)
+		d.ReplicaBaseIndex = 0
+	}
+}
+
+// getSettingsSectionNames returns sorted names of sections, such as profiles or quotas, specified by 'name/something' paths
+func getSettingsSectionNames(settings chiv1.Settings) []string {
+	var names []string
+	for path := range settings {
+		parts := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)
+		if (len(parts) == 2) && !util.InArray(parts[0], names) {
+			names = append(names, parts[0])
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// normalizeDefaultsStorageSize ensures chiv1.ChiDefaults.StorageSize is a positive resource.Quantity
+func (n *Normalizer) normalizeDefaultsStorageSize(d *chiv1.ChiDefaults) {
+	if d.StorageSize == "" {
+		return
+	}
+	if !isPositiveQuantity(d.StorageSize) {
+		log.V(1).Infof("storageSize has to be a positive quantity, got %s. Skip it.", d.StorageSize)
+		d.StorageSize = ""
+	}
+}
+
+// normalizeDefaultsConfigDirPath ensures chiv1.ChiDefaults.ConfigDirPath is an absolute path with trailing slash.
+// Stock ClickHouse config folder is used by default
+func (n *Normalizer) normalizeDefaultsConfigDirPath(d *chiv1.ChiDefaults) {
+	if d.ConfigDirPath == "" {
+		d.ConfigDirPath = dirPathConfig
+		return
+	}
+	if !path.IsAbs(d.ConfigDirPath) {
+		log.V(1).Infof("configDirPath has to be an absolute path, got %s. Use default %s", d.ConfigDirPath, dirPathConfig)
+		d.ConfigDirPath = dirPathConfig
+		return
+	}
+	d.ConfigDirPath = strings.TrimSuffix(path.Clean(d.ConfigDirPath), "/") + "/"
+}
+
+// normalizeDefaultsTerminationGracePeriodSeconds ensures chiv1.ChiDefaults.TerminationGracePeriodSeconds
+// is a non-negative number of seconds
+func (n *Normalizer) normalizeDefaultsTerminationGracePeriodSeconds(d *chiv1.ChiDefaults) {
+	if d.TerminationGracePeriodSeconds == "" {
+		d.TerminationGracePeriodSeconds = terminationGracePeriodSecondsDefault
+		return
+	}
+	if _, err := strconv.ParseUint(d.TerminationGracePeriodSeconds, 10, 32); err != nil {
+		log.V(1).Infof("terminationGracePeriodSeconds has to be a non-negative number, got %s. Use %s", d.TerminationGracePeriodSeconds, terminationGracePeriodSecondsDefault)
+		d.TerminationGracePeriodSeconds = terminationGracePeriodSecondsDefault
+	}
+}
+
+// normalizeDefaultsHostNetwork ensures chiv1.ChiDefaults.HostNetwork and chiv1.ChiDefaults.DNSPolicy have proper values
+func (n *Normalizer) normalizeDefaultsHostNetwork(d *chiv1.ChiDefaults) {
+	// Default value set to false - pods use cluster network
+	d.HostNetwork = util.CastStringBoolToStringTrueFalse(d.HostNetwork, false)
+	switch d.DNSPolicy {
+	case "", v1.DNSClusterFirst, v1.DNSClusterFirstWithHostNet, v1.DNSDefault, v1.DNSNone:
+		// Known DNS policy, all is fine
+	default:
+		log.V(1).Infof("Unknown dnsPolicy %s. Derive it from hostNetwork.", d.DNSPolicy)
+		d.DNSPolicy = ""
+	}
+}
+
+// normalizeDefaultsServiceClusterIP ensures ClusterIP of CHI Service is either headless or a valid IP address
+func (n *Normalizer) normalizeDefaultsServiceClusterIP(d *chiv1.ChiDefaults) {
+	if strings.EqualFold(d.ServiceClusterIP, v1.ClusterIPNone) {
+		d.ServiceClusterIP = v1.ClusterIPNone
+		return
+	}
+	if (d.ServiceClusterIP != "") && (net.ParseIP(d.ServiceClusterIP) == nil) {
+		log.V(1).Infof("serviceClusterIP has to be %s or a valid IP address, got %s. Ignore it.", v1.ClusterIPNone, d.ServiceClusterIP)
+		d.ServiceClusterIP = ""
+	}
+}
+
+// normalizeDefaultsPorts ensures chiv1.ChiDefaults.Ports are valid port numbers.
+// Stock ClickHouse ports are used for ports, which are not specified or are incorrect
+func (n *Normalizer) normalizeDefaultsPorts(d *chiv1.ChiDefaults) {
+	normalizePortNumber("ports.tcpPort", &d.Ports.TCPPort, chDefaultTCPPortNumber)
+	normalizePortNumber("ports.httpPort", &d.Ports.HTTPPort, chDefaultHTTPPortNumber)
+	normalizePortNumber("ports.interserverHTTPPort", &d.Ports.InterserverHTTPPort, chDefaultInterserverHTTPPortNumber)
+}
+
+// normalizePortNumber ensures port is a valid port number. Port not specified or incorrect falls back to _default
+func normalizePortNumber(name string, port *int32, _default int32) {
+	if (*port < 0) || (*port > 65535) {
+		log.V(1).Infof("%s has to be a valid port number, got %d. Use %d.", name, *port, _default)
+		*port = chPortNumberMustBeAssignedLater
+	}
+	ensurePortValue(port, chPortNumberMustBeAssignedLater, _default)
+}
+
+// isPositiveQuantity checks whether str is a valid positive resource.Quantity
+func isPositiveQuantity(str string) bool {
+	quantity, err := resource.ParseQuantity(str)
+	return (err == nil) && (quantity.Sign() > 0)
+}
+
+// hasSettingsSection checks whether settings have any 'name/something' path, such as profile or quota 'name'
+func hasSettingsSection(settings chiv1.Settings, name string) bool {
+	for path := range settings {
+		if strings.HasPrefix(strings.TrimPrefix(path, "/"), name+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// isKnownProfile checks whether profile is either specified in .spec.configuration.profiles,
+// is the default one or is a stock ClickHouse profile
+func isKnownProfile(chi *chiv1.ClickHouseInstallation, profile string) bool {
+	return util.InArray(profile, stockProfiles) ||
+		(profile == chi.Spec.Defaults.DefaultProfile) ||
+		hasSettingsSection(chi.Spec.Configuration.Profiles, profile)
+}
+
 // normalizeDefaultsTemplates ensures chiv1.ChiDefaults.Templates section has proper values
 func (n *Normalizer) normalizeDefaultsTemplates(d *chiv1.ChiDefaults) {
 	d.Templates.HandleDeprecatedFields()
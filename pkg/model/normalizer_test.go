@@ -0,0 +1,240 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	chiv1 "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeCHI(t *testing.T) {
+	runCHITests(t, []chiTest{
+		{
+			name: "defaults",
+			modify: func(chi *chiv1.ClickHouseInstallation) {
+				chi.Spec.Configuration.Users = chiv1.Settings{
+					"test/password": chiv1.NewScalarSetting("secret"),
+				}
+				chi.Spec.Configuration.Clusters[0].Layout = chiv1.ChiClusterLayout{}
+			},
+			check: func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+				require.Nil(t, ValidateCHI(chi), "failed to validate chi")
+
+				// Replica counts
+				cluster := chi.Spec.Configuration.Clusters[0]
+				require.Equal(t, 1, cluster.Layout.ShardsCount, "unexpected shards count")
+				require.Equal(t, 1, cluster.Layout.ReplicasCount, "unexpected replicas count")
+				require.Equal(t, 1, chi.HostsCount(), "unexpected hosts count")
+
+				// Ports
+				host := cluster.Layout.Shards[0].Hosts[0]
+				require.Equal(t, chDefaultTCPPortNumber, host.TCPPort, "unexpected tcp port")
+				require.Equal(t, chDefaultHTTPPortNumber, host.HTTPPort, "unexpected http port")
+				require.Equal(t, chDefaultInterserverHTTPPortNumber, host.InterserverHTTPPort, "unexpected interserver port")
+
+				// Profiles
+				require.Equal(t, creator.chop.Config().CHConfigUserDefaultProfile, chi.Spec.Defaults.DefaultProfile, "unexpected default profile")
+				require.Equal(t, chi.Spec.Defaults.DefaultProfile, chi.Spec.Configuration.Users["test/profile"].String(), "unexpected user profile")
+
+				// Image
+				require.Equal(t, defaultClickHouseDockerImage, getTestContainer(t, creator, host).Image, "unexpected image")
+			},
+		},
+		{
+			name: "stock profiles",
+			modify: func(chi *chiv1.ClickHouseInstallation) {
+				chi.Spec.Defaults.DefaultProfile = "app"
+				chi.Spec.Configuration.Users = chiv1.Settings{
+					"x/profile": chiv1.NewScalarSetting("readonly"),
+					"y/profile": chiv1.NewScalarSetting("default"),
+					"z/profile": chiv1.NewScalarSetting("missing"),
+				}
+				chi.Spec.Configuration.Profiles = chiv1.Settings{
+					"app/max_memory_usage": chiv1.NewScalarSetting("1000000000"),
+				}
+			},
+			check: func(t *testing.T, creator *Creator, chi *chiv1.ClickHouseInstallation) {
+				// Profiles are never replaced, including unknown ones
+				users := chi.Spec.Configuration.Users
+				require.Equal(t, "readonly", users["x/profile"].String(), "readonly profile has to survive normalization")
+				require.Equal(t, "default", users["y/profile"].String(), "default profile has to survive normalization")
+				require.Equal(t, "missing", users["z/profile"].String(), "unknown profile has to survive normalization")
+
+				// Stock profiles are known, unknown profile is reported
+				require.Equal(t, []string{"z refers to unknown profile missing"}, getUnknownUserReferences(chi))
+			},
+		},
+	})
+}
+
+func TestFilterSettingsByRestart(t *testing.T) {
+	settings := chiv1.NewSettings()
+	for name, value := range map[string]string{
+		"tcp_port":                "9000",
+		"logger/level":            "information",
+		"max_server_memory_usage": "1000000000",
+		"some_future_setting":     "1",
+	} {
+		settings[name] = chiv1.NewScalarSetting(value)
+	}
+
+	// Settings not known to be hot-reloadable require restart
+	restart := filterSettingsByRestart(settings, true)
+	require.Len(t, restart, 3)
+	for _, name := range []string{"tcp_port", "logger/level", "some_future_setting"} {
+		require.Contains(t, restart, name)
+	}
+	hot := filterSettingsByRestart(settings, false)
+	require.Len(t, hot, 1)
+	require.Contains(t, hot, "max_server_memory_usage")
+}
+
+func TestNormalizeSettingsMaxWaitingQueries(t *testing.T) {
+	settings := chiv1.NewSettings()
+	settings[settingMaxConcurrentQueries] = chiv1.NewScalarSetting("100")
+	settings[settingMaxWaitingQueries] = chiv1.NewScalarSetting("200")
+	NewNormalizer(newTestCHOp()).normalizeSettingsNumericValues(&settings)
+
+	// Exceeding value is reported only, not dropped
+	require.Equal(t, "200", settings[settingMaxWaitingQueries].String(), "max_waiting_queries is not kept")
+}
+
+func TestRestartSettingsFingerprints(t *testing.T) {
+	normalize := func(name, value string) *chiv1.ChiHost {
+		_, chi := newTestCreator(t, TestCHIData, func(chi *chiv1.ClickHouseInstallation) {
+			chi.Spec.Configuration.Settings = chiv1.Settings{
+				"tcp_port":                chiv1.NewScalarSetting("9000"),
+				"logger/level":            chiv1.NewScalarSetting("information"),
+				"max_server_memory_usage": chiv1.NewScalarSetting("1000000000"),
+				"some_future_setting":     chiv1.NewScalarSetting("1"),
+			}
+			if name != "" {
+				chi.Spec.Configuration.Settings[name] = chiv1.NewScalarSetting(value)
+			}
+		})
+		return chi.Spec.Configuration.Clusters[0].Layout.Shards[0].Hosts[0]
+	}
+	base := normalize("", "")
+
+	// Hot-reloadable setting changed - no restart
+	hot := normalize("max_server_memory_usage", "2000000000")
+	require.Equal(t, base.Config.RestartSettingsFingerprint, hot.Config.RestartSettingsFingerprint)
+	require.NotEqual(t, base.Config.HotSettingsFingerprint, hot.Config.HotSettingsFingerprint)
+
+	// Restart-required setting changed - restart
+	restart := normalize("logger/level", "debug")
+	require.NotEqual(t, base.Config.RestartSettingsFingerprint, restart.Config.RestartSettingsFingerprint)
+	require.Equal(t, base.Config.HotSettingsFingerprint, restart.Config.HotSettingsFingerprint)
+
+	// Unknown setting changed - restart
+	unknown := normalize("some_future_setting", "2")
+	require.NotEqual(t, base.Config.RestartSettingsFingerprint, unknown.Config.RestartSettingsFingerprint)
+}
+
+var OpenUsersData = `
+apiVersion: "clickhouse.altinity.com/v1"
+kind: "ClickHouseInstallation"
+metadata:
+  name: "open-users"
+  namespace: "kube-system"
+spec:
+  defaults:
+    secureByDefault: "yes"
+  configuration:
+    users:
+      default/networks/ip: "::1"
+      local/networks/ip: "127.0.0.1"
+      local/networks/host_regexp: "^localhost$"
+      regexp/password: ""
+      regexp/networks/ip: "127.0.0.1"
+      regexp/networks/host_regexp: ".*"
+      protected/password: "secret"
+      protected/networks/ip: "::/0"
+    userPasswordSecrets:
+      secret:
+        name: "clickhouse-users"
+        key: "secret"
+    clusters:
+      - name: "cluster"
+`
+
+func TestNormalizeOpenUsers(t *testing.T) {
+	normalizer := NewNormalizer(newTestCHOp())
+	_, err := normalizer.NormalizeCHI(newTestCHI(t, OpenUsersData))
+
+	// Users with password, either explicit or provided via Secret, and users restricted to localhost are not open,
+	// while host_regexp matching any host exposes user to the network
+	require.NotNil(t, err, "open user is not reported")
+	require.True(t, strings.HasSuffix(err.Error(), ": regexp"), "unexpected open users: %v", err)
+	for _, username := range []string{"default", "local", "protected", "secret"} {
+		require.False(t, normalizer.isUserOpen(username), "user %s is reported as open", username)
+	}
+	require.True(t, normalizer.isUserOpen("regexp"))
+}
+
+var UnknownTemplatesData = `
+apiVersion: "clickhouse.altinity.com/v1"
+kind: "ClickHouseInstallation"
+metadata:
+  name: "unknown-templates"
+  namespace: "kube-system"
+spec:
+  defaults:
+    templates:
+      podTemplate: "missing-pod"
+  configuration:
+    clusters:
+      - name: "cluster"
+        templates:
+          dataVolumeClaimTemplate: "data"
+  templates:
+    volumeClaimTemplates:
+      - name: "data"
+        spec:
+          accessModes:
+            - ReadWriteOnce
+          resources:
+            requests:
+              storage: 1Gi
+`
+
+func TestValidateCHIUnknownTemplates(t *testing.T) {
+	CHOp := newTestCHOp()
+	_, err := NewNormalizer(CHOp).NormalizeCHI(newTestCHI(t, UnknownTemplatesData))
+	require.Nil(t, err, "unknown templates should not prevent normalization")
+	_, err = NewNormalizer(CHOp).ValidateCHI(newTestCHI(t, UnknownTemplatesData))
+	require.EqualError(t, err, "CHI kube-system/unknown-templates is invalid: unknown template podTemplate/missing-pod")
+}
+
+func TestLayoutLimits(t *testing.T) {
+	CHOp := newTestCHOp()
+	CHOp.Config().CHIMaxShardsCount = 2
+	CHOp.Config().CHIMaxReplicasCount = 2
+	large := func(chi *chiv1.ClickHouseInstallation) {
+		chi.Name = "limits"
+		chi.Spec.Configuration.Clusters = append(chi.Spec.Configuration.Clusters, chiv1.ChiCluster{
+			Name:   "large",
+			Layout: chiv1.ChiClusterLayout{ShardsCount: 4, ReplicasCount: 3},
+		})
+	}
+
+	// Over-limit cluster is rejected, naming requested and allowed counts
+	_, err := NewNormalizer(CHOp).NormalizeCHI(newTestCHI(t, TestCHIData, large))
+	require.EqualError(t, err, "CHI kube-system/limits exceeds limits: [cluster large requests 4 shards, 2 allowed, cluster large requests 3 replicas, 2 allowed]")
+
+	// Within-limit cluster is accepted
+	chi, err := NewNormalizer(CHOp).NormalizeCHI(newTestCHI(t, TestCHIData))
+	require.Nil(t, err, "within-limit chi is rejected")
+	require.Equal(t, 4, chi.HostsCount(), "unexpected hosts count")
+
+	// Over-limit cluster is truncated, in case operator config allows so
+	CHOp.Config().OnCHILimitsExceededAction = chiv1.OnCHILimitsExceededActionTruncate
+	chi, err = NewNormalizer(CHOp).NormalizeCHI(newTestCHI(t, TestCHIData, large))
+	require.Nil(t, err, "over-limit chi is not truncated")
+	for _, cluster := range chi.Spec.Configuration.Clusters {
+		require.Equal(t, 2, cluster.Layout.ShardsCount, "unexpected shards count of cluster %s", cluster.Name)
+		require.Equal(t, 2, cluster.Layout.ReplicasCount, "unexpected replicas count of cluster %s", cluster.Name)
+		require.Equal(t, 4, cluster.HostsCount(), "unexpected hosts count of cluster %s", cluster.Name)
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+
+	chiv1 "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+)
+
+// GetLastReconciledCHI returns CHI with the spec of the last successfully completed reconcile of new CHI.
+// Previous version of the CHI is returned in case no reconcile has been completed yet
+func GetLastReconciledCHI(old, new *chiv1.ClickHouseInstallation) *chiv1.ClickHouseInstallation {
+	if (new == nil) || !new.Status.HasNormalizedCHICompleted() {
+		return old
+	}
+	return &chiv1.ClickHouseInstallation{
+		TypeMeta:   new.TypeMeta,
+		ObjectMeta: new.ObjectMeta,
+		Spec:       new.Status.NormalizedCHICompleted,
+	}
+}
+
+// ValidateScaleDown compares new CHI against the last reconciled one and refuses shards to be scaled down
+// below .spec.defaults.scaleDownSafeguards.minReplicasCount, unless data loss is explicitly allowed.
+// Shard removed completely is considered to be scaled down to 0 replicas
+func ValidateScaleDown(old, new *chiv1.ClickHouseInstallation) error {
+	if (old == nil) || (new == nil) {
+		return nil
+	}
+
+	safeguards := &new.Spec.Defaults.ScaleDownSafeguards
+	if (safeguards.MinReplicasCount == 0) || safeguards.IsDataLossAllowed() {
+		return nil
+	}
+
+	for i := range old.Spec.Configuration.Clusters {
+		oldCluster := &old.Spec.Configuration.Clusters[i]
+		newCluster := new.FindCluster(oldCluster.Name)
+		for j := range oldCluster.Layout.Shards {
+			oldShard := &oldCluster.Layout.Shards[j]
+			from := oldShard.HostsCount()
+			to := 0
+			if newShard := findShard(newCluster, oldShard.Name); newShard != nil {
+				to = newShard.HostsCount()
+			}
+			if (to < from) && (to < safeguards.MinReplicasCount) {
+				return fmt.Errorf(
+					"shard %s of cluster %s is scaled down from %d to %d replicas, which is below minimum of %d replicas. Set scaleDownSafeguards.allowDataLoss to proceed",
+					oldShard.Name, oldCluster.Name, from, to, safeguards.MinReplicasCount,
+				)
+			}
+		}
+	}
+
+	return nil
+}
+
+// findShard finds shard by name within cluster
+func findShard(cluster *chiv1.ChiCluster, name string) *chiv1.ChiShard {
+	if cluster == nil {
+		return nil
+	}
+	for i := range cluster.Layout.Shards {
+		if cluster.Layout.Shards[i].Name == name {
+			return &cluster.Layout.Shards[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package model
+
+import (
+	"testing"
+
+	chiv1 "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func newScaleDownCHI(t *testing.T, normalizer *Normalizer, allowDataLoss string, shards, replicas int) *chiv1.ClickHouseInstallation {
+	chi, err := normalizer.NormalizeCHI(newTestCHI(t, TestCHIData, func(chi *chiv1.ClickHouseInstallation) {
+		chi.Spec.Defaults.ScaleDownSafeguards = chiv1.ChiScaleDownSafeguards{MinReplicasCount: 2, AllowDataLoss: allowDataLoss}
+		chi.Spec.Configuration.Clusters[0].Layout.ShardsCount = shards
+		chi.Spec.Configuration.Clusters[0].Layout.ReplicasCount = replicas
+	}))
+	require.Nil(t, err, "failed to normalize chi")
+	return chi
+}
+
+func TestValidateScaleDown(t *testing.T) {
+	normalizer := NewNormalizer(newTestCHOp())
+
+	old := newScaleDownCHI(t, normalizer, "no", 2, 3)
+
+	// Scale down to minimum and scale up are safe
+	require.Nil(t, ValidateScaleDown(old, newScaleDownCHI(t, normalizer, "no", 2, 2)))
+	require.Nil(t, ValidateScaleDown(old, newScaleDownCHI(t, normalizer, "no", 3, 3)))
+	// Scale down below minimum and shard removal are refused
+	require.NotNil(t, ValidateScaleDown(old, newScaleDownCHI(t, normalizer, "no", 2, 1)))
+	require.NotNil(t, ValidateScaleDown(old, newScaleDownCHI(t, normalizer, "no", 1, 3)))
+	// Unless data loss is allowed explicitly
+	require.Nil(t, ValidateScaleDown(old, newScaleDownCHI(t, normalizer, "yes", 2, 1)))
+}
+
+func TestValidateScaleDownAgainstLastReconciled(t *testing.T) {
+	normalizer := NewNormalizer(newTestCHOp())
+
+	reconciled := newScaleDownCHI(t, normalizer, "no", 2, 3)
+	(&reconciled.Status).ReconcileComplete(&reconciled.Spec)
+	validate := func(old, new *chiv1.ClickHouseInstallation) error {
+		// Status is carried over to the new versions of the CHI
+		new.Status = reconciled.Status
+		last, err := normalizer.CreateTemplatedCHI(GetLastReconciledCHI(old, new), true)
+		require.Nil(t, err, "failed to normalize last reconciled chi")
+		return ValidateScaleDown(last, new)
+	}
+
+	// First edit scales down and is refused
+	first := newScaleDownCHI(t, normalizer, "no", 2, 1)
+	require.NotNil(t, validate(reconciled, first))
+
+	// Second unrelated edit has previous version scaled down already, but is still refused
+	second := newScaleDownCHI(t, normalizer, "no", 2, 1)
+	second.Spec.Defaults.ReplicasUseFQDN = "yes"
+	require.NotNil(t, validate(first, second))
+
+	// Until the change is reverted or data loss is allowed explicitly
+	require.Nil(t, validate(second, newScaleDownCHI(t, normalizer, "no", 2, 3)))
+	require.Nil(t, validate(second, newScaleDownCHI(t, normalizer, "yes", 2, 1)))
+
+	// No reconcile completed yet, previous version is used
+	require.Equal(t, first, GetLastReconciledCHI(first, new(chiv1.ClickHouseInstallation)))
+}
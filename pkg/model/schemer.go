@@ -310,6 +310,92 @@ func (s *Schemer) CHIDropDnsCache(chi *chop.ClickHouseInstallation) error {
 	return s.chiApplySQLs(chi, sqls, false)
 }
 
+// CHICreateDefaultDatabase creates database specified in .spec.defaults.defaultDatabase over the whole CHI
+func (s *Schemer) CHICreateDefaultDatabase(chi *chop.ClickHouseInstallation) error {
+	database := chi.Spec.Defaults.DefaultDatabase
+	if database == "" {
+		return nil
+	}
+	sqls := []string{
+		fmt.Sprintf("CREATE DATABASE IF NOT EXISTS %s", database),
+	}
+	return s.chiApplySQLs(chi, sqls, true)
+}
+
+// CHIReconcileRoles creates roles specified in .spec.configuration.roles and grants privileges to them over the whole CHI.
+// Roles and grants removed since old CHI was reconciled are dropped and revoked respectively.
+// Operator's user has to have access_management enabled in order to manage roles
+func (s *Schemer) CHIReconcileRoles(old, new *chop.ClickHouseInstallation) error {
+	var oldRoles []chop.ChiRole
+	if old != nil {
+		oldRoles = old.Spec.Configuration.Roles
+	}
+	sqls := createRolesSQLs(oldRoles, new.Spec.Configuration.Roles)
+	if len(sqls) == 0 {
+		return nil
+	}
+	return s.chiApplySQLs(new, sqls, true)
+}
+
+// createRolesSQLs creates SQLs which bring roles from old to new state.
+// Removed roles are dropped and removed grants are revoked prior to roles being created and privileges granted,
+// so privileges, which are both revoked and granted, end up granted
+func createRolesSQLs(old, new []chop.ChiRole) []string {
+	var sqls []string
+	for i := range old {
+		oldRole := &old[i]
+		newRole := findRole(new, oldRole.Name)
+		if newRole == nil {
+			sqls = append(sqls, fmt.Sprintf("DROP ROLE IF EXISTS %s", oldRole.Name))
+			continue
+		}
+		for _, grant := range oldRole.Grants {
+			if !hasGrant(newRole, grant) {
+				sqls = append(sqls, fmt.Sprintf("REVOKE %s ON %s FROM %s", grant.Privileges, grant.On, oldRole.Name))
+			}
+		}
+	}
+	for _, role := range new {
+		sqls = append(sqls, fmt.Sprintf("CREATE ROLE IF NOT EXISTS %s", role.Name))
+		for _, grant := range role.Grants {
+			sqls = append(sqls, fmt.Sprintf("GRANT %s ON %s TO %s", grant.Privileges, grant.On, role.Name))
+		}
+	}
+	if len(sqls) == 0 {
+		return nil
+	}
+	// Users config grants roles by name, so it has to be reloaded as soon as roles are in place
+	return append(sqls, `SYSTEM RELOAD USERS`)
+}
+
+// findRole finds role by name
+func findRole(roles []chop.ChiRole, name string) *chop.ChiRole {
+	for i := range roles {
+		if roles[i].Name == name {
+			return &roles[i]
+		}
+	}
+	return nil
+}
+
+// hasGrant checks whether role has the grant
+func hasGrant(role *chop.ChiRole, grant chop.ChiGrant) bool {
+	for _, g := range role.Grants {
+		if (g.Privileges == grant.Privileges) && (g.On == grant.On) {
+			return true
+		}
+	}
+	return false
+}
+
+// HostReloadConfig runs 'SYSTEM RELOAD CONFIG' on the host, so hot-reloadable settings are applied without restart
+func (s *Schemer) HostReloadConfig(host *chop.ChiHost) error {
+	sqls := []string{
+		`SYSTEM RELOAD CONFIG`,
+	}
+	return s.hostApplySQLs(host, sqls, false)
+}
+
 // chiApplySQLs runs set of SQL queries over the whole CHI
 func (s *Schemer) chiApplySQLs(chi *chop.ClickHouseInstallation, sqls []string, retry bool) error {
 	return s.applySQLs(CreatePodFQDNsOfCHI(chi), sqls, retry)
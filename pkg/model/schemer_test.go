@@ -0,0 +1,53 @@
+package model
+
+import (
+	"testing"
+
+	chiv1 "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateRolesSQLs(t *testing.T) {
+	old := []chiv1.ChiRole{
+		{
+			Name: "reader",
+			Grants: []chiv1.ChiGrant{
+				{Privileges: "SELECT", On: "db.*"},
+				{Privileges: "SELECT", On: "system.*"},
+			},
+		},
+		{
+			Name: "writer",
+			Grants: []chiv1.ChiGrant{
+				{Privileges: "INSERT", On: "db.*"},
+			},
+		},
+	}
+	new := []chiv1.ChiRole{
+		{
+			Name: "reader",
+			Grants: []chiv1.ChiGrant{
+				{Privileges: "SELECT", On: "db.*"},
+			},
+		},
+	}
+
+	// Removed grants are revoked and removed roles are dropped prior to roles being created
+	require.Equal(t, []string{
+		"REVOKE SELECT ON system.* FROM reader",
+		"DROP ROLE IF EXISTS writer",
+		"CREATE ROLE IF NOT EXISTS reader",
+		"GRANT SELECT ON db.* TO reader",
+		"SYSTEM RELOAD USERS",
+	}, createRolesSQLs(old, new))
+
+	// All roles removed
+	require.Equal(t, []string{
+		"DROP ROLE IF EXISTS reader",
+		"DROP ROLE IF EXISTS writer",
+		"SYSTEM RELOAD USERS",
+	}, createRolesSQLs(old, nil))
+
+	// Nothing to do
+	require.Empty(t, createRolesSQLs(nil, nil))
+}
@@ -0,0 +1,305 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	chiv1 "github.com/altinity/clickhouse-operator/pkg/apis/clickhouse.altinity.com/v1"
+)
+
+// ValidateCHI checks normalized CHI is well-formed, so objects can be generated out of it.
+// Checks references to templates, profiles and quotas of users, profiles inheritance, empty and duplicate clusters,
+// duplicate hosts and host ports.
+// Returns aggregated error listing every problem found, nil in case CHI is valid
+func ValidateCHI(chi *chiv1.ClickHouseInstallation) error {
+	if chi == nil {
+		return fmt.Errorf("CHI is not specified")
+	}
+
+	var errs []error
+	for _, reference := range getUnknownTemplateReferences(chi) {
+		errs = append(errs, fmt.Errorf("unknown template %s", reference))
+	}
+	for _, reference := range getUnknownUserReferences(chi) {
+		errs = append(errs, fmt.Errorf("user %s", reference))
+	}
+	errs = append(errs, validateProfilesInheritance(chi)...)
+
+	if chi.Spec.Defaults.Container.Image == "" {
+		errs = append(errs, fmt.Errorf("ClickHouse image is not specified"))
+	}
+	// Exporter image has no default, so the exporter version is explicitly pinned by each CHI
+	if sidecar := &chi.Spec.Configuration.Monitoring.ExporterSidecar; sidecar.IsEnabled() && (sidecar.Image == "") {
+		errs = append(errs, fmt.Errorf("exporter sidecar image is not specified"))
+	}
+
+	clusters := make(map[string]bool)
+	chi.WalkClusters(func(cluster *chiv1.ChiCluster) error {
+		if cluster.Name == "" {
+			errs = append(errs, fmt.Errorf("cluster without name"))
+		} else if clusters[cluster.Name] {
+			errs = append(errs, fmt.Errorf("duplicate cluster %s", cluster.Name))
+		}
+		clusters[cluster.Name] = true
+		if len(cluster.Layout.Shards) == 0 {
+			errs = append(errs, fmt.Errorf("cluster %s has no shards", cluster.Name))
+		}
+		return nil
+	})
+	chi.WalkShards(func(shard *chiv1.ChiShard) error {
+		if len(shard.Hosts) == 0 {
+			errs = append(errs, fmt.Errorf("shard %s/%s has no replicas", shard.Address.ClusterName, shard.Name))
+		}
+		return nil
+	})
+
+	// Each host has its own StatefulSet, so hosts with the same StatefulSet name would overwrite each other
+	statefulSets := make(map[string]bool)
+	chi.WalkHosts(func(host *chiv1.ChiHost) error {
+		name := CreateStatefulSetName(host)
+		if statefulSets[name] {
+			errs = append(errs, fmt.Errorf("duplicate host %s", name))
+		}
+		statefulSets[name] = true
+		errs = append(errs, validateHostPorts(host)...)
+		return nil
+	})
+
+	if err := utilerrors.NewAggregate(errs); err != nil {
+		return fmt.Errorf("CHI %s/%s is invalid: %v", chi.Namespace, chi.Name, err)
+	}
+	return nil
+}
+
+// validateHostPorts checks host ports are within valid range and do not overlap
+func validateHostPorts(host *chiv1.ChiHost) []error {
+	var errs []error
+	ports := make(map[int32]string)
+	type namedPort struct {
+		name   string
+		number int32
+	}
+	hostPorts := []namedPort{
+		{chDefaultTCPPortName, host.TCPPort},
+		{chDefaultHTTPPortName, host.HTTPPort},
+		{chDefaultInterserverHTTPPortName, host.InterserverHTTPPort},
+	}
+	// Secure, metrics and exporter sidecar ports are served within the same pod as well
+	if host.CHI.Spec.Configuration.TLS.IsEnabled() {
+		hostPorts = append(hostPorts,
+			namedPort{chDefaultTCPPortSecureName, chDefaultTCPPortSecureNumber},
+			namedPort{chDefaultHTTPSPortName, chDefaultHTTPSPortNumber},
+		)
+	}
+	if prometheus := &host.CHI.Spec.Configuration.Monitoring.Prometheus; prometheus.IsEnabled() {
+		port, _ := strconv.Atoi(prometheus.Port)
+		hostPorts = append(hostPorts, namedPort{prometheusPortName, int32(port)})
+	}
+	if sidecar := &host.CHI.Spec.Configuration.Monitoring.ExporterSidecar; sidecar.IsEnabled() {
+		port, _ := strconv.Atoi(sidecar.Port)
+		hostPorts = append(hostPorts, namedPort{exporterSidecarPortName, int32(port)})
+	}
+	for _, port := range hostPorts {
+		if (port.number <= 0) || (port.number > 65535) {
+			errs = append(errs, fmt.Errorf("host %s has invalid %s port %d", host.Name, port.name, port.number))
+			continue
+		}
+		if name, ok := ports[port.number]; ok {
+			errs = append(errs, fmt.Errorf("host %s has %s and %s ports both set to %d", host.Name, name, port.name, port.number))
+			continue
+		}
+		ports[port.number] = port.name
+	}
+	return errs
+}
+
+// validateProfilesInheritance checks parent profiles, specified as 'profile/profile', are known
+// and profiles do not inherit from themselves, either directly or via other profiles
+func validateProfilesInheritance(chi *chiv1.ClickHouseInstallation) []error {
+	var errs []error
+	profiles := chi.Spec.Configuration.Profiles
+	names := getSettingsSectionNames(profiles)
+
+	parents := make(map[string][]string)
+	for _, name := range names {
+		setting, ok := profiles[name+"/profile"]
+		if !ok {
+			continue
+		}
+		for _, parent := range setting.AsVector() {
+			if !isKnownProfile(chi, parent) {
+				errs = append(errs, fmt.Errorf("profile %s inherits unknown profile %s", name, parent))
+				continue
+			}
+			parents[name] = append(parents[name], parent)
+		}
+	}
+
+	// Depth-first walk over parents, each cycle is reported once - by the first profile of it found
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int)
+	var path []string
+	var walk func(name string)
+	walk = func(name string) {
+		switch state[name] {
+		case visiting:
+			for i := range path {
+				if path[i] == name {
+					cycle := append(append([]string{}, path[i:]...), name)
+					errs = append(errs, fmt.Errorf("profile inheritance cycle %s", strings.Join(cycle, " -> ")))
+					break
+				}
+			}
+			return
+		case visited:
+			return
+		}
+		state[name] = visiting
+		path = append(path, name)
+		for _, parent := range parents[name] {
+			walk(parent)
+		}
+		path = path[:len(path)-1]
+		state[name] = visited
+	}
+	for _, name := range names {
+		walk(name)
+	}
+
+	return errs
+}
+
+// getUnknownTemplateReferences lists sorted references to templates, which are not specified in .spec.templates,
+// as 'kind/name', such as 'podTemplate/clickhouse'
+func getUnknownTemplateReferences(chi *chiv1.ClickHouseInstallation) []string {
+	unknown := make(map[string]bool)
+	check := func(names *chiv1.ChiTemplateNames) {
+		if (names.HostTemplate != "") && !hasHostTemplate(chi, names.HostTemplate) {
+			unknown["hostTemplate/"+names.HostTemplate] = true
+		}
+		if (names.PodTemplate != "") && !hasPodTemplate(chi, names.PodTemplate) {
+			unknown["podTemplate/"+names.PodTemplate] = true
+		}
+		for kind, name := range map[string]string{
+			"dataVolumeClaimTemplate": names.DataVolumeClaimTemplate,
+			"logVolumeClaimTemplate":  names.LogVolumeClaimTemplate,
+			"volumeClaimTemplate":     names.VolumeClaimTemplate,
+		} {
+			if (name != "") && !hasVolumeClaimTemplate(chi, name) {
+				unknown[kind+"/"+name] = true
+			}
+		}
+		for _, mount := range names.ExtraVolumeClaimTemplates {
+			if (mount.Name != "") && !hasVolumeClaimTemplate(chi, mount.Name) {
+				unknown["extraVolumeClaimTemplate/"+mount.Name] = true
+			}
+		}
+		for kind, name := range map[string]string{
+			"serviceTemplate":            names.ServiceTemplate,
+			"clusterServiceTemplate":     names.ClusterServiceTemplate,
+			"shardServiceTemplate":       names.ShardServiceTemplate,
+			"shardLeaderServiceTemplate": names.ShardLeaderServiceTemplate,
+			"replicaServiceTemplate":     names.ReplicaServiceTemplate,
+		} {
+			if (name != "") && !hasServiceTemplate(chi, name) {
+				unknown[kind+"/"+name] = true
+			}
+		}
+	}
+
+	check(&chi.Spec.Defaults.Templates)
+	chi.WalkClusters(func(cluster *chiv1.ChiCluster) error {
+		check(&cluster.Templates)
+		return nil
+	})
+	chi.WalkShards(func(shard *chiv1.ChiShard) error {
+		check(&shard.Templates)
+		return nil
+	})
+	chi.WalkHosts(func(host *chiv1.ChiHost) error {
+		check(&host.Templates)
+		return nil
+	})
+
+	res := make([]string, 0, len(unknown))
+	for reference := range unknown {
+		res = append(res, reference)
+	}
+	sort.Strings(res)
+
+	return res
+}
+
+// getUnknownUserReferences lists sorted references of users to profiles and quotas, which are neither specified
+// in .spec.configuration nor are default or stock ones, as 'username refers to unknown kind name'
+func getUnknownUserReferences(chi *chiv1.ClickHouseInstallation) []string {
+	var res []string
+	users := chi.Spec.Configuration.Users
+	for _, username := range getUsernames(users) {
+		for _, reference := range []struct {
+			kind  string
+			known func(name string) bool
+		}{
+			{"profile", func(name string) bool { return isKnownProfile(chi, name) }},
+			{"quota", func(name string) bool { return isKnownQuota(chi, name) }},
+		} {
+			setting, ok := users[username+"/"+reference.kind]
+			if !ok || !setting.IsScalar() {
+				continue
+			}
+			if name := setting.Scalar(); !reference.known(name) {
+				res = append(res, fmt.Sprintf("%s refers to unknown %s %s", username, reference.kind, name))
+			}
+		}
+	}
+	return res
+}
+
+// isKnownQuota checks whether quota is either specified in .spec.configuration.quotas or quotaIntervals, or is the default one
+func isKnownQuota(chi *chiv1.ClickHouseInstallation, quota string) bool {
+	return (quota == chi.Spec.Defaults.DefaultQuota) ||
+		hasSettingsSection(chi.Spec.Configuration.Quotas, quota) ||
+		chi.Spec.Configuration.HasQuotaIntervals(quota)
+}
+
+func hasHostTemplate(chi *chiv1.ClickHouseInstallation, name string) bool {
+	_, ok := chi.GetHostTemplate(name)
+	return ok
+}
+
+func hasPodTemplate(chi *chiv1.ClickHouseInstallation, name string) bool {
+	_, ok := chi.GetPodTemplate(name)
+	return ok
+}
+
+func hasVolumeClaimTemplate(chi *chiv1.ClickHouseInstallation, name string) bool {
+	_, ok := chi.GetVolumeClaimTemplate(name)
+	return ok
+}
+
+func hasServiceTemplate(chi *chiv1.ClickHouseInstallation, name string) bool {
+	_, ok := chi.GetServiceTemplate(name)
+	return ok
+}
@@ -0,0 +1,73 @@
+// Copyright 2019 Altinity Ltd and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var InvalidCHIData = `
+apiVersion: "clickhouse.altinity.com/v1"
+kind: "ClickHouseInstallation"
+metadata:
+  name: "invalid"
+  namespace: "kube-system"
+spec:
+  defaults:
+    templates:
+      dataVolumeClaimTemplate: "missing-volume"
+  configuration:
+    tls:
+      secret: "tls"
+    monitoring:
+      prometheus:
+        enabled: "yes"
+        port: "9009"
+    users:
+      app/profile: "missing-profile"
+      app/quota: "missing-quota"
+    clusters:
+      - name: "cluster"
+        layout:
+          shards:
+            - replicas:
+                - tcpPort: 9000
+                  httpPort: 9000
+                - tcpPort: 9440
+      - name: "cluster"
+`
+
+func TestValidateCHI(t *testing.T) {
+	creator, chi := newTestCreator(t, InvalidCHIData)
+
+	// All problems are reported at once
+	err := ValidateCHI(chi)
+	require.NotNil(t, err, "invalid chi passed validation")
+	require.Contains(t, err.Error(), "unknown template dataVolumeClaimTemplate/missing-volume")
+	require.Contains(t, err.Error(), "duplicate cluster cluster")
+	require.Contains(t, err.Error(), "tcp and http ports both set to 9000")
+	// Secure and metrics ports clash with ClickHouse ports as well
+	require.Contains(t, err.Error(), "tcp and tcp-secure ports both set to 9440")
+	require.Contains(t, err.Error(), "interserver and metrics ports both set to 9009")
+	require.Contains(t, err.Error(), "user app refers to unknown profile missing-profile")
+	require.Contains(t, err.Error(), "user app refers to unknown quota missing-quota")
+
+	// Validation is up to the caller, so CHI reconciled earlier is not stopped by validation introduced later
+	objects, err := creator.CreateHostsObjects(1)
+	require.Nil(t, err, "objects of validated chi are not created")
+	require.NotEmpty(t, objects, "objects of validated chi are not created")
+}
@@ -81,6 +81,16 @@ func MapHasKeys(m map[string]string, keys ...string) bool {
 	return true
 }
 
+// SortedKeys returns sorted keys of the map
+func SortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // Map2String returns named map[string]string mas as a string
 func Map2String(name string, m map[string]string) string {
 	// Write map entries according to sorted keys
@@ -206,7 +206,7 @@ def test_011():
 
         with And("Password should be encrypted"):
             cfm = kube_get("configmap", "chi-test-011-secured-cluster-common-usersd")
-            users_xml = cfm["data"]["chop-generated-users.xml"]
+            users_xml = cfm["data"]["02-chop-generated-users.xml"]
             assert "<password>" not in users_xml
             assert "<password_sha256_hex>" in users_xml
 